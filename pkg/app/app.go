@@ -19,8 +19,12 @@ import (
 
 	"github.com/yeisme/notevault/pkg/api"
 	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
 	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/scheduler"
+	"github.com/yeisme/notevault/pkg/internal/service"
 	"github.com/yeisme/notevault/pkg/internal/storage"
+	"github.com/yeisme/notevault/pkg/internal/types"
 	"github.com/yeisme/notevault/pkg/log"
 	"github.com/yeisme/notevault/pkg/metrics"
 	"github.com/yeisme/notevault/pkg/middleware"
@@ -39,7 +43,13 @@ type App struct {
 	config        *configs.AppConfig
 	log           *zerolog.Logger
 	mg            *storage.Manager
-	done          chan struct{} // 用于通知 Run 方法退出（带缓冲，避免阻塞）
+	jobWorker     *service.JobWorker           // 异步批量任务 worker（依赖缺失或未启用时为 nil）
+	scheduler     *scheduler.Scheduler         // 周期维护任务（对账等）
+	eventConsumer *service.BucketEventConsumer // 存储桶事件 MQ 消费者（未配置主题时为 nil）
+	previewWorker *service.PreviewWorker       // 缩略图预生成 worker（未启用 eager 时为 nil）
+	mediaWorker   *service.MediaWorker         // 媒体元数据提取 worker（未启用时为 nil）
+	replWorker    *service.ReplicationWorker   // 副本复制 worker（未启用复制时为 nil）
+	done          chan struct{}                // 用于通知 Run 方法退出（带缓冲，避免阻塞）
 }
 
 // NewApp 创建并返回一个新的 App 实例.
@@ -71,6 +81,17 @@ func NewApp(configPath string) *App {
 		if err := manager.GetDBClient().GetDB().AutoMigrate(
 			&model.Files{},
 			&model.Share{},
+			&model.ShareAccess{},
+			&model.Job{},
+			&model.JobItem{},
+			&model.FileMedia{},
+			&model.TrashItem{},
+			&model.FileVersion{},
+			&model.VersionRetention{},
+			&model.ReplicationStatus{},
+			&model.ObjectHold{},
+			&model.ObjectHoldEvent{},
+			&model.AuditEvent{},
 		); err != nil {
 			fmt.Printf("AutoMigrate failed: %v\n", err)
 		}
@@ -86,6 +107,7 @@ func NewApp(configPath string) *App {
 		gzip.Gzip(gzip.DefaultCompression),
 		middleware.CORSMiddleware(config.Server),
 		middleware.TracingMiddleware(),
+		middleware.RequestInfoMiddleware(),
 		middleware.PrometheusMiddleware(),
 		middleware.StorageMiddleware(manager),
 		// 限流与熔断（按配置启用）
@@ -118,6 +140,12 @@ func NewApp(configPath string) *App {
 		config:        config,
 		log:           l,
 		mg:            manager,
+		jobWorker:     newJobWorker(manager, config),
+		scheduler:     newScheduler(manager, config),
+		eventConsumer: newBucketEventConsumer(manager, config),
+		previewWorker: newPreviewWorker(manager, config),
+		mediaWorker:   newMediaWorker(manager, config),
+		replWorker:    newReplicationWorker(manager, config),
 		done:          make(chan struct{}, 1),
 	}
 }
@@ -126,6 +154,37 @@ func NewApp(configPath string) *App {
 func (a *App) Run() error {
 	g, _ := errgroup.WithContext(context.Background())
 
+	// 启动异步任务 worker 与周期维护任务
+	if a.jobWorker != nil {
+		a.jobWorker.Start()
+	}
+
+	a.scheduler.Start(ctxPkg.WithStorageManager(context.Background(), a.mg))
+
+	if a.eventConsumer != nil {
+		if err := a.eventConsumer.Start(); err != nil {
+			a.log.Error().Err(err).Msg("Error starting bucket event consumer")
+		}
+	}
+
+	if a.previewWorker != nil {
+		if err := a.previewWorker.Start(); err != nil {
+			a.log.Error().Err(err).Msg("Error starting preview worker")
+		}
+	}
+
+	if a.mediaWorker != nil {
+		if err := a.mediaWorker.Start(); err != nil {
+			a.log.Error().Err(err).Msg("Error starting media worker")
+		}
+	}
+
+	if a.replWorker != nil {
+		if err := a.replWorker.Start(); err != nil {
+			a.log.Error().Err(err).Msg("Error starting replication worker")
+		}
+	}
+
 	// 启动指标服务器
 	if a.metricsServer != nil {
 		g.Go(func() error {
@@ -170,6 +229,17 @@ func (a *App) Run() error {
 	return g.Wait()
 }
 
+// RecordConfigReload 配置热重载后由新实例写入审计日志（操作者为 system）.
+func (a *App) RecordConfigReload() {
+	if a.mg == nil {
+		return
+	}
+
+	ctx := ctxPkg.WithStorageManager(context.Background(), a.mg)
+	service.RecordAudit(ctx, types.AuditActorSystem, types.AuditActionConfigReload,
+		[]string{configs.GetViper().ConfigFileUsed()}, nil, "")
+}
+
 // Shutdown 优雅关闭服务器和资源（公开方法）.
 func (a *App) Shutdown() {
 	a.shutdown()
@@ -194,6 +264,29 @@ func (a *App) shutdown() {
 		}
 	}
 
+	// 停止后台任务（需在关闭存储之前）
+	if a.eventConsumer != nil {
+		a.eventConsumer.Stop()
+	}
+
+	if a.previewWorker != nil {
+		a.previewWorker.Stop()
+	}
+
+	if a.mediaWorker != nil {
+		a.mediaWorker.Stop()
+	}
+
+	if a.replWorker != nil {
+		a.replWorker.Stop()
+	}
+
+	a.scheduler.Stop()
+
+	if a.jobWorker != nil {
+		a.jobWorker.Stop()
+	}
+
 	if err := a.mg.Close(); err != nil {
 		a.log.Error().Err(err).Msg("Error closing storage manager")
 	}
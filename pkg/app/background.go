@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/scheduler"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/storage"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// storageReady 判断后台任务依赖的存储是否均已初始化（FileService 需要 S3/DB/MQ）.
+func storageReady(manager *storage.Manager) bool {
+	return manager != nil && manager.GetDBClient() != nil && manager.GetS3Client() != nil && manager.GetMQClient() != nil
+}
+
+// newJobWorker 在任务依赖的存储均可用时创建异步任务 worker.
+func newJobWorker(manager *storage.Manager, config *configs.AppConfig) *service.JobWorker {
+	if !config.Jobs.Enabled {
+		return nil
+	}
+
+	if !storageReady(manager) {
+		log.Logger().Warn().Msg("storage not ready, async job worker disabled")
+		return nil
+	}
+
+	return service.NewJobWorker(manager, config.Jobs)
+}
+
+// newBucketEventConsumer 在启用存储桶事件且配置了 MQ 主题时创建事件消费者.
+func newBucketEventConsumer(manager *storage.Manager, config *configs.AppConfig) *service.BucketEventConsumer {
+	if config.BucketEvents.Enabled && config.BucketEvents.WebhookToken == "" {
+		log.Logger().Warn().Msg("bucket_events.webhook_token not set, webhook endpoint /api/v1/events/s3 rejects all requests")
+	}
+
+	if !config.BucketEvents.Enabled || config.BucketEvents.MQTopic == "" {
+		return nil
+	}
+
+	if !storageReady(manager) {
+		log.Logger().Warn().Msg("storage not ready, bucket event consumer disabled")
+		return nil
+	}
+
+	return service.NewBucketEventConsumer(manager, config.BucketEvents.MQTopic)
+}
+
+// newPreviewWorker 在启用缩略图预生成时创建 worker.
+func newPreviewWorker(manager *storage.Manager, config *configs.AppConfig) *service.PreviewWorker {
+	if !config.Preview.Enabled || !config.Preview.Eager {
+		return nil
+	}
+
+	if !storageReady(manager) {
+		log.Logger().Warn().Msg("storage not ready, preview worker disabled")
+		return nil
+	}
+
+	return service.NewPreviewWorker(manager)
+}
+
+// newMediaWorker 在启用媒体元数据提取时创建 worker.
+func newMediaWorker(manager *storage.Manager, config *configs.AppConfig) *service.MediaWorker {
+	if !config.Media.Enabled {
+		return nil
+	}
+
+	if !storageReady(manager) {
+		log.Logger().Warn().Msg("storage not ready, media worker disabled")
+		return nil
+	}
+
+	return service.NewMediaWorker(manager)
+}
+
+// newReplicationWorker 在启用复制且副本存储可用时创建复制 worker.
+func newReplicationWorker(manager *storage.Manager, config *configs.AppConfig) *service.ReplicationWorker {
+	if !config.Replication.Enabled {
+		return nil
+	}
+
+	if !storageReady(manager) || manager.GetReplicaS3Client() == nil {
+		log.Logger().Warn().Msg("storage not ready, replication worker disabled")
+		return nil
+	}
+
+	return service.NewReplicationWorker(manager)
+}
+
+// newScheduler 根据配置注册周期维护任务；存储不可用时不注册任何任务.
+func newScheduler(manager *storage.Manager, config *configs.AppConfig) *scheduler.Scheduler {
+	s := scheduler.New()
+
+	if !storageReady(manager) {
+		return s
+	}
+
+	if config.Reconcile.Enabled {
+		req := &types.ReconcileRequest{Fix: config.Reconcile.Fix, Deep: config.Reconcile.Deep}
+
+		s.Add(scheduler.Task{
+			Name:     "reconcile",
+			Interval: config.Reconcile.GetInterval(),
+			Run: func(ctx context.Context) error {
+				reports, err := service.NewFileService(ctx).ReconcileAllUsers(ctx, req)
+				for _, r := range reports {
+					log.Logger().Info().Str("user", r.User).Int("scanned", r.Scanned).
+						Interface("counts", r.Counts).Int("fixed", r.Fixed).Msg("reconcile finished")
+				}
+
+				return err
+			},
+		})
+	}
+
+	if config.Trash.CleanIntervalMinutes > 0 {
+		s.Add(scheduler.Task{
+			Name:     "trash-clean",
+			Interval: config.Trash.GetCleanInterval(),
+			Run: func(ctx context.Context) error {
+				resp, err := service.NewFileService(ctx).CleanExpiredTrash(ctx, "")
+				if resp != nil && resp.Total > 0 {
+					log.Logger().Info().Int("purged", resp.Success).Int("failed", resp.Failed).
+						Msg("expired trash cleaned")
+				}
+
+				return err
+			},
+		})
+	}
+
+	if config.Versions.PruneIntervalMinutes > 0 {
+		s.Add(scheduler.Task{
+			Name:     "version-prune",
+			Interval: config.Versions.GetPruneInterval(),
+			Run: func(ctx context.Context) error {
+				resp, err := service.NewFileService(ctx).PruneVersions(ctx, "", false)
+				if resp != nil && (resp.Pruned > 0 || resp.Failed > 0) {
+					log.Logger().Info().Int("pruned", resp.Pruned).Int("failed", resp.Failed).
+						Int64("reclaimed_bytes", resp.ReclaimedBytes).Msg("old versions pruned")
+				}
+
+				return err
+			},
+		})
+	}
+
+	if config.Lifecycle.IntervalMinutes > 0 {
+		s.Add(scheduler.Task{
+			Name:     "lifecycle",
+			Interval: config.Lifecycle.GetInterval(),
+			Run: func(ctx context.Context) error {
+				resp, err := service.NewFileService(ctx).RunLifecycle(ctx, "", config.Lifecycle.DryRun)
+				if resp != nil && (resp.Transitioned > 0 || resp.Expired > 0 || resp.Aborted > 0 || resp.Failed > 0) {
+					log.Logger().Info().Bool("dry_run", resp.DryRun).Int("transitioned", resp.Transitioned).
+						Int("expired", resp.Expired).Int("aborted", resp.Aborted).Int("failed", resp.Failed).
+						Msg("lifecycle rules applied")
+				}
+
+				return err
+			},
+		})
+	}
+
+	if config.Replication.Enabled && config.Replication.RetryIntervalMinutes > 0 && manager.GetReplicaS3Client() != nil {
+		s.Add(scheduler.Task{
+			Name:     "replication-retry",
+			Interval: config.Replication.GetRetryInterval(),
+			Run: func(ctx context.Context) error {
+				resp, err := service.NewFileService(ctx).RetryReplication(ctx)
+				if resp != nil && resp.Scanned > 0 {
+					log.Logger().Info().Int("replicated", resp.Replicated).Int("failed", resp.Failed).
+						Msg("failed replications retried")
+				}
+
+				return err
+			},
+		})
+	}
+
+	if config.Share.SweepIntervalMinutes > 0 {
+		s.Add(scheduler.Task{
+			Name:     "share-sweep",
+			Interval: config.Share.GetSweepInterval(),
+			Run: func(ctx context.Context) error {
+				removed, purged, err := service.NewShareService(ctx).SweepShares(ctx)
+				if removed > 0 || purged > 0 {
+					log.Logger().Info().Int("shares", removed).Int64("access_logs", purged).
+						Msg("stale shares swept")
+				}
+
+				return err
+			},
+		})
+	}
+
+	return s
+}
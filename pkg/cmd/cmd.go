@@ -28,8 +28,13 @@ var (
 				}
 			})
 
+			reloaded := false
+
 			for {
 				applications := app.NewApp(configPath)
+				if reloaded {
+					applications.RecordConfigReload()
+				}
 
 				// 启动应用并监听重新启动信号
 				errChan := make(chan error, 1)
@@ -48,6 +53,8 @@ var (
 					// 收到重新启动信号，停止当前应用
 					fmt.Println("Configuration changed, restarting server...")
 					applications.Shutdown()
+
+					reloaded = true
 					// 继续循环，重新创建应用
 				}
 			}
@@ -74,6 +81,9 @@ func Execute() error {
 	registerDBCommands()
 	registerKVCommands()
 	registerMQCommands()
+	registerReconcileCommands()
+	registerRotateKeysCommands()
+	registerReplicateCommands()
 
 	return rootCmd.Execute()
 }
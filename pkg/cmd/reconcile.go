@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+var (
+	reconcileUser   string
+	reconcilePrefix string
+	reconcileAll    bool
+	reconcileFix    bool
+	reconcileDeep   bool
+
+	reconcileCmd = &cobra.Command{
+		Use:   "reconcile",
+		Short: "diff object storage against database records and report (or fix) drift",
+		Long: `Compare the bucket listing with the files table and classify each difference as
+missing_in_db, missing_in_s3, stale_etag or stale_metadata. With --fix, missing or
+stale records are refreshed from object storage and records of deleted objects are
+soft-deleted.`,
+		Example: `  notevault reconcile --user alice@example.com
+  notevault reconcile --user alice@example.com --prefix alice@example.com/2025/01/ --fix
+  notevault reconcile --all --deep`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !reconcileAll && reconcileUser == "" {
+				return fmt.Errorf("either --user or --all is required")
+			}
+
+			return withStorage(func(ctx context.Context) error {
+				svc := service.NewFileService(ctx)
+				req := &types.ReconcileRequest{Prefix: reconcilePrefix, Fix: reconcileFix, Deep: reconcileDeep}
+
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+
+				if reconcileAll {
+					reports, err := svc.ReconcileAllUsers(ctx, req)
+					if encErr := enc.Encode(reports); encErr != nil {
+						return encErr
+					}
+
+					return err
+				}
+
+				report, err := svc.ReconcileObjects(ctx, reconcileUser, req)
+				if err != nil {
+					return err
+				}
+
+				return enc.Encode(report)
+			})
+		},
+	}
+)
+
+// registerReconcileCommands 注册对账命令.
+func registerReconcileCommands() {
+	rootCmd.AddCommand(reconcileCmd)
+
+	reconcileCmd.Flags().StringVarP(&reconcileUser, "user", "u", "", "user to reconcile")
+	reconcileCmd.Flags().StringVar(&reconcilePrefix, "prefix", "", "object key prefix under the user directory (default: whole user directory)")
+	reconcileCmd.Flags().BoolVar(&reconcileAll, "all", false, "reconcile every known user (per-object drift is written to the log)")
+	reconcileCmd.Flags().BoolVar(&reconcileFix, "fix", false, "fix drift instead of only reporting it")
+	reconcileCmd.Flags().BoolVar(&reconcileDeep, "deep", false, "stat every object to compare content type")
+}
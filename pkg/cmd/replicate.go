@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+var (
+	replicateUser   string
+	replicateAll    bool
+	replicateDryRun bool
+
+	replicateCmd = &cobra.Command{
+		Use:   "replicate",
+		Short: "manage replication to the secondary object store",
+	}
+
+	replicateBackfillCmd = &cobra.Command{
+		Use:   "backfill",
+		Short: "copy existing files to the replica",
+		Long: `Walk the files table and bring the replica up to date: objects whose replicated
+ETag or metadata differs from the source (or that were never replicated) are copied again.
+Use it after enabling replication, or after events were lost.`,
+		Example: `  notevault replicate backfill --all --dry-run
+  notevault replicate backfill --user alice@example.com`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !replicateAll && replicateUser == "" {
+				return fmt.Errorf("either --user or --all is required")
+			}
+
+			return withReplication(func(ctx context.Context, svc *service.FileService) error {
+				user := replicateUser
+				if replicateAll {
+					user = ""
+				}
+
+				resp, err := svc.BackfillReplication(ctx, user, replicateDryRun)
+
+				return printReplication(cmd.OutOrStdout(), resp, err)
+			})
+		},
+	}
+
+	replicateRetryCmd = &cobra.Command{
+		Use:   "retry",
+		Short: "retry failed replications that are due",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withReplication(func(ctx context.Context, svc *service.FileService) error {
+				resp, err := svc.RetryReplication(ctx)
+
+				return printReplication(cmd.OutOrStdout(), resp, err)
+			})
+		},
+	}
+)
+
+// withReplication 初始化存储并确保复制状态表存在.
+func withReplication(fn func(ctx context.Context, svc *service.FileService) error) error {
+	return withStorage(func(ctx context.Context) error {
+		if ctxPkg.GetReplicaS3Client(ctx) == nil {
+			return service.ErrReplicationDisabled
+		}
+
+		if err := ctxPkg.GetDBClient(ctx).GetDB().AutoMigrate(&model.ReplicationStatus{}); err != nil {
+			return fmt.Errorf("migrate replication status: %w", err)
+		}
+
+		return fn(ctx, service.NewFileService(ctx))
+	})
+}
+
+// printReplication 输出复制结果；存在失败对象时返回错误以便脚本判断.
+func printReplication(w io.Writer, resp *types.ReplicationRunResponse, err error) error {
+	if resp != nil {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		if encErr := enc.Encode(resp); encErr != nil {
+			return encErr
+		}
+	}
+
+	if err == nil && resp.Failed > 0 {
+		return fmt.Errorf("%d objects failed to replicate", resp.Failed)
+	}
+
+	return err
+}
+
+// registerReplicateCommands 注册副本复制命令.
+func registerReplicateCommands() {
+	rootCmd.AddCommand(replicateCmd)
+	replicateCmd.AddCommand(replicateBackfillCmd, replicateRetryCmd)
+
+	replicateBackfillCmd.Flags().StringVarP(&replicateUser, "user", "u", "", "user whose files are replicated")
+	replicateBackfillCmd.Flags().BoolVar(&replicateAll, "all", false, "replicate the files of every user")
+	replicateBackfillCmd.Flags().BoolVar(&replicateDryRun, "dry-run", false, "only count the files that would be replicated")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/storage"
+)
+
+// withStorage 初始化存储并将 Manager 注入 context 后执行 fn，结束时关闭存储连接.
+// 服务层依赖 S3/DB/MQ 均可用，任一缺失时直接返回错误.
+func withStorage(fn func(ctx context.Context) error) error {
+	ctx := context.Background()
+
+	mgr, err := storage.Init(ctx)
+	if mgr == nil {
+		return fmt.Errorf("init storage: %w", err)
+	}
+
+	defer func() { _ = mgr.Close() }()
+
+	if mgr.GetS3Client() == nil || mgr.GetDBClient() == nil || mgr.GetMQClient() == nil {
+		return fmt.Errorf("storage not ready: %w", err)
+	}
+
+	return fn(ctxPkg.WithStorageManager(ctx, mgr))
+}
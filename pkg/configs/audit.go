@@ -0,0 +1,27 @@
+package configs
+
+import (
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认审计日志配置.
+	DefaultAuditEnabled   = true
+	DefaultAuditHashChain = false
+)
+
+// AuditConfig 审计日志配置.
+type AuditConfig struct {
+	// Enabled 是否记录审计日志（上传、下载、删除、分享与权限变更、配置重载等）
+	Enabled bool `mapstructure:"enabled"`
+	// HashChain 启用后每条记录包含前一条记录的哈希，形成哈希链，可通过校验接口发现记录被篡改或删除
+	HashChain bool `mapstructure:"hash_chain"`
+	// Admins 可查询与导出审计日志的管理员（用户邮箱）
+	Admins []string `mapstructure:"admins" rule:"dive,email"`
+}
+
+func (c *AuditConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("audit.enabled", DefaultAuditEnabled)
+	v.SetDefault("audit.hash_chain", DefaultAuditHashChain)
+	v.SetDefault("audit.admins", []string{})
+}
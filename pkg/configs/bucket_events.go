@@ -0,0 +1,28 @@
+package configs
+
+import (
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认存储桶事件通知配置.
+	DefaultBucketEventsEnabled = false
+	DefaultBucketEventsMQTopic = ""
+)
+
+// BucketEventsConfig 存储桶事件通知（S3/MinIO Event Notification）接入配置.
+// 预签名直传的对象不经过服务端，需依赖事件通知同步元数据.
+type BucketEventsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// WebhookToken webhook 请求需携带 Authorization: Bearer <token>（与 MinIO webhook auth_token 对应）；
+	// 为空时 webhook 拒绝所有请求，只能通过 MQTopic 接入事件
+	WebhookToken string `mapstructure:"webhook_token"`
+	// MQTopic 非空时从消息队列的该主题消费事件（MinIO 的 NATS/Redis 通知目标）
+	MQTopic string `mapstructure:"mq_topic"`
+}
+
+func (c *BucketEventsConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("bucket_events.enabled", DefaultBucketEventsEnabled)
+	v.SetDefault("bucket_events.webhook_token", "")
+	v.SetDefault("bucket_events.mq_topic", DefaultBucketEventsMQTopic)
+}
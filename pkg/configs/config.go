@@ -70,6 +70,19 @@ type (
 		Metrics        MetricsConfig        `mapstructure:"metrics"`         // MetricsConfig 监控指标配置
 		RateLimit      RateLimitConfig      `mapstructure:"rate_limit"`      // 速率限制配置（可选）
 		CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"` // 熔断器配置（可选）
+		Jobs           JobsConfig           `mapstructure:"jobs"`            // 异步批量任务配置
+		Reconcile      ReconcileConfig      `mapstructure:"reconcile"`       // 定时对账配置
+		BucketEvents   BucketEventsConfig   `mapstructure:"bucket_events"`   // 存储桶事件通知接入配置
+		Preview        PreviewConfig        `mapstructure:"preview"`         // 图片预览/缩略图配置
+		Media          MediaConfig          `mapstructure:"media"`           // 媒体元数据提取配置
+		Trash          TrashConfig          `mapstructure:"trash"`           // 回收站配置
+		Share          ShareConfig          `mapstructure:"share"`           // 分享访问保护配置
+		Versions       VersionsConfig       `mapstructure:"versions"`        // 文件版本保留配置
+		Lifecycle      LifecycleConfig      `mapstructure:"lifecycle"`       // 对象生命周期（分层迁移、过期）配置
+		Encryption     EncryptionConfig     `mapstructure:"encryption"`      // 对象加密配置
+		Replication    ReplicationConfig    `mapstructure:"replication"`     // 副本存储复制配置
+		Holds          HoldsConfig          `mapstructure:"holds"`           // 法律保留与保留期（WORM）配置
+		Audit          AuditConfig          `mapstructure:"audit"`           // 审计日志配置
 	}
 )
 
@@ -166,6 +179,19 @@ func setAllDefaults(v *viper.Viper) {
 		metricsConfig   MetricsConfig
 		rateLimitConfig RateLimitConfig
 		cbConfig        CircuitBreakerConfig
+		jobsConfig      JobsConfig
+		reconcileConfig ReconcileConfig
+		eventsConfig    BucketEventsConfig
+		previewConfig   PreviewConfig
+		mediaConfig     MediaConfig
+		trashConfig     TrashConfig
+		shareConfig     ShareConfig
+		versionsConfig  VersionsConfig
+		lifecycleConfig LifecycleConfig
+		encConfig       EncryptionConfig
+		replConfig      ReplicationConfig
+		holdsConfig     HoldsConfig
+		auditConfig     AuditConfig
 	)
 
 	serverConfig.setDefaults(v)
@@ -178,6 +204,19 @@ func setAllDefaults(v *viper.Viper) {
 	metricsConfig.setDefaults(v)
 	rateLimitConfig.setDefaults(v)
 	cbConfig.setDefaults(v)
+	jobsConfig.setDefaults(v)
+	reconcileConfig.setDefaults(v)
+	eventsConfig.setDefaults(v)
+	previewConfig.setDefaults(v)
+	mediaConfig.setDefaults(v)
+	trashConfig.setDefaults(v)
+	shareConfig.setDefaults(v)
+	versionsConfig.setDefaults(v)
+	lifecycleConfig.setDefaults(v)
+	encConfig.setDefaults(v)
+	replConfig.setDefaults(v)
+	holdsConfig.setDefaults(v)
+	auditConfig.setDefaults(v)
 }
 
 func reloadConfigs(v *viper.Viper, isHotReload bool, onReload func()) {
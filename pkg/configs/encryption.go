@@ -0,0 +1,73 @@
+package configs
+
+import (
+	"github.com/spf13/viper"
+)
+
+// 对象加密模式.
+const (
+	EncryptionModeNone     = "none"     // 不加密（按客户端发送的内容存储）
+	EncryptionModeSSES3    = "sse-s3"   // 对象存储托管密钥的服务端加密
+	EncryptionModeSSEC     = "sse-c"    // 服务端加密，密钥由 notevault 从主密钥派生并随请求提供
+	EncryptionModeEnvelope = "envelope" // notevault 信封加密：数据密钥由主密钥包装，内容分块 AES-GCM 加密
+)
+
+const (
+	// 默认加密配置.
+	DefaultEncryptionMode        = EncryptionModeNone
+	DefaultEncryptionChunkSizeKB = 64
+)
+
+// EncryptionConfig 对象加密配置：默认模式、按 bucket/用户覆盖的规则与主密钥.
+type EncryptionConfig struct {
+	// Mode 未命中规则时新写入对象的加密模式：none / sse-s3 / sse-c / envelope
+	Mode string `mapstructure:"mode" rule:"oneof=none sse-s3 sse-c envelope"`
+	// Rules 按顺序匹配的加密规则，第一条命中的规则决定新写入对象的加密模式
+	Rules []EncryptionRule `mapstructure:"rules" rule:"dive"`
+	// ActiveKeyID 包装新数据密钥（以及派生 SSE-C 密钥）使用的主密钥，为空时使用第一个主密钥
+	ActiveKeyID string `mapstructure:"active_key_id"`
+	// MasterKeys 主密钥列表；轮换时追加新密钥并切换 ActiveKeyID，旧密钥保留到对象全部重新包装
+	MasterKeys []MasterKeyConfig `mapstructure:"master_keys" rule:"dive"`
+	// ChunkSizeKB 信封加密的明文分块大小，Range 读取以分块为单位解密
+	ChunkSizeKB int `mapstructure:"chunk_size_kb" rule:"min=1,max=16384"`
+}
+
+// EncryptionRule 加密规则：同一条规则内所有非空条件需同时满足.
+type EncryptionRule struct {
+	// Mode 命中时使用的加密模式
+	Mode string `mapstructure:"mode" rule:"oneof=none sse-s3 sse-c envelope"`
+	// Buckets 对象所在 bucket
+	Buckets []string `mapstructure:"buckets"`
+	// Users 精确匹配用户（对象键的第一级目录）
+	Users []string `mapstructure:"users"`
+	// Tenants 用户邮箱 @ 之后的域名
+	Tenants []string `mapstructure:"tenants"`
+}
+
+// MasterKeyConfig 主密钥：base64 编码的 32 字节密钥，从文件或环境变量读取.
+type MasterKeyConfig struct {
+	// ID 主密钥标识，写入对象元数据，用于解包时选择密钥
+	ID string `mapstructure:"id" rule:"required"`
+	// File 存放 base64 密钥的文件路径
+	File string `mapstructure:"file" rule:"required_without=Env"`
+	// Env 存放 base64 密钥的环境变量名
+	Env string `mapstructure:"env"`
+}
+
+// Enabled 判断是否需要启用加密层：配置了加密模式、规则或主密钥（仅用于读取已加密的旧对象）时启用.
+func (c *EncryptionConfig) Enabled() bool {
+	return (c.Mode != "" && c.Mode != EncryptionModeNone) || len(c.Rules) > 0 || len(c.MasterKeys) > 0
+}
+
+// GetChunkSize 返回信封加密的明文分块大小（字节）.
+func (c *EncryptionConfig) GetChunkSize() int {
+	return c.ChunkSizeKB << 10
+}
+
+func (c *EncryptionConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("encryption.mode", DefaultEncryptionMode)
+	v.SetDefault("encryption.rules", []EncryptionRule{})
+	v.SetDefault("encryption.active_key_id", "")
+	v.SetDefault("encryption.master_keys", []MasterKeyConfig{})
+	v.SetDefault("encryption.chunk_size_kb", DefaultEncryptionChunkSizeKB)
+}
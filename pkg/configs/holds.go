@@ -0,0 +1,35 @@
+package configs
+
+import (
+	"github.com/spf13/viper"
+)
+
+// S3 对象锁定的保留模式.
+const (
+	HoldRetentionGovernance = "governance" // 具备特殊权限的账号可绕过保留期删除
+	HoldRetentionCompliance = "compliance" // 保留期内任何账号都无法删除或缩短保留期
+)
+
+const (
+	// 默认合规保留配置.
+	DefaultHoldObjectLock       = true
+	DefaultHoldRetentionMode    = HoldRetentionGovernance
+	DefaultHoldMaxRetentionDays = 3650
+)
+
+// HoldsConfig 法律保留与保留期（WORM）配置.
+type HoldsConfig struct {
+	// ObjectLock bucket 启用了 S3 对象锁定时同步设置对象的法律保留与保留期；
+	// 未启用或关闭该选项时仅由 notevault 拦截删除、移动与修改
+	ObjectLock bool `mapstructure:"object_lock"`
+	// RetentionMode 同步到 S3 的保留模式：governance / compliance
+	RetentionMode string `mapstructure:"retention_mode" rule:"oneof=governance compliance"`
+	// MaxRetentionDays 保留截止时间距今的最大天数，0 表示不限制
+	MaxRetentionDays int `mapstructure:"max_retention_days" rule:"min=0"`
+}
+
+func (c *HoldsConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("holds.object_lock", DefaultHoldObjectLock)
+	v.SetDefault("holds.retention_mode", DefaultHoldRetentionMode)
+	v.SetDefault("holds.max_retention_days", DefaultHoldMaxRetentionDays)
+}
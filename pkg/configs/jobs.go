@@ -0,0 +1,45 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认异步任务配置.
+	DefaultJobsEnabled             = true
+	DefaultJobsWorkers             = 2
+	DefaultJobsPollIntervalSeconds = 2
+	DefaultJobsFlushSize           = 100
+	DefaultJobsRetentionHours      = 72
+)
+
+// JobsConfig 异步批量任务配置.
+type JobsConfig struct {
+	Enabled             bool `mapstructure:"enabled"`
+	Workers             int  `mapstructure:"workers"               rule:"min=1,max=64"`
+	PollIntervalSeconds int  `mapstructure:"poll_interval_seconds" rule:"min=1,max=300"`
+	// FlushSize 每处理多少条目落盘一次进度（同时检查取消标记）
+	FlushSize int `mapstructure:"flush_size" rule:"min=1,max=10000"`
+	// RetentionHours 已结束任务（含条目结果）的保留时长
+	RetentionHours int `mapstructure:"retention_hours" rule:"min=1"`
+}
+
+// GetPollInterval 返回轮询间隔作为time.Duration.
+func (c *JobsConfig) GetPollInterval() time.Duration {
+	return time.Duration(c.PollIntervalSeconds) * time.Second
+}
+
+// GetRetention 返回已结束任务的保留时长.
+func (c *JobsConfig) GetRetention() time.Duration {
+	return time.Duration(c.RetentionHours) * time.Hour
+}
+
+func (c *JobsConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("jobs.enabled", DefaultJobsEnabled)
+	v.SetDefault("jobs.workers", DefaultJobsWorkers)
+	v.SetDefault("jobs.poll_interval_seconds", DefaultJobsPollIntervalSeconds)
+	v.SetDefault("jobs.flush_size", DefaultJobsFlushSize)
+	v.SetDefault("jobs.retention_hours", DefaultJobsRetentionHours)
+}
@@ -0,0 +1,70 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认生命周期配置.
+	DefaultLifecycleIntervalMinutes     = 0
+	DefaultLifecycleDryRun              = false
+	DefaultLifecycleAbortIncompleteDays = 7
+	DefaultLifecycleExpire              = true
+)
+
+// LifecycleConfig 对象生命周期配置：存储类型/冷 bucket 迁移、按上传时指定的 expiry_days 过期、清理未完成的分片上传.
+type LifecycleConfig struct {
+	// IntervalMinutes 生命周期任务的执行间隔，0 表示不自动执行
+	IntervalMinutes int `mapstructure:"interval_minutes" rule:"min=0"`
+	// DryRun 定时任务只记录将执行的动作，不修改对象
+	DryRun bool `mapstructure:"dry_run"`
+	// Expire 是否让上传时指定了 expiry_days 的文件到期后移入回收站
+	Expire bool `mapstructure:"expire"`
+	// AbortIncompleteUploadDays 分片上传发起超过该天数仍未完成时中止，0 表示不清理
+	AbortIncompleteUploadDays int `mapstructure:"abort_incomplete_upload_days" rule:"min=0"`
+	// Transitions 迁移规则，按顺序匹配，第一条命中的规则决定文件的目标存储类型与 bucket
+	Transitions []LifecycleTransition `mapstructure:"transitions" rule:"dive"`
+}
+
+// LifecycleTransition 迁移规则：文件最后修改时间超过 AfterDays 天后迁移到目标存储类型和/或目标 bucket.
+type LifecycleTransition struct {
+	// Name 规则名称，用于事件、指标与日志
+	Name string `mapstructure:"name" rule:"required"`
+	// Prefix 相对用户目录的对象键前缀，为空表示全部文件
+	Prefix string `mapstructure:"prefix"`
+	// ContentTypes Content-Type 前缀，为空表示全部类型
+	ContentTypes []string `mapstructure:"content_types"`
+	// Buckets 只迁移位于这些 bucket 中的文件，为空表示全部 bucket
+	Buckets []string `mapstructure:"buckets"`
+	// AfterDays 文件最后修改后经过的天数
+	AfterDays int `mapstructure:"after_days" rule:"min=1"`
+	// StorageClass 目标存储类型，如 STANDARD_IA、GLACIER
+	StorageClass string `mapstructure:"storage_class" rule:"required_without=TargetBucket"`
+	// TargetBucket 目标 bucket，必须出现在 s3.bucket_name 中
+	TargetBucket string `mapstructure:"target_bucket"`
+}
+
+// GetInterval 返回生命周期任务的执行间隔.
+func (c *LifecycleConfig) GetInterval() time.Duration {
+	return time.Duration(c.IntervalMinutes) * time.Minute
+}
+
+// GetAbortIncompleteUploadAge 返回中止未完成分片上传的时长阈值.
+func (c *LifecycleConfig) GetAbortIncompleteUploadAge() time.Duration {
+	return time.Duration(c.AbortIncompleteUploadDays) * 24 * time.Hour
+}
+
+// GetAfter 返回规则的生效时长.
+func (r *LifecycleTransition) GetAfter() time.Duration {
+	return time.Duration(r.AfterDays) * 24 * time.Hour
+}
+
+func (c *LifecycleConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("lifecycle.interval_minutes", DefaultLifecycleIntervalMinutes)
+	v.SetDefault("lifecycle.dry_run", DefaultLifecycleDryRun)
+	v.SetDefault("lifecycle.expire", DefaultLifecycleExpire)
+	v.SetDefault("lifecycle.abort_incomplete_upload_days", DefaultLifecycleAbortIncompleteDays)
+	v.SetDefault("lifecycle.transitions", []LifecycleTransition{})
+}
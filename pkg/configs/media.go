@@ -0,0 +1,26 @@
+package configs
+
+import "github.com/spf13/viper"
+
+const (
+	// 默认媒体元数据提取配置.
+	DefaultMediaEnabled       = true
+	DefaultMediaMaxSourceMB   = 200
+	DefaultMediaMaxMegapixels = 50
+)
+
+// MediaConfig 媒体元数据（EXIF、尺寸、PDF 信息、音频标签）提取配置.
+type MediaConfig struct {
+	// Enabled 为 true 时订阅对象存储/更新事件提取元数据，并在删除事件后清理
+	Enabled bool `mapstructure:"enabled"`
+	// MaxSourceMB 超过该大小的 PDF 不做解析（图片与音频只读取头部/标签，不受限制）
+	MaxSourceMB int `mapstructure:"max_source_mb" rule:"min=1"`
+	// MaxMegapixels 超过该像素数的图片不计算感知哈希（需要完整解码）
+	MaxMegapixels int `mapstructure:"max_megapixels" rule:"min=1"`
+}
+
+func (c *MediaConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("media.enabled", DefaultMediaEnabled)
+	v.SetDefault("media.max_source_mb", DefaultMediaMaxSourceMB)
+	v.SetDefault("media.max_megapixels", DefaultMediaMaxMegapixels)
+}
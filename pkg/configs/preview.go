@@ -0,0 +1,54 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认缩略图配置.
+	DefaultPreviewEnabled         = true
+	DefaultPreviewEager           = false
+	DefaultPreviewFormat          = "jpeg"
+	DefaultPreviewQuality         = 80
+	DefaultPreviewMaxSourceMB     = 50
+	DefaultPreviewMaxMegapixels   = 50
+	DefaultPreviewCacheMaxAgeSecs = 86400
+)
+
+// DefaultPreviewSizes 默认缩略图尺寸（长边像素）.
+var DefaultPreviewSizes = []int{128, 512, 1024}
+
+// PreviewConfig 图片预览/缩略图配置.
+type PreviewConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Eager 为 true 时订阅 nv.object.image.stored 事件预生成所有尺寸，否则在首次请求时生成
+	Eager bool `mapstructure:"eager"`
+	// Sizes 允许的缩略图尺寸（长边像素），请求的 size 必须在其中
+	Sizes   []int  `mapstructure:"sizes"   rule:"min=1,dive,min=16,max=4096"`
+	Format  string `mapstructure:"format"  rule:"oneof=jpeg webp"` // webp 为无损编码，体积通常大于 jpeg
+	Quality int    `mapstructure:"quality" rule:"min=1,max=100"`   // 仅 jpeg 生效
+	// MaxSourceMB 原图超过该大小时不生成缩略图
+	MaxSourceMB int `mapstructure:"max_source_mb" rule:"min=1"`
+	// MaxMegapixels 原图像素数上限，防止解码超大图片耗尽内存
+	MaxMegapixels int `mapstructure:"max_megapixels" rule:"min=1"`
+	// CacheMaxAgeSeconds 预览响应的 Cache-Control max-age
+	CacheMaxAgeSeconds int `mapstructure:"cache_max_age_seconds" rule:"min=0"`
+}
+
+// GetCacheMaxAge 返回预览响应的缓存时长.
+func (c *PreviewConfig) GetCacheMaxAge() time.Duration {
+	return time.Duration(c.CacheMaxAgeSeconds) * time.Second
+}
+
+func (c *PreviewConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("preview.enabled", DefaultPreviewEnabled)
+	v.SetDefault("preview.eager", DefaultPreviewEager)
+	v.SetDefault("preview.sizes", DefaultPreviewSizes)
+	v.SetDefault("preview.format", DefaultPreviewFormat)
+	v.SetDefault("preview.quality", DefaultPreviewQuality)
+	v.SetDefault("preview.max_source_mb", DefaultPreviewMaxSourceMB)
+	v.SetDefault("preview.max_megapixels", DefaultPreviewMaxMegapixels)
+	v.SetDefault("preview.cache_max_age_seconds", DefaultPreviewCacheMaxAgeSecs)
+}
@@ -0,0 +1,35 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认定时对账配置.
+	DefaultReconcileEnabled         = false
+	DefaultReconcileIntervalMinutes = 1440
+	DefaultReconcileFix             = false
+	DefaultReconcileDeep            = false
+)
+
+// ReconcileConfig 对象存储与数据库定时对账配置.
+type ReconcileConfig struct {
+	Enabled         bool `mapstructure:"enabled"`
+	IntervalMinutes int  `mapstructure:"interval_minutes" rule:"min=1"`
+	Fix             bool `mapstructure:"fix"`  // 是否自动修复差异，否则仅输出报告
+	Deep            bool `mapstructure:"deep"` // 是否逐个 Stat 比对 ContentType
+}
+
+// GetInterval 返回对账间隔作为time.Duration.
+func (c *ReconcileConfig) GetInterval() time.Duration {
+	return time.Duration(c.IntervalMinutes) * time.Minute
+}
+
+func (c *ReconcileConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("reconcile.enabled", DefaultReconcileEnabled)
+	v.SetDefault("reconcile.interval_minutes", DefaultReconcileIntervalMinutes)
+	v.SetDefault("reconcile.fix", DefaultReconcileFix)
+	v.SetDefault("reconcile.deep", DefaultReconcileDeep)
+}
@@ -0,0 +1,84 @@
+package configs
+
+import (
+	"slices"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认复制配置.
+	DefaultReplicationEnabled              = false
+	DefaultReplicationTargetEndpoint       = "localhost:9010"
+	DefaultReplicationTargetLocalRootDir   = "data/replica"
+	DefaultReplicationMaxAttempts          = 10
+	DefaultReplicationRetryIntervalMinutes = 5
+	DefaultReplicationRetryBackoffSeconds  = 30
+)
+
+// maxReplicationBackoff 重试等待时间上限.
+const maxReplicationBackoff = time.Hour
+
+// ReplicationConfig 对象复制配置：将对象的写入与删除同步到另一个对象存储，作为灾备副本.
+type ReplicationConfig struct {
+	// Enabled 是否启用复制；启用后订阅对象存储/更新/删除事件
+	Enabled bool `mapstructure:"enabled"`
+	// Target 副本所在的对象存储，格式与 s3 相同；buckets 按顺序对应 s3.buckets，为空时沿用相同的 bucket 名称
+	Target S3Config `mapstructure:"target"`
+	// MaxAttempts 单个对象的最多尝试次数，超过后保持 failed，等待 backfill 重新复制
+	MaxAttempts int `mapstructure:"max_attempts" rule:"min=1"`
+	// RetryIntervalMinutes 重试失败复制的任务间隔，0 表示不自动重试
+	RetryIntervalMinutes int `mapstructure:"retry_interval_minutes" rule:"min=0"`
+	// RetryBackoffSeconds 第一次重试前的等待时间，之后每次翻倍，最长 1 小时
+	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds" rule:"min=1"`
+}
+
+// GetRetryInterval 返回重试任务的执行间隔.
+func (c *ReplicationConfig) GetRetryInterval() time.Duration {
+	return time.Duration(c.RetryIntervalMinutes) * time.Minute
+}
+
+// GetBackoff 返回第 attempts 次失败后的重试等待时间.
+func (c *ReplicationConfig) GetBackoff(attempts int) time.Duration {
+	d := time.Duration(c.RetryBackoffSeconds) * time.Second
+	for i := 1; i < attempts && d < maxReplicationBackoff; i++ {
+		d *= 2
+	}
+
+	return min(d, maxReplicationBackoff)
+}
+
+// TargetBuckets 返回副本存储的 bucket 列表：未配置时与主存储相同.
+func (c *ReplicationConfig) TargetBuckets(primary []string) []string {
+	if len(c.Target.Buckets) == 0 {
+		return primary
+	}
+
+	return c.Target.Buckets
+}
+
+// TargetBucket 返回主存储 bucket 对应的副本 bucket：按在 s3.buckets 中的位置对应，没有对应项时使用相同名称.
+func (c *ReplicationConfig) TargetBucket(primary []string, bucket string) string {
+	if i := slices.Index(primary, bucket); i >= 0 && i < len(c.Target.Buckets) {
+		return c.Target.Buckets[i]
+	}
+
+	return bucket
+}
+
+func (c *ReplicationConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("replication.enabled", DefaultReplicationEnabled)
+	v.SetDefault("replication.target.driver", DefaultS3Driver)
+	v.SetDefault("replication.target.endpoint", DefaultReplicationTargetEndpoint)
+	v.SetDefault("replication.target.access_key_id", DefaultS3AccessKeyID)
+	v.SetDefault("replication.target.secret_access_key", DefaultS3SecretAccessKey)
+	v.SetDefault("replication.target.use_ssl", DefaultS3UseSSL)
+	v.SetDefault("replication.target.buckets", []string{})
+	v.SetDefault("replication.target.region", DefaultS3Region)
+	v.SetDefault("replication.target.local.root_dir", DefaultReplicationTargetLocalRootDir)
+	v.SetDefault("replication.target.local.public_url", DefaultS3LocalPublicURL)
+	v.SetDefault("replication.max_attempts", DefaultReplicationMaxAttempts)
+	v.SetDefault("replication.retry_interval_minutes", DefaultReplicationRetryIntervalMinutes)
+	v.SetDefault("replication.retry_backoff_seconds", DefaultReplicationRetryBackoffSeconds)
+}
@@ -7,22 +7,62 @@ import (
 )
 
 const (
-	DefaultS3Endpoint        = "localhost:9000" // 默认S3端点
-	DefaultS3AccessKeyID     = "minioadmin"     // 默认访问密钥ID
-	DefaultS3SecretAccessKey = "minioadmin"     // 默认秘密访问密钥
-	DefaultS3UseSSL          = false            // 默认是否使用SSL
-	DefaultS3BucketName      = "notevault"      // 默认单个存储桶名称（向后兼容，转换为切片）
-	DefaultS3Region          = "us-east-1"      // 默认区域
+	DefaultS3Endpoint        = "localhost:9000"        // 默认S3端点
+	DefaultS3AccessKeyID     = "minioadmin"            // 默认访问密钥ID
+	DefaultS3SecretAccessKey = "minioadmin"            // 默认秘密访问密钥
+	DefaultS3UseSSL          = false                   // 默认是否使用SSL
+	DefaultS3BucketName      = "notevault"             // 默认单个存储桶名称（向后兼容，转换为切片）
+	DefaultS3Region          = "us-east-1"             // 默认区域
+	DefaultS3Driver          = S3DriverMinio           // 默认对象存储驱动
+	DefaultS3LocalRootDir    = "data/objects"          // 本地驱动默认存储目录
+	DefaultS3LocalPublicURL  = "http://localhost:8080" // 本地驱动默认对外访问地址
 )
 
-// S3Config MinIO S3存储配置.
+// 对象存储驱动.
+const (
+	S3DriverMinio = "minio" // S3 兼容存储（MinIO、AWS S3 等）
+	S3DriverLocal = "local" // 本地磁盘，预签名 URL 由 notevault 的签名接口提供
+)
+
+// S3Config 对象存储配置.
 type S3Config struct {
+	Driver          string   `mapstructure:"driver"            rule:"oneof=minio local"`
 	Endpoint        string   `mapstructure:"endpoint"          rule:"hostname_port"`
 	AccessKeyID     string   `mapstructure:"access_key_id"`
 	SecretAccessKey string   `mapstructure:"secret_access_key"`
 	UseSSL          bool     `mapstructure:"use_ssl"`
 	Buckets         []string `mapstructure:"buckets"` // 支持多个 bucket
 	Region          string   `mapstructure:"region"`
+	// Local 本地磁盘驱动配置（driver 为 local 时使用）
+	Local LocalStoreConfig `mapstructure:"local"`
+	// Routing 新对象写入时的 bucket 路由规则，按顺序匹配，第一条命中的规则生效；都不命中时使用 buckets[0]
+	Routing []BucketRouteRule `mapstructure:"routing" rule:"dive"`
+}
+
+// BucketRouteRule bucket 路由规则：所有非空条件同时满足时命中.
+type BucketRouteRule struct {
+	// Bucket 目标 bucket，必须出现在 buckets 列表中
+	Bucket string `mapstructure:"bucket"        rule:"required"`
+	// ContentTypes Content-Type 前缀，如 "video/"、"application/pdf"
+	ContentTypes []string `mapstructure:"content_types"`
+	// MinSizeMB 文件大小下限（MB），大小未知（预签名上传）时该条件不命中
+	MinSizeMB int64 `mapstructure:"min_size_mb"   rule:"gte=0"`
+	// Users 用户列表（精确匹配）
+	Users []string `mapstructure:"users"`
+	// Tenants 租户列表，按用户邮箱 @ 之后的域名匹配
+	Tenants []string `mapstructure:"tenants"`
+	// Categories 文件分类列表
+	Categories []string `mapstructure:"categories"`
+}
+
+// LocalStoreConfig 本地磁盘对象存储配置.
+type LocalStoreConfig struct {
+	// RootDir 对象存储根目录，每个 bucket 对应一个子目录
+	RootDir string `mapstructure:"root_dir"   rule:"required"`
+	// PublicURL 客户端访问 notevault 的地址，用于拼接预签名 URL
+	PublicURL string `mapstructure:"public_url" rule:"required,url"`
+	// SigningSecret 预签名 URL 的 HMAC 密钥；为空时进程启动时随机生成（重启后已签发的 URL 失效）
+	SigningSecret string `mapstructure:"signing_secret"`
 }
 
 // GetEndpointURL 获取完整的端点URL.
@@ -37,6 +77,7 @@ func (c *S3Config) GetEndpointURL() string {
 
 // setDefaults 设置 S3 配置的默认值.
 func (c *S3Config) setDefaults(v *viper.Viper) {
+	v.SetDefault("s3.driver", DefaultS3Driver)
 	v.SetDefault("s3.endpoint", DefaultS3Endpoint)
 	v.SetDefault("s3.access_key_id", DefaultS3AccessKeyID)
 	v.SetDefault("s3.secret_access_key", DefaultS3SecretAccessKey)
@@ -44,4 +85,7 @@ func (c *S3Config) setDefaults(v *viper.Viper) {
 	v.SetDefault("s3.bucket_name", DefaultS3BucketName)
 	v.SetDefault("s3.buckets", []string{DefaultS3BucketName})
 	v.SetDefault("s3.region", DefaultS3Region)
+	v.SetDefault("s3.local.root_dir", DefaultS3LocalRootDir)
+	v.SetDefault("s3.local.public_url", DefaultS3LocalPublicURL)
+	v.SetDefault("s3.local.signing_secret", "")
 }
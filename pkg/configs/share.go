@@ -0,0 +1,83 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认分享访问保护配置.
+	DefaultShareMaxFailedAttempts    = 5
+	DefaultShareFailureWindowMinutes = 15
+	DefaultShareLockoutMinutes       = 15
+	DefaultShareTokenTTLMinutes      = 30
+	DefaultShareSweepIntervalMinutes = 60
+	DefaultShareAccessLogDays        = 90
+	DefaultShareUploadMaxFileSizeMB  = 1024
+	DefaultShareUploadMaxFiles       = 20
+)
+
+// ShareConfig 分享配置.
+type ShareConfig struct {
+	// MaxFailedAttempts 统计窗口内允许的密码错误次数，达到后锁定（按分享与来源 IP 分别计数）
+	MaxFailedAttempts int `mapstructure:"max_failed_attempts" rule:"min=1"`
+	// FailureWindowMinutes 失败次数统计窗口，自第一次失败起计算
+	FailureWindowMinutes int `mapstructure:"failure_window_minutes" rule:"min=1"`
+	// LockoutMinutes 锁定时长，锁定期间即使密码正确也拒绝访问
+	LockoutMinutes int `mapstructure:"lockout_minutes" rule:"min=1"`
+	// TokenSecret 分享访问令牌的 HMAC 密钥；为空时进程启动时随机生成（重启或多实例部署时令牌失效）
+	TokenSecret string `mapstructure:"token_secret"`
+	// TokenTTLMinutes 分享访问令牌有效期
+	TokenTTLMinutes int `mapstructure:"token_ttl_minutes" rule:"min=1"`
+	// SweepIntervalMinutes 清理已过期或次数用尽的分享的间隔，0 表示不自动清理
+	SweepIntervalMinutes int `mapstructure:"sweep_interval_minutes" rule:"min=0"`
+	// AccessLogRetentionDays 访问日志保留天数，由清理任务删除更早的记录，0 表示永久保留
+	AccessLogRetentionDays int `mapstructure:"access_log_retention_days" rule:"min=0"`
+	// UploadMaxFileSizeMB 上传型分享中单个文件的大小上限，owner 设置的限制不能超过该值
+	UploadMaxFileSizeMB int64 `mapstructure:"upload_max_file_size_mb" rule:"min=1"`
+	// UploadMaxFiles 上传型分享单次申请上传的最大文件数
+	UploadMaxFiles int `mapstructure:"upload_max_files" rule:"min=1"`
+}
+
+// GetFailureWindow 返回失败次数统计窗口.
+func (c *ShareConfig) GetFailureWindow() time.Duration {
+	return time.Duration(c.FailureWindowMinutes) * time.Minute
+}
+
+// GetLockout 返回锁定时长.
+func (c *ShareConfig) GetLockout() time.Duration {
+	return time.Duration(c.LockoutMinutes) * time.Minute
+}
+
+// GetTokenTTL 返回分享访问令牌有效期.
+func (c *ShareConfig) GetTokenTTL() time.Duration {
+	return time.Duration(c.TokenTTLMinutes) * time.Minute
+}
+
+// GetSweepInterval 返回分享清理间隔.
+func (c *ShareConfig) GetSweepInterval() time.Duration {
+	return time.Duration(c.SweepIntervalMinutes) * time.Minute
+}
+
+// GetAccessLogRetention 返回访问日志保留时长，0 表示永久保留.
+func (c *ShareConfig) GetAccessLogRetention() time.Duration {
+	return time.Duration(c.AccessLogRetentionDays) * 24 * time.Hour
+}
+
+// GetUploadMaxFileSize 返回上传型分享单个文件的字节上限.
+func (c *ShareConfig) GetUploadMaxFileSize() int64 {
+	return c.UploadMaxFileSizeMB << 20
+}
+
+func (c *ShareConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("share.max_failed_attempts", DefaultShareMaxFailedAttempts)
+	v.SetDefault("share.failure_window_minutes", DefaultShareFailureWindowMinutes)
+	v.SetDefault("share.lockout_minutes", DefaultShareLockoutMinutes)
+	v.SetDefault("share.token_secret", "")
+	v.SetDefault("share.token_ttl_minutes", DefaultShareTokenTTLMinutes)
+	v.SetDefault("share.sweep_interval_minutes", DefaultShareSweepIntervalMinutes)
+	v.SetDefault("share.access_log_retention_days", DefaultShareAccessLogDays)
+	v.SetDefault("share.upload_max_file_size_mb", DefaultShareUploadMaxFileSizeMB)
+	v.SetDefault("share.upload_max_files", DefaultShareUploadMaxFiles)
+}
@@ -0,0 +1,36 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// 默认回收站配置.
+	DefaultTrashRetentionDays        = 30
+	DefaultTrashCleanIntervalMinutes = 60
+)
+
+// TrashConfig 回收站配置.
+type TrashConfig struct {
+	// RetentionDays 回收站中的文件保留天数，过期后由定时任务永久删除
+	RetentionDays int `mapstructure:"retention_days" rule:"min=1"`
+	// CleanIntervalMinutes 过期清理任务的执行间隔，0 表示不自动清理
+	CleanIntervalMinutes int `mapstructure:"clean_interval_minutes" rule:"min=0"`
+}
+
+// GetRetention 返回回收站保留时长.
+func (c *TrashConfig) GetRetention() time.Duration {
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// GetCleanInterval 返回过期清理间隔.
+func (c *TrashConfig) GetCleanInterval() time.Duration {
+	return time.Duration(c.CleanIntervalMinutes) * time.Minute
+}
+
+func (c *TrashConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("trash.retention_days", DefaultTrashRetentionDays)
+	v.SetDefault("trash.clean_interval_minutes", DefaultTrashCleanIntervalMinutes)
+}
@@ -0,0 +1,52 @@
+package configs
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 版本化模式.
+const (
+	VersionsModeAuto     = "auto"     // 存储桶启用版本化时使用原生版本，否则由 notevault 模拟
+	VersionsModeNative   = "native"   // 始终使用对象存储的原生版本
+	VersionsModeEmulated = "emulated" // 始终由 notevault 模拟：覆盖前将旧内容复制到 .versions/ 下
+)
+
+const (
+	// 默认版本保留配置.
+	DefaultVersionsPruneIntervalMinutes = 60
+	DefaultVersionsDiffMaxSizeKB        = 1024
+)
+
+// VersionsConfig 文件版本配置.
+type VersionsConfig struct {
+	// Mode 版本化模式：auto / native / emulated
+	Mode string `mapstructure:"mode" rule:"oneof=auto native emulated"`
+	// PruneIntervalMinutes 按保留规则清理旧版本的间隔，0 表示不自动清理
+	PruneIntervalMinutes int `mapstructure:"prune_interval_minutes" rule:"min=0"`
+	// DefaultKeepLast 用户未设置保留规则时保留的最近版本数，0 表示不按数量清理
+	DefaultKeepLast int `mapstructure:"default_keep_last" rule:"min=0"`
+	// DefaultKeepDays 用户未设置保留规则时保留的天数，0 表示不按时间清理
+	DefaultKeepDays int `mapstructure:"default_keep_days" rule:"min=0"`
+	// DiffMaxSizeKB 版本文本对比时单个版本允许读取的最大大小，超过时只比较大小、校验和与元数据
+	DiffMaxSizeKB int `mapstructure:"diff_max_size_kb" rule:"min=1"`
+}
+
+// GetPruneInterval 返回旧版本清理间隔.
+func (c *VersionsConfig) GetPruneInterval() time.Duration {
+	return time.Duration(c.PruneIntervalMinutes) * time.Minute
+}
+
+// GetDiffMaxSize 返回版本文本对比的单个版本大小上限（字节）.
+func (c *VersionsConfig) GetDiffMaxSize() int64 {
+	return int64(c.DiffMaxSizeKB) * 1024
+}
+
+func (c *VersionsConfig) setDefaults(v *viper.Viper) {
+	v.SetDefault("versions.mode", VersionsModeAuto)
+	v.SetDefault("versions.prune_interval_minutes", DefaultVersionsPruneIntervalMinutes)
+	v.SetDefault("versions.default_keep_last", 0)
+	v.SetDefault("versions.default_keep_days", 0)
+	v.SetDefault("versions.diff_max_size_kb", DefaultVersionsDiffMaxSizeKB)
+}
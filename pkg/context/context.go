@@ -18,8 +18,19 @@ type ContextKey string
 
 const (
 	StorageManagerKey ContextKey = "storageManager"
+	RequestInfoKey    ContextKey = "requestInfo"
 )
 
+// RequestInfo 请求来源信息，由中间件写入，审计日志使用.
+type RequestInfo struct {
+	IP        string
+	UserAgent string
+	// User 请求声明的用户（X-User 头或 user 参数），服务层未提供操作者时使用
+	User string
+	// RequestID X-Request-ID 头，未启用追踪时代替 trace ID
+	RequestID string
+}
+
 // WithStorageManager 将 Manager 存储到 context 中.
 func WithStorageManager(ctx context.Context, mgr *storage.Manager) context.Context {
 	return context.WithValue(ctx, StorageManagerKey, mgr)
@@ -43,6 +54,15 @@ func GetS3Client(ctx context.Context) *s3c.Client {
 	return nil
 }
 
+// GetReplicaS3Client 从 context 中获取复制目标的对象存储客户端，未启用复制时返回 nil.
+func GetReplicaS3Client(ctx context.Context) *s3c.Client {
+	if mgr := GetManager(ctx); mgr != nil {
+		return mgr.GetReplicaS3Client()
+	}
+
+	return nil
+}
+
 // GetDBClient 从 context 中获取 DB 客户端.
 func GetDBClient(ctx context.Context) *dbc.Client {
 	if mgr := GetManager(ctx); mgr != nil {
@@ -70,6 +90,26 @@ func GetKVClient(ctx context.Context) *kvc.Client {
 	return nil
 }
 
+// WithRequestInfo 将请求来源信息存储到 context 中.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, RequestInfoKey, info)
+}
+
+// GetRequestInfo 从 context 中获取请求来源信息，非 HTTP 请求（后台任务、命令行）返回零值.
+func GetRequestInfo(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(RequestInfoKey).(RequestInfo)
+	return info
+}
+
+// TraceID 返回 context 中正在记录的 span 的 trace ID，未启用追踪时返回空串.
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanFromContext(ctx).SpanContext(); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+
+	return ""
+}
+
 // WithTraceContext 创建带有追踪上下文的logger.
 func WithTraceContext(ctx context.Context, logger zerolog.Logger) zerolog.Logger {
 	span := trace.SpanFromContext(ctx)
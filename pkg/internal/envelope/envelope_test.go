@@ -0,0 +1,156 @@
+package envelope_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/yeisme/notevault/pkg/internal/envelope"
+)
+
+func newKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, envelope.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// encrypt 加密 plain 并返回密文.
+func encrypt(t *testing.T, p *envelope.Params, plain []byte) []byte {
+	t.Helper()
+
+	r, err := p.Encrypt(iotest.HalfReader(bytes.NewReader(plain)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	return out
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	const chunk = 16
+
+	for _, size := range []int{0, 1, chunk - 1, chunk, chunk + 1, 3 * chunk, 5*chunk + 7} {
+		p, err := envelope.NewParams(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		plain := make([]byte, size)
+		_, _ = rand.Read(plain)
+
+		ct := encrypt(t, p, plain)
+		if int64(len(ct)) != envelope.CiphertextSize(int64(size), chunk) {
+			t.Fatalf("size %d: ciphertext %d, want %d", size, len(ct), envelope.CiphertextSize(int64(size), chunk))
+		}
+
+		ra, err := p.NewReaderAt(bytes.NewReader(ct), int64(len(ct)))
+		if err != nil || ra.Size() != int64(size) {
+			t.Fatalf("size %d: reader = %v, %v", size, ra, err)
+		}
+
+		got, err := io.ReadAll(io.NewSectionReader(ra, 0, ra.Size()))
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Fatalf("size %d: read = %v", size, err)
+		}
+
+		// 跨块的随机读取
+		for off := 0; off < size; off += 5 {
+			for _, n := range []int{1, chunk, 2*chunk + 3} {
+				end := min(off+n, size)
+
+				buf := make([]byte, end-off)
+				if _, err := ra.ReadAt(buf, int64(off)); err != nil && !errors.Is(err, io.EOF) {
+					t.Fatalf("size %d: ReadAt(%d, %d): %v", size, off, n, err)
+				}
+
+				if !bytes.Equal(buf, plain[off:end]) {
+					t.Fatalf("size %d: ReadAt(%d, %d) mismatch", size, off, n)
+				}
+			}
+		}
+	}
+}
+
+func TestStreamTamperAndTruncate(t *testing.T) {
+	const chunk = 16
+
+	p, _ := envelope.NewParams(chunk)
+	plain := bytes.Repeat([]byte("a"), 3*chunk)
+	ct := encrypt(t, p, plain)
+
+	tampered := bytes.Clone(ct)
+	tampered[chunk+20] ^= 1
+
+	ra, _ := p.NewReaderAt(bytes.NewReader(tampered), int64(len(tampered)))
+	if _, err := ra.ReadAt(make([]byte, 4), chunk+2); err == nil {
+		t.Fatalf("tampered chunk decrypted")
+	}
+
+	// 在块边界截断：原倒数第二块不带末块标记，无法作为末块通过认证
+	truncated := ct[:2*(chunk+16)]
+
+	ra, err := p.NewReaderAt(bytes.NewReader(truncated), int64(len(truncated)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.ReadAll(io.NewSectionReader(ra, 0, ra.Size())); err == nil {
+		t.Fatalf("truncated ciphertext accepted")
+	}
+}
+
+func TestKeyringWrapAndRotate(t *testing.T) {
+	oldKey, newKeyBytes := newKey(t), newKey(t)
+
+	old, err := envelope.NewKeyring("k1", map[string][]byte{"k1": oldKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataKey, _ := envelope.NewDataKey()
+
+	id, wrapped, err := old.Wrap(dataKey)
+	if err != nil || id != "k1" {
+		t.Fatalf("wrap = %s, %v", id, err)
+	}
+
+	rotated, err := envelope.NewKeyring("k2", map[string][]byte{"k1": oldKey, "k2": newKeyBytes})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := rotated.IDs(); len(ids) != 2 || ids[0] != "k2" {
+		t.Fatalf("ids = %v", ids)
+	}
+
+	got, err := rotated.Unwrap(id, wrapped)
+	if err != nil || !bytes.Equal(got, dataKey) {
+		t.Fatalf("unwrap with old key = %v", err)
+	}
+
+	// 包装数据绑定了主密钥 ID
+	if _, err := rotated.Unwrap("k2", wrapped); err == nil {
+		t.Fatalf("unwrap with wrong key succeeded")
+	}
+
+	if _, err := envelope.NewKeyring("k3", map[string][]byte{"k1": oldKey}); !errors.Is(err, envelope.ErrUnknownKey) {
+		t.Fatalf("missing active key: %v", err)
+	}
+
+	if _, err := envelope.NewKeyring("k1", map[string][]byte{"k1": oldKey[:16]}); !errors.Is(err, envelope.ErrInvalidKey) {
+		t.Fatalf("short key: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Package envelope 实现 notevault 管理的信封加密：每个对象随机生成数据密钥，
+// 数据密钥由主密钥以 AES-256-GCM 包装后随对象元数据保存；对象内容按固定大小分块以 AES-256-GCM 加密，
+// 每块独立认证，因此可以只解密 Range 覆盖的分块.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// KeySize 主密钥与数据密钥长度（AES-256）.
+const KeySize = 32
+
+var (
+	// ErrUnknownKey 主密钥 ID 不在密钥环中（已被移除的旧密钥）.
+	ErrUnknownKey = errors.New("unknown master key")
+	// ErrInvalidKey 密钥长度不是 32 字节.
+	ErrInvalidKey = errors.New("master key must be 32 bytes")
+)
+
+// Keyring 主密钥环：Active 用于包装新的数据密钥，其余密钥只用于解包旧对象，轮换后可在对象全部重新包装后移除.
+type Keyring struct {
+	active string
+	keys   map[string][]byte
+}
+
+// NewKeyring 创建密钥环，active 必须是 keys 中的一个 ID.
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no master key configured")
+	}
+
+	k := &Keyring{active: active, keys: make(map[string][]byte, len(keys))}
+
+	for id, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("master key %s: %w", id, ErrInvalidKey)
+		}
+
+		k.keys[id] = slices.Clone(key)
+	}
+
+	if _, ok := k.keys[active]; !ok {
+		return nil, fmt.Errorf("active master key %s: %w", active, ErrUnknownKey)
+	}
+
+	return k, nil
+}
+
+// ActiveID 返回当前主密钥 ID.
+func (k *Keyring) ActiveID() string {
+	return k.active
+}
+
+// IDs 返回所有主密钥 ID，当前主密钥在前，其余按字典序.
+func (k *Keyring) IDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		if id != k.active {
+			ids = append(ids, id)
+		}
+	}
+
+	slices.Sort(ids)
+
+	return append([]string{k.active}, ids...)
+}
+
+// Wrap 用当前主密钥包装数据密钥，返回主密钥 ID 与 nonce||密文.
+func (k *Keyring) Wrap(dataKey []byte) (string, []byte, error) {
+	aead, err := newGCM(k.keys[k.active])
+	if err != nil {
+		return "", nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return k.active, aead.Seal(nonce, nonce, dataKey, []byte(k.active)), nil
+}
+
+// Unwrap 用指定主密钥解包数据密钥.
+func (k *Keyring) Unwrap(id string, wrapped []byte) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrapped) < aead.NonceSize()+aead.Overhead() {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+
+	dataKey, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key with %s: %w", id, err)
+	}
+
+	return dataKey, nil
+}
+
+// DeriveKey 由主密钥派生用途相关的 32 字节密钥（HMAC-SHA256），如 SSE-C 的客户提供密钥.
+func (k *Keyring) DeriveKey(id, purpose string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil), nil
+}
+
+// NewDataKey 生成随机数据密钥.
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generate data key: %w", err)
+	}
+
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
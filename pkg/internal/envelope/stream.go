@@ -0,0 +1,287 @@
+package envelope
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// DefaultChunkSize 默认明文分块大小.
+	DefaultChunkSize = 64 << 10
+	// NoncePrefixSize 每个对象随机的 nonce 前缀长度；分块 nonce = 前缀(7) || 块序号(4, 大端) || 末块标记(1).
+	NoncePrefixSize = 7
+	// tagSize 每个分块的 GCM 认证标签长度.
+	tagSize = 16
+)
+
+// ErrTruncated 密文长度与分块格式不符（对象被截断或不是该格式）.
+var ErrTruncated = errors.New("encrypted object truncated")
+
+// Params 单个对象的加密参数：数据密钥、nonce 前缀与明文分块大小.
+type Params struct {
+	DataKey     []byte
+	NoncePrefix []byte
+	ChunkSize   int
+}
+
+// NewParams 生成新对象的加密参数（随机数据密钥与 nonce 前缀）.
+func NewParams(chunkSize int) (*Params, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	key, err := NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, NoncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, fmt.Errorf("generate nonce prefix: %w", err)
+	}
+
+	return &Params{DataKey: key, NoncePrefix: prefix, ChunkSize: chunkSize}, nil
+}
+
+// CiphertextSize 返回明文大小对应的密文大小，plain 小于 0（未知）时返回 -1.
+// 空对象也会生成一个只含认证标签的末块.
+func CiphertextSize(plain int64, chunkSize int) int64 {
+	if plain < 0 {
+		return -1
+	}
+
+	chunks := max((plain+int64(chunkSize)-1)/int64(chunkSize), 1)
+
+	return plain + chunks*tagSize
+}
+
+// PlaintextSize 由密文大小计算明文大小.
+func PlaintextSize(cipherSize int64, chunkSize int) (int64, error) {
+	full := int64(chunkSize) + tagSize
+
+	chunks := (cipherSize + full - 1) / full
+	if chunks == 0 || cipherSize-(chunks-1)*full < tagSize {
+		return 0, ErrTruncated
+	}
+
+	return cipherSize - chunks*tagSize, nil
+}
+
+// Encrypt 返回读取 r 中明文并输出分块密文的 Reader.
+func (p *Params) Encrypt(r io.Reader) (io.Reader, error) {
+	aead, err := p.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptReader{p: p, aead: aead, src: r, next: make([]byte, p.ChunkSize)}, nil
+}
+
+// NewReaderAt 返回对密文 src（大小为 cipherSize）按明文偏移随机读取的 ReaderAt.
+func (p *Params) NewReaderAt(src io.ReaderAt, cipherSize int64) (*ReaderAt, error) {
+	aead, err := p.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := PlaintextSize(cipherSize, p.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	full := int64(p.ChunkSize) + tagSize
+
+	return &ReaderAt{
+		p:      p,
+		aead:   aead,
+		src:    src,
+		size:   size,
+		chunks: (cipherSize + full - 1) / full,
+		cached: -1,
+	}, nil
+}
+
+func (p *Params) aead() (cipher.AEAD, error) {
+	if p.ChunkSize <= 0 || len(p.NoncePrefix) != NoncePrefixSize {
+		return nil, fmt.Errorf("invalid encryption parameters")
+	}
+
+	return newGCM(p.DataKey)
+}
+
+// nonce 返回第 index 个分块的 nonce.
+func (p *Params) nonce(index int64, final bool) []byte {
+	n := make([]byte, 12)
+	copy(n, p.NoncePrefix)
+	binary.BigEndian.PutUint32(n[NoncePrefixSize:], uint32(index)) //nolint:gosec // 块数上限 2^32，分块 64KiB 时对应 256TiB
+
+	if final {
+		n[11] = 1
+	}
+
+	return n
+}
+
+// encryptReader 预读下一块以判断当前块是否为末块，末块标记防止密文在块边界被截断.
+type encryptReader struct {
+	p     *Params
+	aead  cipher.AEAD
+	src   io.Reader
+	index int64
+
+	cur     []byte // 已读取、待加密的明文块
+	next    []byte
+	started bool
+	done    bool
+
+	out []byte // 待输出的密文
+	err error
+}
+
+func (e *encryptReader) Read(b []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+
+		if e.done {
+			return 0, io.EOF
+		}
+
+		e.fill()
+	}
+
+	n := copy(b, e.out)
+	e.out = e.out[n:]
+
+	return n, nil
+}
+
+// fill 加密下一个分块到 out.
+func (e *encryptReader) fill() {
+	if !e.started {
+		e.started = true
+
+		chunk, err := e.readChunk(make([]byte, e.p.ChunkSize))
+		if err != nil {
+			e.err = err
+			return
+		}
+
+		e.cur = chunk
+	}
+
+	following, err := e.readChunk(e.next)
+	if err != nil {
+		e.err = err
+		return
+	}
+
+	final := len(following) == 0
+	e.out = e.aead.Seal(e.out[:0], e.p.nonce(e.index, final), e.cur, nil)
+	e.index++
+
+	if final {
+		e.done = true
+		return
+	}
+
+	// 交换缓冲区：刚读取的块成为当前块
+	e.cur, e.next = following, e.cur[:cap(e.cur)]
+}
+
+// readChunk 读取一个完整分块，源数据结束时返回不足一块（可能为空）的数据.
+func (e *encryptReader) readChunk(buf []byte) ([]byte, error) {
+	n, err := io.ReadFull(e.src, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+
+	return buf[:n], nil
+}
+
+// ReaderAt 按明文偏移解密分块密文；缓存最近解密的一块，顺序读取时每块只解密一次.
+type ReaderAt struct {
+	p      *Params
+	aead   cipher.AEAD
+	src    io.ReaderAt
+	size   int64
+	chunks int64
+
+	mu     sync.Mutex
+	cached int64
+	plain  []byte
+	buf    []byte
+}
+
+// Size 返回明文大小.
+func (r *ReaderAt) Size() int64 {
+	return r.size
+}
+
+// ReadAt 实现 io.ReaderAt.
+func (r *ReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for n < len(b) {
+		if off >= r.size {
+			return n, io.EOF
+		}
+
+		index := off / int64(r.p.ChunkSize)
+		if err := r.load(index); err != nil {
+			return n, err
+		}
+
+		c := copy(b[n:], r.plain[off-index*int64(r.p.ChunkSize):])
+		n += c
+		off += int64(c)
+	}
+
+	return n, nil
+}
+
+// load 读取并解密第 index 块.
+func (r *ReaderAt) load(index int64) error {
+	if index == r.cached {
+		return nil
+	}
+
+	full := int64(r.p.ChunkSize) + tagSize
+	start := index * full
+	length := min(full, r.size+r.chunks*tagSize-start)
+
+	if cap(r.buf) < int(length) {
+		r.buf = make([]byte, full)
+	}
+
+	buf := r.buf[:length]
+	if n, err := r.src.ReadAt(buf, start); n < len(buf) {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = ErrTruncated
+		}
+
+		return fmt.Errorf("read encrypted chunk %d: %w", index, err)
+	}
+
+	plain, err := r.aead.Open(r.plain[:0], r.p.nonce(index, index == r.chunks-1), buf, nil)
+	if err != nil {
+		r.cached = -1
+		return fmt.Errorf("decrypt chunk %d: %w", index, err)
+	}
+
+	r.plain, r.cached = plain, index
+
+	return nil
+}
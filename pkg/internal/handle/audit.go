@@ -0,0 +1,163 @@
+package handle
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// ListAuditEvents 按条件查询审计日志（仅管理员）.
+//
+//	@Summary	查询审计日志
+//	@Tags		审计日志
+//	@Produce	json
+//	@Param		actor		query		string	false	"操作者"
+//	@Param		action		query		string	false	"动作，如 file.delete、share.create"
+//	@Param		outcome		query		string	false	"结果：success/partial/failure/denied"
+//	@Param		target		query		string	false	"目标对象键或分享 ID"
+//	@Param		trace_id	query		string	false	"trace ID 或请求 ID"
+//	@Param		since		query		string	false	"起始时间（RFC3339，含）"
+//	@Param		until		query		string	false	"截止时间（RFC3339，不含）"
+//	@Param		page		query		int		false	"页码（从 1 开始）"
+//	@Param		page_size	query		int		false	"每页数量"
+//	@Success	200			{object}	types.ListAuditEventsResponse
+//	@Failure	400			{object}	map[string]string
+//	@Failure	403			{object}	map[string]string
+//	@Failure	500			{object}	map[string]string
+//	@Router		/api/v1/admin/audit [get]
+func ListAuditEvents(c *gin.Context) {
+	l := log.Logger()
+
+	if _, ok := checkAdmin(c); !ok {
+		return
+	}
+
+	var q types.AuditQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := service.NewAuditService(c.Request.Context())
+
+	resp, err := svc.ListAuditEvents(c.Request.Context(), &q)
+	if err != nil {
+		l.Error().Err(err).Msg("list audit events failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ExportAuditEvents 按条件以 NDJSON 流式导出审计日志（仅管理员），按时间正序，忽略分页参数.
+// 导出操作本身也写入审计日志.
+//
+//	@Summary	导出审计日志（NDJSON）
+//	@Tags		审计日志
+//	@Produce	x-ndjson
+//	@Param		actor		query		string	false	"操作者"
+//	@Param		action		query		string	false	"动作"
+//	@Param		outcome		query		string	false	"结果：success/partial/failure/denied"
+//	@Param		target		query		string	false	"目标对象键或分享 ID"
+//	@Param		trace_id	query		string	false	"trace ID 或请求 ID"
+//	@Param		since		query		string	false	"起始时间（RFC3339，含）"
+//	@Param		until		query		string	false	"截止时间（RFC3339，不含）"
+//	@Success	200			{string}	string	"每行一条 types.AuditEventInfo"
+//	@Failure	400			{object}	map[string]string
+//	@Failure	403			{object}	map[string]string
+//	@Failure	500			{object}	map[string]string
+//	@Router		/api/v1/admin/audit/export [get]
+func ExportAuditEvents(c *gin.Context) {
+	l := log.Logger()
+
+	admin, ok := checkAdmin(c)
+	if !ok {
+		return
+	}
+
+	var q types.AuditQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	svc := service.NewAuditService(ctx)
+
+	c.Header("Content-Type", ndjsonContentType)
+	c.Header("Content-Disposition", "attachment; filename=\"audit-"+time.Now().UTC().Format("20060102T150405Z")+".ndjson\"")
+	c.Status(http.StatusOK)
+
+	n, err := svc.ExportAuditEvents(ctx, &q, c.Writer)
+	service.RecordAudit(ctx, admin, types.AuditActionAuditExport, nil, err, c.Request.URL.RawQuery)
+
+	if err != nil {
+		l.Error().Err(err).Int("exported", n).Msg("export audit events failed")
+
+		// 尚未写出任何内容时仍可返回 JSON 错误，否则响应头已发送，只能记录日志
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+	}
+}
+
+// VerifyAuditChain 校验审计日志的哈希链（仅管理员），返回第一条被篡改、删除或插入的记录.
+//
+//	@Summary	校验审计日志哈希链
+//	@Tags		审计日志
+//	@Produce	json
+//	@Success	200	{object}	types.VerifyAuditResponse
+//	@Failure	403	{object}	map[string]string
+//	@Failure	500	{object}	map[string]string
+//	@Router		/api/v1/admin/audit/verify [get]
+func VerifyAuditChain(c *gin.Context) {
+	l := log.Logger()
+
+	if _, ok := checkAdmin(c); !ok {
+		return
+	}
+
+	svc := service.NewAuditService(c.Request.Context())
+
+	resp, err := svc.VerifyAuditChain(c.Request.Context())
+	if err != nil {
+		l.Error().Err(err).Msg("verify audit chain failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// checkAdmin 校验调用者为 audit.admins 中配置的管理员，否则写入错误响应并返回 false.
+func checkAdmin(c *gin.Context) (string, bool) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return "", false
+	}
+
+	if !slices.Contains(configs.GetConfig().Audit.Admins, user) {
+		l.Warn().Str("user", user).Msg("admin access denied")
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+
+		return "", false
+	}
+
+	return user, true
+}
@@ -0,0 +1,95 @@
+package handle
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// maxBucketEventBodyBytes 单次事件通知请求体上限.
+const maxBucketEventBodyBytes = 8 << 20
+
+// ReceiveBucketEvents 接收 S3/MinIO 存储桶事件通知（webhook），同步对象元数据到数据库.
+//
+//	@Summary		接收存储桶事件通知
+//	@Description	标准 S3 事件通知 JSON，处理 s3:ObjectCreated:* 与 s3:ObjectRemoved:* 事件；处理失败返回 5xx 以便事件源重试
+//	@Tags			事件
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"Bearer <webhook_token>（未配置 webhook_token 时 webhook 不可用）"
+//	@Success		200				{object}	types.BucketEventsResponse
+//	@Failure		400				{object}	map[string]string
+//	@Failure		401				{object}	map[string]string
+//	@Failure		404				{object}	map[string]string
+//	@Failure		500				{object}	map[string]string
+//	@Router			/api/v1/events/s3 [post]
+func ReceiveBucketEvents(c *gin.Context) {
+	l := log.Logger()
+	cfg := configs.GetConfig().BucketEvents
+
+	if !cfg.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "bucket events disabled"})
+		return
+	}
+
+	// 未配置 token 时拒绝所有 webhook 请求，避免匿名请求触发对任意键的 Stat 与数据库写入
+	if cfg.WebhookToken == "" {
+		l.Warn().Str("ip", c.ClientIP()).Msg("bucket event webhook rejected: webhook_token not configured")
+		c.JSON(http.StatusNotFound, gin.H{"error": "bucket event webhook disabled: webhook_token not configured"})
+
+		return
+	}
+
+	if !checkWebhookToken(c.GetHeader("Authorization"), cfg.WebhookToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBucketEventBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := service.ParseBucketEvents(body)
+	if err != nil {
+		l.Warn().Err(err).Msg("invalid bucket notification")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.HandleBucketEvents(c.Request.Context(), events)
+	if err != nil {
+		l.Error().Err(err).Msg("handle bucket events failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// checkWebhookToken 校验 Authorization 头；token 为空时一律不通过.
+// MinIO 对不含空格的 auth_token 自动添加 "Bearer " 前缀，这里两种形式均接受.
+func checkWebhookToken(header, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	got := strings.TrimSpace(header)
+	if after, ok := strings.CutPrefix(got, "Bearer "); ok {
+		got = strings.TrimSpace(after)
+	}
+
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,446 @@
+package handle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+const (
+	// archiveManifestName 压缩包根目录的清单文件名.
+	archiveManifestName = "MANIFEST.json"
+	// archiveFailedTrailer 打包结束后以 HTTP Trailer 返回失败条目数.
+	archiveFailedTrailer = "X-Archive-Failed"
+	// archiveReadAttempts 单个对象读取中断时的最大尝试次数（从已写出的位置继续读取）.
+	archiveReadAttempts = 3
+)
+
+// archiveFormats 打包格式 -> 文件扩展名与 Content-Type.
+var archiveFormats = map[string]struct{ ext, contentType string }{
+	types.ArchiveFormatZip:    {".zip", "application/zip"},
+	types.ArchiveFormatTar:    {".tar", "application/x-tar"},
+	types.ArchiveFormatTarGz:  {".tar.gz", "application/gzip"},
+	types.ArchiveFormatTarZst: {".tar.zst", "application/zstd"},
+}
+
+// archiveItem 待打包的条目；info 为空表示解析阶段已失败，仅记录到清单.
+type archiveItem struct {
+	entry types.ArchiveManifestEntry
+	info  *types.ObjectInfo
+}
+
+// serveArchive 将请求的对象与文件夹打包流式返回，并在根目录写入 MANIFEST.json.
+// 单个对象失败不会中断打包，失败原因记录在清单中，失败数量通过 X-Archive-Failed trailer 返回；下载审计记录在 actor 名下.
+func serveArchive(c *gin.Context, svc *service.FileService, actor, user string, req *types.DownloadFilesRequest) error {
+	ctx := c.Request.Context()
+
+	format := req.ArchiveFormat
+	if format == "" {
+		format = types.ArchiveFormatZip
+	}
+
+	items, folderName := resolveArchiveItems(ctx, svc, user, req.Objects)
+
+	archiveName := strings.TrimSpace(req.ArchiveName)
+	if archiveName == "" {
+		archiveName = folderName
+	}
+
+	if archiveName == "" {
+		archiveName = suggestArchiveName(req.Objects)
+	}
+
+	ext := archiveFormats[format].ext
+	if !strings.HasSuffix(strings.ToLower(archiveName), ext) {
+		archiveName += ext
+	}
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", archiveFormats[format].contentType)
+	h.Set("Content-Disposition", "attachment; filename=\""+escapeRFC5987(archiveName)+"\"")
+	h.Set("Trailer", archiveFailedTrailer)
+	c.Status(http.StatusOK)
+
+	sink := &sinkWriter{w: c.Writer}
+
+	aw, err := newArchiveWriter(format, sink)
+	if err != nil {
+		return err
+	}
+
+	manifest := types.ArchiveManifest{
+		Format:    format,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Entries:   make([]types.ArchiveManifestEntry, 0, len(items)),
+	}
+	// 预留清单文件名，同名对象自动重命名
+	names := map[string]int{archiveManifestName: 1}
+
+	for i := range items {
+		it := &items[i]
+
+		if it.info != nil {
+			it.entry.Path = uniqueArchivePath(names, it.entry.Path)
+			if err := writeArchiveEntry(ctx, svc, user, aw, sink, it); err != nil {
+				return err
+			}
+		}
+
+		if it.entry.Status == types.ArchiveEntryFailed {
+			manifest.Failed++
+		}
+
+		manifest.Entries = append(manifest.Entries, it.entry)
+	}
+
+	manifest.Total = len(manifest.Entries)
+
+	svc.RecordArchiveDownload(ctx, actor, &manifest)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	w, err := aw.Create(archiveManifestName, int64(len(data)), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	if err := aw.Close(); err != nil {
+		return err
+	}
+
+	h.Set(archiveFailedTrailer, strconv.Itoa(manifest.Failed))
+
+	return nil
+}
+
+// resolveArchiveItems 解析请求条目：对象逐个 Stat，文件夹展开为其中的全部对象.
+// 仅请求了一个文件夹时返回其名称，用作默认压缩包名.
+func resolveArchiveItems(ctx context.Context, svc *service.FileService,
+	user string, objects []types.DownloadObjectItem) ([]archiveItem, string) {
+	l := log.Logger()
+	items := make([]archiveItem, 0, len(objects))
+	folderName := ""
+
+	for _, obj := range objects {
+		if obj.FolderID != "" {
+			fullPath, infos, err := svc.ListFolderObjects(ctx, user, obj.FolderID)
+			if err != nil {
+				l.Warn().Err(err).Str("folder_id", obj.FolderID).Msg("resolve archive folder failed")
+				items = append(items, archiveItem{entry: types.ArchiveManifestEntry{
+					FolderID: obj.FolderID, Status: types.ArchiveEntryFailed, Error: err.Error(),
+				}})
+
+				continue
+			}
+
+			name := path.Base(fullPath)
+			if len(objects) == 1 {
+				folderName = name
+			}
+
+			prefix := user + "/" + fullPath + "/"
+
+			for i := range infos {
+				items = append(items, archiveItem{
+					entry: types.ArchiveManifestEntry{
+						ObjectKey: infos[i].ObjectKey,
+						FolderID:  obj.FolderID,
+						Path:      sanitizeArchivePath(name + "/" + strings.TrimPrefix(infos[i].ObjectKey, prefix)),
+						Size:      infos[i].Size,
+					},
+					info: &infos[i],
+				})
+			}
+
+			continue
+		}
+
+		entry := types.ArchiveManifestEntry{
+			ObjectKey: obj.ObjectKey,
+			Path:      sanitizeArchivePath(resolveFileName(obj.FileName, obj.ObjectKey)),
+		}
+
+		info, err := svc.StatObject(ctx, user, obj.ObjectKey)
+		if err != nil {
+			l.Warn().Err(err).Str("key", obj.ObjectKey).Msg("resolve archive object failed")
+			entry.Status = types.ArchiveEntryFailed
+			entry.Error = err.Error()
+			items = append(items, archiveItem{entry: entry})
+
+			continue
+		}
+
+		entry.Size = info.Size
+		items = append(items, archiveItem{entry: entry, info: info})
+	}
+
+	return items, folderName
+}
+
+// writeArchiveEntry 写入单个对象并更新清单条目；仅在写出失败（客户端断开）时返回错误.
+func writeArchiveEntry(ctx context.Context, svc *service.FileService, user string,
+	aw archiveWriter, sink *sinkWriter, it *archiveItem) error {
+	modTime, _ := time.Parse(time.RFC3339, it.info.LastModified)
+
+	w, err := aw.Create(it.entry.Path, it.info.Size, modTime)
+	if err != nil {
+		return err
+	}
+
+	n, sum, err := copyObjectResilient(ctx, svc, user, it.info, w, sink)
+	if sink.err != nil {
+		return sink.err
+	}
+
+	if err != nil {
+		log.Logger().Warn().Err(err).Str("key", it.info.ObjectKey).Msg("archive entry incomplete")
+		it.entry.Status = types.ArchiveEntryFailed
+		it.entry.Error = fmt.Sprintf("read object: %v (wrote %d of %d bytes)", err, n, it.info.Size)
+
+		return nil
+	}
+
+	it.entry.Status = types.ArchiveEntryOK
+	it.entry.SHA256 = sum
+
+	return nil
+}
+
+// copyObjectResilient 将对象写入 w 并计算 SHA-256；读取中断时从已写出的位置以 Range 继续读取.
+func copyObjectResilient(ctx context.Context, svc *service.FileService, user string,
+	info *types.ObjectInfo, w io.Writer, sink *sinkWriter) (int64, string, error) {
+	hasher := sha256.New()
+	dst := io.MultiWriter(w, hasher)
+
+	var (
+		written int64
+		lastErr error
+	)
+
+	for range archiveReadAttempts {
+		if err := ctx.Err(); err != nil {
+			return written, "", err
+		}
+
+		remaining := info.Size - written
+
+		rc, err := svc.OpenObjectRange(ctx, user, info.ObjectKey, info.ETag, written, remaining)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		n, err := io.Copy(dst, io.LimitReader(rc, remaining))
+		_ = rc.Close()
+		written += n
+
+		if sink.err != nil {
+			return written, "", sink.err
+		}
+
+		if err == nil && written == info.Size {
+			return written, hex.EncodeToString(hasher.Sum(nil)), nil
+		}
+
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+
+		lastErr = err
+	}
+
+	return written, "", lastErr
+}
+
+// sinkWriter 记录写出错误，用于区分客户端断开（终止打包）与对象读取失败（记录后继续）.
+type sinkWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (s *sinkWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+
+	return n, err
+}
+
+// archiveWriter 压缩包写入器.
+type archiveWriter interface {
+	// Create 开始一个文件条目，size 为预期大小（tar 需要预先写入头部）
+	Create(name string, size int64, modTime time.Time) (io.Writer, error)
+	Close() error
+}
+
+func newArchiveWriter(format string, w io.Writer) (archiveWriter, error) {
+	switch format {
+	case types.ArchiveFormatTar:
+		return newTarArchive(w, nil), nil
+	case types.ArchiveFormatTarGz:
+		gw := gzip.NewWriter(w)
+		return newTarArchive(gw, gw), nil
+	case types.ArchiveFormatTarZst:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+
+		return newTarArchive(zw, zw), nil
+	default:
+		return &zipArchive{zw: zip.NewWriter(w)}, nil
+	}
+}
+
+// zipArchive ZIP 写入器；条目大小或数量超出 ZIP 限制（4 GiB / 65535）时 archive/zip 自动写入 ZIP64 扩展.
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchive) Create(name string, _ int64, modTime time.Time) (io.Writer, error) {
+	fh := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	if !modTime.IsZero() {
+		fh.Modified = modTime
+	}
+
+	return a.zw.CreateHeader(fh)
+}
+
+func (a *zipArchive) Close() error {
+	return a.zw.Close()
+}
+
+// tarArchive tar 写入器（可选外层压缩）.
+// tar 头部需预先声明大小，对象读取失败导致内容不足时以 0 填充，保证压缩包结构完整.
+type tarArchive struct {
+	tw        *tar.Writer
+	comp      io.WriteCloser
+	remaining int64
+}
+
+func newTarArchive(w io.Writer, comp io.WriteCloser) *tarArchive {
+	return &tarArchive{tw: tar.NewWriter(w), comp: comp}
+}
+
+func (a *tarArchive) Create(name string, size int64, modTime time.Time) (io.Writer, error) {
+	if err := a.pad(); err != nil {
+		return nil, err
+	}
+
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     0o644,
+		ModTime:  modTime,
+	}
+
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+
+	a.remaining = size
+
+	return a, nil
+}
+
+// Write 写入当前条目内容.
+func (a *tarArchive) Write(p []byte) (int, error) {
+	n, err := a.tw.Write(p)
+	a.remaining -= int64(n)
+
+	return n, err
+}
+
+func (a *tarArchive) Close() error {
+	if err := a.pad(); err != nil {
+		return err
+	}
+
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+
+	if a.comp != nil {
+		return a.comp.Close()
+	}
+
+	return nil
+}
+
+// pad 以 0 补齐当前条目未写满的部分.
+func (a *tarArchive) pad() error {
+	if a.remaining <= 0 {
+		return nil
+	}
+
+	_, err := io.CopyN(a, zeroReader{}, a.remaining)
+
+	return err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// sanitizeArchivePath 规范化压缩包内路径，去除绝对路径与 ".." 以免解压时越界.
+func sanitizeArchivePath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(p, "\\", "/")), "/")
+	if p == "" {
+		return "file"
+	}
+
+	return p
+}
+
+// uniqueArchivePath 同名条目追加序号，如 a.txt -> a (1).txt.
+func uniqueArchivePath(names map[string]int, p string) string {
+	n, exists := names[p]
+	names[p] = n + 1
+
+	if !exists {
+		return p
+	}
+
+	ext := path.Ext(p)
+	base := strings.TrimSuffix(p, ext)
+
+	for {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if _, taken := names[candidate]; !taken {
+			names[candidate] = 1
+			return candidate
+		}
+
+		n++
+	}
+}
@@ -1,10 +1,11 @@
 package handle
 
 import (
-	"archive/zip"
-	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"mime"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strconv"
@@ -14,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
 	"github.com/yeisme/notevault/pkg/internal/types"
 	"github.com/yeisme/notevault/pkg/log"
 )
@@ -54,7 +56,13 @@ func GetDownloadURL(c *gin.Context) {
 	resp, err := svc.PresignedGetURLs(c.Request.Context(), &req)
 	if err != nil {
 		l.Error().Err(err).Msg("failed to generate presigned get urls")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, s3.ErrPresignEncrypted) {
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, gin.H{"error": err.Error()})
 
 		return
 	}
@@ -65,12 +73,14 @@ func GetDownloadURL(c *gin.Context) {
 // DownloadFiles 直传下载（单文件或批量打包）。
 //
 //	@Summary		下载文件（直传/打包）
-//	@Description	当对象数量为1且未指定archive时，直接返回文件流；否则按zip打包返回，同时会在响应头附带部分元信息。
+//	@Description	当对象数量为1、未指定archive且不是文件夹时，直接返回文件流（支持 Range 与条件请求，同 GET 接口）；
+//	@Description	否则按 archive_format（zip/tar/tar.gz/tar.zst，默认 zip）打包返回，文件夹展开为 <文件夹名>/<相对路径>。
+//	@Description	压缩包根目录包含 MANIFEST.json，列出每个请求对象的 SHA-256 与状态；失败条目数通过 X-Archive-Failed trailer 返回。
 //	@Tags			文件下载
 //	@Accept			json
 //	@Produce		application/octet-stream
 //	@Param			req	body		types.DownloadFilesRequest	true	"下载请求"
-//	@Success		200	{file}		file						"文件流或zip包"
+//	@Success		200	{file}		file						"文件流或压缩包"
 //	@Failure		400	{object}	map[string]string			"请求参数错误"
 //	@Failure		500	{object}	map[string]string			"服务器内部错误"
 //	@Router			/api/v1/files/download [post]
@@ -102,16 +112,63 @@ func DownloadFiles(c *gin.Context) {
 
 	svc := service.NewFileService(c.Request.Context())
 
-	if len(req.Objects) == 1 && !req.Archive {
-		if err := serveSingleFile(c, svc, user, req.Objects[0]); err != nil {
+	if len(req.Objects) == 1 && !req.Archive && req.Objects[0].FolderID == "" {
+		if err := serveSingleFile(c, svc, user, user, req.Objects[0], false); err != nil {
 			l.Error().Err(err).Msg("serve single file failed")
 		}
 
 		return
 	}
 
-	if err := serveZip(c, svc, user, req.Objects, req.ArchiveName); err != nil {
-		l.Error().Err(err).Msg("serve zip failed")
+	if err := serveArchive(c, svc, user, user, &req); err != nil {
+		l.Error().Err(err).Msg("serve archive failed")
+	}
+}
+
+// DownloadFile 以 GET 方式下载单个文件，便于浏览器直接链接（如 <video src>）.
+//
+//	@Summary		下载单个文件（GET）
+//	@Description	支持 Range（单段/多段 multipart/byteranges）、If-Range 与条件请求（If-None-Match/If-Modified-Since 返回 304，If-Match/If-Unmodified-Since 返回 412）。
+//	@Tags			文件下载
+//	@Produce		application/octet-stream
+//	@Param			object_key	query		string	true	"对象键"
+//	@Param			file_name	query		string	false	"下载文件名，默认取对象键最后一段"
+//	@Param			inline		query		bool	false	"为 true 时以 inline 方式返回，便于浏览器直接播放/预览"
+//	@Param			Range		header		string	false	"字节范围，如 bytes=0-1023"
+//	@Success		200			{file}		file	"完整文件流"
+//	@Success		206			{file}		file	"部分内容"
+//	@Success		304			"未修改"
+//	@Failure		400			{object}	map[string]string	"请求参数错误"
+//	@Failure		403			{object}	map[string]string	"无权访问"
+//	@Failure		404			{object}	map[string]string	"对象不存在"
+//	@Failure		412			"前置条件失败"
+//	@Failure		416			"范围无法满足"
+//	@Failure		500			{object}	map[string]string	"服务器内部错误"
+//	@Router			/api/v1/files/download [get]
+func DownloadFile(c *gin.Context) {
+	l := log.Logger()
+
+	var req types.DownloadFileQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		l.Warn().Err(err).Msg("invalid query")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	item := types.DownloadObjectItem{ObjectKey: req.ObjectKey, FileName: req.FileName}
+	if err := serveSingleFile(c, svc, user, user, item, req.Inline); err != nil {
+		l.Error().Err(err).Msg("serve single file failed")
 	}
 }
 
@@ -121,96 +178,157 @@ func escapeRFC5987(s string) string {
 	return replacer.Replace(s)
 }
 
-// serveSingleFile 直接返回单个文件流。
+// serveSingleFile 以 actor 身份返回 user 的单个文件，支持条件请求（304/412）与 Range 请求（单段/multipart 多段）.
+// 每个分段通过对象存储的 Range 读取，不会读取整个对象；HEAD 请求只返回响应头，不记录下载审计.
 func serveSingleFile(c *gin.Context, svc *service.FileService,
-	user string, item types.DownloadObjectItem) error {
-	obj, info, err := svc.OpenObject(c.Request.Context(), user, item.ObjectKey)
+	actor, user string, item types.DownloadObjectItem, inline bool) error {
+	ctx := c.Request.Context()
+	head := c.Request.Method == http.MethodHead
+
+	var (
+		info *types.ObjectInfo
+		err  error
+	)
+
+	if head {
+		info, err = svc.StatObject(ctx, user, item.ObjectKey)
+	} else {
+		info, err = svc.StatDownload(ctx, actor, user, item.ObjectKey)
+	}
+
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondObjectError(c, err)
 		return err
 	}
 
-	defer func() { _ = obj.Close() }()
+	etag := quoteETag(info.ETag)
+	modTime, _ := time.Parse(time.RFC3339, info.LastModified)
+
+	h := c.Writer.Header()
+	h.Set("Accept-Ranges", "bytes")
+
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+
+	if !modTime.IsZero() {
+		h.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+
+	if status := checkPreconditions(c.Request, etag, modTime); status != 0 {
+		c.Status(status)
+		return nil
+	}
 
 	fileName := resolveFileName(item.FileName, item.ObjectKey)
+
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+
+	h.Set("Content-Disposition", disposition+"; filename=\""+escapeRFC5987(fileName)+"\"")
+
 	contentType := determineContentType(fileName, info.ContentType)
-	reader := io.Reader(obj)
-	// 若仍未知类型，尝试读取前 512 字节进行嗅探
-	if contentType == "application/octet-stream" {
-		const sniffLen = 512
-
-		buf := make([]byte, sniffLen)
-
-		n, _ := io.ReadFull(obj, buf)
-		if n > 0 {
-			contentType = http.DetectContentType(buf[:n])
-			reader = io.MultiReader(bytes.NewReader(buf[:n]), obj)
-		} else {
-			reader = obj
-		}
+	// 若仍未知类型，读取前 512 字节进行嗅探
+	if contentType == "application/octet-stream" && info.Size > 0 {
+		contentType = sniffContentType(c, svc, user, info)
 	}
 
-	c.Header("Content-Type", contentType)
-	c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
-	c.Header("ETag", info.ETag)
+	var ranges []byteRange
+
+	if rh := c.GetHeader("Range"); rh != "" && ifRangeMatch(c.Request, etag, modTime) {
+		ranges, err = parseByteRanges(rh, info.Size)
+
+		switch {
+		case errors.Is(err, errUnsatisfiableRange):
+			h.Set("Content-Range", fmt.Sprintf("bytes */%d", info.Size))
+			c.Status(http.StatusRequestedRangeNotSatisfiable)
 
-	if info.LastModified != "" {
-		c.Header("Last-Modified", info.LastModified)
+			return nil
+		case err != nil, len(ranges) > maxByteRanges, sumRangesSize(ranges) > info.Size:
+			// 语法错误或分段过多（含大量重叠）时忽略 Range，返回完整内容
+			ranges = nil
+		}
+	}
+
+	if len(ranges) > 1 {
+		return serveMultipartRanges(c, svc, actor, user, info, ranges, contentType)
 	}
 
-	c.Header("Content-Disposition", "attachment; filename=\""+escapeRFC5987(fileName)+"\"")
+	h.Set("Content-Type", contentType)
+
+	if len(ranges) == 1 {
+		h.Set("Content-Range", ranges[0].contentRange(info.Size))
+		h.Set("Content-Length", strconv.FormatInt(ranges[0].length, 10))
+		c.Status(http.StatusPartialContent)
+	} else {
+		h.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+		c.Status(http.StatusOK)
+	}
 
-	_, copyErr := io.Copy(c.Writer, reader)
+	if head {
+		return nil
+	}
 
-	return copyErr
+	return svc.DownloadObject(ctx, actor, user, info, objectRanges(ranges), func(int) (io.Writer, error) { return c.Writer, nil })
 }
 
-// serveZip 将多个对象打包为 ZIP 并流式返回。
-func serveZip(c *gin.Context, svc *service.FileService,
-	user string, items []types.DownloadObjectItem, archiveName string) error { //nolint:ireturn
-	c.Header("Content-Type", "application/zip")
+// serveMultipartRanges 以 multipart/byteranges 返回多个分段.
+func serveMultipartRanges(c *gin.Context, svc *service.FileService, actor, user string,
+	info *types.ObjectInfo, ranges []byteRange, contentType string) error {
+	h := c.Writer.Header()
+	h.Set("Content-Length", strconv.FormatInt(multipartRangesSize(ranges, contentType, info.Size), 10))
 
-	if strings.TrimSpace(archiveName) == "" {
-		archiveName = suggestArchiveName(items)
+	mw := multipart.NewWriter(c.Writer)
+	h.Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	c.Status(http.StatusPartialContent)
+
+	if c.Request.Method == http.MethodHead {
+		return nil
 	}
 
-	if !strings.HasSuffix(strings.ToLower(archiveName), ".zip") {
-		archiveName += ".zip"
+	err := svc.DownloadObject(c.Request.Context(), actor, user, info, objectRanges(ranges), func(i int) (io.Writer, error) {
+		return mw.CreatePart(ranges[i].mimeHeader(contentType, info.Size))
+	})
+	if err != nil {
+		return err
 	}
 
-	c.Header("Content-Disposition", "attachment; filename=\""+escapeRFC5987(archiveName)+"\"")
+	return mw.Close()
+}
 
-	zw := zip.NewWriter(c.Writer)
+// sniffContentType 读取对象前 512 字节推断 Content-Type.
+func sniffContentType(c *gin.Context, svc *service.FileService, user string, info *types.ObjectInfo) string {
+	const sniffLen = 512
 
-	defer func() { _ = zw.Close() }()
+	rc, err := svc.OpenObjectRange(c.Request.Context(), user, info.ObjectKey, info.ETag, 0, min(info.Size, sniffLen))
+	if err != nil {
+		return "application/octet-stream"
+	}
 
-	for _, it := range items {
-		obj, info, err := svc.OpenObject(c.Request.Context(), user, it.ObjectKey)
-		if err != nil {
-			// 跳过错误的条目，继续打包其他文件
-			continue
-		}
+	defer func() { _ = rc.Close() }()
 
-		name := resolveFileName(it.FileName, it.ObjectKey)
-		fh := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	buf := make([]byte, sniffLen)
 
-		if info.LastModified != "" {
-			if t, parseErr := time.Parse(time.RFC3339, info.LastModified); parseErr == nil {
-				fh.Modified = t
-			}
-		}
+	n, _ := io.ReadFull(rc, buf)
+	if n == 0 {
+		return "application/octet-stream"
+	}
 
-		w, err := zw.CreateHeader(fh)
-		if err != nil {
-			_ = obj.Close()
-			continue
-		}
+	return http.DetectContentType(buf[:n])
+}
 
-		_, _ = io.Copy(w, obj)
-		_ = obj.Close()
+// respondObjectError 将对象读取错误映射为 HTTP 状态码.
+func respondObjectError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrObjectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrObjectAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
-
-	return nil
 }
 
 // resolveFileName 解析下载文件名。
@@ -0,0 +1,104 @@
+package handle
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// FindDuplicateFiles 查找重复文件：内容完全相同的文件与感知哈希相近的图片.
+//
+//	@Summary		查找重复文件
+//	@Description	exact 分组按 ETag 与大小；similar 分组按图片 pHash/dHash 汉明距离（需已提取媒体元数据）。每组 Files[0] 为建议保留的文件
+//	@Tags			文件操作
+//	@Produce		json
+//	@Param			mode		query		string	false	"exact / similar / all（默认）"
+//	@Param			distance	query		int		false	"近似判定的最大汉明距离（0-32，默认 6）"
+//	@Param			prefix		query		string	false	"对象键前缀"
+//	@Success		200			{object}	types.DuplicatesResponse
+//	@Failure		400			{object}	map[string]string	"请求参数错误"
+//	@Failure		500			{object}	map[string]string	"服务器内部错误"
+//	@Router			/api/v1/files/duplicates [get]
+func FindDuplicateFiles(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	var q types.DuplicatesQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.FindDuplicates(c.Request.Context(), user, &q)
+	if err != nil {
+		l.Error().Err(err).Msg("find duplicates failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ResolveDuplicateFiles 批量处理重复文件：每组保留一个，其余移入回收站.
+//
+//	@Summary		处理重复文件
+//	@Description	每组保留 keep 指定的文件，object_keys 中的其余文件移入回收站（可在回收站中恢复）
+//	@Tags			文件操作
+//	@Accept			json
+//	@Produce		json
+//	@Param			req	body		types.ResolveDuplicatesRequest	true	"处理请求"
+//	@Success		200	{object}	types.TrashOpResponse
+//	@Failure		400	{object}	map[string]string	"请求参数错误"
+//	@Failure		500	{object}	map[string]string	"服务器内部错误"
+//	@Router			/api/v1/files/duplicates/resolve [post]
+func ResolveDuplicateFiles(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	var req types.ResolveDuplicatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, g := range req.Groups {
+		if !slices.Contains(g.ObjectKeys, g.Keep) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "keep must be one of object_keys"})
+			return
+		}
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.ResolveDuplicates(c.Request.Context(), user, &req)
+	if err != nil {
+		l.Error().Err(err).Msg("resolve duplicates failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
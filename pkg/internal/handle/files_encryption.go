@@ -0,0 +1,59 @@
+package handle
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// RotateEncryptionKeys 将当前用户仍由旧主密钥保护的对象改用当前主密钥。
+//
+//	@Summary		轮换加密主密钥
+//	@Description	信封加密的对象重新包装数据密钥，SSE-C 对象以当前主密钥派生的密钥重新加密；覆盖用户的文件、模拟版本与回收站对象。dry_run=true 时只统计需要轮换的对象
+//	@Tags			文件操作
+//	@Produce		json
+//	@Param			dry_run	query		bool	false	"仅统计，不修改对象"
+//	@Success		200		{object}	types.RotateKeysResponse
+//	@Failure		400		{object}	map[string]string
+//	@Failure		500		{object}	map[string]string
+//	@Router			/api/v1/files/encryption/rotate [post]
+func RotateEncryptionKeys(c *gin.Context) {
+	l := log.Logger()
+
+	var q types.RotateKeysQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.RotateEncryptionKeys(c.Request.Context(), user, q.DryRun)
+	if err != nil {
+		l.Error().Err(err).Msg("rotate encryption keys failed")
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrEncryptionDisabled) {
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -2,6 +2,7 @@ package handle
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -158,7 +159,13 @@ func handleFolderWithID(c *gin.Context, operation string, serviceFunc func(*serv
 	resp, err := serviceFunc(svc, c.Request.Context(), user, folderID, req)
 	if err != nil {
 		l.Error().Err(err).Msgf("failed to %s folder", operation)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrObjectHeld) {
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, gin.H{"error": err.Error()})
 
 		return
 	}
@@ -0,0 +1,118 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// SetObjectHold 设置或解除文件的法律保留，设置或延长保留期.
+//
+//	@Summary		设置文件保护（法律保留 / 保留期）
+//	@Description	受保护的文件不能删除、移入回收站、移动、覆盖、修改元数据或删除历史版本；保留期只能延长。bucket 启用 S3 对象锁定时同步设置到对象
+//	@Tags			文件操作
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		types.SetObjectHoldRequest	true	"保护设置"
+//	@Success		200		{object}	types.ObjectHoldInfo
+//	@Failure		400		{object}	map[string]string
+//	@Failure		403		{object}	map[string]string
+//	@Failure		404		{object}	map[string]string
+//	@Failure		500		{object}	map[string]string
+//	@Router			/api/v1/files/holds [put]
+func SetObjectHold(c *gin.Context) {
+	l := log.Logger()
+
+	var req types.SetObjectHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.SetObjectHold(c.Request.Context(), user, &req)
+	if err != nil {
+		l.Error().Err(err).Msg("set object hold failed")
+
+		switch {
+		case errors.Is(err, service.ErrInvalidHold):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrObjectAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrObjectNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListObjectHolds 获取当前用户文件的保护状态.
+//
+//	@Summary		获取文件保护列表
+//	@Tags			文件操作
+//	@Produce		json
+//	@Param			prefix		query		string	false	"对象键前缀（相对用户目录）"
+//	@Param			active		query		bool	false	"只返回生效中的保护"
+//	@Param			page		query		int		false	"页码（从 1 开始）"
+//	@Param			page_size	query		int		false	"每页数量"
+//	@Success		200			{object}	types.ListObjectHoldsResponse
+//	@Failure		400			{object}	map[string]string
+//	@Failure		500			{object}	map[string]string
+//	@Router			/api/v1/files/holds [get]
+func ListObjectHolds(c *gin.Context) {
+	var req types.ListObjectHoldsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleRetentionOperation(c, "list object holds", func(ctx context.Context, svc *service.FileService, user string) (any, error) {
+		return svc.ListObjectHolds(ctx, user, &req)
+	})
+}
+
+// ListObjectHoldEvents 获取当前用户文件的保护审计记录.
+//
+//	@Summary		获取文件保护审计记录
+//	@Description	包含保护的设置、解除，以及因保护被拒绝的删除、移动、修改等操作
+//	@Tags			文件操作
+//	@Produce		json
+//	@Param			object_key	query		string	false	"对象键"
+//	@Param			outcome		query		string	false	"结果：applied / denied"
+//	@Param			page		query		int		false	"页码（从 1 开始）"
+//	@Param			page_size	query		int		false	"每页数量"
+//	@Success		200			{object}	types.ListObjectHoldEventsResponse
+//	@Failure		400			{object}	map[string]string
+//	@Failure		500			{object}	map[string]string
+//	@Router			/api/v1/files/holds/events [get]
+func ListObjectHoldEvents(c *gin.Context) {
+	var req types.ListObjectHoldEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleRetentionOperation(c, "list object hold events", func(ctx context.Context, svc *service.FileService, user string) (any, error) {
+		return svc.ListObjectHoldEvents(ctx, user, &req)
+	})
+}
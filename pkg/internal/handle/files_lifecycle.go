@@ -0,0 +1,34 @@
+package handle
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// RunLifecycle 对当前用户的文件执行一次生命周期规则。
+//
+//	@Summary		执行生命周期规则
+//	@Description	按服务端配置迁移长期未修改的文件、将 expiry_days 到期的文件移入回收站并中止超时的分片上传；dry_run=true 时只预览将执行的动作
+//	@Tags			文件操作
+//	@Produce		json
+//	@Param			dry_run	query		bool	false	"仅预览，不修改对象"
+//	@Success		200		{object}	types.LifecycleRunResponse
+//	@Failure		400		{object}	map[string]string
+//	@Failure		500		{object}	map[string]string
+//	@Router			/api/v1/files/lifecycle/run [post]
+func RunLifecycle(c *gin.Context) {
+	var q types.LifecycleRunQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleRetentionOperation(c, "run lifecycle", func(ctx context.Context, svc *service.FileService, user string) (any, error) {
+		return svc.RunLifecycle(ctx, user, q.DryRun)
+	})
+}
@@ -3,9 +3,11 @@ package handle
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/yeisme/notevault/pkg/internal/model"
 	"github.com/yeisme/notevault/pkg/internal/service"
 	"github.com/yeisme/notevault/pkg/internal/types"
 	"github.com/yeisme/notevault/pkg/log"
@@ -15,6 +17,9 @@ import (
 const (
 	maxMonth = 12
 	maxDay   = 31
+
+	// syncModeReconcile /meta/sync 的对账模式.
+	syncModeReconcile = "reconcile"
 )
 
 // GetFileMeta 获取对象元数据（包含对象基本信息与用户元数据）。
@@ -66,7 +71,9 @@ func GetFileMeta(c *gin.Context) {
 //	@Produce	json
 //	@Param		id	path		string					true	"对象键（含用户前缀）"
 //	@Param		req	body		types.MetaUpdateRequest	true	"更新内容"
+//	@Param		async	query		bool	false	"是否以异步任务执行（返回任务 ID）"
 //	@Success	200	{object}	types.UpdateFilesMetadataResponse
+//	@Success	202	{object}	types.SubmitJobResponse
 //	@Failure	400	{object}	map[string]string
 //	@Failure	500	{object}	map[string]string
 //	@Router		/api/v1/meta/{id} [post]
@@ -82,7 +89,9 @@ func CreateOrUpdateFileMeta(c *gin.Context) {
 //	@Produce	json
 //	@Param		id	path		string					true	"对象键（含用户前缀）"
 //	@Param		req	body		types.MetaUpdateRequest	true	"更新内容"
+//	@Param		async	query		bool	false	"是否以异步任务执行（返回任务 ID）"
 //	@Success	200	{object}	types.UpdateFilesMetadataResponse
+//	@Success	202	{object}	types.SubmitJobResponse
 //	@Failure	400	{object}	map[string]string
 //	@Failure	500	{object}	map[string]string
 //	@Router		/api/v1/meta/{id} [put]
@@ -113,9 +122,7 @@ func updateFileMeta(c *gin.Context) {
 		return
 	}
 
-	svc := service.NewFileService(c.Request.Context())
-
-	resp, err := svc.UpdateFilesMetadata(c.Request.Context(), user, &types.UpdateFilesMetadataRequest{
+	updateReq := &types.UpdateFilesMetadataRequest{
 		Items: []types.UpdateFileMetadataItem{
 			{
 				ObjectKey:   objectKey,
@@ -126,7 +133,16 @@ func updateFileMeta(c *gin.Context) {
 				IsPublic:    req.IsPublic,
 			},
 		},
-	})
+	}
+
+	if isAsyncRequest(c) {
+		submitJob(c, "update meta", user, model.JobTypeUpdateMetadata, updateReq)
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.UpdateFilesMetadata(c.Request.Context(), user, updateReq)
 	if err != nil {
 		l.Error().Err(err).Str("objectKey", objectKey).Msg("update meta failed")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -278,12 +294,22 @@ func MetaBatch(c *gin.Context) {
 // SyncFileMeta 手动触发：将对象存储中的对象元数据同步到数据库。
 //
 //	@Summary		同步对象存储元数据到数据库
-//	@Description	扫描当前用户在对象存储中的对象，并将基础元信息落库（upsert）。
+//	@Description	扫描当前用户在对象存储中的对象，并将基础元信息落库（upsert）。async=true 时以异步任务执行。
+//	@Description	mode=reconcile 时执行对账：比对对象存储与数据库并报告差异（missing_in_db/missing_in_s3/stale_etag/stale_metadata），fix=true 时同时修复。
 //	@Tags			文件元数据
 //	@Produce		json
-//	@Success		200	{object}	map[string]any
-//	@Failure		400	{object}	map[string]string
-//	@Failure		500	{object}	map[string]string
+//	@Param			year	query		int		false	"年"
+//	@Param			month	query		int		false	"月"
+//	@Param			day		query		int		false	"日"
+//	@Param			mode	query		string	false	"sync（默认）或 reconcile"
+//	@Param			prefix	query		string	false	"对账范围（对象键前缀，仅 reconcile）"
+//	@Param			fix		query		bool	false	"是否修复差异（仅 reconcile）"
+//	@Param			deep	query		bool	false	"是否逐个 Stat 比对 ContentType（仅 reconcile）"
+//	@Param			async	query		bool	false	"是否以异步任务执行（返回任务 ID）"
+//	@Success		200		{object}	map[string]any	"sync 模式为同步结果，reconcile 模式为 types.ReconcileResponse"
+//	@Success		202		{object}	types.SubmitJobResponse
+//	@Failure		400		{object}	map[string]string
+//	@Failure		500		{object}	map[string]string
 //	@Router			/api/v1/meta/sync [post]
 func SyncFileMeta(c *gin.Context) {
 	l := log.Logger()
@@ -296,13 +322,31 @@ func SyncFileMeta(c *gin.Context) {
 		return
 	}
 
-	svc := service.NewFileService(c.Request.Context())
+	if c.Query("mode") == syncModeReconcile {
+		reconcileFileMeta(c, user)
+		return
+	}
 
 	// 可选参数：year/month/day，用于定向同步
 	yearStr := c.Query("year")
 	monthStr := c.Query("month")
 	dayStr := c.Query("day")
 
+	if isAsyncRequest(c) {
+		year, month, day, verr := parseAndValidateYMD(yearStr, monthStr, dayStr)
+		if verr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": verr.Error()})
+			return
+		}
+
+		submitJob(c, "sync meta", user, model.JobTypeSyncObjects,
+			&types.SyncObjectsRequest{Year: year, Month: month, Day: day})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
 	// 若未提供筛选参数，则执行全量同步
 	if yearStr == "" && monthStr == "" && dayStr == "" {
 		if err := svc.SyncObjectsToDB(c.Request.Context(), user); err != nil {
@@ -333,6 +377,39 @@ func SyncFileMeta(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "sync completed", "user": user, "year": year, "month": month, "day": day})
 }
 
+// reconcileFileMeta 对账模式：比对对象存储与数据库并返回差异报告.
+func reconcileFileMeta(c *gin.Context, user string) {
+	l := log.Logger()
+
+	var req types.ReconcileRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Prefix != "" && !strings.HasPrefix(req.Prefix, user+"/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix must be under user directory"})
+		return
+	}
+
+	if isAsyncRequest(c) {
+		submitJob(c, "reconcile meta", user, model.JobTypeReconcile, &req)
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.ReconcileObjects(c.Request.Context(), user, &req)
+	if err != nil {
+		l.Error().Err(err).Str("user", user).Msg("reconcile objects failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // parseAndValidateYMD 解析并校验 year/month/day 查询参数，避免在 handler 内过多分支。
 func parseAndValidateYMD(yearStr, monthStr, dayStr string) (int, int, int, error) {
 	parseInt := func(s string) (int, error) {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/yeisme/notevault/pkg/internal/model"
 	"github.com/yeisme/notevault/pkg/internal/service"
 	"github.com/yeisme/notevault/pkg/internal/types"
 	"github.com/yeisme/notevault/pkg/log"
@@ -20,13 +21,15 @@ import (
 //	@Accept			json
 //	@Produce		json
 //	@Param			req	body		types.DeleteFilesRequest	true	"删除请求"
+//	@Param			async	query		bool	false	"是否以异步任务执行（返回任务 ID）"
 //	@Success		200	{object}	types.DeleteFilesResponse	"删除结果"
+//	@Success		202	{object}	types.SubmitJobResponse	"异步任务已提交"
 //	@Failure		400	{object}	map[string]string			"请求参数错误"
 //	@Failure		500	{object}	map[string]string			"服务器内部错误"
 //	@Router			/api/v1/files [delete]
 func DeleteFiles(c *gin.Context) {
 	var req types.DeleteFilesRequest
-	handleFilesOperation(c, "delete files", &req,
+	handleFilesOperation(c, "delete files", model.JobTypeDeleteFiles, &req,
 		func() error {
 			if len(req.ObjectKeys) == 0 {
 				return fmt.Errorf("no object_keys provided")
@@ -48,13 +51,15 @@ func DeleteFiles(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			req	body		types.CopyFilesRequest	true	"复制请求"
+//	@Param			async	query		bool	false	"是否以异步任务执行（返回任务 ID）"
 //	@Success		200	{object}	types.CopyFilesResponse	"复制结果"
+//	@Success		202	{object}	types.SubmitJobResponse	"异步任务已提交"
 //	@Failure		400	{object}	map[string]string		"请求参数错误"
 //	@Failure		500	{object}	map[string]string		"服务器内部错误"
 //	@Router			/api/v1/files/copy [post]
 func CopyFiles(c *gin.Context) {
 	var req types.CopyFilesRequest
-	handleFilesOperation(c, "copy files", &req,
+	handleFilesOperation(c, "copy files", model.JobTypeCopyFiles, &req,
 		func() error {
 			if len(req.Items) == 0 {
 				return fmt.Errorf("no items provided")
@@ -76,13 +81,15 @@ func CopyFiles(c *gin.Context) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			req	body		types.MoveFilesRequest	true	"移动请求"
+//	@Param			async	query		bool	false	"是否以异步任务执行（返回任务 ID）"
 //	@Success		200	{object}	types.MoveFilesResponse	"移动结果"
+//	@Success		202	{object}	types.SubmitJobResponse	"异步任务已提交"
 //	@Failure		400	{object}	map[string]string		"请求参数错误"
 //	@Failure		500	{object}	map[string]string		"服务器内部错误"
 //	@Router			/api/v1/files/move [post]
 func MoveFiles(c *gin.Context) {
 	var req types.MoveFilesRequest
-	handleFilesOperation(c, "move files", &req,
+	handleFilesOperation(c, "move files", model.JobTypeMoveFiles, &req,
 		func() error {
 			if len(req.Items) == 0 {
 				return fmt.Errorf("no items provided")
@@ -97,7 +104,8 @@ func MoveFiles(c *gin.Context) {
 }
 
 // handleFilesOperation 封装公共流程：绑定/校验/用户校验/调用 service/统一返回。
-func handleFilesOperation(c *gin.Context, operation string, req any,
+// 请求携带 async=true 时不直接执行，而是以 jobType 提交异步任务并返回任务 ID。
+func handleFilesOperation(c *gin.Context, operation, jobType string, req any,
 	validate func() error,
 	serviceCall func(*service.FileService, context.Context, string) (any, error),
 ) {
@@ -125,6 +133,11 @@ func handleFilesOperation(c *gin.Context, operation string, req any,
 		return
 	}
 
+	if isAsyncRequest(c) {
+		submitJob(c, operation, user, jobType, req)
+		return
+	}
+
 	ctx := c.Request.Context()
 	svc := service.NewFileService(ctx)
 
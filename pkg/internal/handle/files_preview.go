@@ -0,0 +1,100 @@
+package handle
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// GetFilePreview 获取图片缩略图，首次请求时生成并缓存到对象存储.
+//
+//	@Summary		获取图片缩略图
+//	@Description	返回 JPEG 或 WebP 缩略图（按配置），带 ETag 与 Cache-Control，支持 If-None-Match 返回 304
+//	@Tags			文件下载
+//	@Produce		image/jpeg
+//	@Produce		image/webp
+//	@Param			key		query		string	true	"原图对象键"
+//	@Param			size	query		int		true	"长边像素，必须为配置的尺寸之一"
+//	@Success		200		{file}		file	"缩略图"
+//	@Success		304		"未修改"
+//	@Failure		400		{object}	map[string]string	"请求参数错误或尺寸不支持"
+//	@Failure		403		{object}	map[string]string	"无权访问"
+//	@Failure		404		{object}	map[string]string	"对象不存在或未启用预览"
+//	@Failure		415		{object}	map[string]string	"对象不是支持的图片格式"
+//	@Failure		500		{object}	map[string]string	"服务器内部错误"
+//	@Router			/api/v1/files/preview [get]
+func GetFilePreview(c *gin.Context) {
+	l := log.Logger()
+
+	var req types.PreviewQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	info, rc, err := svc.GetPreview(c.Request.Context(), user, req.Key, req.Size)
+	if err != nil {
+		respondPreviewError(c, err)
+		return
+	}
+
+	defer func() { _ = rc.Close() }()
+
+	etag := quoteETag(info.ETag)
+	modTime, _ := time.Parse(time.RFC3339, info.LastModified)
+
+	h := c.Writer.Header()
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", "private, max-age="+strconv.Itoa(int(configs.GetConfig().Preview.GetCacheMaxAge().Seconds())))
+
+	if !modTime.IsZero() {
+		h.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+
+	if status := checkPreconditions(c.Request, etag, modTime); status != 0 {
+		c.Status(status)
+		return
+	}
+
+	h.Set("Content-Type", info.ContentType)
+	h.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	h.Set("Content-Disposition", "inline")
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, rc); err != nil {
+		l.Warn().Err(err).Str("key", req.Key).Msg("write preview failed")
+	}
+}
+
+// respondPreviewError 将缩略图错误映射为 HTTP 状态码.
+func respondPreviewError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPreviewSize):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPreviewDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPreviewUnsupported):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		respondObjectError(c, err)
+	}
+}
@@ -0,0 +1,280 @@
+package handle
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// maxByteRanges 单个请求允许的最大分段数，超出时忽略 Range 返回完整内容.
+const maxByteRanges = 32
+
+var (
+	// errInvalidRange Range 头语法错误，按 RFC 7233 忽略该头.
+	errInvalidRange = errors.New("invalid range")
+	// errUnsatisfiableRange 所有分段均超出对象范围，返回 416.
+	errUnsatisfiableRange = errors.New("range not satisfiable")
+)
+
+// byteRange 对象中的一个字节区间 [start, start+length).
+type byteRange struct {
+	start, length int64
+}
+
+func (r byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+func (r byteRange) mimeHeader(contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Range": {r.contentRange(size)},
+		"Content-Type":  {contentType},
+	}
+}
+
+// objectRanges 转换为服务层读取的分段.
+func objectRanges(ranges []byteRange) []service.ObjectRange {
+	out := make([]service.ObjectRange, 0, len(ranges))
+	for _, r := range ranges {
+		out = append(out, service.ObjectRange{Offset: r.start, Length: r.length})
+	}
+
+	return out
+}
+
+// parseByteRanges 解析 RFC 7233 Range 头（仅支持 bytes 单位），返回落在对象范围内的分段.
+func parseByteRanges(header string, size int64) ([]byteRange, error) {
+	specs, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return nil, errInvalidRange
+	}
+
+	var ranges []byteRange
+
+	unsatisfiable := false
+
+	for spec := range strings.SplitSeq(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		first, last, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, errInvalidRange
+		}
+
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+		var r byteRange
+
+		if first == "" {
+			// 后缀形式 -N：最后 N 个字节
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errInvalidRange
+			}
+
+			if n == 0 || size == 0 {
+				unsatisfiable = true
+				continue
+			}
+
+			n = min(n, size)
+			r = byteRange{start: size - n, length: n}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, errInvalidRange
+			}
+
+			end := size - 1
+
+			if last != "" {
+				end, err = strconv.ParseInt(last, 10, 64)
+				if err != nil || end < start {
+					return nil, errInvalidRange
+				}
+
+				end = min(end, size-1)
+			}
+
+			if start >= size {
+				unsatisfiable = true
+				continue
+			}
+
+			r = byteRange{start: start, length: end - start + 1}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	if len(ranges) == 0 {
+		if unsatisfiable {
+			return nil, errUnsatisfiableRange
+		}
+
+		return nil, errInvalidRange
+	}
+
+	return ranges, nil
+}
+
+// sumRangesSize 计算分段总长度（用于识别重叠分段过多的请求）.
+func sumRangesSize(ranges []byteRange) int64 {
+	var n int64
+	for _, r := range ranges {
+		n += r.length
+	}
+
+	return n
+}
+
+// multipartRangesSize 预先计算 multipart/byteranges 响应体长度（边界长度固定，与实际写出一致）.
+func multipartRangesSize(ranges []byteRange, contentType string, size int64) int64 {
+	var cw countingWriter
+
+	mw := multipart.NewWriter(&cw)
+
+	var total int64
+
+	for _, r := range ranges {
+		_, _ = mw.CreatePart(r.mimeHeader(contentType, size))
+		total += r.length
+	}
+
+	_ = mw.Close()
+
+	return total + int64(cw)
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// checkPreconditions 按 RFC 7232 第 6 节的顺序评估条件请求头，返回需直接响应的状态码（0 表示继续）.
+func checkPreconditions(r *http.Request, etag string, modTime time.Time) int {
+	isRead := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !etagListMatch(im, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if t, ok := parseHTTPDate(r.Header.Get("If-Unmodified-Since")); ok && modTime.After(t) {
+		return http.StatusPreconditionFailed
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etagListMatch(inm, etag, true) {
+			if isRead {
+				return http.StatusNotModified
+			}
+
+			return http.StatusPreconditionFailed
+		}
+	} else if t, ok := parseHTTPDate(r.Header.Get("If-Modified-Since")); ok && isRead && !modTime.After(t) {
+		return http.StatusNotModified
+	}
+
+	return 0
+}
+
+// ifRangeMatch 判断 If-Range 是否允许使用 Range；未携带 If-Range 时始终允许.
+// If-Range 为 ETag 时要求强匹配，为日期时要求与 Last-Modified 完全一致.
+func ifRangeMatch(r *http.Request, etag string, modTime time.Time) bool {
+	v := strings.TrimSpace(r.Header.Get("If-Range"))
+	if v == "" {
+		return true
+	}
+
+	if strings.HasPrefix(v, "\"") || strings.HasPrefix(v, "W/") {
+		return !strings.HasPrefix(v, "W/") && v == etag
+	}
+
+	t, ok := parseHTTPDate(v)
+
+	return ok && !modTime.IsZero() && modTime.Equal(t)
+}
+
+// etagListMatch 判断逗号分隔的 ETag 列表（或 "*"）是否包含 etag；weak 为 true 时忽略 W/ 前缀.
+func etagListMatch(list, etag string, weak bool) bool {
+	for candidate := range strings.SplitSeq(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		} else if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+
+		if candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseHTTPDate(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// quoteETag 将对象存储返回的 ETag 格式化为 HTTP 强校验器形式.
+func quoteETag(etag string) string {
+	if etag == "" {
+		return ""
+	}
+
+	return "\"" + strings.Trim(etag, "\"") + "\""
+}
+
+// isRangeContinuation 判断请求是否只取对象的中间或尾部：Range 按 serveSingleFile 的规则生效（If-Range 匹配、
+// 语法有效、分段数与总大小未超限），且没有分段从偏移 0 开始。Range 被忽略时返回完整内容，不算续传.
+func isRangeContinuation(r *http.Request, info *types.ObjectInfo) bool {
+	rh := r.Header.Get("Range")
+	if rh == "" {
+		return false
+	}
+
+	modTime, _ := time.Parse(time.RFC3339, info.LastModified)
+	if !ifRangeMatch(r, quoteETag(info.ETag), modTime) {
+		return false
+	}
+
+	ranges, err := parseByteRanges(rh, info.Size)
+	if err != nil || len(ranges) == 0 || len(ranges) > maxByteRanges || sumRangesSize(ranges) > info.Size {
+		return false
+	}
+
+	for _, ra := range ranges {
+		if ra.start == 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,36 @@
+package handle
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// ListReplicationStatus 获取当前用户文件复制到副本存储的状态.
+//
+//	@Summary		获取复制状态
+//	@Description	列出当前用户对象同步到副本存储的状态（replicated / failed）及各状态的数量
+//	@Tags			文件操作
+//	@Produce		json
+//	@Param			status		query		string	false	"状态：replicated / failed"
+//	@Param			page		query		int		false	"页码（从 1 开始）"
+//	@Param			page_size	query		int		false	"每页数量"
+//	@Success		200			{object}	types.ListReplicationResponse
+//	@Failure		400			{object}	map[string]string
+//	@Failure		500			{object}	map[string]string
+//	@Router			/api/v1/files/replication [get]
+func ListReplicationStatus(c *gin.Context) {
+	var req types.ListReplicationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleRetentionOperation(c, "list replication status", func(ctx context.Context, svc *service.FileService, user string) (any, error) {
+		return svc.ListReplicationStatus(ctx, user, &req)
+	})
+}
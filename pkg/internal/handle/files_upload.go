@@ -3,6 +3,7 @@ package handle
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
 	"github.com/yeisme/notevault/pkg/internal/types"
 	"github.com/yeisme/notevault/pkg/log"
 )
@@ -114,7 +116,13 @@ func handleUpload(c *gin.Context, req any, serviceFunc func(context.Context, str
 	res, err := serviceFunc(c.Request.Context(), user, req)
 	if err != nil {
 		l.Error().Err(err).Msg("failed to generate " + logMsg)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, s3.ErrPresignEncrypted) {
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, gin.H{"error": err.Error()})
 
 		return
 	}
@@ -2,6 +2,7 @@ package handle
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -14,7 +15,7 @@ import (
 // ListFileVersions 获取指定文件的版本列表。
 //
 //	@Summary		获取文件版本列表
-//	@Description	根据对象键查询其版本列表（当前实现返回最新可见版本信息，后端如启用版本化可扩展）
+//	@Description	根据对象键查询其版本列表（来自版本元数据表，含作者、说明与标签；版本记录之前的历史版本首次查询时从对象存储导入）
 //	@Tags			文件版本
 //	@Produce		json
 //	@Param			fileId	path		string	true	"对象键（完整 object_key）"
@@ -66,6 +67,7 @@ func ListFileVersions(c *gin.Context) {
 //	@Param			req		body		types.CreateFileVersionRequest	true	"创建版本请求"
 //	@Success		200		{object}	types.CreateFileVersionResponse
 //	@Failure		400		{object}	map[string]string
+//	@Failure		409		{object}	map[string]string	"对象受法律保留或保留期保护"
 //	@Failure		500		{object}	map[string]string
 //	@Router			/api/v1/files/versions/{fileId} [post]
 func CreateFileVersion(c *gin.Context) {
@@ -100,7 +102,13 @@ func CreateFileVersion(c *gin.Context) {
 	resp, err := svc.CreateFileVersion(c.Request.Context(), user, &req)
 	if err != nil {
 		l.Error().Err(err).Msg("create version failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrObjectHeld) {
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, gin.H{"error": err.Error()})
 
 		return
 	}
@@ -117,6 +125,7 @@ func CreateFileVersion(c *gin.Context) {
 //	@Param		versionId	path		string	true	"版本ID"
 //	@Success	200			{object}	types.DeleteFileVersionResponse
 //	@Failure	400			{object}	map[string]string
+//	@Failure	409			{object}	map[string]string
 //	@Failure	500			{object}	map[string]string
 //	@Router		/api/v1/files/versions/{fileId}/{versionId} [delete]
 func DeleteFileVersion(c *gin.Context) {
@@ -134,6 +143,7 @@ func DeleteFileVersion(c *gin.Context) {
 //	@Param		versionId	path		string	true	"版本ID"
 //	@Success	200			{object}	types.RestoreFileVersionResponse
 //	@Failure	400			{object}	map[string]string
+//	@Failure	409			{object}	map[string]string
 //	@Failure	500			{object}	map[string]string
 //	@Router		/api/v1/files/versions/{fileId}/{versionId}/restore [post]
 func RestoreFileVersion(c *gin.Context) {
@@ -142,6 +152,106 @@ func RestoreFileVersion(c *gin.Context) {
 	})
 }
 
+// SetFileVersionLabels 设置版本标签与说明。
+//
+//	@Summary		设置文件版本标签
+//	@Description	替换版本的命名标签（如 final）并可选更新版本说明；同一对象内标签唯一，已被其他版本使用的标签会移到该版本
+//	@Tags			文件版本
+//	@Accept			json
+//	@Produce		json
+//	@Param			fileId		path		string								true	"对象键（完整 object_key）"
+//	@Param			versionId	path		string								true	"版本ID"
+//	@Param			req			body		types.SetFileVersionLabelsRequest	true	"标签与说明"
+//	@Success		200			{object}	types.FileVersionInfo
+//	@Failure		400			{object}	map[string]string
+//	@Failure		403			{object}	map[string]string
+//	@Failure		404			{object}	map[string]string
+//	@Failure		500			{object}	map[string]string
+//	@Router			/api/v1/files/versions/{fileId}/{versionId}/labels [put]
+func SetFileVersionLabels(c *gin.Context) {
+	var req types.SetFileVersionLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleVersionOperation(c, "set version labels", func(ctx context.Context, svc *service.FileService, user, fileID, versionID string) (any, error) {
+		return svc.SetFileVersionLabels(ctx, user, fileID, versionID, &req)
+	})
+}
+
+// DiffFileVersions 对比文件的两个版本。
+//
+//	@Summary		对比文件版本
+//	@Description	文本文件返回结构化行差异或 unified diff（format=unified 时以 text/plain 流式返回）；二进制文件或超过大小上限时只比较大小、校验和与元数据
+//	@Tags			文件版本
+//	@Produce		json
+//	@Produce		plain
+//	@Param			fileId	path		string	true	"对象键（完整 object_key）"
+//	@Param			from	query		string	true	"旧版本ID"
+//	@Param			to		query		string	false	"新版本ID，为空表示当前版本"
+//	@Param			format	query		string	false	"lines(默认) 或 unified"
+//	@Param			context	query		int		false	"上下文行数，默认 3"
+//	@Success		200		{object}	types.FileVersionDiff
+//	@Failure		400		{object}	map[string]string
+//	@Failure		403		{object}	map[string]string
+//	@Failure		404		{object}	map[string]string
+//	@Failure		500		{object}	map[string]string
+//	@Router			/api/v1/files/versions/{fileId}/diff [get]
+func DiffFileVersions(c *gin.Context) {
+	l := log.Logger()
+
+	fileID := c.Param("fileId")
+	if fileID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing fileId"})
+		return
+	}
+
+	var q types.DiffFileVersionsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	diff, err := svc.DiffFileVersions(c.Request.Context(), user, fileID, &q)
+	if err != nil {
+		l.Error().Err(err).Msg("diff versions failed")
+
+		switch {
+		case errors.Is(err, service.ErrObjectAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrVersionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+
+		return
+	}
+
+	if q.Format != "unified" {
+		c.JSON(http.StatusOK, diff)
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	if err := service.WriteUnifiedDiff(c.Writer, diff); err != nil {
+		l.Warn().Err(err).Msg("write unified diff failed")
+	}
+}
+
 // handleVersionOperation 抽取公共处理逻辑，减少 Delete/Restore 的重复代码.
 func handleVersionOperation(
 	c *gin.Context,
@@ -169,6 +279,139 @@ func handleVersionOperation(
 	svc := service.NewFileService(c.Request.Context())
 
 	resp, err := fn(c.Request.Context(), svc, user, fileID, versionID)
+	if err != nil {
+		l.Error().Err(err).Msg(opName + " failed")
+
+		switch {
+		case errors.Is(err, service.ErrObjectAccessDenied):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrVersionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrObjectHeld):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListVersionRetention 获取版本保留规则。
+//
+//	@Summary	获取版本保留规则
+//	@Tags		文件版本
+//	@Produce	json
+//	@Success	200	{object}	types.ListVersionRetentionResponse
+//	@Failure	400	{object}	map[string]string
+//	@Failure	500	{object}	map[string]string
+//	@Router		/api/v1/files/retention [get]
+func ListVersionRetention(c *gin.Context) {
+	handleRetentionOperation(c, "list version retention", func(ctx context.Context, svc *service.FileService, user string) (any, error) {
+		return svc.ListVersionRetention(ctx, user)
+	})
+}
+
+// SetVersionRetention 设置用户级或文件夹级版本保留规则。
+//
+//	@Summary		设置版本保留规则
+//	@Description	保留最近 N 个版本和/或 D 天内的版本，当前版本与带标签的版本总是保留；folder_id 为空时为用户级规则
+//	@Tags			文件版本
+//	@Accept			json
+//	@Produce		json
+//	@Param			req	body		types.SetVersionRetentionRequest	true	"保留规则"
+//	@Success		200	{object}	types.VersionRetentionRule
+//	@Failure		400	{object}	map[string]string
+//	@Failure		500	{object}	map[string]string
+//	@Router			/api/v1/files/retention [put]
+func SetVersionRetention(c *gin.Context) {
+	var req types.SetVersionRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleRetentionOperation(c, "set version retention", func(ctx context.Context, svc *service.FileService, user string) (any, error) {
+		return svc.SetVersionRetention(ctx, user, &req)
+	})
+}
+
+// DeleteVersionRetention 删除版本保留规则。
+//
+//	@Summary	删除版本保留规则
+//	@Tags		文件版本
+//	@Produce	json
+//	@Param		folder_id	query	string	false	"文件夹 ID，为空表示用户级规则"
+//	@Success	204
+//	@Failure	404	{object}	map[string]string
+//	@Failure	500	{object}	map[string]string
+//	@Router		/api/v1/files/retention [delete]
+func DeleteVersionRetention(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+	if err := svc.DeleteVersionRetention(c.Request.Context(), user, c.Query("folder_id")); err != nil {
+		if errors.Is(err, service.ErrRetentionRuleNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		l.Error().Err(err).Msg("delete version retention failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// PruneVersions 按保留规则清理当前用户的旧版本。
+//
+//	@Summary		清理旧版本
+//	@Description	按保留规则删除旧版本并返回释放的空间；dry_run=true 时只预览将被删除的版本
+//	@Tags			文件版本
+//	@Produce		json
+//	@Param			dry_run	query		bool	false	"仅预览，不删除"
+//	@Success		200		{object}	types.PruneVersionsResponse
+//	@Failure		400		{object}	map[string]string
+//	@Failure		500		{object}	map[string]string
+//	@Router			/api/v1/files/retention/prune [post]
+func PruneVersions(c *gin.Context) {
+	var q types.PruneVersionsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	handleRetentionOperation(c, "prune versions", func(ctx context.Context, svc *service.FileService, user string) (any, error) {
+		return svc.PruneVersions(ctx, user, q.DryRun)
+	})
+}
+
+// handleRetentionOperation 校验用户并执行版本保留相关操作.
+func handleRetentionOperation(c *gin.Context, opName string,
+	fn func(ctx context.Context, svc *service.FileService, user string) (any, error)) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	resp, err := fn(c.Request.Context(), service.NewFileService(c.Request.Context()), user)
 	if err != nil {
 		l.Error().Err(err).Msg(opName + " failed")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -34,3 +34,22 @@ func checkUser(c *gin.Context) (string, error) {
 
 	return user, nil
 }
+
+// optionalUser 提取可选的调用者身份（分享访问等允许匿名的接口使用），未提供时返回空串.
+func optionalUser(c *gin.Context) (string, error) {
+	user := c.GetHeader("X-User")
+	if user == "" {
+		user = c.Query("user")
+	}
+
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return "", nil
+	}
+
+	if err := rule.ValidateVar(user, "email"); err != nil {
+		return "", err
+	}
+
+	return user, nil
+}
@@ -57,7 +57,7 @@ func HealthDB(c *gin.Context) {
 //	@Router			/health/s3 [get].
 func HealthS3(c *gin.Context) {
 	s3c := ctxPkg.GetS3Client(c.Request.Context())
-	if s3c == nil || s3c.Client == nil { // s3c.Client 为底层 *minio.Client
+	if s3c == nil || s3c.ObjectStore == nil { // s3c.ObjectStore 为 MinIO 或本地磁盘驱动
 		c.JSON(http.StatusServiceUnavailable, gin.H{"component": "s3", "status": "unhealthy", "error": "s3 client not initialized"})
 		return
 	}
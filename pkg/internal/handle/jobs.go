@@ -0,0 +1,238 @@
+package handle
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// ndjsonContentType NDJSON 导出的内容类型.
+const ndjsonContentType = "application/x-ndjson"
+
+// ListJobs 获取我的异步任务列表.
+//
+//	@Summary	获取异步任务列表
+//	@Tags		异步任务
+//	@Produce	json
+//	@Param		status		query		string	false	"按状态过滤：pending/running/completed/failed/canceled"
+//	@Param		type		query		string	false	"按任务类型过滤"
+//	@Param		page		query		int		false	"页码（从 1 开始）"
+//	@Param		page_size	query		int		false	"每页数量"
+//	@Success	200			{object}	types.ListJobsResponse
+//	@Failure	400			{object}	map[string]string
+//	@Failure	500			{object}	map[string]string
+//	@Router		/api/v1/jobs [get]
+func ListJobs(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	var req types.ListJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := service.NewJobService(c.Request.Context())
+
+	resp, err := svc.ListJobs(c.Request.Context(), user, &req)
+	if err != nil {
+		l.Error().Err(err).Msg("list jobs failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetJob 获取异步任务详情与进度.
+//
+//	@Summary	获取异步任务详情
+//	@Tags		异步任务
+//	@Produce	json
+//	@Param		jobId	path		string	true	"任务 ID"
+//	@Success	200		{object}	types.JobInfo
+//	@Failure	404		{object}	map[string]string
+//	@Failure	500		{object}	map[string]string
+//	@Router		/api/v1/jobs/{jobId} [get]
+func GetJob(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewJobService(c.Request.Context())
+
+	resp, err := svc.GetJob(c.Request.Context(), user, c.Param("jobId"))
+	if err != nil {
+		respondJobError(c, "get job", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetJobResults 获取异步任务的条目结果.
+// 默认返回分页 JSON；format=ndjson 或 Accept: application/x-ndjson 时流式导出全部结果.
+//
+//	@Summary	获取异步任务条目结果
+//	@Tags		异步任务
+//	@Produce	json
+//	@Produce	x-ndjson
+//	@Param		jobId		path		string	true	"任务 ID"
+//	@Param		page		query		int		false	"页码（从 1 开始）"
+//	@Param		page_size	query		int		false	"每页数量"
+//	@Param		format		query		string	false	"json（默认）或 ndjson"
+//	@Success	200			{object}	types.ListJobResultsResponse
+//	@Failure	404			{object}	map[string]string
+//	@Failure	500			{object}	map[string]string
+//	@Router		/api/v1/jobs/{jobId}/results [get]
+func GetJobResults(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	jobID := c.Param("jobId")
+	svc := service.NewJobService(c.Request.Context())
+
+	if wantsNDJSON(c) {
+		streamJobResults(c, svc, user, jobID)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.Query("page"))
+	size, _ := strconv.Atoi(c.Query("page_size"))
+
+	resp, err := svc.ListJobResults(c.Request.Context(), user, jobID, page, size)
+	if err != nil {
+		respondJobError(c, "list job results", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CancelJob 取消异步任务.
+//
+//	@Summary	取消异步任务
+//	@Tags		异步任务
+//	@Produce	json
+//	@Param		jobId	path		string	true	"任务 ID"
+//	@Success	200		{object}	types.JobInfo
+//	@Failure	404		{object}	map[string]string
+//	@Failure	409		{object}	map[string]string
+//	@Failure	500		{object}	map[string]string
+//	@Router		/api/v1/jobs/{jobId}/cancel [post]
+func CancelJob(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewJobService(c.Request.Context())
+
+	resp, err := svc.CancelJob(c.Request.Context(), user, c.Param("jobId"))
+	if err != nil {
+		respondJobError(c, "cancel job", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// isAsyncRequest 判断请求是否要求以异步任务执行（?async=true）.
+func isAsyncRequest(c *gin.Context) bool {
+	async, _ := strconv.ParseBool(c.Query("async"))
+	return async
+}
+
+// submitJob 提交异步任务并返回 202 与任务信息.
+func submitJob(c *gin.Context, operation, user, jobType string, payload any) {
+	svc := service.NewJobService(c.Request.Context())
+
+	resp, err := svc.SubmitJob(c.Request.Context(), user, jobType, payload)
+	if err != nil {
+		respondJobError(c, operation, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// streamJobResults 以 NDJSON 逐行输出任务条目结果.
+func streamJobResults(c *gin.Context, svc *service.JobService, user, jobID string) {
+	// 先校验任务归属，保证出错时仍可返回 JSON 错误
+	if _, err := svc.GetJob(c.Request.Context(), user, jobID); err != nil {
+		respondJobError(c, "stream job results", err)
+		return
+	}
+
+	c.Header("Content-Type", ndjsonContentType)
+	c.Status(http.StatusOK)
+
+	enc := json.NewEncoder(c.Writer)
+
+	err := svc.StreamJobResults(c.Request.Context(), user, jobID, func(item types.JobItemResult) error {
+		return enc.Encode(item)
+	})
+	if err != nil {
+		// 响应头已发送，只能记录日志
+		log.Logger().Error().Err(err).Str("job_id", jobID).Msg("stream job results failed")
+	}
+}
+
+func wantsNDJSON(c *gin.Context) bool {
+	if strings.EqualFold(c.Query("format"), "ndjson") {
+		return true
+	}
+
+	return strings.Contains(c.GetHeader("Accept"), ndjsonContentType)
+}
+
+// respondJobError 将任务相关错误映射为 HTTP 状态码.
+func respondJobError(c *gin.Context, operation string, err error) {
+	l := log.Logger()
+
+	switch {
+	case errors.Is(err, service.ErrJobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrJobFinished):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrJobsDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		l.Error().Err(err).Str("op", operation).Msg("job operation failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
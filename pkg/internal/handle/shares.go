@@ -1,11 +1,15 @@
 package handle
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
 	"github.com/yeisme/notevault/pkg/internal/types"
 	"github.com/yeisme/notevault/pkg/log"
 )
@@ -112,7 +116,7 @@ func GetShareDetail(c *gin.Context) {
 	resp, err := svc.GetShareDetail(c.Request.Context(), shareID)
 	if err != nil {
 		l.Error().Err(err).Msg("get share detail failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondShareError(c, err)
 
 		return
 	}
@@ -120,7 +124,7 @@ func GetShareDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// AccessShare 访问分享内容（可选密码）.
+// AccessShare 访问分享内容（校验访问权限与可选密码），返回分享信息与访问令牌.
 func AccessShare(c *gin.Context) {
 	l := log.Logger()
 
@@ -138,20 +142,25 @@ func AccessShare(c *gin.Context) {
 		return
 	}
 
+	visitor, err := shareVisitor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user"})
+		return
+	}
+
 	svc := service.NewShareService(c.Request.Context())
 
-	resp, err := svc.AccessShare(c.Request.Context(), shareID, req.Password)
+	resp, err := svc.AccessShare(c.Request.Context(), shareID, req.Password, visitor)
 	if err != nil {
-		l.Error().Err(err).Msg("access share failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-
+		respondShareAccessError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
-// DownloadShare 下载分享文件（返回直链或重定向）.
+// DownloadShare 下载分享文件（需要访问令牌）：单个对象直接流式返回（支持 Range 与条件请求），
+// 多个对象或文件夹分享流式打包返回（archive=true 时总是打包）；对象解析成功后才计入下载次数.
 func DownloadShare(c *gin.Context) {
 	l := log.Logger()
 
@@ -161,17 +170,306 @@ func DownloadShare(c *gin.Context) {
 		return
 	}
 
+	var q types.ShareDownloadQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	visitor, err := shareVisitor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	svc := service.NewShareService(ctx)
+
+	owner, req, err := svc.ShareDownloadRequest(ctx, shareID, shareToken(c), visitor.User)
+	if err != nil {
+		l.Error().Err(err).Msg("resolve share download failed")
+		respondShareError(c, err)
+
+		return
+	}
+
+	files := service.NewFileService(ctx)
+
+	if len(req.Objects) == 1 && req.Objects[0].FolderID == "" && !q.Archive {
+		item := req.Objects[0]
+
+		info, err := files.StatObject(ctx, owner, item.ObjectKey)
+		if err != nil {
+			respondObjectError(c, err)
+			return
+		}
+
+		continuation := isRangeContinuation(c.Request, info)
+		if err := svc.RecordShareFileDownload(ctx, shareID, shareToken(c), types.ShareActionDownload, item.ObjectKey,
+			continuation, visitor); err != nil {
+			l.Warn().Err(err).Str("share_id", shareID).Msg("share download rejected")
+			respondShareError(c, err)
+
+			return
+		}
+
+		if err := serveSingleFile(c, files, visitor.User, owner, item, false); err != nil {
+			l.Error().Err(err).Str("share_id", shareID).Msg("serve share file failed")
+		}
+
+		return
+	}
+
+	if err := svc.RecordShareDownload(ctx, shareID, types.ShareActionDownload, "", visitor); err != nil {
+		l.Warn().Err(err).Str("share_id", shareID).Msg("share download rejected")
+		respondShareError(c, err)
+
+		return
+	}
+
+	req.ArchiveFormat = q.ArchiveFormat
+	if err := serveArchive(c, files, visitor.User, owner, req); err != nil {
+		l.Error().Err(err).Str("share_id", shareID).Msg("serve share archive failed")
+	}
+}
+
+// ListShareFiles 获取分享中的文件列表（需要访问令牌，文件夹分享实时解析）.
+func ListShareFiles(c *gin.Context) {
+	l := log.Logger()
+
+	shareID := c.Param("shareId")
+	if shareID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing shareId"})
+		return
+	}
+
+	visitor, err := shareVisitor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user"})
+		return
+	}
+
 	svc := service.NewShareService(c.Request.Context())
 
-	url, err := svc.GetShareDownloadURL(c.Request.Context(), shareID)
+	resp, err := svc.ListShareFiles(c.Request.Context(), shareID, shareToken(c), visitor)
 	if err != nil {
-		l.Error().Err(err).Msg("get share download url failed")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		l.Error().Err(err).Msg("list share files failed")
+		respondShareError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// DownloadShareFile 下载分享中的单个文件（需要访问令牌，支持 Range 与条件请求）.
+func DownloadShareFile(c *gin.Context) {
+	l := log.Logger()
+
+	shareID := c.Param("shareId")
+	if shareID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing shareId"})
+		return
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file index"})
+		return
+	}
+
+	var q types.ShareFileDownloadQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	visitor, err := shareVisitor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	svc := service.NewShareService(ctx)
+
+	owner, item, err := svc.ResolveShareFile(ctx, shareID, shareToken(c), visitor.User, index)
+	if err != nil {
+		l.Error().Err(err).Msg("resolve share file failed")
+		respondShareError(c, err)
+
+		return
+	}
+
+	files := service.NewFileService(ctx)
+
+	info, err := files.StatObject(ctx, owner, item.ObjectKey)
+	if err != nil {
+		respondObjectError(c, err)
+		return
+	}
+
+	// 续传/拖动播放产生的 Range 请求在同一会话内不重复计次
+	continuation := isRangeContinuation(c.Request, info)
+	if err := svc.RecordShareFileDownload(ctx, shareID, shareToken(c), types.ShareActionFileDownload, item.ObjectKey,
+		continuation, visitor); err != nil {
+		l.Warn().Err(err).Str("share_id", shareID).Msg("share file download rejected")
+		respondShareError(c, err)
+
+		return
+	}
+
+	if err := serveSingleFile(c, files, visitor.User, owner, item, q.Inline); err != nil {
+		l.Error().Err(err).Str("share_id", shareID).Msg("serve share file failed")
+	}
+}
+
+// RequestShareUpload 向上传型分享申请上传（需要访问令牌），返回每个文件的预签名 POST 表单.
+func RequestShareUpload(c *gin.Context) {
+	l := log.Logger()
+
+	shareID := c.Param("shareId")
+	if shareID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing shareId"})
+		return
+	}
+
+	user, err := optionalUser(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user"})
+		return
+	}
+
+	var req types.ShareUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.Warn().Err(err).Msg("invalid request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	svc := service.NewShareService(c.Request.Context())
+
+	resp, err := svc.RequestShareUpload(c.Request.Context(), shareID, shareToken(c), user, &req)
+	if err != nil {
+		l.Error().Err(err).Str("share_id", shareID).Msg("request share upload failed")
+		respondShareError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CompleteShareUpload 确认上传型分享的文件已上传完成（需要访问令牌），写入 owner 的文件记录并通知 owner.
+func CompleteShareUpload(c *gin.Context) {
+	l := log.Logger()
+
+	shareID := c.Param("shareId")
+	if shareID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing shareId"})
+		return
+	}
+
+	visitor, err := shareVisitor(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user"})
+		return
+	}
+
+	var req types.ShareUploadCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.Warn().Err(err).Msg("invalid request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	svc := service.NewShareService(c.Request.Context())
+
+	resp, err := svc.CompleteShareUpload(c.Request.Context(), shareID, shareToken(c), req.ObjectKeys, visitor)
+	if err != nil {
+		l.Error().Err(err).Str("share_id", shareID).Msg("complete share upload failed")
+		respondShareError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateSharePassword 修改或取消分享密码.
+func UpdateSharePassword(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	shareID := c.Param("shareId")
+	if shareID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing shareId"})
+		return
+	}
+
+	var req types.UpdateSharePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.Warn().Err(err).Msg("invalid request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	svc := service.NewShareService(c.Request.Context())
+	if err := svc.UpdateSharePassword(c.Request.Context(), user, shareID, req.Password); err != nil {
+		l.Error().Err(err).Msg("update share password failed")
+		respondShareError(c, err)
 
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"download_url": url})
+	c.Status(http.StatusNoContent)
+}
+
+// GetShareStats 获取分享的访问统计（仅 owner）.
+func GetShareStats(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	shareID := c.Param("shareId")
+	if shareID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing shareId"})
+		return
+	}
+
+	var q types.ShareStatsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := service.NewShareService(c.Request.Context())
+
+	resp, err := svc.GetShareStats(c.Request.Context(), user, shareID, &q)
+	if err != nil {
+		l.Error().Err(err).Msg("get share stats failed")
+		respondShareError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 // GetSharePermissions 获取分享权限.
@@ -317,3 +615,63 @@ func RemoveShareUser(c *gin.Context) {
 
 	c.Status(http.StatusNoContent)
 }
+
+// respondShareAccessError 将分享访问错误映射为 HTTP 状态码：密码错误 401，锁定 429（附 Retry-After）.
+func respondShareAccessError(c *gin.Context, err error) {
+	l := log.Logger()
+
+	var locked *service.ShareLockedError
+
+	switch {
+	case errors.As(err, &locked):
+		l.Warn().Err(err).Str("ip", c.ClientIP()).Msg("share access locked")
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter().Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": service.ErrShareLocked.Error()})
+	case errors.Is(err, service.ErrShareInvalidPassword):
+		l.Warn().Str("ip", c.ClientIP()).Msg("share access denied: invalid password")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		l.Error().Err(err).Msg("access share failed")
+		respondShareError(c, err)
+	}
+}
+
+// respondShareError 将分享相关错误映射为 HTTP 状态码.
+func respondShareError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrShareAuthRequired), errors.Is(err, service.ErrShareTokenInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrShareForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrShareNotFound), errors.Is(err, service.ErrShareFileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrShareExpired), errors.Is(err, service.ErrShareExhausted):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrShareDownloadNotAllowed), errors.Is(err, service.ErrShareUploadOnly),
+		errors.Is(err, service.ErrShareUploadNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrShareUploadRejected), errors.Is(err, s3.ErrPresignEncrypted):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// shareToken 读取分享访问令牌：X-Share-Token 头优先，其次 token 查询参数（便于直接链接）.
+func shareToken(c *gin.Context) string {
+	if t := c.GetHeader("X-Share-Token"); t != "" {
+		return t
+	}
+
+	return c.Query("token")
+}
+
+// shareVisitor 收集访问者信息（来源 IP、User-Agent 与可选的登录用户）.
+func shareVisitor(c *gin.Context) (service.ShareVisitor, error) {
+	user, err := optionalUser(c)
+	if err != nil {
+		return service.ShareVisitor{}, err
+	}
+
+	return service.ShareVisitor{IP: c.ClientIP(), UserAgent: c.Request.UserAgent(), User: user}, nil
+}
@@ -0,0 +1,50 @@
+package handle
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+)
+
+// localStore 返回本地磁盘驱动，其他驱动下签名接口不可用.
+func localStore(c *gin.Context) *s3.LocalStore {
+	s3c := ctxPkg.GetS3Client(c.Request.Context())
+	if s3c == nil {
+		return nil
+	}
+
+	inner := s3c.ObjectStore
+	if enc, ok := inner.(*s3.EncryptedStore); ok {
+		inner = enc.Unwrap()
+	}
+
+	store, _ := inner.(*s3.LocalStore)
+
+	return store
+}
+
+// ServeStorageObject 本地存储驱动的预签名 URL 访问（GET/HEAD 下载，PUT 上传），由签名而非用户身份鉴权.
+func ServeStorageObject(c *gin.Context) {
+	store := localStore(c)
+	if store == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "local object store not enabled"})
+		return
+	}
+
+	store.ServeObject(c.Writer, c.Request, c.Param("bucket"), strings.TrimPrefix(c.Param("key"), "/"))
+}
+
+// PostStorageObject 本地存储驱动的表单上传（PresignedPostPolicy），由策略签名鉴权.
+func PostStorageObject(c *gin.Context) {
+	store := localStore(c)
+	if store == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "local object store not enabled"})
+		return
+	}
+
+	store.ServePostPolicy(c.Writer, c.Request, c.Param("bucket"))
+}
@@ -0,0 +1,348 @@
+package handle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	"github.com/yeisme/notevault/pkg/log"
+)
+
+// trashBatchFunc 回收站批量操作.
+type trashBatchFunc func(svc *service.FileService, ctx context.Context, user string, ids []string) (*types.TrashOpResponse, error)
+
+// TrashFiles 将文件移入回收站.
+//
+//	@Summary		移入回收站
+//	@Description	对象移动到回收站目录并从文件列表中移除，保留期内可恢复，过期后自动永久删除
+//	@Tags			回收站
+//	@Accept			json
+//	@Produce		json
+//	@Param			req	body		types.TrashFilesRequest	true	"对象键列表"
+//	@Success		200	{object}	types.TrashOpResponse
+//	@Failure		400	{object}	map[string]string	"请求参数错误"
+//	@Failure		500	{object}	map[string]string	"服务器内部错误"
+//	@Router			/api/v1/trash [post]
+func TrashFiles(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	var req types.TrashFilesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.TrashFiles(c.Request.Context(), user, req.ObjectKeys, types.TrashReasonUser)
+	if err != nil {
+		respondTrashError(c, "trash files", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListTrash 获取回收站文件列表.
+//
+//	@Summary	获取回收站文件列表
+//	@Tags		回收站
+//	@Produce	json
+//	@Param		keyword		query		string	false	"文件名/原对象键关键字"
+//	@Param		reason		query		string	false	"来源：user / duplicate"
+//	@Param		page		query		int		false	"页码（从 1 开始）"
+//	@Param		page_size	query		int		false	"每页数量"
+//	@Success	200			{object}	types.ListTrashResponse
+//	@Failure	400			{object}	map[string]string
+//	@Failure	500			{object}	map[string]string
+//	@Router		/api/v1/trash [get]
+func ListTrash(c *gin.Context) {
+	var req types.ListTrashRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listTrash(c, &req)
+}
+
+// SearchTrash 搜索回收站文件.
+//
+//	@Summary	搜索回收站文件
+//	@Tags		回收站
+//	@Accept		json
+//	@Produce	json
+//	@Param		req	body		types.ListTrashRequest	true	"搜索条件"
+//	@Success	200	{object}	types.ListTrashResponse
+//	@Failure	400	{object}	map[string]string
+//	@Failure	500	{object}	map[string]string
+//	@Router		/api/v1/trash/search [post]
+func SearchTrash(c *gin.Context) {
+	var req types.ListTrashRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	listTrash(c, &req)
+}
+
+// GetTrashItem 获取回收站条目详情.
+//
+//	@Summary	获取回收站文件详情
+//	@Tags		回收站
+//	@Produce	json
+//	@Param		id	path		string	true	"回收站条目 ID"
+//	@Success	200	{object}	types.TrashItemInfo
+//	@Failure	404	{object}	map[string]string
+//	@Failure	500	{object}	map[string]string
+//	@Router		/api/v1/trash/{id} [get]
+func GetTrashItem(c *gin.Context) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.GetTrashItem(c.Request.Context(), user, c.Param("id"))
+	if err != nil {
+		respondTrashError(c, "get trash item", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RestoreTrashItem 恢复回收站中的文件到原位置.
+//
+//	@Summary	恢复文件
+//	@Tags		回收站
+//	@Produce	json
+//	@Param		id	path		string	true	"回收站条目 ID"
+//	@Success	200	{object}	types.TrashOpResult
+//	@Failure	404	{object}	map[string]string
+//	@Failure	409	{object}	types.TrashOpResult	"原位置已存在同名对象等原因导致恢复失败"
+//	@Router		/api/v1/trash/{id}/restore [post]
+func RestoreTrashItem(c *gin.Context) {
+	handleTrashItem(c, "restore trash item", http.StatusConflict,
+		func(svc *service.FileService, ctx context.Context, user string, ids []string) (*types.TrashOpResponse, error) {
+			return svc.RestoreTrash(ctx, user, ids)
+		})
+}
+
+// DeleteTrashItem 永久删除回收站中的文件.
+//
+//	@Summary	永久删除文件
+//	@Tags		回收站
+//	@Produce	json
+//	@Param		id	path		string	true	"回收站条目 ID"
+//	@Success	200	{object}	types.TrashOpResult
+//	@Failure	404	{object}	map[string]string
+//	@Failure	500	{object}	types.TrashOpResult
+//	@Router		/api/v1/trash/{id} [delete]
+func DeleteTrashItem(c *gin.Context) {
+	handleTrashItem(c, "delete trash item", http.StatusInternalServerError,
+		func(svc *service.FileService, ctx context.Context, user string, ids []string) (*types.TrashOpResponse, error) {
+			return svc.DeleteTrash(ctx, user, ids)
+		})
+}
+
+// BatchRestoreTrash 批量恢复回收站中的文件.
+//
+//	@Summary	批量恢复
+//	@Tags		回收站
+//	@Accept		json
+//	@Produce	json
+//	@Param		req	body		types.TrashBatchRequest	true	"回收站条目 ID 列表"
+//	@Success	200	{object}	types.TrashOpResponse
+//	@Failure	400	{object}	map[string]string
+//	@Router		/api/v1/trash/batch/restore [post]
+func BatchRestoreTrash(c *gin.Context) {
+	handleTrashBatch(c, "batch restore trash",
+		func(svc *service.FileService, ctx context.Context, user string, ids []string) (*types.TrashOpResponse, error) {
+			return svc.RestoreTrash(ctx, user, ids)
+		})
+}
+
+// BatchDeleteTrash 批量永久删除回收站中的文件.
+//
+//	@Summary	批量永久删除
+//	@Tags		回收站
+//	@Accept		json
+//	@Produce	json
+//	@Param		req	body		types.TrashBatchRequest	true	"回收站条目 ID 列表"
+//	@Success	200	{object}	types.TrashOpResponse
+//	@Failure	400	{object}	map[string]string
+//	@Router		/api/v1/trash/batch [delete]
+func BatchDeleteTrash(c *gin.Context) {
+	handleTrashBatch(c, "batch delete trash",
+		func(svc *service.FileService, ctx context.Context, user string, ids []string) (*types.TrashOpResponse, error) {
+			return svc.DeleteTrash(ctx, user, ids)
+		})
+}
+
+// EmptyTrash 清空回收站.
+//
+//	@Summary	清空回收站
+//	@Tags		回收站
+//	@Produce	json
+//	@Success	200	{object}	types.TrashOpResponse
+//	@Failure	500	{object}	map[string]string
+//	@Router		/api/v1/trash [delete]
+func EmptyTrash(c *gin.Context) {
+	handleTrashUser(c, "empty trash", func(svc *service.FileService, ctx context.Context, user string) (*types.TrashOpResponse, error) {
+		return svc.EmptyTrash(ctx, user)
+	})
+}
+
+// CleanExpiredTrash 立即清理当前用户回收站中已过保留期的文件.
+//
+//	@Summary	清理过期文件
+//	@Tags		回收站
+//	@Produce	json
+//	@Success	200	{object}	types.TrashOpResponse
+//	@Failure	500	{object}	map[string]string
+//	@Router		/api/v1/trash/auto-clean [post]
+func CleanExpiredTrash(c *gin.Context) {
+	handleTrashUser(c, "clean expired trash", func(svc *service.FileService, ctx context.Context, user string) (*types.TrashOpResponse, error) {
+		return svc.CleanExpiredTrash(ctx, user)
+	})
+}
+
+func listTrash(c *gin.Context, req *types.ListTrashRequest) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	svc := service.NewFileService(c.Request.Context())
+
+	resp, err := svc.ListTrash(c.Request.Context(), user, req)
+	if err != nil {
+		respondTrashError(c, "list trash", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleTrashItem 对路径参数指定的单个条目执行操作，失败时以 failStatus 返回条目结果.
+func handleTrashItem(c *gin.Context, operation string, failStatus int, fn trashBatchFunc) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Str("op", operation).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	ctx := c.Request.Context()
+	svc := service.NewFileService(ctx)
+	id := c.Param("id")
+
+	if _, err := svc.GetTrashItem(ctx, user, id); err != nil {
+		respondTrashError(c, operation, err)
+		return
+	}
+
+	resp, err := fn(svc, ctx, user, []string{id})
+	if err != nil {
+		respondTrashError(c, operation, err)
+		return
+	}
+
+	result := resp.Results[0]
+	if !result.Success {
+		l.Warn().Str("op", operation).Str("trash_id", id).Str("error", result.Error).Msg("trash operation failed")
+		c.JSON(failStatus, result)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleTrashBatch 解析条目 ID 列表并执行批量操作.
+func handleTrashBatch(c *gin.Context, operation string, fn trashBatchFunc) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Str("op", operation).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	var req types.TrashBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := fn(service.NewFileService(c.Request.Context()), c.Request.Context(), user, req.TrashIDs)
+	if err != nil {
+		respondTrashError(c, operation, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// handleTrashUser 执行作用于当前用户整个回收站的操作.
+func handleTrashUser(c *gin.Context, operation string,
+	fn func(*service.FileService, context.Context, string) (*types.TrashOpResponse, error)) {
+	l := log.Logger()
+
+	user, err := checkUser(c)
+	if user == "" || err != nil {
+		l.Warn().Err(err).Str("op", operation).Msg("missing or invalid user")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing user"})
+
+		return
+	}
+
+	resp, err := fn(service.NewFileService(c.Request.Context()), c.Request.Context(), user)
+	if err != nil {
+		respondTrashError(c, operation, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// respondTrashError 将回收站相关错误映射为 HTTP 状态码.
+func respondTrashError(c *gin.Context, operation string, err error) {
+	if errors.Is(err, service.ErrTrashItemNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Logger().Error().Err(err).Str("op", operation).Msg("trash operation failed")
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
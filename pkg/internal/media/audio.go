@@ -0,0 +1,36 @@
+package media
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dhowden/tag"
+)
+
+// extractAudio 读取 ID3/Vorbis/MP4 标签.
+func extractAudio(r io.ReadSeeker) (*Metadata, error) {
+	t, err := tag.ReadFrom(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	track, _ := t.Track()
+
+	artist := t.Artist()
+	if artist == "" {
+		artist = t.AlbumArtist()
+	}
+
+	return &Metadata{
+		Kind:   KindAudio,
+		Title:  strings.TrimSpace(t.Title()),
+		Artist: strings.TrimSpace(artist),
+		Album:  strings.TrimSpace(t.Album()),
+		Genre:  strings.TrimSpace(t.Genre()),
+		Year:   t.Year(),
+		Track:  track,
+		Author: strings.TrimSpace(t.Composer()),
+		Format: string(t.Format()),
+	}, nil
+}
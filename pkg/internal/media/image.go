@@ -0,0 +1,73 @@
+package media
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+
+	// 纯 Go 图片解码器（仅用于读取尺寸）.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// extractImage 读取图片尺寸与 EXIF；EXIF 缺失或损坏时只返回尺寸.
+func extractImage(r io.ReadSeeker) (*Metadata, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	m := &Metadata{Kind: KindImage, Width: cfg.Width, Height: cfg.Height}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return m, nil //nolint:nilerr // 无法回退时跳过 EXIF
+	}
+
+	x, err := exif.Decode(r)
+	if err != nil {
+		return m, nil //nolint:nilerr // 大多数 PNG/GIF 没有 EXIF
+	}
+
+	if t, err := x.DateTime(); err == nil && !t.IsZero() {
+		t = t.UTC()
+		m.TakenAt = &t
+	}
+
+	m.CameraMake = exifString(x, exif.Make)
+	m.CameraModel = exifString(x, exif.Model)
+
+	if lat, long, err := x.LatLong(); err == nil && (lat != 0 || long != 0) {
+		m.Latitude, m.Longitude = &lat, &long
+	}
+
+	// Orientation 5~8 表示旋转 90°，显示尺寸需交换宽高
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if o, err := tag.Int(0); err == nil && o >= 5 && o <= 8 {
+			m.Width, m.Height = m.Height, m.Width
+		}
+	}
+
+	return m, nil
+}
+
+func exifString(x *exif.Exif, name exif.FieldName) string {
+	tag, err := x.Get(name)
+	if err != nil {
+		return ""
+	}
+
+	s, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+}
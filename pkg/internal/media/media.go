@@ -0,0 +1,97 @@
+// Package media 从图片、PDF 与音频文件中提取可检索的媒体元数据（纯 Go 实现）.
+//
+// 支持：
+//   - 图片：尺寸（image.DecodeConfig），JPEG/TIFF 的 EXIF（拍摄时间、相机、GPS）
+//   - PDF：页数与文档信息字典（Title/Author/Subject/Creator/Producer）
+//   - 音频：ID3v1/ID3v2（MP3）、Vorbis Comment（FLAC/OGG）、MP4 标签
+//
+// Example:
+//
+//	meta, err := media.Extract(r, size, "image/jpeg")
+//	if errors.Is(err, media.ErrUnsupported) {
+//		return nil
+//	}
+package media
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// 媒体类型.
+const (
+	KindImage = "image"
+	KindPDF   = "pdf"
+	KindAudio = "audio"
+)
+
+// ErrUnsupported 内容类型没有对应的提取器.
+var ErrUnsupported = errors.New("unsupported media type")
+
+// Metadata 提取结果；未识别的字段保持零值.
+type Metadata struct {
+	Kind string
+
+	// 图片
+	Width       int
+	Height      int
+	TakenAt     *time.Time
+	CameraMake  string
+	CameraModel string
+	Latitude    *float64
+	Longitude   *float64
+
+	// PDF / 通用文档信息
+	PageCount int
+	Title     string
+	Author    string
+	Subject   string
+	Creator   string
+	Producer  string
+
+	// 音频
+	Artist string
+	Album  string
+	Genre  string
+	Year   int
+	Track  int
+	Format string // 标签格式，如 ID3v2.4、VORBIS、MP4
+}
+
+// HasGPS 是否包含 GPS 坐标.
+func (m *Metadata) HasGPS() bool {
+	return m.Latitude != nil && m.Longitude != nil
+}
+
+// Extract 根据内容类型选择提取器；r 需支持 Seek（音频标签可能位于文件末尾）.
+func Extract(r io.ReadSeeker, size int64, contentType string) (*Metadata, error) {
+	switch KindOf(contentType) {
+	case KindImage:
+		return extractImage(r)
+	case KindPDF:
+		return extractPDF(r, size)
+	case KindAudio:
+		return extractAudio(r)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+// KindOf 返回内容类型对应的媒体类型，无提取器时返回空串.
+func KindOf(contentType string) string {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	ct = strings.TrimSpace(ct)
+
+	switch {
+	case ct == "application/pdf":
+		return KindPDF
+	case strings.HasPrefix(ct, "image/"):
+		return KindImage
+	case strings.HasPrefix(ct, "audio/"), ct == "application/ogg":
+		return KindAudio
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,113 @@
+package media_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/yeisme/notevault/pkg/internal/media"
+)
+
+// TestExtractImage 验证图片尺寸读取；无 EXIF 的图片不报错.
+func TestExtractImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 64, 48))); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := media.Extract(bytes.NewReader(buf.Bytes()), int64(buf.Len()), "image/png")
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	if m.Kind != media.KindImage || m.Width != 64 || m.Height != 48 || m.TakenAt != nil || m.HasGPS() {
+		t.Fatalf("unexpected metadata: %+v", m)
+	}
+}
+
+// TestExtractPDF 验证页数与文档信息字典（字面量转义与 UTF-16BE 十六进制字符串）.
+func TestExtractPDF(t *testing.T) {
+	doc := []byte("%PDF-1.4\n" +
+		"1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >> endobj\n" +
+		"3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+		"4 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+		"5 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+		"6 0 obj << /Title (Annual \\(Draft\\) Report) /Author <FEFF004A006F0073006500DF> >> endobj\n" +
+		"trailer << /Root 1 0 R /Info 6 0 R >>\n%%EOF\n")
+
+	m, err := media.Extract(bytes.NewReader(doc), int64(len(doc)), "application/pdf")
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+
+	if m.Kind != media.KindPDF || m.PageCount != 3 {
+		t.Fatalf("unexpected kind/pages: %+v", m)
+	}
+
+	if m.Title != "Annual (Draft) Report" || m.Author != "Joseß" {
+		t.Fatalf("unexpected info: title=%q author=%q", m.Title, m.Author)
+	}
+}
+
+// TestExtractUnsupported 未知类型与无法解析的内容均返回 ErrUnsupported.
+func TestExtractUnsupported(t *testing.T) {
+	cases := []struct {
+		contentType string
+		data        []byte
+	}{
+		{"application/zip", []byte("PK")},
+		{"image/jpeg", []byte("not an image")},
+		{"application/pdf", []byte("not a pdf")},
+		{"audio/mpeg", []byte("no tags")},
+	}
+
+	for _, c := range cases {
+		_, err := media.Extract(bytes.NewReader(c.data), int64(len(c.data)), c.contentType)
+		if !errors.Is(err, media.ErrUnsupported) {
+			t.Errorf("%s: expected ErrUnsupported, got %v", c.contentType, err)
+		}
+	}
+}
+
+// TestHashImage 验证缩放后的同一图片感知哈希相近，不同图片差异明显.
+func TestHashImage(t *testing.T) {
+	pattern := func(w, h int, invert bool) image.Image {
+		img := image.NewGray(image.Rect(0, 0, w, h))
+		for y := range h {
+			for x := range w {
+				v := uint8((x*255/w + y*128/h) % 256) //nolint:gosec // 测试图案
+				if (x*4/w+y*4/h)%2 == 1 {
+					v = 255 - v
+				}
+
+				if invert {
+					v = 255 - v
+				}
+
+				img.SetGray(x, y, color.Gray{Y: v})
+			}
+		}
+
+		return img
+	}
+
+	a := media.HashImage(pattern(256, 192, false))
+	b := media.HashImage(pattern(128, 96, false))
+	c := media.HashImage(pattern(256, 192, true))
+
+	if d := media.Hamming(a.PHash, b.PHash); d > 6 {
+		t.Fatalf("resized pHash distance = %d, want <= 6", d)
+	}
+
+	if d := media.Hamming(a.DHash, b.DHash); d > 6 {
+		t.Fatalf("resized dHash distance = %d, want <= 6", d)
+	}
+
+	if d := media.Hamming(a.PHash, c.PHash); d < 16 {
+		t.Fatalf("inverted pHash distance = %d, want >= 16", d)
+	}
+}
@@ -0,0 +1,241 @@
+package media
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode/utf16"
+)
+
+// maxObjStmBytes 单个压缩对象流解压后的上限.
+const maxObjStmBytes = 16 << 20
+
+var (
+	rePDFPages  = regexp.MustCompile(`/Type\s*/Pages\b`)
+	rePDFPage   = regexp.MustCompile(`/Type\s*/Page\b`)
+	rePDFCount  = regexp.MustCompile(`/Count\s+(\d+)`)
+	rePDFObjStm = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+	rePDFStream = regexp.MustCompile(`stream\r?\n`)
+	rePDFInfo   = regexp.MustCompile(`/(Title|Author|Subject|Creator|Producer)\s*([(<])`)
+)
+
+// extractPDF 轻量解析 PDF：统计页数并读取文档信息字典.
+// 不做完整的交叉引用解析，仅扫描未压缩对象与 /ObjStm 压缩对象流；加密文档只返回页数.
+func extractPDF(r io.Reader, size int64) (*Metadata, error) {
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, fmt.Errorf("read pdf: %w", err)
+	}
+
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return nil, fmt.Errorf("%w: missing %%PDF header", ErrUnsupported)
+	}
+
+	// PDF 1.5+ 常把页面对象放在压缩对象流中，解压后一并扫描
+	text := append(bytes.Clone(data), inflateObjectStreams(data)...)
+
+	m := &Metadata{Kind: KindPDF, PageCount: pdfPageCount(text)}
+
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return m, nil
+	}
+
+	for _, match := range rePDFInfo.FindAllSubmatchIndex(text, -1) {
+		key := string(text[match[2]:match[3]])
+		value := pdfString(text[match[4]:])
+
+		switch key {
+		case "Title":
+			m.Title = firstNonEmpty(m.Title, value)
+		case "Author":
+			m.Author = firstNonEmpty(m.Author, value)
+		case "Subject":
+			m.Subject = firstNonEmpty(m.Subject, value)
+		case "Creator":
+			m.Creator = firstNonEmpty(m.Creator, value)
+		case "Producer":
+			m.Producer = firstNonEmpty(m.Producer, value)
+		}
+	}
+
+	return m, nil
+}
+
+// pdfPageCount 优先取页面树节点 (/Type /Pages) 的最大 /Count，否则统计页面对象数量.
+func pdfPageCount(text []byte) int {
+	count := 0
+
+	for _, loc := range rePDFPages.FindAllIndex(text, -1) {
+		start := bytes.LastIndex(text[:loc[0]], []byte("<<"))
+		end := bytes.Index(text[loc[1]:], []byte(">>"))
+
+		if start < 0 || end < 0 {
+			continue
+		}
+
+		for _, m := range rePDFCount.FindAllSubmatch(text[start:loc[1]+end], -1) {
+			if n, err := strconv.Atoi(string(m[1])); err == nil && n > count {
+				count = n
+			}
+		}
+	}
+
+	if count > 0 {
+		return count
+	}
+
+	return len(rePDFPage.FindAllIndex(text, -1))
+}
+
+// inflateObjectStreams 解压所有 /Type /ObjStm 的 Flate 对象流并拼接返回.
+func inflateObjectStreams(data []byte) []byte {
+	var out bytes.Buffer
+
+	for _, loc := range rePDFStream.FindAllIndex(data, -1) {
+		dictStart := bytes.LastIndex(data[:loc[0]], []byte(" obj"))
+		if dictStart < 0 || !rePDFObjStm.Match(data[dictStart:loc[0]]) {
+			continue
+		}
+
+		end := bytes.Index(data[loc[1]:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+
+		zr, err := zlib.NewReader(bytes.NewReader(data[loc[1] : loc[1]+end]))
+		if err != nil {
+			continue
+		}
+
+		_, _ = io.Copy(&out, io.LimitReader(zr, maxObjStmBytes))
+		_ = zr.Close()
+		out.WriteByte('\n')
+	}
+
+	return out.Bytes()
+}
+
+// pdfString 解析以 '(' 开头的字面量字符串或以 '<' 开头的十六进制字符串.
+func pdfString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+
+	var raw []byte
+
+	if b[0] == '<' {
+		end := bytes.IndexByte(b, '>')
+		if end < 0 {
+			return ""
+		}
+
+		h := bytes.Join(bytes.Fields(b[1:end]), nil)
+		if len(h)%2 == 1 {
+			h = append(h, '0')
+		}
+
+		decoded, err := hex.DecodeString(string(h))
+		if err != nil {
+			return ""
+		}
+
+		raw = decoded
+	} else {
+		raw = pdfLiteral(b)
+	}
+
+	return decodePDFText(raw)
+}
+
+// pdfLiteral 解析字面量字符串（处理转义与嵌套括号）.
+func pdfLiteral(b []byte) []byte {
+	var out []byte
+
+	depth := 0
+
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+
+		switch {
+		case c == '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case c == '\\' && i+1 < len(b):
+			i++
+
+			switch e := b[i]; e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				continue // 续行
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					j := i
+					for ; j < len(b) && j < i+3 && b[j] >= '0' && b[j] <= '7'; j++ {
+						n = n*8 + int(b[j]-'0')
+					}
+
+					i = j - 1
+					c = byte(n)
+				} else {
+					c = e
+				}
+			}
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// decodePDFText 处理 UTF-16BE（带 BOM）文本，其余按 PDFDocEncoding 近似为 Latin-1.
+func decodePDFText(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		u := make([]uint16, 0, (len(b)-2)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+
+		return string(utf16.Decode(u))
+	}
+
+	if len(b) >= 3 && b[0] == 0xEF && b[1] == 0xBB && b[2] == 0xBF {
+		return string(b[3:]) // PDF 2.0 允许 UTF-8
+	}
+
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+
+	return string(r)
+}
+
+func firstNonEmpty(a, b string) string {
+	if a != "" {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,123 @@
+package media
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"slices"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ImageHashes 感知哈希：内容相同或仅经过缩放、重新编码的图片哈希值相同或汉明距离很小.
+type ImageHashes struct {
+	PHash uint64 // 基于 32x32 灰度图 DCT 低频系数
+	DHash uint64 // 基于 9x8 灰度图相邻像素梯度
+}
+
+// HashImage 计算图片的 pHash 与 dHash.
+func HashImage(img image.Image) ImageHashes {
+	return ImageHashes{PHash: pHash(img), DHash: dHash(img)}
+}
+
+// Hamming 返回两个哈希值的汉明距离（0-64）.
+func Hamming(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// dHash 缩放为 9x8 灰度图，每行相邻像素左亮于右记 1.
+func dHash(img image.Image) uint64 {
+	g := grayscale(img, 9, 8)
+
+	var h uint64
+
+	for y := range 8 {
+		for x := range 8 {
+			h <<= 1
+			if g.GrayAt(x, y).Y > g.GrayAt(x+1, y).Y {
+				h |= 1
+			}
+		}
+	}
+
+	return h
+}
+
+// pHash 缩放为 32x32 灰度图做二维 DCT，取左上 8x8 低频系数与其中位数（不含直流分量）比较.
+func pHash(img image.Image) uint64 {
+	const n, k = 32, 8
+
+	g := grayscale(img, n, n)
+
+	px := make([][]float64, n)
+	for y := range n {
+		px[y] = make([]float64, n)
+		for x := range n {
+			px[y][x] = float64(g.GrayAt(x, y).Y)
+		}
+	}
+
+	// 可分离 DCT-II：先对行再对列，只需要前 k 个频率
+	rows := make([][]float64, n)
+	for y := range n {
+		rows[y] = dct(px[y], k)
+	}
+
+	coeffs := make([]float64, 0, k*k)
+	col := make([]float64, n)
+
+	freq := make([][]float64, k)
+	for u := range k {
+		for y := range n {
+			col[y] = rows[y][u]
+		}
+
+		freq[u] = dct(col, k)
+	}
+
+	for v := range k {
+		for u := range k {
+			coeffs = append(coeffs, freq[u][v])
+		}
+	}
+
+	sorted := slices.Clone(coeffs[1:])
+	slices.Sort(sorted)
+	median := sorted[len(sorted)/2]
+
+	var h uint64
+
+	for _, c := range coeffs {
+		h <<= 1
+		if c > median {
+			h |= 1
+		}
+	}
+
+	return h
+}
+
+// dct 返回一维 DCT-II 的前 k 个系数.
+func dct(in []float64, k int) []float64 {
+	n := len(in)
+	out := make([]float64, k)
+
+	for u := range k {
+		var sum float64
+		for x, v := range in {
+			sum += v * math.Cos(math.Pi*float64(u)*(2*float64(x)+1)/(2*float64(n)))
+		}
+
+		out[u] = sum
+	}
+
+	return out
+}
+
+// grayscale 将图片缩放为 w×h 的灰度图.
+func grayscale(img image.Image, w, h int) *image.Gray {
+	dst := image.NewGray(image.Rect(0, 0, w, h))
+	xdraw.BiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+
+	return dst
+}
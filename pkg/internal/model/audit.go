@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+// AuditEvent 审计日志：记录用户与管理员操作的操作者、动作、目标、结果与请求来源.
+// 启用哈希链时 Hash 由 PrevHash 与本条记录的内容计算，PrevHash 为前一条记录的 Hash.
+type AuditEvent struct {
+	ID     uint   `gorm:"primaryKey"     json:"id"`
+	Actor  string `gorm:"size:255;index" json:"actor"`
+	Action string `gorm:"size:64;index"  json:"action"`
+	// TargetKeys 操作目标（对象键、分享 ID 等）的 JSON 数组
+	TargetKeys string `gorm:"type:text" json:"target_keys"`
+	// Outcome success / partial / failure / denied
+	Outcome   string    `gorm:"size:16;index" json:"outcome"`
+	Error     string    `gorm:"type:text"     json:"error,omitempty"`
+	Detail    string    `gorm:"type:text"     json:"detail,omitempty"`
+	IP        string    `gorm:"size:64"       json:"ip,omitempty"`
+	UserAgent string    `gorm:"size:512"      json:"user_agent,omitempty"`
+	TraceID   string    `gorm:"size:64;index" json:"trace_id,omitempty"`
+	PrevHash  string    `gorm:"size:64"       json:"prev_hash,omitempty"`
+	Hash      string    `gorm:"size:64"       json:"hash,omitempty"`
+	CreatedAt time.Time `gorm:"index"         json:"created_at"`
+}
@@ -26,6 +26,8 @@ type Files struct {
 	StorageClass string `gorm:"size:64"   json:"storage_class"`
 	// 来自对象存储的最后修改时间
 	LastModified time.Time `gorm:"index" json:"last_modified"`
+	// 上传时按 expiry_days 计算的过期时间，到期后由生命周期任务移入回收站
+	ExpireAt *time.Time `gorm:"index" json:"expire_at,omitempty"`
 	// 软删除与审计
 	CreatedAt time.Time
 	UpdatedAt time.Time
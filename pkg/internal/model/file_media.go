@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// FileMedia 从文件内容中提取的媒体元数据（EXIF、尺寸、PDF 文档信息、音频标签），
+// 通过 User + ObjectKey 与 Files 关联，每个对象一行，内容变化（ETag 不同）时覆盖.
+type FileMedia struct {
+	ID        uint   `gorm:"primaryKey"                                  json:"-"`
+	User      string `gorm:"size:255;index:idx_media_user_key,unique"    json:"user"`
+	ObjectKey string `gorm:"size:1024;index:idx_media_user_key,unique"   json:"object_key"`
+	Kind      string `gorm:"size:16;index"                               json:"kind"` // image / pdf / audio
+	// 图片
+	Width       int        `gorm:"index"          json:"width,omitempty"`
+	Height      int        `gorm:"index"          json:"height,omitempty"`
+	TakenAt     *time.Time `gorm:"index"          json:"taken_at,omitempty"`
+	CameraMake  string     `gorm:"size:128"       json:"camera_make,omitempty"`
+	CameraModel string     `gorm:"size:128;index" json:"camera_model,omitempty"`
+	Latitude    *float64   `json:"latitude,omitempty"`
+	Longitude   *float64   `json:"longitude,omitempty"`
+	HasGPS      bool       `gorm:"index"          json:"has_gps"`
+	// 感知哈希（uint64 按位存为 int64），用于近似重复图片检测；NULL 表示未计算
+	PHash *int64 `gorm:"column:phash;index" json:"-"`
+	DHash *int64 `gorm:"column:dhash"       json:"-"`
+	// PDF / 文档信息
+	PageCount int    `gorm:"index"    json:"page_count,omitempty"`
+	Title     string `gorm:"size:512" json:"title,omitempty"`
+	Author    string `gorm:"size:255" json:"author,omitempty"`
+	Subject   string `gorm:"size:512" json:"subject,omitempty"`
+	Producer  string `gorm:"size:255" json:"producer,omitempty"`
+	// 音频
+	Artist string `gorm:"size:255;index" json:"artist,omitempty"`
+	Album  string `gorm:"size:255;index" json:"album,omitempty"`
+	Genre  string `gorm:"size:128"       json:"genre,omitempty"`
+	Year   int    `gorm:"index"          json:"year,omitempty"`
+	Track  int    `json:"track,omitempty"`
+	// SourceETag 提取时对象的 ETag，用于跳过重复提取
+	SourceETag  string    `gorm:"column:source_etag;size:64" json:"source_etag"`
+	ExtractedAt time.Time `json:"extracted_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名.
+func (FileMedia) TableName() string {
+	return "file_media"
+}
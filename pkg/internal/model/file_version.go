@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FileVersion 文件版本元数据：版本创建、恢复时写入，删除版本时软删除；版本列表以此表为准.
+// 未启用版本化的存储桶中 VersionID 为空，同一对象只保留一条记录并随覆盖写入更新.
+type FileVersion struct {
+	ID           uint64         `gorm:"primaryKey;autoIncrement"               json:"id"`
+	User         string         `gorm:"size:255;index:idx_file_version,unique" json:"user"`
+	ObjectKey    string         `gorm:"size:1024;index:idx_file_version,unique" json:"object_key"`
+	VersionID    string         `gorm:"size:255;index:idx_file_version,unique" json:"version_id"`
+	Bucket       string         `gorm:"size:255"                               json:"bucket"`
+	Action       string         `gorm:"size:32"                                json:"action"`  // upload / create / restore / bucket_event 等
+	Creator      string         `gorm:"size:255;index"                         json:"creator"` // 创建该版本的用户
+	Message      string         `gorm:"type:text"                              json:"message"`
+	LabelsJSON   string         `gorm:"type:text"                              json:"-"` // 命名标签（如 final），同一对象内唯一
+	Size         int64          `json:"size"`
+	ETag         string         `gorm:"size:64"                                json:"etag"`
+	Checksum     string         `gorm:"size:128"                               json:"checksum"` // 内容 MD5（十六进制），分片上传的对象为空
+	ContentType  string         `gorm:"size:255"                               json:"content_type"`
+	RestoredFrom string         `gorm:"size:255"                               json:"restored_from,omitempty"`
+	CreatedAt    time.Time      `gorm:"index"                                  json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index"                                  json:"-"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// ObjectHold 文件的法律保留与保留期（WORM），每个对象键一条；生效期间拒绝删除、移动与修改.
+type ObjectHold struct {
+	ID        uint   `gorm:"primaryKey"            json:"id"`
+	User      string `gorm:"size:255;index"        json:"user"`
+	Bucket    string `gorm:"size:255"              json:"bucket"`
+	ObjectKey string `gorm:"size:1024;uniqueIndex" json:"object_key"`
+	// LegalHold 法律保留，需显式解除
+	LegalHold bool `gorm:"index" json:"legal_hold"`
+	// RetainUntil 保留截止时间，只能延长
+	RetainUntil *time.Time `gorm:"index"    json:"retain_until,omitempty"`
+	Reason      string     `gorm:"size:512" json:"reason,omitempty"`
+	// SetBy 最近一次修改保护的用户
+	SetBy string `gorm:"size:255" json:"set_by"`
+	// ObjectLock 是否已同步到 S3 对象锁定
+	ObjectLock bool      `json:"object_lock"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Active 判断保护在 now 时是否生效.
+func (h *ObjectHold) Active(now time.Time) bool {
+	return h.LegalHold || (h.RetainUntil != nil && h.RetainUntil.After(now))
+}
+
+// ObjectHoldEvent 法律保留与保留期的审计记录：保护的设置、解除，以及因保护被拒绝的操作.
+type ObjectHoldEvent struct {
+	ID        uint   `gorm:"primaryKey"     json:"id"`
+	User      string `gorm:"size:255;index" json:"user"`
+	ObjectKey string `gorm:"size:1024"      json:"object_key"`
+	// Actor 发起操作的用户，后台任务为 system
+	Actor string `gorm:"size:255" json:"actor"`
+	// Action set_hold / 被拒绝的操作（delete、move、update_metadata、trash、purge_trash、delete_version 等）
+	Action string `gorm:"size:32;index" json:"action"`
+	// Outcome applied / denied
+	Outcome   string    `gorm:"size:16;index" json:"outcome"`
+	Detail    string    `gorm:"type:text"     json:"detail,omitempty"`
+	CreatedAt time.Time `gorm:"index"         json:"created_at"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+)
+
+// 任务类型.
+const (
+	JobTypeDeleteFiles    = "delete_files"
+	JobTypeCopyFiles      = "copy_files"
+	JobTypeMoveFiles      = "move_files"
+	JobTypeUpdateMetadata = "update_metadata"
+	JobTypeSyncObjects    = "sync_objects"
+	JobTypeReconcile      = "reconcile_objects"
+)
+
+// 任务状态.
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed" // 全部条目已处理（单条失败记录在条目结果中）
+	JobStatusFailed    = "failed"    // 任务整体失败（如参数无法解析、执行被中断）
+	JobStatusCanceled  = "canceled"
+)
+
+// Job 异步批量任务，请求参数以 JSON 文本存储，条目结果存放在 JobItem 中.
+type Job struct {
+	JobID       string `gorm:"primaryKey;size:64" json:"job_id"`
+	Owner       string `gorm:"size:255;index"     json:"owner"`
+	Type        string `gorm:"size:64;index"      json:"type"`
+	Status      string `gorm:"size:32;index"      json:"status"`
+	PayloadJSON string `gorm:"type:text"          json:"-"`
+	// 进度统计：Total 在执行开始后确定（同步类任务随扫描递增）
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	Success   int    `json:"success"`
+	Failed    int    `json:"failed"`
+	Error     string `gorm:"type:text" json:"error,omitempty"`
+	// CancelRequested 由取消接口设置，执行方在每次落盘进度时检查
+	CancelRequested bool       `json:"cancel_requested"`
+	CreatedAt       time.Time  `gorm:"index"              json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `gorm:"index"              json:"finished_at,omitempty"`
+	// ClaimID 每次领取时生成，终态只由持有该次领取的 worker 写入
+	ClaimID string `gorm:"size:64" json:"-"`
+}
+
+// JobItem 任务中单个条目的执行结果，按 Seq 保持提交顺序.
+type JobItem struct {
+	ID        uint      `gorm:"primaryKey"                        json:"-"`
+	JobID     string    `gorm:"size:64;index:idx_job_seq,unique"  json:"job_id"`
+	Seq       int       `gorm:"index:idx_job_seq,unique"          json:"seq"`
+	Key       string    `gorm:"size:1024"                         json:"key"`
+	Target    string    `gorm:"size:1024"                         json:"target,omitempty"`
+	Detail    string    `gorm:"type:text"                         json:"detail,omitempty"` // 附加说明（如对账差异类型与详情）
+	Success   bool      `json:"success"`
+	Error     string    `gorm:"type:text"                         json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsFinished 任务是否已进入终态.
+func (j *Job) IsFinished() bool {
+	switch j.Status {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCanceled:
+		return true
+	default:
+		return false
+	}
+}
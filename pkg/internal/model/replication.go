@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// ReplicationStatus 对象复制到副本存储的状态，每个 (bucket, object_key) 一条；复制失败的记录由重试任务按 NextAttemptAt 重新复制.
+type ReplicationStatus struct {
+	ID        uint   `gorm:"primaryKey"                                     json:"id"`
+	User      string `gorm:"size:255;index"                                 json:"user"`
+	Bucket    string `gorm:"size:255;index:idx_replication_object,unique"  json:"bucket"`
+	ObjectKey string `gorm:"size:1024;index:idx_replication_object,unique" json:"object_key"`
+	// Operation 最近一次同步的动作：put（复制内容）/ delete（删除副本）
+	Operation string `gorm:"size:16" json:"operation"`
+	// Status replicated / failed
+	Status string `gorm:"size:16;index" json:"status"`
+	// ETag 已复制到副本的源对象 ETag，未变化时跳过复制
+	ETag string `gorm:"size:64" json:"etag"`
+	// MetaHash 已复制的 Content-Type 与用户元数据摘要：只替换元数据的原位复制不改变 ETag，据此发现元数据变化
+	MetaHash      string     `gorm:"size:64"   json:"-"`
+	Size          int64      `json:"size"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `gorm:"index"     json:"next_attempt_at,omitempty"`
+	ReplicatedAt  *time.Time `json:"replicated_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
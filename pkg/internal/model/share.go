@@ -15,10 +15,18 @@ import (
 type Share struct {
 	ShareID         string         `gorm:"primaryKey;size:64" json:"share_id"`
 	Owner           string         `gorm:"size:255;index"     json:"owner"`
+	Type            string         `gorm:"size:16"            json:"type"`
+	FolderID        string         `gorm:"size:64"            json:"folder_id,omitempty"`
 	ObjectKeysJSON  string         `gorm:"type:text"          json:"-"`
 	AllowDownload   bool           `json:"allow_download"`
 	PasswordHash    string         `gorm:"size:128"           json:"-"`
 	PermissionsJSON string         `gorm:"type:text"          json:"-"`
+	TokenVersion    int            `gorm:"not null;default:0" json:"-"`             // 权限或密码变更时递增，使已签发的访问令牌失效
+	MaxViews        int            `gorm:"not null;default:0" json:"max_views"`     // 0 表示不限
+	MaxDownloads    int            `gorm:"not null;default:0" json:"max_downloads"` // 0 表示不限
+	ViewCount       int64          `gorm:"not null;default:0" json:"view_count"`
+	DownloadCount   int64          `gorm:"not null;default:0" json:"download_count"`
+	UploadPolicy    string         `gorm:"type:text"          json:"-"` // 上传型分享的上传限制（JSON）
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	ExpireAt        *time.Time     `gorm:"index"              json:"expire_at,omitempty"`
@@ -30,12 +38,18 @@ type Share struct {
 type ShareRecord struct {
 	ShareID       string
 	Owner         string
+	Type          string
+	FolderID      string
 	ObjectKeys    []string
 	CreatedAt     time.Time
 	ExpireAt      *time.Time
 	AllowDownload bool
 	PasswordHash  string
 	Permissions   itypes.SharePermissions
+	TokenVersion  int
+	MaxViews      int
+	MaxDownloads  int
+	Upload        *itypes.ShareUploadPolicy
 }
 
 // ToRecord 将 DB 模型反序列化为 ShareRecord。
@@ -54,15 +68,29 @@ func (s *Share) ToRecord() (*ShareRecord, error) {
 		}
 	}
 
+	var upload *itypes.ShareUploadPolicy
+	if s.UploadPolicy != "" {
+		upload = &itypes.ShareUploadPolicy{}
+		if err := json.Unmarshal([]byte(s.UploadPolicy), upload); err != nil {
+			return nil, fmt.Errorf("unmarshal upload policy: %w", err)
+		}
+	}
+
 	return &ShareRecord{
 		ShareID:       s.ShareID,
 		Owner:         s.Owner,
+		Type:          s.Type,
+		FolderID:      s.FolderID,
 		ObjectKeys:    keys,
 		CreatedAt:     s.CreatedAt,
 		ExpireAt:      s.ExpireAt,
 		AllowDownload: s.AllowDownload,
 		PasswordHash:  s.PasswordHash,
 		Permissions:   perms,
+		TokenVersion:  s.TokenVersion,
+		MaxViews:      s.MaxViews,
+		MaxDownloads:  s.MaxDownloads,
+		Upload:        upload,
 	}, nil
 }
 
@@ -78,13 +106,29 @@ func FromRecord(r *ShareRecord) (*Share, error) {
 		return nil, fmt.Errorf("marshal permissions: %w", err)
 	}
 
+	var uploadPolicy string
+	if r.Upload != nil {
+		b, err := json.Marshal(r.Upload)
+		if err != nil {
+			return nil, fmt.Errorf("marshal upload policy: %w", err)
+		}
+
+		uploadPolicy = string(b)
+	}
+
 	return &Share{
 		ShareID:         r.ShareID,
 		Owner:           r.Owner,
+		Type:            r.Type,
+		FolderID:        r.FolderID,
 		ObjectKeysJSON:  string(objBytes),
 		AllowDownload:   r.AllowDownload,
 		PasswordHash:    r.PasswordHash,
 		PermissionsJSON: string(permBytes),
+		TokenVersion:    r.TokenVersion,
+		MaxViews:        r.MaxViews,
+		MaxDownloads:    r.MaxDownloads,
+		UploadPolicy:    uploadPolicy,
 		CreatedAt:       r.CreatedAt,
 		ExpireAt:        r.ExpireAt,
 	}, nil
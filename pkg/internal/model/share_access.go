@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// ShareAccess 分享访问日志：每次访问（含被拒绝的访问）记录一条.
+type ShareAccess struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"            json:"id"`
+	ShareID   string    `gorm:"size:64;index:idx_share_access_time" json:"share_id"`
+	Action    string    `gorm:"size:32"                             json:"action"`
+	IP        string    `gorm:"size:64"                             json:"ip"`
+	UserAgent string    `gorm:"size:512"                            json:"user_agent"`
+	User      string    `gorm:"size:255"                            json:"user,omitempty"` // 已登录的访问者
+	ObjectKey string    `gorm:"size:1024"                           json:"object_key,omitempty"`
+	Detail    string    `gorm:"size:255"                            json:"detail,omitempty"` // 拒绝原因等
+	CreatedAt time.Time `gorm:"index:idx_share_access_time;index"   json:"created_at"`
+}
+
+// TableName 指定表名.
+func (ShareAccess) TableName() string { return "share_accesses" }
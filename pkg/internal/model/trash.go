@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// TrashItem 回收站条目：对象被移动到 .trash/ 系统目录下，恢复时移回原对象键.
+type TrashItem struct {
+	TrashID     string    `gorm:"primaryKey;size:64" json:"trash_id"`
+	User        string    `gorm:"size:255;index"     json:"user"`
+	ObjectKey   string    `gorm:"size:1024;index"    json:"object_key"` // 原对象键
+	TrashKey    string    `gorm:"size:1100"          json:"-"`          // 回收站中的对象键
+	Bucket      string    `gorm:"size:255"           json:"bucket"`
+	FileName    string    `gorm:"size:512;index"     json:"file_name"`
+	Size        int64     `json:"size"`
+	ETag        string    `gorm:"size:64"            json:"etag"`
+	ContentType string    `gorm:"size:255"           json:"content_type"`
+	Reason      string    `gorm:"size:64"            json:"reason,omitempty"` // 来源，如 duplicate
+	TrashedAt   time.Time `gorm:"index"              json:"trashed_at"`
+	ExpireAt    time.Time `gorm:"index"              json:"expire_at"`
+}
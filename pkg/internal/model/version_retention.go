@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+)
+
+// VersionRetention 文件版本保留规则：FolderID 为空表示用户级规则，文件夹规则作用于文件夹及其子目录.
+// Prefix 在设置规则时由文件夹解析得到（文件夹 ID 随路径变化，重命名后需重新设置）.
+type VersionRetention struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"                   json:"id"`
+	User      string    `gorm:"size:255;index:idx_version_retention,unique" json:"user"`
+	FolderID  string    `gorm:"size:64;index:idx_version_retention,unique"  json:"folder_id"`
+	Prefix    string    `gorm:"size:1024"                                  json:"prefix"`    // 规则作用的对象键前缀（以 / 结尾）
+	KeepLast  int       `gorm:"not null;default:0"                         json:"keep_last"` // 保留最近 N 个版本，0 表示不按数量清理
+	KeepDays  int       `gorm:"not null;default:0"                         json:"keep_days"` // 保留 D 天内的版本，0 表示不按时间清理
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
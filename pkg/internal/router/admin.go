@@ -0,0 +1,18 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/handle"
+)
+
+// RegisterAdminRoutes 注册管理员路由，调用者需在 audit.admins 中配置.
+func RegisterAdminRoutes(g *gin.RouterGroup) {
+	adminRoutes := g.Group("/admin")
+
+	{
+		adminRoutes.GET("/audit", handle.ListAuditEvents)          // 查询审计日志
+		adminRoutes.GET("/audit/export", handle.ExportAuditEvents) // 导出审计日志（NDJSON）
+		adminRoutes.GET("/audit/verify", handle.VerifyAuditChain)  // 校验哈希链
+	}
+}
@@ -0,0 +1,16 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/handle"
+)
+
+// RegisterEventsRoutes 注册外部事件接入路由.
+func RegisterEventsRoutes(g *gin.RouterGroup) {
+	eventsRoutes := g.Group("/events")
+
+	{
+		eventsRoutes.POST("/s3", handle.ReceiveBucketEvents) // S3/MinIO 存储桶事件通知 webhook
+	}
+}
@@ -45,17 +45,62 @@ func RegisterFilesRoutes(g *gin.RouterGroup) {
 		// ===== 文件下载路由 =====
 		downloadGroup := filesRoutes.Group("/download")
 		{
+			downloadGroup.GET("", handle.DownloadFile)        // 下载单个文件(支持 Range/条件请求，可直接链接)
+			downloadGroup.HEAD("", handle.DownloadFile)       // 仅获取单个文件的响应头
 			downloadGroup.POST("", handle.DownloadFiles)      // 下载文件(单个/批量)
 			downloadGroup.POST("/url", handle.GetDownloadURL) // 获取下载URL(单个/批量)
 		}
 
+		filesRoutes.GET("/preview", handle.GetFilePreview) // 图片缩略图（首次请求时生成）
+
+		// 重复文件
+		duplicateGroup := filesRoutes.Group("/duplicates")
+		{
+			duplicateGroup.GET("", handle.FindDuplicateFiles)             // 查找重复/近似重复文件
+			duplicateGroup.POST("/resolve", handle.ResolveDuplicateFiles) // 每组保留一个，其余移入回收站
+		}
+
 		// ===== 文件版本管理路由 =====
 		versionGroup := filesRoutes.Group("/versions")
 		{
 			versionGroup.GET("/:fileId", handle.ListFileVersions)                       // 获取版本列表
+			versionGroup.GET("/:fileId/diff", handle.DiffFileVersions)                  // 对比两个版本
 			versionGroup.POST("/:fileId", handle.CreateFileVersion)                     // 创建新版本
 			versionGroup.DELETE("/:fileId/:versionId", handle.DeleteFileVersion)        // 删除指定版本
 			versionGroup.POST("/:fileId/:versionId/restore", handle.RestoreFileVersion) // 恢复到指定版本
+			versionGroup.PUT("/:fileId/:versionId/labels", handle.SetFileVersionLabels) // 设置版本标签与说明
+		}
+
+		// ===== 版本保留规则路由 =====
+		retentionGroup := filesRoutes.Group("/retention")
+		{
+			retentionGroup.GET("", handle.ListVersionRetention)      // 获取保留规则
+			retentionGroup.PUT("", handle.SetVersionRetention)       // 设置用户级/文件夹级保留规则
+			retentionGroup.DELETE("", handle.DeleteVersionRetention) // 删除保留规则
+			retentionGroup.POST("/prune", handle.PruneVersions)      // 按规则清理旧版本（支持 dry_run 预览）
+		}
+
+		// ===== 对象生命周期路由 =====
+		lifecycleGroup := filesRoutes.Group("/lifecycle")
+		{
+			lifecycleGroup.POST("/run", handle.RunLifecycle) // 对当前用户执行迁移、过期与分片清理（支持 dry_run 预览）
+		}
+
+		// ===== 副本复制路由 =====
+		filesRoutes.GET("/replication", handle.ListReplicationStatus) // 获取当前用户对象的复制状态
+
+		// ===== 法律保留与保留期（WORM）路由 =====
+		holdGroup := filesRoutes.Group("/holds")
+		{
+			holdGroup.GET("", handle.ListObjectHolds)             // 获取文件保护列表
+			holdGroup.PUT("", handle.SetObjectHold)               // 设置/解除法律保留，设置/延长保留期
+			holdGroup.GET("/events", handle.ListObjectHoldEvents) // 保护变更与被拒绝操作的审计记录
+		}
+
+		// ===== 加密路由 =====
+		encryptionGroup := filesRoutes.Group("/encryption")
+		{
+			encryptionGroup.POST("/rotate", handle.RotateEncryptionKeys) // 将当前用户的对象改用当前主密钥（支持 dry_run 预览）
 		}
 	}
 
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/handle"
+)
+
+// RegisterJobsRoutes 注册异步任务相关路由.
+func RegisterJobsRoutes(g *gin.RouterGroup) {
+	jobsRoutes := g.Group("/jobs")
+
+	{
+		jobsRoutes.GET("", handle.ListJobs)                     // 获取我的任务列表
+		jobsRoutes.GET("/:jobId", handle.GetJob)                // 获取任务详情与进度
+		jobsRoutes.GET("/:jobId/results", handle.GetJobResults) // 获取条目结果（分页 JSON / NDJSON）
+		jobsRoutes.POST("/:jobId/cancel", handle.CancelJob)     // 取消任务
+	}
+}
@@ -12,4 +12,8 @@ func RegisterRoutes(g *gin.RouterGroup) {
 	RegisterSharesRoutes(g)
 	RegisterTrashRoutes(g)
 	RegisterStatsRoutes(g)
+	RegisterJobsRoutes(g)
+	RegisterEventsRoutes(g)
+	RegisterStorageRoutes(g)
+	RegisterAdminRoutes(g)
 }
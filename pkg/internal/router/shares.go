@@ -22,9 +22,18 @@ func RegisterSharesRoutes(g *gin.RouterGroup) {
 		{
 			shareAccessGroup.GET("", handle.GetShareDetail)         // 获取分享详情
 			shareAccessGroup.POST("/access", handle.AccessShare)    // 访问分享内容
-			shareAccessGroup.GET("/download", handle.DownloadShare) // 下载分享文件
+			shareAccessGroup.GET("/download", handle.DownloadShare) // 下载分享文件（多个对象/文件夹时打包）
+
+			shareAccessGroup.GET("/files", handle.ListShareFiles)                    // 分享文件列表
+			shareAccessGroup.GET("/files/:index/download", handle.DownloadShareFile) // 下载分享中的单个文件
+
+			shareAccessGroup.POST("/uploads", handle.RequestShareUpload)           // 上传型分享：申请预签名上传表单
+			shareAccessGroup.POST("/uploads/complete", handle.CompleteShareUpload) // 上传型分享：确认上传完成
 		}
 
+		sharesRoutes.PUT("/:shareId/password", handle.UpdateSharePassword) // 修改分享密码
+		sharesRoutes.GET("/:shareId/stats", handle.GetShareStats)          // 分享访问统计
+
 		// ===== 分享权限管理路由 =====
 		permissionGroup := sharesRoutes.Group("/:shareId/permissions")
 		{
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/internal/handle"
+)
+
+// RegisterStorageRoutes 注册本地存储驱动的签名访问路由（预签名 URL 指向这里）.
+func RegisterStorageRoutes(g *gin.RouterGroup) {
+	objectsRoutes := g.Group("/storage/objects")
+
+	{
+		objectsRoutes.POST("/:bucket", handle.PostStorageObject)       // 表单上传（PresignedPostPolicy）
+		objectsRoutes.GET("/:bucket/*key", handle.ServeStorageObject)  // 预签名下载
+		objectsRoutes.HEAD("/:bucket/*key", handle.ServeStorageObject) // 预签名获取对象信息
+		objectsRoutes.PUT("/:bucket/*key", handle.ServeStorageObject)  // 预签名上传
+	}
+}
@@ -0,0 +1,116 @@
+// Package scheduler 提供进程内的周期任务调度（对账、清理等后台维护任务）.
+//
+// Example:
+//
+//	s := scheduler.New()
+//	s.Add(scheduler.Task{
+//		Name:     "reconcile",
+//		Interval: time.Hour,
+//		Run:      func(ctx context.Context) error { return nil },
+//	})
+//	s.Start(ctx)
+//	defer s.Stop()
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// Task 周期任务定义.
+type Task struct {
+	Name     string
+	Interval time.Duration
+	// RunOnStart 为 true 时启动后立即执行一次，否则等待第一个周期
+	RunOnStart bool
+	Run        func(ctx context.Context) error
+}
+
+// Scheduler 按固定间隔执行已注册的任务；同一任务不会并发执行.
+type Scheduler struct {
+	tasks  []Task
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建调度器.
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add 注册任务，需在 Start 之前调用；Interval 非正数的任务会被忽略.
+func (s *Scheduler) Add(task Task) {
+	if task.Interval <= 0 || task.Run == nil {
+		nlog.Logger().Warn().Str("task", task.Name).Msg("invalid scheduled task ignored")
+		return
+	}
+
+	s.tasks = append(s.tasks, task)
+}
+
+// Len 返回已注册的任务数量.
+func (s *Scheduler) Len() int {
+	return len(s.tasks)
+}
+
+// Start 为每个任务启动一个协程.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, task := range s.tasks {
+		s.wg.Add(1)
+
+		go s.loop(ctx, task)
+	}
+}
+
+// Stop 停止所有任务并等待执行中的任务返回.
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, task Task) {
+	defer s.wg.Done()
+
+	if task.RunOnStart {
+		s.runOnce(ctx, task)
+	}
+
+	ticker := time.NewTicker(task.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, task)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, task Task) {
+	l := nlog.Logger()
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			l.Error().Str("task", task.Name).Interface("panic", r).Msg("scheduled task panicked")
+		}
+	}()
+
+	if err := task.Run(ctx); err != nil && ctx.Err() == nil {
+		l.Error().Err(err).Str("task", task.Name).Msg("scheduled task failed")
+		return
+	}
+
+	l.Debug().Str("task", task.Name).Dur("elapsed", time.Since(start)).Msg("scheduled task finished")
+}
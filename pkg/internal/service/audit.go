@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/storage/db"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// auditBatchSize 导出与校验每批加载的记录数.
+const auditBatchSize = 500
+
+// auditChainMu 串行化哈希链写入，保证每条记录链接到实际的前一条记录.
+var auditChainMu sync.Mutex
+
+// AuditService 审计日志的查询、导出与哈希链校验.
+type AuditService struct {
+	dbc *db.Client
+}
+
+// NewAuditService 创建并返回一个新的 AuditService 实例.
+func NewAuditService(c context.Context) *AuditService {
+	svc := &AuditService{dbc: ctxPkg.GetDBClient(c)}
+	if svc.dbc == nil {
+		nlog.Logger().Warn().Msg("DB client not initialized, audit log unavailable")
+	}
+
+	return svc
+}
+
+// RecordAudit 写入一条审计记录，结果由 err 推断：nil 为 success，无权限或对象受保护为 denied，其余为 failure.
+// actor 为空时使用请求声明的用户（仍为空时记为 anonymous）；未启用审计时不记录，写入失败只记录日志.
+func RecordAudit(ctx context.Context, actor, action string, targets []string, err error, detail string) {
+	outcome, msg := types.AuditOutcomeSuccess, ""
+	if err != nil {
+		outcome, msg = auditOutcome(err), err.Error()
+	}
+
+	recordAuditEvent(ctx, actor, action, targets, outcome, msg, detail)
+}
+
+// recordAuditResult 供 defer 使用，在函数返回时按返回的错误写入审计记录.
+func recordAuditResult(ctx context.Context, actor, action string, targets []string, detail string, err *error) {
+	RecordAudit(ctx, actor, action, targets, *err, detail)
+}
+
+// RecordAuditBatch 将批量操作汇总为一条审计记录：全部成功为 success，全部失败为 failure，否则为 partial.
+func RecordAuditBatch(ctx context.Context, actor, action string, targets []string, failed int) {
+	outcome := types.AuditOutcomePartial
+
+	switch failed {
+	case 0:
+		outcome = types.AuditOutcomeSuccess
+	case len(targets):
+		outcome = types.AuditOutcomeFailure
+	}
+
+	recordAuditEvent(ctx, actor, action, targets, outcome, "", fmt.Sprintf("total=%d failed=%d", len(targets), failed))
+}
+
+func recordAuditEvent(ctx context.Context, actor, action string, targets []string, outcome, msg, detail string) {
+	cfg := configs.GetConfig().Audit
+
+	dbc := ctxPkg.GetDBClient(ctx)
+	if !cfg.Enabled || dbc == nil || dbc.GetDB() == nil {
+		return
+	}
+
+	info := ctxPkg.GetRequestInfo(ctx)
+	if actor == "" {
+		actor = info.User
+	}
+
+	if actor == "" {
+		actor = types.AuditActorAnonymous
+	}
+
+	traceID := ctxPkg.TraceID(ctx)
+	if traceID == "" {
+		traceID = info.RequestID
+	}
+
+	if targets == nil {
+		targets = []string{}
+	}
+
+	keys, _ := json.Marshal(targets)
+
+	e := &model.AuditEvent{
+		Actor:      truncate(actor, 255),
+		Action:     truncate(action, 64),
+		TargetKeys: string(keys),
+		Outcome:    outcome,
+		Error:      msg,
+		Detail:     detail,
+		IP:         truncate(info.IP, 64),
+		UserAgent:  truncate(info.UserAgent, 512),
+		TraceID:    truncate(traceID, 64),
+		// 各数据库的时间精度不同，截断到毫秒保证读回后哈希一致
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	// 请求被取消时仍需写入审计记录
+	dbx := dbc.GetDB().WithContext(context.WithoutCancel(ctx))
+	if err := writeAuditEvent(dbx, e, cfg.HashChain); err != nil {
+		nlog.Logger().Warn().Err(err).Str("action", action).Str("actor", actor).Msg("record audit event failed")
+	}
+}
+
+// writeAuditEvent 写入审计记录；启用哈希链时链接到最新一条记录.
+func writeAuditEvent(dbx *gorm.DB, e *model.AuditEvent, chain bool) error {
+	if !chain {
+		return dbx.Create(e).Error
+	}
+
+	auditChainMu.Lock()
+	defer auditChainMu.Unlock()
+
+	return dbx.Transaction(func(tx *gorm.DB) error {
+		var last model.AuditEvent
+		if err := tx.Select("hash").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+			return fmt.Errorf("load last audit event: %w", err)
+		}
+
+		e.PrevHash = last.Hash
+		e.Hash = auditHash(e)
+
+		return tx.Create(e).Error
+	})
+}
+
+// auditHash 计算记录的哈希：覆盖前一条记录的哈希与本条记录除 ID 外的全部内容.
+func auditHash(e *model.AuditEvent) string {
+	h := sha256.New()
+
+	for _, f := range []string{
+		e.PrevHash, strconv.FormatInt(e.CreatedAt.UnixMilli(), 10), e.Actor, e.Action, e.TargetKeys,
+		e.Outcome, e.Error, e.Detail, e.IP, e.UserAgent, e.TraceID,
+	} {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// auditOutcome 无权限、访问受限或对象受保护的错误记为 denied，其余为 failure.
+func auditOutcome(err error) string {
+	for _, target := range []error{
+		ErrObjectAccessDenied, ErrObjectHeld, ErrShareForbidden, ErrShareAuthRequired,
+		ErrShareInvalidPassword, ErrShareLocked, ErrShareTokenInvalid,
+	} {
+		if errors.Is(err, target) {
+			return types.AuditOutcomeDenied
+		}
+	}
+
+	return types.AuditOutcomeFailure
+}
+
+// ListAuditEvents 按条件查询审计记录，按时间倒序分页.
+func (s *AuditService) ListAuditEvents(ctx context.Context, q *types.AuditQuery) (*types.ListAuditEventsResponse, error) {
+	dbx, err := s.filter(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	if err := dbx.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("count audit events: %w", err)
+	}
+
+	page, size := normalizeJobPage(q.Page, q.PageSize)
+
+	var rows []model.AuditEvent
+	if err := dbx.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list audit events: %w", err)
+	}
+
+	items := make([]types.AuditEventInfo, 0, len(rows))
+	for i := range rows {
+		items = append(items, toAuditEventInfo(&rows[i]))
+	}
+
+	return &types.ListAuditEventsResponse{Total: int(total), Page: page, Size: size, Items: items}, nil
+}
+
+// ExportAuditEvents 将满足条件的审计记录按时间正序以 NDJSON（每行一条 JSON）写入 w，返回导出的记录数；忽略分页参数.
+func (s *AuditService) ExportAuditEvents(ctx context.Context, q *types.AuditQuery, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	count := 0
+
+	var rows []model.AuditEvent
+
+	for last := uint(0); ; last = rows[len(rows)-1].ID {
+		dbx, err := s.filter(ctx, q)
+		if err != nil {
+			return count, err
+		}
+
+		rows = rows[:0]
+		if err := dbx.Where("id > ?", last).Order("id").Limit(auditBatchSize).Find(&rows).Error; err != nil {
+			return count, fmt.Errorf("export audit events: %w", err)
+		}
+
+		if len(rows) == 0 {
+			return count, nil
+		}
+
+		for i := range rows {
+			if err := enc.Encode(toAuditEventInfo(&rows[i])); err != nil {
+				return count, err
+			}
+
+			count++
+		}
+	}
+}
+
+// VerifyAuditChain 按写入顺序校验哈希链：每条启用哈希链的记录的 PrevHash 必须等于前一条记录的 Hash，
+// 且 Hash 与记录内容一致。记录被修改、删除或插入时返回第一条断开的记录.
+func (s *AuditService) VerifyAuditChain(ctx context.Context) (*types.VerifyAuditResponse, error) {
+	if s.dbc == nil || s.dbc.GetDB() == nil {
+		return nil, errors.New("db not initialized")
+	}
+
+	resp := &types.VerifyAuditResponse{Valid: true}
+	prev := ""
+
+	var rows []model.AuditEvent
+
+	for last := uint(0); ; last = rows[len(rows)-1].ID {
+		rows = rows[:0]
+		if err := s.dbc.GetDB().WithContext(ctx).Where("id > ?", last).Order("id").
+			Limit(auditBatchSize).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("load audit events: %w", err)
+		}
+
+		if len(rows) == 0 {
+			return resp, nil
+		}
+
+		for i := range rows {
+			e := &rows[i]
+			resp.Checked++
+
+			if e.Hash == "" {
+				resp.Unchained++
+				prev = ""
+
+				continue
+			}
+
+			switch {
+			case e.PrevHash != prev:
+				resp.Valid, resp.BrokenAt, resp.Reason = false, e.ID, "prev_hash does not match the previous record"
+			case auditHash(e) != e.Hash:
+				resp.Valid, resp.BrokenAt, resp.Reason = false, e.ID, "hash does not match the record content"
+			}
+
+			if !resp.Valid {
+				return resp, nil
+			}
+
+			prev = e.Hash
+			resp.LastHash = e.Hash
+		}
+	}
+}
+
+// filter 构建查询条件.
+func (s *AuditService) filter(ctx context.Context, q *types.AuditQuery) (*gorm.DB, error) {
+	if s.dbc == nil || s.dbc.GetDB() == nil {
+		return nil, errors.New("db not initialized")
+	}
+
+	dbx := s.dbc.GetDB().WithContext(ctx).Model(&model.AuditEvent{})
+
+	if q.Actor != "" {
+		dbx = dbx.Where("actor = ?", q.Actor)
+	}
+
+	if q.Action != "" {
+		dbx = dbx.Where("action = ?", q.Action)
+	}
+
+	if q.Outcome != "" {
+		dbx = dbx.Where("outcome = ?", q.Outcome)
+	}
+
+	if q.TraceID != "" {
+		dbx = dbx.Where("trace_id = ?", q.TraceID)
+	}
+
+	if q.Target != "" {
+		// 按 JSON 编码后的完整元素匹配，'!' 作为 LIKE 转义字符
+		quoted, _ := json.Marshal(q.Target)
+		escaper := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+		dbx = dbx.Where("target_keys LIKE ? ESCAPE '!'", "%"+escaper.Replace(string(quoted))+"%")
+	}
+
+	if q.Since != nil {
+		dbx = dbx.Where("created_at >= ?", q.Since.UTC())
+	}
+
+	if q.Until != nil {
+		dbx = dbx.Where("created_at < ?", q.Until.UTC())
+	}
+
+	return dbx, nil
+}
+
+func toAuditEventInfo(e *model.AuditEvent) types.AuditEventInfo {
+	var targets []string
+	_ = json.Unmarshal([]byte(e.TargetKeys), &targets)
+
+	return types.AuditEventInfo{
+		ID:         e.ID,
+		Actor:      e.Actor,
+		Action:     e.Action,
+		TargetKeys: targets,
+		Outcome:    e.Outcome,
+		Error:      e.Error,
+		Detail:     e.Detail,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		TraceID:    e.TraceID,
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+		CreatedAt:  e.CreatedAt,
+	}
+}
@@ -0,0 +1,134 @@
+package service_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// TestAuditLog 验证服务层写入审计记录、按条件查询、NDJSON 导出以及哈希链发现篡改.
+func TestAuditLog(t *testing.T) {
+	ctx := newTestContext(t, false)
+	configs.GetConfig().Audit.HashChain = true
+
+	ctx = ctxPkg.WithRequestInfo(ctx, ctxPkg.RequestInfo{IP: "192.0.2.7", UserAgent: "audit-test", RequestID: "req-1"})
+
+	key := uploadText(t, ctx, "ledger.txt", "balance")
+	other := uploadText(t, ctx, "notes.txt", "notes")
+
+	if _, err := service.NewShareService(ctx).CreateShare(ctx, testUser, &types.CreateShareRequest{ObjectKeys: []string{key}}); err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	fs := service.NewFileService(ctx)
+
+	del, err := fs.DeleteFiles(ctx, testUser, &types.DeleteFilesRequest{ObjectKeys: []string{other, "someone@example.com/y.txt"}})
+	if err != nil || del.Failed != 1 {
+		t.Fatalf("delete = %+v, %v", del, err)
+	}
+
+	// 不属于当前用户的对象记为 denied
+	if _, err := fs.SetObjectHold(ctx, testUser, &types.SetObjectHoldRequest{ObjectKey: "someone@example.com/x.txt"}); err == nil {
+		t.Fatal("hold foreign object: want error")
+	}
+
+	svc := service.NewAuditService(ctx)
+
+	all, err := svc.ListAuditEvents(ctx, &types.AuditQuery{})
+	if err != nil || all.Total != 5 {
+		t.Fatalf("all events = %+v, %v", all, err)
+	}
+
+	tests := []struct {
+		name  string
+		query types.AuditQuery
+		want  int
+	}{
+		{"by action", types.AuditQuery{Action: types.AuditActionFileUpload}, 2},
+		{"by target", types.AuditQuery{Target: key}, 2},
+		{"target is exact", types.AuditQuery{Target: strings.TrimSuffix(key, ".txt")}, 0},
+		{"partial", types.AuditQuery{Outcome: types.AuditOutcomePartial}, 1},
+		{"denied", types.AuditQuery{Outcome: types.AuditOutcomeDenied, Actor: testUser}, 1},
+		{"by request id", types.AuditQuery{TraceID: "req-1"}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.ListAuditEvents(ctx, &tt.query)
+			if err != nil || got.Total != tt.want {
+				t.Fatalf("ListAuditEvents(%+v) = %+v, %v; want %d", tt.query, got, err, tt.want)
+			}
+		})
+	}
+
+	if e := all.Items[len(all.Items)-1]; e.IP != "192.0.2.7" || e.UserAgent != "audit-test" || e.Actor != testUser {
+		t.Fatalf("request info not recorded: %+v", e)
+	}
+
+	var buf bytes.Buffer
+	if n, err := svc.ExportAuditEvents(ctx, &types.AuditQuery{}, &buf); err != nil || n != 5 || strings.Count(buf.String(), "\n") != 5 {
+		t.Fatalf("export = %d, %v:\n%s", n, err, buf.String())
+	}
+
+	verify, err := svc.VerifyAuditChain(ctx)
+	if err != nil || !verify.Valid || verify.Checked != 5 || verify.LastHash == "" {
+		t.Fatalf("verify = %+v, %v", verify, err)
+	}
+
+	// 将中间一条部分失败的记录改为成功后校验失败
+	tampered := all.Items[1].ID
+	if err := ctxPkg.GetDBClient(ctx).GetDB().Model(&model.AuditEvent{}).Where("id = ?", tampered).
+		Update("outcome", types.AuditOutcomeSuccess).Error; err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+
+	verify, err = svc.VerifyAuditChain(ctx)
+	if err != nil || verify.Valid || verify.BrokenAt != tampered {
+		t.Fatalf("verify tampered = %+v, %v", verify, err)
+	}
+}
+
+// TestDownloadAudit 验证下载审计记录在实际读取者名下：分享访问者下载 owner 的对象时 actor 为访问者.
+func TestDownloadAudit(t *testing.T) {
+	ctx := newTestContext(t, false)
+	key := uploadText(t, ctx, "report.txt", "quarterly")
+	fs := service.NewFileService(ctx)
+
+	const visitor = "visitor@example.com"
+
+	info, err := fs.StatDownload(ctx, visitor, testUser, key)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := fs.DownloadObject(ctx, visitor, testUser, info, nil, func(int) (io.Writer, error) { return &buf, nil }); err != nil {
+		t.Fatalf("download: %v", err)
+	}
+
+	if buf.String() != "quarterly" {
+		t.Fatalf("content = %q", buf.String())
+	}
+
+	if _, err := fs.StatDownload(ctx, visitor, testUser, testUser+"/missing.txt"); err == nil {
+		t.Fatal("stat missing object: want error")
+	}
+
+	got, err := service.NewAuditService(ctx).ListAuditEvents(ctx, &types.AuditQuery{Action: types.AuditActionFileDownload})
+	if err != nil || got.Total != 2 {
+		t.Fatalf("download events = %+v, %v", got, err)
+	}
+
+	for _, e := range got.Items {
+		if e.Actor != visitor {
+			t.Fatalf("actor = %q, want %q", e.Actor, visitor)
+		}
+	}
+}
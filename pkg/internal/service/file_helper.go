@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"maps"
+	"mime"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 
@@ -169,9 +171,11 @@ func (fs *FileService) uploadFile(ctx context.Context, bucket,
 	// 准备上传选项
 	opts := minio.PutObjectOptions{}
 
-	// 设置内容类型
+	// 设置内容类型；未指定时按扩展名推断，便于后续按类型处理（缩略图、媒体元数据）
 	if metadata != nil && metadata.ContentType != "" {
 		opts.ContentType = metadata.ContentType
+	} else {
+		opts.ContentType = mime.TypeByExtension(path.Ext(objectKey))
 	}
 
 	// 设置用户元数据（标签等）
@@ -248,8 +252,9 @@ func findFolderPath(ctx context.Context, s3Client *s3.Client, bucket, user, fold
 	return "", "", fmt.Errorf("folder not found")
 }
 
-// renameFolderObjects 重命名文件夹及其内容.
-func renameFolderObjects(ctx context.Context, s3Client *s3.Client, bucket, user, oldPath, newPath string) error {
+// renameFolderObjects 重命名文件夹及其内容；moved 在每个对象复制到新位置后调用，removed 表示旧对象是否已删除.
+func renameFolderObjects(ctx context.Context, s3Client *s3.Client, bucket, user, oldPath, newPath string,
+	moved func(oldKey, newKey string, removed bool)) error {
 	oldPrefix := user + "/" + oldPath + "/"
 	newPrefix := user + "/" + newPath + "/"
 
@@ -306,6 +311,27 @@ func renameFolderObjects(ctx context.Context, s3Client *s3.Client, bucket, user,
 			nlog.Logger().Warn().Err(err).Str("object", oldKey).Msg("failed to remove old object after copy")
 			// 不返回错误，继续处理其他对象
 		}
+
+		if moved != nil {
+			moved(oldKey, newKey, err == nil)
+		}
+	}
+
+	return nil
+}
+
+// checkFolderEmpty 确认 bucket 中没有 folderPrefix 下的对象.
+func checkFolderEmpty(ctx context.Context, s3Client *s3.Client, bucket, folderPrefix string) error {
+	// 读到第一个对象即返回，取消 ctx 以结束列举
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for object := range s3Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: folderPrefix}) {
+		if object.Err != nil {
+			return fmt.Errorf("list objects error: %v", object.Err)
+		}
+
+		return fmt.Errorf("folder is not empty, use recursive=true to delete all contents")
 	}
 
 	return nil
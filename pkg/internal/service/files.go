@@ -24,6 +24,8 @@ type FileService struct {
 	s3Client *s3.Client
 	dbClient *db.Client
 	mqClient *mq.Client
+	// replica 复制目标的对象存储，未启用复制时为 nil
+	replica *s3.Client
 }
 
 // NewFileService 从 context 获取依赖实例.
@@ -33,7 +35,7 @@ func NewFileService(c context.Context) *FileService {
 	mqc := ctxPkg.GetMQClient(c)
 
 	// 为了安全起见，应该直接 panic 而不是返回 nil，依赖此服务就不需要再检查
-	if s3c == nil || s3c.Client == nil || dbc == nil || dbc.DB == nil || mqc == nil {
+	if s3c == nil || s3c.ObjectStore == nil || dbc == nil || dbc.DB == nil || mqc == nil {
 		nlog.Logger().Fatal().Msg("storage clients not initialized")
 	}
 
@@ -41,6 +43,7 @@ func NewFileService(c context.Context) *FileService {
 		s3Client: s3c,
 		dbClient: dbc,
 		mqClient: mqc,
+		replica:  ctxPkg.GetReplicaS3Client(c),
 	}
 }
 
@@ -51,40 +54,41 @@ func (fs *FileService) ListFilesByMonth(ctx context.Context, user string, year i
 		return nil, fmt.Errorf("user is required")
 	}
 
-	bucket, err := fs.defaultBucket()
+	buckets, err := fs.allBuckets()
 	if err != nil {
 		return nil, err
 	}
 
 	// Prefix pattern matches buildObjectKey's datePath = YYYY/MM
 	prefix := fmt.Sprintf("%s/%04d/%02d/", user, year, int(month))
+	files := make([]types.ObjectInfo, 0, DefaultSliceCapacity)
 
-	// List objects under the prefix
-	opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true}
-	ch := fs.s3Client.ListObjects(ctx, bucket, opts)
+	// 路由规则可能把对象写入不同 bucket，逐个列举
+	for _, bucket := range buckets {
+		opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true}
+		ch := fs.s3Client.ListObjects(ctx, bucket, opts)
 
-	files := make([]types.ObjectInfo, 0, DefaultSliceCapacity)
+		for obj := range ch {
+			if obj.Err != nil {
+				return nil, fmt.Errorf("list objects: %v", obj.Err)
+			}
+			// skip "folders"
+			if strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
 
-	for obj := range ch {
-		if obj.Err != nil {
-			return nil, fmt.Errorf("list objects: %v", obj.Err)
+			files = append(files, types.ObjectInfo{
+				ObjectKey: obj.Key,
+				Size:      obj.Size,
+				ETag:      strings.Trim(obj.ETag, "\""),
+				// ContentType not available in ListObjects; leave empty to be filled by Stat when needed
+				LastModified: obj.LastModified.UTC().Format(time.RFC3339),
+				VersionID:    obj.VersionID,
+				StorageClass: obj.StorageClass,
+				Bucket:       bucket,
+				UserMetadata: nil,
+			})
 		}
-		// skip "folders"
-		if strings.HasSuffix(obj.Key, "/") {
-			continue
-		}
-
-		files = append(files, types.ObjectInfo{
-			ObjectKey: obj.Key,
-			Size:      obj.Size,
-			ETag:      strings.Trim(obj.ETag, "\""),
-			// ContentType not available in ListObjects; leave empty to be filled by Stat when needed
-			LastModified: obj.LastModified.UTC().Format(time.RFC3339),
-			VersionID:    obj.VersionID,
-			StorageClass: obj.StorageClass,
-			Bucket:       bucket,
-			UserMetadata: nil,
-		})
 	}
 
 	return files, nil
@@ -147,6 +151,9 @@ func (fs *FileService) SearchFiles(ctx context.Context, user string, req *types.
 		dbx = dbx.Where("file_name LIKE ? OR description LIKE ? OR tags_json LIKE ?", kw, kw, kw)
 	}
 
+	// 媒体元数据过滤
+	dbx = fs.applyMediaFilters(dbx, user, req)
+
 	// 统计总数
 	var total int64
 	if err := dbx.Count(&total).Error; err != nil {
@@ -203,62 +210,30 @@ func (fs *FileService) SearchFiles(ctx context.Context, user string, req *types.
 		})
 	}
 
-	return types.SearchFilesResponse{Total: int(total), Page: page, Size: size, Files: files}, nil
-}
-
-// SyncObjectsToDB 同步：扫描对象存储并将对象元数据落库（占位实现，可扩展事件驱动）。
-func (fs *FileService) SyncObjectsToDB(ctx context.Context, user string) error {
-	if user == "" {
-		return fmt.Errorf("user is required")
+	keys := make([]string, len(rows))
+	for i := range rows {
+		keys[i] = rows[i].ObjectKey
 	}
 
-	bucket, err := fs.defaultBucket()
+	medias, err := fs.loadMediaInfos(ctx, user, keys)
 	if err != nil {
-		return err
+		return types.SearchFilesResponse{}, err
 	}
 
-	prefix := user + "/"
-	ch := fs.s3Client.ListObjects(ctx, bucket,
-		minio.ListObjectsOptions{Prefix: prefix, Recursive: true})
-
-	dbx := fs.dbClient.GetDB().WithContext(ctx)
-	now := time.Now().UTC()
-
-	for obj := range ch {
-		if obj.Err != nil {
-			return fmt.Errorf("list objects: %v", obj.Err)
-		}
-
-		if strings.HasSuffix(obj.Key, "/") {
-			continue
-		}
+	for i := range files {
+		files[i].Media = medias[files[i].ObjectKey]
+	}
 
-		// upsert by (user, object_key)
-		rec := model.Files{
-			User:         user,
-			ObjectKey:    obj.Key,
-			FileName:     lastPathComponent(obj.Key),
-			Size:         obj.Size,
-			ETag:         strings.Trim(obj.ETag, "\""),
-			ContentType:  "", // TODO 通过 Stat 可补充，这里简化
-			Category:     "", // 通过 LLM 分类
-			Description:  "", // 通过 LLM 生成摘要
-			TagsJSON:     "", // 通过 LLM 提取标签
-			Bucket:       bucket,
-			VersionID:    obj.VersionID,
-			StorageClass: obj.StorageClass,
-			LastModified: obj.LastModified.UTC(),
-			UpdatedAt:    now,
-		}
+	return types.SearchFilesResponse{Total: int(total), Page: page, Size: size, Files: files}, nil
+}
 
-		// 使用 PostgreSQL/SQLite 的 UPSERT 语法；GORM 提供了统一的 OnConflict
-		if err := dbx.Clauses(onConflictUserKeyUpdate()).Create(&rec).Error; err != nil {
-			nlog.Logger().Warn().Err(err).Str("key", obj.Key).Msg("upsert file failed")
-			// 不中断整体同步
-		}
+// SyncObjectsToDB 同步：扫描对象存储并将对象元数据落库（占位实现，可扩展事件驱动）。
+func (fs *FileService) SyncObjectsToDB(ctx context.Context, user string) error {
+	if user == "" {
+		return fmt.Errorf("user is required")
 	}
 
-	return nil
+	return fs.syncObjects(ctx, user, user+"/", nil, nil)
 }
 
 // SyncObjectsToDBByDate 按日期范围（年/月/日）同步对象到数据库。
@@ -272,11 +247,13 @@ func (fs *FileService) SyncObjectsToDBByDate(ctx context.Context, user string, y
 		return fmt.Errorf("user is required")
 	}
 
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return err
-	}
+	prefix, filter := syncScope(user, year, month, day)
 
+	return fs.syncObjects(ctx, user, prefix, filter, nil)
+}
+
+// syncScope 根据年/月/日计算同步前缀与可选的按天过滤器.
+func syncScope(user string, year, month, day int) (string, func(minio.ObjectInfo) bool) {
 	// 构建前缀
 	prefix := user + "/"
 	if year > 0 && month <= 0 {
@@ -287,6 +264,39 @@ func (fs *FileService) SyncObjectsToDBByDate(ctx context.Context, user string, y
 		prefix = fmt.Sprintf("%s/%04d/%02d/", user, year, month)
 	}
 
+	// 如果指定了 day，并且 month/year 也指定了，则按 LastModified 的天过滤
+	if year <= 0 || month <= 0 || day <= 0 {
+		return prefix, nil
+	}
+
+	return prefix, func(obj minio.ObjectInfo) bool {
+		y, m, d := obj.LastModified.UTC().Date()
+		return y == year && int(m) == month && d == day
+	}
+}
+
+// syncObjects 扫描 prefix 下的对象并 upsert 到数据库。
+// filter 为空表示不过滤；report 为空时单条失败仅记录日志，否则交由调用方（异步任务）记录，
+// report 返回错误将中断扫描。
+func (fs *FileService) syncObjects(ctx context.Context, user, prefix string,
+	filter func(minio.ObjectInfo) bool, report func(key string, err error) error) error {
+	buckets, err := fs.allBuckets()
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range buckets {
+		if err := fs.syncBucketObjects(ctx, bucket, user, prefix, filter, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncBucketObjects 扫描单个 bucket 中 prefix 下的对象并 upsert 到数据库，记录中的 bucket 即对象所在 bucket.
+func (fs *FileService) syncBucketObjects(ctx context.Context, bucket, user, prefix string,
+	filter func(minio.ObjectInfo) bool, report func(key string, err error) error) error {
 	ch := fs.s3Client.ListObjects(ctx, bucket,
 		minio.ListObjectsOptions{Prefix: prefix, Recursive: true})
 
@@ -302,26 +312,21 @@ func (fs *FileService) SyncObjectsToDBByDate(ctx context.Context, user string, y
 			continue
 		}
 
-		// 如果指定了 day，并且 month/year 也指定了，则按 LastModified 的天过滤
-		if year > 0 && month > 0 && day > 0 {
-			ts := obj.LastModified.UTC()
-
-			y, m, d := ts.Date()
-			if y != year || int(m) != month || d != day {
-				continue
-			}
+		if filter != nil && !filter(obj) {
+			continue
 		}
 
+		// upsert by (user, object_key)
 		rec := model.Files{
 			User:         user,
 			ObjectKey:    obj.Key,
 			FileName:     lastPathComponent(obj.Key),
 			Size:         obj.Size,
 			ETag:         strings.Trim(obj.ETag, "\""),
-			ContentType:  "",
-			Category:     "",
-			Description:  "",
-			TagsJSON:     "",
+			ContentType:  "", // TODO 通过 Stat 可补充，这里简化
+			Category:     "", // 通过 LLM 分类
+			Description:  "", // 通过 LLM 生成摘要
+			TagsJSON:     "", // 通过 LLM 提取标签
 			Bucket:       bucket,
 			VersionID:    obj.VersionID,
 			StorageClass: obj.StorageClass,
@@ -329,8 +334,19 @@ func (fs *FileService) SyncObjectsToDBByDate(ctx context.Context, user string, y
 			UpdatedAt:    now,
 		}
 
-		if err := dbx.Clauses(onConflictUserKeyUpdate()).Create(&rec).Error; err != nil {
-			nlog.Logger().Warn().Err(err).Str("key", obj.Key).Msg("upsert file failed")
+		// 使用 PostgreSQL/SQLite 的 UPSERT 语法；GORM 提供了统一的 OnConflict
+		upsertErr := dbx.Clauses(onConflictUserKeyUpdate()).Create(&rec).Error
+		if report != nil {
+			if err := report(obj.Key, upsertErr); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if upsertErr != nil {
+			nlog.Logger().Warn().Err(upsertErr).Str("key", obj.Key).Msg("upsert file failed")
+			// 不中断整体同步
 		}
 	}
 
@@ -361,7 +377,7 @@ func onConflictUserKeyUpdate() clause.Expression {
 		DoUpdates: clause.Assignments(map[string]any{
 			"file_name": gorm.Expr("EXCLUDED.file_name"),
 			"size":      gorm.Expr("EXCLUDED.size"),
-			"etag":      gorm.Expr("EXCLUDED.etag"),
+			"e_tag":     gorm.Expr("EXCLUDED.e_tag"),
 			// PostgreSQL/SQLite 兼容：只在新值非空时覆盖
 			"content_type": gorm.Expr("COALESCE(NULLIF(EXCLUDED.content_type, ''), content_type)"),
 			// LLM 富元数据：不在"对象→DB"同步中覆盖
@@ -372,7 +388,11 @@ func onConflictUserKeyUpdate() clause.Expression {
 			"version_id":    gorm.Expr("EXCLUDED.version_id"),
 			"storage_class": gorm.Expr("EXCLUDED.storage_class"),
 			"last_modified": gorm.Expr("EXCLUDED.last_modified"),
-			"updated_at":    gorm.Expr("EXCLUDED.updated_at"),
+			// 过期时间只在上传时指定，同步与对账不会清除
+			"expire_at":  gorm.Expr("COALESCE(EXCLUDED.expire_at, expire_at)"),
+			"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+			// 对象重新出现时恢复此前被软删除（对象曾不存在）的记录
+			"deleted_at": gorm.Expr("NULL"),
 		}),
 	}
 }
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// routeTarget 参与 bucket 路由匹配的对象属性.
+type routeTarget struct {
+	User        string
+	ContentType string
+	// Size 对象大小，小于 0 表示未知（如预签名上传）
+	Size     int64
+	Category string
+}
+
+// routeBucket 按 s3.routing 规则为新写入的对象选择 bucket，第一条命中的规则生效，都不命中时使用默认 bucket.
+func (fs *FileService) routeBucket(t routeTarget) (string, error) {
+	bucket, err := fs.defaultBucket()
+	if err != nil {
+		return "", err
+	}
+
+	cfg := fs.s3Client.GetConfig()
+	for i := range cfg.Routing {
+		rule := &cfg.Routing[i]
+
+		// 未在 buckets 中声明的 bucket 不会在启动时创建，忽略该规则
+		if !slices.Contains(cfg.Buckets, rule.Bucket) {
+			nlog.Logger().Warn().Str("bucket", rule.Bucket).Msg("routing rule targets unknown bucket, skipped")
+			continue
+		}
+
+		if matchRoute(rule, t) {
+			return rule.Bucket, nil
+		}
+	}
+
+	return bucket, nil
+}
+
+// matchRoute 判断对象是否满足路由规则中的所有非空条件.
+func matchRoute(rule *configs.BucketRouteRule, t routeTarget) bool {
+	if len(rule.ContentTypes) > 0 {
+		ct := strings.ToLower(t.ContentType)
+		if !slices.ContainsFunc(rule.ContentTypes, func(p string) bool { return strings.HasPrefix(ct, strings.ToLower(p)) }) {
+			return false
+		}
+	}
+
+	if rule.MinSizeMB > 0 && (t.Size < 0 || t.Size < rule.MinSizeMB<<20) {
+		return false
+	}
+
+	if len(rule.Users) > 0 && !slices.Contains(rule.Users, t.User) {
+		return false
+	}
+
+	if len(rule.Tenants) > 0 {
+		_, tenant, ok := strings.Cut(t.User, "@")
+		if !ok || !slices.ContainsFunc(rule.Tenants, func(d string) bool { return strings.EqualFold(d, tenant) }) {
+			return false
+		}
+	}
+
+	if len(rule.Categories) > 0 && !slices.Contains(rule.Categories, t.Category) {
+		return false
+	}
+
+	return true
+}
+
+// objectBucket 返回已有对象所在的 bucket：优先使用文件记录中的 bucket；
+// 没有记录时（如尚未同步的复制目标）在所有 bucket 中查找，都不存在时使用默认 bucket.
+func (fs *FileService) objectBucket(ctx context.Context, objectKey string) (string, error) {
+	bucket, err := fs.recordedBucket(ctx, objectKey)
+	if err != nil || bucket != "" {
+		return bucket, err
+	}
+
+	if len(fs.s3Client.GetConfig().Buckets) > 1 {
+		if bucket, _, err := fs.locateObject(ctx, objectKey); err == nil {
+			return bucket, nil
+		}
+	}
+
+	return fs.defaultBucket()
+}
+
+// writeBucket 返回写入对象的目标 bucket：已有文件记录时沿用原 bucket（保证覆盖与版本历史在同一 bucket），否则按路由规则选择.
+func (fs *FileService) writeBucket(ctx context.Context, objectKey string, t routeTarget) (string, error) {
+	bucket, err := fs.recordedBucket(ctx, objectKey)
+	if err != nil || bucket != "" {
+		return bucket, err
+	}
+
+	return fs.routeBucket(t)
+}
+
+// locateObject 在所有 bucket 中查找对象（默认 bucket 优先），用于尚无文件记录的新对象；都不存在时返回 NoSuchKey 错误.
+func (fs *FileService) locateObject(ctx context.Context, objectKey string) (string, minio.ObjectInfo, error) {
+	buckets, err := fs.allBuckets()
+	if err != nil {
+		return "", minio.ObjectInfo{}, err
+	}
+
+	for _, bucket := range buckets {
+		info, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
+		if err == nil {
+			return bucket, info, nil
+		}
+
+		if !isNoSuchKey(err) {
+			return "", minio.ObjectInfo{}, err
+		}
+	}
+
+	return "", minio.ObjectInfo{}, minio.ErrorResponse{StatusCode: http.StatusNotFound, Code: "NoSuchKey", Key: objectKey}
+}
+
+// recordedBucket 查询文件记录（包含已软删除的记录）中的 bucket，没有记录时返回空字符串.
+func (fs *FileService) recordedBucket(ctx context.Context, objectKey string) (string, error) {
+	var rec model.Files
+
+	err := fs.dbClient.GetDB().WithContext(ctx).Unscoped().
+		Select("bucket").
+		Where("object_key = ?", objectKey).
+		Order("deleted_at IS NOT NULL, id DESC").
+		Take(&rec).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("lookup bucket of %s: %w", objectKey, err)
+	}
+
+	return rec.Bucket, nil
+}
+
+// uploadRouteTarget 构造服务端上传的路由属性，Content-Type 的推断与 uploadFile 保持一致.
+func uploadRouteTarget(user, objectKey string, size int64, meta *types.UploadFileMetadata) routeTarget {
+	t := routeTarget{User: user, Size: size}
+
+	if meta != nil {
+		t.ContentType = meta.ContentType
+		t.Category = meta.Category
+	}
+
+	if t.ContentType == "" {
+		t.ContentType = mime.TypeByExtension(path.Ext(objectKey))
+	}
+
+	return t
+}
+
+// allBuckets 返回所有配置的 bucket（去重，默认 bucket 在前）；用于需要跨 bucket 列举的场景（目录、同步、对账）.
+func (fs *FileService) allBuckets() ([]string, error) {
+	if _, err := fs.defaultBucket(); err != nil {
+		return nil, err
+	}
+
+	var buckets []string
+	for _, b := range fs.s3Client.GetConfig().Buckets {
+		if !slices.Contains(buckets, b) {
+			buckets = append(buckets, b)
+		}
+	}
+
+	return buckets, nil
+}
+
+// forEachBucket 依次对所有 bucket 执行 fn，出错时中止.
+func (fs *FileService) forEachBucket(fn func(bucket string) error) error {
+	buckets, err := fs.allBuckets()
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range buckets {
+		if err := fn(bucket); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
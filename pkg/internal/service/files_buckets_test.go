@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// TestBucketRouting 验证按 Content-Type、大小与租户路由写入 bucket，且读取、覆盖与删除都使用记录中的 bucket.
+func TestBucketRouting(t *testing.T) {
+	ctx := newTestContext(t, false, "cold", "acme")
+	svc := service.NewFileService(ctx)
+
+	cfg := configs.GetConfig()
+	def, cold, acme := cfg.S3.Buckets[0], cfg.S3.Buckets[1], cfg.S3.Buckets[2]
+	cfg.S3.Routing = []configs.BucketRouteRule{
+		{Bucket: cold, ContentTypes: []string{"video/"}},
+		// 未声明的 bucket：规则被忽略
+		{Bucket: def + "-missing", Users: []string{testUser}},
+		{Bucket: acme, Tenants: []string{"Example.com"}, MinSizeMB: 1},
+	}
+
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		size        int
+		want        string
+	}{
+		{name: "content type", fileName: "clip.mp4", contentType: "video/mp4", size: 16, want: cold},
+		{name: "tenant and size", fileName: "big.bin", size: 1 << 20, want: acme},
+		{name: "no match", fileName: "notes.txt", size: 16, want: def},
+	}
+
+	keys := make(map[string]string)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := strings.Repeat("x", tt.size)
+
+			resp, err := svc.UploadSingleFile(ctx, testUser, tt.fileName, strings.NewReader(body), int64(tt.size),
+				&types.UploadFileMetadata{ContentType: tt.contentType})
+			if err != nil || resp.Bucket != tt.want {
+				t.Fatalf("upload = %+v, %v; want bucket %s", resp, err, tt.want)
+			}
+
+			keys[tt.fileName] = resp.ObjectKey
+
+			info, err := svc.StatObject(ctx, testUser, resp.ObjectKey)
+			if err != nil || info.Bucket != tt.want || info.Size != int64(tt.size) {
+				t.Fatalf("stat = %+v, %v", info, err)
+			}
+
+			urls, err := svc.PresignedGetURLs(ctx, &types.GetFilesURLRequest{Objects: []types.GetFileURLItem{{ObjectKey: resp.ObjectKey}}})
+			if err != nil || !strings.HasPrefix(urls.Results[0].GetURL, "memory://"+tt.want+"/") {
+				t.Fatalf("presign = %+v, %v", urls, err)
+			}
+		})
+	}
+
+	// 已有文件覆盖时沿用记录中的 bucket，即使新内容不再命中原规则
+	key := keys["clip.mp4"]
+
+	resp, err := svc.UploadSingleFile(ctx, testUser, "clip.mp4", strings.NewReader("text"), 4,
+		&types.UploadFileMetadata{ContentType: "text/plain"})
+	if err != nil || resp.ObjectKey != key || resp.Bucket != cold {
+		t.Fatalf("overwrite = %+v, %v", resp, err)
+	}
+
+	obj, _, err := svc.OpenObject(ctx, testUser, key)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	b, _ := io.ReadAll(obj)
+	_ = obj.Close()
+
+	if string(b) != "text" {
+		t.Fatalf("read = %q", b)
+	}
+
+	del, err := svc.DeleteFiles(ctx, testUser, &types.DeleteFilesRequest{ObjectKeys: []string{key}})
+	if err != nil || del.Success != 1 {
+		t.Fatalf("delete = %+v, %v", del, err)
+	}
+
+	if _, err := svc.StatObject(ctx, testUser, key); err == nil {
+		t.Fatalf("object still exists after delete")
+	}
+}
@@ -2,27 +2,42 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
 	"github.com/yeisme/notevault/pkg/internal/types"
 )
 
-// PresignedGetURLs 生成对象的预签名 GET 访问 URL（支持单个/批量）.
-func (fs *FileService) PresignedGetURLs(ctx context.Context, req *types.GetFilesURLRequest) (*types.GetFilesURLResponse, error) {
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return nil, err
-	}
+var (
+	// ErrObjectNotFound 对象不存在.
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrObjectAccessDenied 对象不属于当前用户.
+	ErrObjectAccessDenied = errors.New("access denied")
+)
 
+// PresignedGetURLs 生成对象的预签名 GET 访问 URL（支持单个/批量）.
+// 接口不携带用户，审计记录的操作者取自请求声明的用户.
+func (fs *FileService) PresignedGetURLs(ctx context.Context, req *types.GetFilesURLRequest) (_ *types.GetFilesURLResponse, err error) {
 	expiry := resolveGetExpiry(req)
 	results := make([]types.PresignedDownloadItem, 0, len(req.Objects))
 
+	keys := make([]string, 0, len(req.Objects))
+	defer func() { RecordAudit(ctx, "", types.AuditActionFileDownloadURL, keys, err, "") }()
+
 	for i := range req.Objects {
 		item := &req.Objects[i]
+		keys = append(keys, item.ObjectKey)
+
+		bucket, err := fs.objectBucket(ctx, item.ObjectKey)
+		if err != nil {
+			return nil, err
+		}
 
 		d, err := fs.presignGet(ctx, bucket, item, expiry)
 		if err != nil {
@@ -38,16 +53,20 @@ func (fs *FileService) PresignedGetURLs(ctx context.Context, req *types.GetFiles
 // StatObject 查询对象信息（包含大小、类型、ETag、最后修改时间等）。
 func (fs *FileService) StatObject(ctx context.Context, user, objectKey string) (*types.ObjectInfo, error) {
 	if user == "" || !strings.HasPrefix(objectKey, user+"/") {
-		return nil, fmt.Errorf("access denied: object does not belong to user")
+		return nil, fmt.Errorf("%w: object does not belong to user", ErrObjectAccessDenied)
 	}
 
-	bucket, err := fs.defaultBucket()
+	bucket, err := fs.objectBucket(ctx, objectKey)
 	if err != nil {
 		return nil, err
 	}
 
 	info, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
 	if err != nil {
+		if isNoSuchKey(err) {
+			return nil, fmt.Errorf("stat object %s: %w", objectKey, ErrObjectNotFound)
+		}
+
 		return nil, fmt.Errorf("stat object %s: %w", objectKey, err)
 	}
 
@@ -67,12 +86,12 @@ func (fs *FileService) StatObject(ctx context.Context, user, objectKey string) (
 }
 
 // OpenObject 打开对象获取可读流与其信息。
-func (fs *FileService) OpenObject(ctx context.Context, user, objectKey string) (*minio.Object, *types.ObjectInfo, error) { //nolint:ireturn
+func (fs *FileService) OpenObject(ctx context.Context, user, objectKey string) (s3.Object, *types.ObjectInfo, error) { //nolint:ireturn
 	if user == "" || !strings.HasPrefix(objectKey, user+"/") {
-		return nil, nil, fmt.Errorf("access denied: object does not belong to user")
+		return nil, nil, fmt.Errorf("%w: object does not belong to user", ErrObjectAccessDenied)
 	}
 
-	bucket, err := fs.defaultBucket()
+	bucket, err := fs.objectBucket(ctx, objectKey)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -104,3 +123,109 @@ func (fs *FileService) OpenObject(ctx context.Context, user, objectKey string) (
 
 	return obj, meta, nil
 }
+
+// OpenObjectRange 以 Range 请求读取对象 [offset, offset+length) 区间，length<0 表示读到末尾.
+// etag 非空时要求对象 ETag 一致，避免分段读取期间对象被覆盖导致内容拼接错乱.
+func (fs *FileService) OpenObjectRange(ctx context.Context, user, objectKey, etag string, offset, length int64) (io.ReadCloser, error) {
+	if user == "" || !strings.HasPrefix(objectKey, user+"/") {
+		return nil, fmt.Errorf("%w: object does not belong to user", ErrObjectAccessDenied)
+	}
+
+	bucket, err := fs.objectBucket(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := minio.GetObjectOptions{}
+
+	if etag != "" {
+		if err := opts.SetMatchETag(etag); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case length > 0:
+		err = opts.SetRange(offset, offset+length-1)
+	case offset > 0:
+		err = opts.SetRange(offset, 0)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid range: %w", err)
+	}
+
+	obj, err := fs.s3Client.GetObject(ctx, bucket, objectKey, opts)
+	if err != nil {
+		return nil, fmt.Errorf("get object %s: %w", objectKey, err)
+	}
+
+	return obj, nil
+}
+
+// ObjectRange 对象中的一个字节分段，Length<0 表示读到末尾.
+type ObjectRange struct {
+	Offset int64
+	Length int64
+}
+
+// StatDownload 查询待下载对象的信息；对象不存在或无权访问时以 actor 身份写入失败的下载审计记录.
+func (fs *FileService) StatDownload(ctx context.Context, actor, user, objectKey string) (*types.ObjectInfo, error) {
+	info, err := fs.StatObject(ctx, user, objectKey)
+	if err != nil {
+		RecordAudit(ctx, actor, types.AuditActionFileDownload, []string{objectKey}, err, "")
+	}
+
+	return info, err
+}
+
+// DownloadObject 以 actor 身份下载 user 的对象：依次读取 ranges 中的分段（为空时为完整对象），写入 next 为每个分段返回的 Writer，
+// 完成后写入一条下载审计记录；分享下载时 actor 为访问者而非 owner。不传输内容的请求（HEAD、条件请求命中）不应调用.
+func (fs *FileService) DownloadObject(ctx context.Context, actor, user string, info *types.ObjectInfo, ranges []ObjectRange,
+	next func(i int) (io.Writer, error)) (err error) {
+	detail := ""
+	if len(ranges) > 0 {
+		detail = fmt.Sprintf("ranges=%d", len(ranges))
+	} else {
+		ranges = []ObjectRange{{Length: -1}}
+	}
+
+	defer recordAuditResult(ctx, actor, types.AuditActionFileDownload, []string{info.ObjectKey}, detail, &err)
+
+	for i, ra := range ranges {
+		w, err := next(i)
+		if err != nil {
+			return err
+		}
+
+		if err := fs.copyObjectRange(ctx, user, info, ra, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordArchiveDownload 以 actor 身份为打包下载写入一条汇总的下载审计记录.
+func (fs *FileService) RecordArchiveDownload(ctx context.Context, actor string, manifest *types.ArchiveManifest) {
+	keys := make([]string, 0, len(manifest.Entries))
+	for _, e := range manifest.Entries {
+		keys = append(keys, e.ObjectKey)
+	}
+
+	RecordAuditBatch(ctx, actor, types.AuditActionFileDownload, keys, manifest.Failed)
+}
+
+// copyObjectRange 将对象的一个分段写入 w.
+func (fs *FileService) copyObjectRange(ctx context.Context, user string, info *types.ObjectInfo, ra ObjectRange, w io.Writer) error {
+	rc, err := fs.OpenObjectRange(ctx, user, info.ObjectKey, info.ETag, ra.Offset, ra.Length)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = rc.Close() }()
+
+	_, err = io.Copy(w, rc)
+
+	return err
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/yeisme/notevault/pkg/internal/media"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+const (
+	// defaultDuplicateDistance 近似重复的默认汉明距离阈值.
+	defaultDuplicateDistance = 6
+	// maxSimilarCandidates 参与两两比较的图片数上限（O(n²)）.
+	maxSimilarCandidates = 20000
+)
+
+// duplicateRow 文件记录与可选的媒体元数据.
+type duplicateRow struct {
+	ObjectKey    string
+	FileName     string
+	Size         int64
+	ETag         string
+	ContentType  string
+	LastModified time.Time
+	Width        int
+	Height       int
+	PHash        *int64 `gorm:"column:phash"`
+	DHash        *int64 `gorm:"column:dhash"`
+}
+
+// FindDuplicates 按内容（ETag + 大小）与图片感知哈希查找用户的重复文件.
+// 近似分组把 pHash 与 dHash 汉明距离均不超过阈值的图片连通为一组；仅包含同一内容的分组不重复列出.
+func (fs *FileService) FindDuplicates(ctx context.Context, user string, q *types.DuplicatesQuery) (*types.DuplicatesResponse, error) {
+	mode := q.Mode
+	if mode == "" {
+		mode = types.DuplicateModeAll
+	}
+
+	distance := defaultDuplicateDistance
+	if q.Distance != nil {
+		distance = *q.Distance
+	}
+
+	resp := &types.DuplicatesResponse{Distance: distance, Exact: []types.DuplicateGroup{}, Similar: []types.DuplicateGroup{}}
+
+	if mode != types.DuplicateModeSimilar {
+		groups, err := fs.findExactDuplicates(ctx, user, q.Prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Exact = groups
+	}
+
+	if mode != types.DuplicateModeExact {
+		groups, truncated, err := fs.findSimilarImages(ctx, user, q.Prefix, distance)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Similar, resp.Truncated = groups, truncated
+	}
+
+	return resp, nil
+}
+
+// ResolveDuplicates 每组保留 Keep，其余文件移入回收站.
+func (fs *FileService) ResolveDuplicates(ctx context.Context, user string, req *types.ResolveDuplicatesRequest) (*types.TrashOpResponse, error) {
+	for i, g := range req.Groups {
+		if !slices.Contains(g.ObjectKeys, g.Keep) {
+			return nil, fmt.Errorf("groups[%d]: keep %q is not in object_keys", i, g.Keep)
+		}
+	}
+
+	resp := &types.TrashOpResponse{Results: []types.TrashOpResult{}}
+
+	for _, g := range req.Groups {
+		trash := slices.DeleteFunc(slices.Clone(g.ObjectKeys), func(k string) bool { return k == g.Keep })
+		slices.Sort(trash)
+		trash = slices.Compact(trash)
+
+		r, err := fs.TrashFiles(ctx, user, trash, types.TrashReasonDuplicate)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Total += r.Total
+		resp.Success += r.Success
+		resp.Failed += r.Failed
+		resp.Results = append(resp.Results, r.Results...)
+	}
+
+	return resp, nil
+}
+
+// findExactDuplicates 按 ETag 与大小分组；最早写入的文件作为建议保留项.
+func (fs *FileService) findExactDuplicates(ctx context.Context, user, prefix string) ([]types.DuplicateGroup, error) {
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	keys := dbx.Model(&model.Files{}).Select("e_tag, size").
+		Where("user = ? AND e_tag <> '' AND object_key LIKE ?", user, prefix+"%").
+		Group("e_tag, size").Having("COUNT(*) > 1")
+
+	var rows []duplicateRow
+	if err := dbx.Model(&model.Files{}).
+		Select("object_key, file_name, size, e_tag, content_type, last_modified").
+		Where("user = ? AND object_key LIKE ? AND (e_tag, size) IN (?)", user, prefix+"%", keys).
+		Order("e_tag, size, last_modified ASC, object_key ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("query exact duplicates: %w", err)
+	}
+
+	groups := make([]types.DuplicateGroup, 0)
+
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && rows[end].ETag == rows[start].ETag && rows[end].Size == rows[start].Size {
+			end++
+		}
+
+		g := types.DuplicateGroup{Mode: types.DuplicateModeExact, Hash: rows[start].ETag, SuggestedKeep: rows[start].ObjectKey}
+		for i := start; i < end; i++ {
+			g.Files = append(g.Files, toDuplicateFile(&rows[i], 0))
+		}
+
+		groups = append(groups, g)
+		start = end
+	}
+
+	return groups, nil
+}
+
+// findSimilarImages 两两比较已计算感知哈希的图片，按连通分量分组；分辨率最高的作为建议保留项.
+func (fs *FileService) findSimilarImages(ctx context.Context, user, prefix string, distance int) ([]types.DuplicateGroup, bool, error) {
+	var rows []duplicateRow
+	if err := fs.dbClient.GetDB().WithContext(ctx).Table("files").
+		Select("files.object_key, files.file_name, files.size, files.e_tag, files.content_type, files.last_modified, "+
+			"file_media.width, file_media.height, file_media.phash, file_media.dhash").
+		Joins("JOIN file_media ON file_media.user = files.user AND file_media.object_key = files.object_key").
+		Where("files.user = ? AND files.deleted_at IS NULL AND files.object_key LIKE ? AND file_media.phash IS NOT NULL",
+			user, prefix+"%").
+		Order("files.object_key ASC").Limit(maxSimilarCandidates + 1).
+		Scan(&rows).Error; err != nil {
+		return nil, false, fmt.Errorf("query image hashes: %w", err)
+	}
+
+	truncated := len(rows) > maxSimilarCandidates
+	if truncated {
+		rows = rows[:maxSimilarCandidates]
+	}
+
+	parent := make([]int, len(rows))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(int) int
+
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+
+		return parent[i]
+	}
+
+	for i := range rows {
+		for j := i + 1; j < len(rows); j++ {
+			if hashDistance(&rows[i], &rows[j]) <= distance {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range rows {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+
+	groups := make([]types.DuplicateGroup, 0)
+
+	for _, idx := range members {
+		if len(idx) < 2 || sameContent(rows, idx) {
+			continue
+		}
+
+		// 分辨率优先，其次文件大小，最后按对象键稳定排序
+		slices.SortFunc(idx, func(a, b int) int {
+			ra, rb := &rows[a], &rows[b]
+			return cmp.Or(
+				cmp.Compare(rb.Width*rb.Height, ra.Width*ra.Height),
+				cmp.Compare(rb.Size, ra.Size),
+				cmp.Compare(ra.ObjectKey, rb.ObjectKey),
+			)
+		})
+
+		keep := &rows[idx[0]]
+		g := types.DuplicateGroup{
+			Mode:          types.DuplicateModeSimilar,
+			Hash:          strconv.FormatUint(uint64(*keep.PHash), 16), //nolint:gosec // 按位还原
+			SuggestedKeep: keep.ObjectKey,
+		}
+
+		for _, i := range idx {
+			g.Files = append(g.Files, toDuplicateFile(&rows[i], hashDistance(keep, &rows[i])))
+		}
+
+		groups = append(groups, g)
+	}
+
+	slices.SortFunc(groups, func(a, b types.DuplicateGroup) int { return cmp.Compare(a.SuggestedKeep, b.SuggestedKeep) })
+
+	return groups, truncated, nil
+}
+
+// hashDistance 返回 pHash 与 dHash 汉明距离中的较大值.
+func hashDistance(a, b *duplicateRow) int {
+	d := media.Hamming(uint64(*a.PHash), uint64(*b.PHash)) //nolint:gosec // 按位还原
+	if a.DHash != nil && b.DHash != nil {
+		d = max(d, media.Hamming(uint64(*a.DHash), uint64(*b.DHash))) //nolint:gosec // 按位还原
+	}
+
+	return d
+}
+
+// sameContent 分组内文件内容是否完全相同（已由 exact 分组覆盖）.
+func sameContent(rows []duplicateRow, idx []int) bool {
+	for _, i := range idx[1:] {
+		if rows[i].ETag != rows[idx[0]].ETag || rows[i].Size != rows[idx[0]].Size {
+			return false
+		}
+	}
+
+	return true
+}
+
+func toDuplicateFile(r *duplicateRow, distance int) types.DuplicateFile {
+	return types.DuplicateFile{
+		ObjectKey:    r.ObjectKey,
+		FileName:     r.FileName,
+		Size:         r.Size,
+		ETag:         r.ETag,
+		ContentType:  r.ContentType,
+		LastModified: r.LastModified.UTC().Format(time.RFC3339),
+		Width:        r.Width,
+		Height:       r.Height,
+		Distance:     distance,
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// maxRotateErrors 响应中最多列出的失败对象数，超出部分只计入统计.
+const maxRotateErrors = 100
+
+// ErrEncryptionDisabled 未启用 notevault 管理的加密，没有可轮换的主密钥.
+var ErrEncryptionDisabled = errors.New("encryption is not enabled")
+
+// RotateEncryptionKeys 将仍由旧主密钥保护的对象改用当前主密钥：信封加密的对象只重新包装数据密钥，
+// SSE-C 对象由存储端以新密钥重新加密。user 为空时扫描所有 bucket（包括回收站与模拟版本目录），
+// 否则只处理该用户的文件、模拟版本与回收站对象。dryRun 为 true 时只统计需要轮换的对象.
+func (fs *FileService) RotateEncryptionKeys(ctx context.Context, user string, dryRun bool) (*types.RotateKeysResponse, error) {
+	rotator, ok := fs.s3Client.ObjectStore.(s3.KeyRotator)
+	if !ok {
+		return nil, ErrEncryptionDisabled
+	}
+
+	resp := &types.RotateKeysResponse{DryRun: dryRun, Errors: []types.RotateKeyError{}}
+
+	prefixes := []string{""}
+	if user != "" {
+		prefixes = []string{user + "/", versionsPrefix + user + "/"}
+	}
+
+	err := fs.forEachBucket(func(bucket string) error {
+		for _, prefix := range prefixes {
+			opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true}
+			for obj := range fs.s3Client.ListObjects(ctx, bucket, opts) {
+				if obj.Err != nil {
+					return obj.Err
+				}
+
+				fs.rotateObjectKey(ctx, rotator, bucket, obj.Key, dryRun, resp)
+			}
+		}
+
+		return nil
+	})
+	if err != nil || user == "" {
+		return resp, err
+	}
+
+	// 回收站对象位于 .trash/<id>/ 下，按回收站记录定位
+	var items []model.TrashItem
+	if err := fs.dbClient.GetDB().WithContext(ctx).Where("user = ?", user).Find(&items).Error; err != nil {
+		return resp, err
+	}
+
+	for i := range items {
+		fs.rotateObjectKey(ctx, rotator, items[i].Bucket, items[i].TrashKey, dryRun, resp)
+	}
+
+	return resp, nil
+}
+
+// rotateObjectKey 轮换单个对象并记录结果；对象内容的 ETag 与版本随之改变，同步更新文件记录.
+func (fs *FileService) rotateObjectKey(ctx context.Context, rotator s3.KeyRotator, bucket, objectKey string, dryRun bool,
+	resp *types.RotateKeysResponse) {
+	resp.Scanned++
+
+	rotated, err := rotator.RotateObjectKey(ctx, bucket, objectKey, dryRun)
+	if err != nil {
+		nlog.Logger().Warn().Err(err).Str("bucket", bucket).Str("object_key", objectKey).Msg("rotate object key failed")
+
+		resp.Failed++
+		if len(resp.Errors) < maxRotateErrors {
+			resp.Errors = append(resp.Errors, types.RotateKeyError{Bucket: bucket, ObjectKey: objectKey, Error: err.Error()})
+		}
+
+		return
+	}
+
+	if !rotated {
+		return
+	}
+
+	resp.Rotated++
+
+	if dryRun || strings.HasPrefix(objectKey, ".") {
+		return
+	}
+
+	info, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		return
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Model(&model.Files{}).
+		Where("bucket = ? AND object_key = ?", bucket, objectKey).
+		Updates(map[string]any{"etag": strings.Trim(info.ETag, `"`), "version_id": info.VersionID}).Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Msg("update rotated file record failed")
+	}
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/notification"
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/storage/mq"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/queue"
+)
+
+// eventProducer 发布领域事件时使用的生产者标识.
+const eventProducer = "notevault"
+
+// bucketEventSource 通过存储桶事件入库的对象在 ObjectStoredPayload.Source 中的来源标识.
+const bucketEventSource = "bucket_event"
+
+// bucketNotification S3 事件通知；MinIO webhook 在外层额外携带 EventName 与 Key.
+type bucketNotification struct {
+	EventName string               `json:"EventName"`
+	Key       string               `json:"Key"`
+	Records   []notification.Event `json:"Records"`
+}
+
+// ParseBucketEvents 解析标准 S3 事件通知 JSON，返回对象创建/删除事件.
+// 其他事件类型（如 s3:TestEvent）、目录标记对象与系统目录（以 "." 开头）下的对象被忽略.
+func ParseBucketEvents(data []byte) ([]types.BucketEvent, error) {
+	var n bucketNotification
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("decode bucket notification: %w", err)
+	}
+
+	events := make([]types.BucketEvent, 0, len(n.Records))
+
+	for i := range n.Records {
+		ev, ok, err := normalizeBucketEvent(&n.Records[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			events = append(events, ev)
+		}
+	}
+
+	return events, nil
+}
+
+// normalizeBucketEvent 将单条 Record 转换为 BucketEvent，返回是否需要处理.
+func normalizeBucketEvent(r *notification.Event) (types.BucketEvent, bool, error) {
+	// AWS 的事件名不带 "s3:" 前缀，MinIO 带
+	name := strings.TrimPrefix(r.EventName, "s3:")
+
+	var action string
+
+	switch {
+	case strings.HasPrefix(name, "ObjectCreated:"):
+		action = types.BucketEventCreated
+	case strings.HasPrefix(name, "ObjectRemoved:"):
+		action = types.BucketEventRemoved
+	default:
+		return types.BucketEvent{}, false, nil
+	}
+
+	// 事件中的对象键经过 URL 编码（空格编码为 '+'）
+	key, err := url.QueryUnescape(r.S3.Object.Key)
+	if err != nil {
+		return types.BucketEvent{}, false, fmt.Errorf("decode object key %q: %w", r.S3.Object.Key, err)
+	}
+
+	user, _, found := strings.Cut(key, "/")
+	if !found || user == "" || strings.HasPrefix(user, ".") || strings.HasSuffix(key, "/") {
+		return types.BucketEvent{}, false, nil
+	}
+
+	ev := types.BucketEvent{
+		Name:      r.EventName,
+		Action:    action,
+		Bucket:    r.S3.Bucket.Name,
+		ObjectKey: key,
+		User:      user,
+		Size:      r.S3.Object.Size,
+		ETag:      strings.Trim(r.S3.Object.ETag, "\""),
+		VersionID: r.S3.Object.VersionID,
+	}
+
+	if r.EventTime != "" {
+		t, err := time.Parse(time.RFC3339, r.EventTime)
+		if err != nil {
+			return types.BucketEvent{}, false, fmt.Errorf("parse event time %q: %w", r.EventTime, err)
+		}
+
+		ev.EventTime = t.UTC()
+	}
+
+	return ev, true, nil
+}
+
+// HandleBucketEvents 按顺序将存储桶事件同步到数据库并发布对应的 nv.object.* 事件.
+// 事件可能重复或乱序投递，因此以对象存储的当前状态（Stat）为准：
+// 创建事件在对象已不存在时跳过，删除事件在对象已被重新写入时跳过.
+// 返回错误时调用方应让事件源重试（webhook 返回 5xx、MQ Nack）.
+func (fs *FileService) HandleBucketEvents(ctx context.Context, events []types.BucketEvent) (*types.BucketEventsResponse, error) {
+	resp := &types.BucketEventsResponse{Received: len(events)}
+	buckets := fs.s3Client.GetConfig().Buckets
+
+	for i := range events {
+		ev := &events[i]
+
+		if !slices.Contains(buckets, ev.Bucket) {
+			resp.Skipped++
+			continue
+		}
+
+		var (
+			applied bool
+			err     error
+		)
+
+		switch ev.Action {
+		case types.BucketEventCreated:
+			applied, err = fs.applyObjectCreated(ctx, ev)
+		case types.BucketEventRemoved:
+			applied, err = fs.applyObjectRemoved(ctx, ev)
+		}
+
+		if err != nil {
+			return resp, fmt.Errorf("handle %s %s: %w", ev.Name, ev.ObjectKey, err)
+		}
+
+		if applied {
+			resp.Applied++
+		} else {
+			resp.Skipped++
+		}
+	}
+
+	return resp, nil
+}
+
+// applyObjectCreated 使用对象的当前元数据 upsert 记录，新对象发布 stored 事件，内容变化发布 updated 事件.
+func (fs *FileService) applyObjectCreated(ctx context.Context, ev *types.BucketEvent) (bool, error) {
+	info, err := fs.s3Client.StatObject(ctx, ev.Bucket, ev.ObjectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if isNoSuchKey(err) {
+			return false, nil // 对象在事件处理前已被删除
+		}
+
+		return false, fmt.Errorf("stat object: %w", err)
+	}
+
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	var prev model.Files
+
+	existed := true
+	if err := dbx.Where("user = ? AND object_key = ?", ev.User, ev.ObjectKey).Take(&prev).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, fmt.Errorf("load file record: %w", err)
+		}
+
+		existed = false
+	}
+
+	etag := strings.Trim(info.ETag, "\"")
+	if existed && prev.ETag == etag && prev.VersionID == info.VersionID {
+		return false, nil // 重复投递或已由其他途径同步
+	}
+
+	rec := model.Files{
+		User:         ev.User,
+		ObjectKey:    ev.ObjectKey,
+		FileName:     lastPathComponent(ev.ObjectKey),
+		Size:         info.Size,
+		ETag:         etag,
+		ContentType:  info.ContentType,
+		Bucket:       ev.Bucket,
+		VersionID:    info.VersionID,
+		StorageClass: info.StorageClass,
+		LastModified: info.LastModified.UTC(),
+		UpdatedAt:    time.Now().UTC(),
+	}
+
+	// 经上传型分享写入的对象：还原文件名并以 share_id 标签标记来源
+	shareID := shareUploadID(&info)
+	if shareID != "" {
+		rec.FileName = shareUploadFileName(ev.ObjectKey)
+		if b, err := json.Marshal(map[string]string{shareUploadTag: shareID}); err == nil {
+			rec.TagsJSON = string(b)
+		}
+	}
+
+	if err := dbx.Clauses(onConflictUserKeyUpdate()).Create(&rec).Error; err != nil {
+		return false, fmt.Errorf("upsert file record: %w", err)
+	}
+
+	fs.saveVersion(ctx, &model.FileVersion{
+		User:        ev.User,
+		ObjectKey:   ev.ObjectKey,
+		VersionID:   info.VersionID,
+		Bucket:      ev.Bucket,
+		Action:      bucketEventSource,
+		Creator:     ev.User,
+		Size:        info.Size,
+		ETag:        etag,
+		Checksum:    versionChecksum("", etag),
+		ContentType: info.ContentType,
+	})
+
+	ref := queue.ObjectRef{
+		Bucket:      ev.Bucket,
+		ObjectKey:   ev.ObjectKey,
+		VersionID:   info.VersionID,
+		ETag:        etag,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+	}
+
+	if existed {
+		publishEvent(ctx, fs.mqClient, queue.TopicObjectUpdated,
+			queue.ObjectUpdatedPayload{Object: ref, PrevVersionID: prev.VersionID})
+
+		return true, nil
+	}
+
+	stored := queue.ObjectStoredPayload{Object: ref, Source: bucketEventSource, FileName: rec.FileName}
+	publishEvent(ctx, fs.mqClient, queue.TopicObjectStored, stored)
+
+	if topic := storedTopicByContentType(info.ContentType); topic != "" {
+		publishEvent(ctx, fs.mqClient, topic, stored)
+	}
+
+	if shareID != "" {
+		publishEvent(ctx, fs.mqClient, queue.TopicShareUploadReceived, queue.ShareUploadReceivedPayload{
+			ShareID:  shareID,
+			Owner:    ev.User,
+			Object:   ref,
+			FileName: rec.FileName,
+		})
+	}
+
+	return true, nil
+}
+
+// applyObjectRemoved 对象确实不存在时软删除记录并发布 deleted 事件.
+func (fs *FileService) applyObjectRemoved(ctx context.Context, ev *types.BucketEvent) (bool, error) {
+	_, err := fs.s3Client.StatObject(ctx, ev.Bucket, ev.ObjectKey, minio.StatObjectOptions{})
+	if err == nil {
+		return false, nil // 删除后又被重新写入
+	}
+
+	if !isNoSuchKey(err) {
+		return false, fmt.Errorf("stat object: %w", err)
+	}
+
+	res := fs.dbClient.GetDB().WithContext(ctx).
+		Where("user = ? AND object_key = ?", ev.User, ev.ObjectKey).Delete(&model.Files{})
+	if res.Error != nil {
+		return false, fmt.Errorf("delete file record: %w", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+
+	publishEvent(ctx, fs.mqClient, queue.TopicObjectDeleted, queue.ObjectDeletedPayload{
+		Object: queue.ObjectRef{Bucket: ev.Bucket, ObjectKey: ev.ObjectKey, VersionID: ev.VersionID},
+	})
+
+	return true, nil
+}
+
+// storedTopicByContentType 返回按数据类型细分的存储主题；无法归类时返回空串.
+func storedTopicByContentType(contentType string) string {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	ct = strings.TrimSpace(ct)
+
+	switch {
+	case strings.HasPrefix(ct, "text/"), ct == "application/json", ct == "application/xml":
+		return queue.TopicObjectTextStored
+	case strings.HasPrefix(ct, "image/"):
+		return queue.TopicObjectImageStored
+	case strings.HasPrefix(ct, "audio/"):
+		return queue.TopicObjectAudioStored
+	case strings.HasPrefix(ct, "video/"):
+		return queue.TopicObjectVideoStored
+	default:
+		return ""
+	}
+}
+
+// isNoSuchKey 判断 S3 错误是否为对象不存在.
+func isNoSuchKey(err error) bool {
+	return minio.ToErrorResponse(err).Code == "NoSuchKey"
+}
+
+// publishEvent 发布领域事件；MQ 未初始化或发布失败时仅记录日志，不影响主流程.
+func publishEvent[T any](ctx context.Context, mqc *mq.Client, topic string, payload T) {
+	if mqc == nil {
+		return
+	}
+
+	msg, err := queue.NewWatermillMessage(topic, payload, queue.WithProducer(eventProducer))
+	if err == nil {
+		err = mqc.Publish(ctx, topic, msg)
+	}
+
+	if err != nil {
+		nlog.Logger().Warn().Err(err).Str("topic", topic).Msg("publish event failed")
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/storage"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// BucketEventConsumer 从消息队列消费存储桶事件通知并同步到数据库.
+type BucketEventConsumer struct {
+	mgr    *storage.Manager
+	topic  string
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBucketEventConsumer 创建事件消费者，需要 DB、S3 与 MQ 均已初始化.
+func NewBucketEventConsumer(mgr *storage.Manager, topic string) *BucketEventConsumer {
+	return &BucketEventConsumer{mgr: mgr, topic: topic}
+}
+
+// Start 订阅主题并启动消费协程.
+func (c *BucketEventConsumer) Start() error {
+	ctx, cancel := context.WithCancel(ctxPkg.WithStorageManager(context.Background(), c.mgr))
+
+	ch, err := c.mgr.GetMQClient().Subscribe(ctx, c.topic)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("subscribe %s: %w", c.topic, err)
+	}
+
+	c.cancel = cancel
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+
+		fs := NewFileService(ctx)
+
+		for msg := range ch {
+			c.handle(ctx, fs, msg)
+		}
+	}()
+
+	nlog.Logger().Info().Str("topic", c.topic).Msg("bucket event consumer started")
+
+	return nil
+}
+
+// Stop 取消订阅并等待当前消息处理完成.
+func (c *BucketEventConsumer) Stop() {
+	if c.cancel == nil {
+		return
+	}
+
+	c.cancel()
+	c.wg.Wait()
+}
+
+// handle 处理单条消息：无法解析的消息直接确认丢弃，处理失败时 Nack 以便重投.
+func (c *BucketEventConsumer) handle(ctx context.Context, fs *FileService, msg *message.Message) {
+	l := nlog.Logger()
+
+	events, err := ParseBucketEvents(msg.Payload)
+	if err != nil {
+		l.Warn().Err(err).Str("message_id", msg.UUID).Msg("drop invalid bucket notification")
+		msg.Ack()
+
+		return
+	}
+
+	resp, err := fs.HandleBucketEvents(ctx, events)
+	if err != nil {
+		l.Error().Err(err).Str("message_id", msg.UUID).Msg("handle bucket events failed")
+		msg.Nack()
+
+		return
+	}
+
+	l.Debug().Int("applied", resp.Applied).Int("skipped", resp.Skipped).Msg("bucket events handled")
+	msg.Ack()
+}
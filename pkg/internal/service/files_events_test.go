@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// TestParseBucketEvents 使用录制的 MinIO / AWS 事件通知验证解析与过滤.
+func TestParseBucketEvents(t *testing.T) {
+	tests := []struct {
+		file string
+		want []types.BucketEvent
+	}{
+		{
+			file: "minio_put.json",
+			want: []types.BucketEvent{{
+				Name:      "s3:ObjectCreated:Put",
+				Action:    types.BucketEventCreated,
+				Bucket:    "notevault",
+				ObjectKey: "alice@example.com/2025/09/meeting notes.md",
+				User:      "alice@example.com",
+				Size:      2048,
+				ETag:      "5d41402abc4b2a76b9719d911017c592",
+				EventTime: time.Date(2025, 9, 21, 8, 15, 42, 317000000, time.UTC),
+			}},
+		},
+		{
+			file: "minio_delete.json",
+			want: []types.BucketEvent{{
+				Name:      "s3:ObjectRemoved:Delete",
+				Action:    types.BucketEventRemoved,
+				Bucket:    "notevault",
+				ObjectKey: "alice@example.com/2025/09/meeting notes.md",
+				User:      "alice@example.com",
+				EventTime: time.Date(2025, 9, 21, 9, 2, 10, 4000000, time.UTC),
+			}},
+		},
+		{
+			// 目录标记、系统目录与非创建/删除事件被忽略
+			file: "aws_mixed.json",
+			want: []types.BucketEvent{
+				{
+					Name:      "ObjectCreated:CompleteMultipartUpload",
+					Action:    types.BucketEventCreated,
+					Bucket:    "notevault",
+					ObjectKey: "bob@example.com/2025/09/video.mp4",
+					User:      "bob@example.com",
+					Size:      73400320,
+					ETag:      "d41d8cd98f00b204e9800998ecf8427e-14",
+					VersionID: "096fKKXTRTtl3on89fVO.nfljtsv6qko",
+					EventTime: time.Date(2025, 9, 21, 10, 0, 0, 0, time.UTC),
+				},
+				{
+					Name:      "ObjectRemoved:DeleteMarkerCreated",
+					Action:    types.BucketEventRemoved,
+					Bucket:    "notevault",
+					ObjectKey: "bob@example.com/2025/09/old.txt",
+					User:      "bob@example.com",
+					VersionID: "Xb5lLq6jK8xjF0ZsP3bWnQ",
+					EventTime: time.Date(2025, 9, 21, 10, 0, 1, 0, time.UTC),
+				},
+			},
+		},
+		{
+			file: "aws_test_event.json",
+			want: []types.BucketEvent{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatalf("read testdata: %v", err)
+			}
+
+			got, err := service.ParseBucketEvents(data)
+			if err != nil {
+				t.Fatalf("ParseBucketEvents() error = %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseBucketEvents() got %d events, want %d: %+v", len(got), len(tt.want), got)
+			}
+
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("event[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestParseBucketEventsInvalid 非法 JSON 返回错误.
+func TestParseBucketEventsInvalid(t *testing.T) {
+	if _, err := service.ParseBucketEvents([]byte("not json")); err == nil {
+		t.Fatal("ParseBucketEvents() expected error for invalid payload")
+	}
+}
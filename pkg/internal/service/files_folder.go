@@ -64,7 +64,7 @@ func (fs *FileService) RenameFolder(ctx context.Context, user string, folderID s
 
 	// 查找文件夹的当前路径
 	// 注意：在实际项目中，这应该从数据库中查询，这里通过扫描S3对象来模拟
-	oldPath, oldName, err := findFolderPath(ctx, fs.s3Client, bucket, user, folderID)
+	parentPath, oldName, err := findFolderPath(ctx, fs.s3Client, bucket, user, folderID)
 	if err != nil {
 		return &types.RenameFolderResponse{
 			FolderID: folderID,
@@ -73,41 +73,41 @@ func (fs *FileService) RenameFolder(ctx context.Context, user string, folderID s
 		}, err
 	}
 
-	// 构建新的路径
-	var newPath string
-	if oldPath == "" {
-		// 根级文件夹
-		newPath = req.NewName
-	} else if strings.Contains(oldPath, "/") {
-		// 如果有父路径，替换最后一部分
-		parts := strings.Split(oldPath, "/")
-		parts[len(parts)-1] = req.NewName
-		newPath = strings.Join(parts, "/")
-	} else {
-		// 单级文件夹
-		newPath = req.NewName
-	}
-
 	// 如果新旧名称相同，返回成功
 	if oldName == req.NewName {
 		return &types.RenameFolderResponse{
 			FolderID:  folderID,
 			OldName:   oldName,
 			NewName:   req.NewName,
-			Path:      oldPath,
+			Path:      parentPath,
 			UpdatedAt: time.Now().UTC().Format(time.RFC3339),
 			Success:   true,
 		}, nil
 	}
 
-	// 执行重命名操作
-	err = renameFolderObjects(ctx, fs.s3Client, bucket, user, oldPath, newPath)
+	// 构建新旧完整路径（相对用户目录），只替换最后一级名称
+	oldPath, newPath := oldName, req.NewName
+	if parentPath != "" {
+		oldPath = parentPath + "/" + oldName
+		newPath = parentPath + "/" + req.NewName
+	}
+
+	// 文件夹下有受保护的文件时拒绝重命名；否则文件夹下的对象可能被路由到不同 bucket，逐个处理
+	err = fs.checkHoldPrefix(ctx, user, user+"/"+oldPath+"/", holdActionRenameFolder)
+	if err == nil {
+		err = fs.forEachBucket(func(b string) error {
+			return renameFolderObjects(ctx, fs.s3Client, b, user, oldPath, newPath, func(oldKey, newKey string, removed bool) {
+				fs.renameFolderRecord(ctx, user, b, oldKey, newKey, removed)
+			})
+		})
+	}
+
 	if err != nil {
 		return &types.RenameFolderResponse{
 			FolderID: folderID,
 			OldName:  oldName,
 			NewName:  req.NewName,
-			Path:     oldPath,
+			Path:     parentPath,
 			Success:  false,
 			Error:    err.Error(),
 		}, err
@@ -117,12 +117,26 @@ func (fs *FileService) RenameFolder(ctx context.Context, user string, folderID s
 		FolderID:  folderID,
 		OldName:   oldName,
 		NewName:   req.NewName,
-		Path:      newPath,
+		Path:      parentPath,
 		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
 		Success:   true,
 	}, nil
 }
 
+// renameFolderRecord 文件夹下的对象改名后迁移文件记录并发布 stored/deleted 事件，文件夹标记对象不做记录.
+func (fs *FileService) renameFolderRecord(ctx context.Context, user, bucket, oldKey, newKey string, removed bool) {
+	if strings.HasSuffix(newKey, "/") {
+		return
+	}
+
+	meta := fs.fileRecordMetadata(ctx, user, oldKey)
+	if removed {
+		fs.forgetObject(ctx, user, bucket, oldKey)
+	}
+
+	fs.recordObject(ctx, user, bucket, newKey, lastPathComponent(newKey), moveSource, "", meta)
+}
+
 // DeleteFolder 删除文件夹.
 func (fs *FileService) DeleteFolder(ctx context.Context, user string, folderID string, req *types.DeleteFolderRequest) (*types.DeleteFolderResponse, error) {
 	bucket, err := fs.defaultBucket()
@@ -148,8 +162,33 @@ func (fs *FileService) DeleteFolder(ctx context.Context, user string, folderID s
 		folderPrefix = user + "/" + folderName + "/"
 	}
 
-	// 执行删除操作
-	deletedFiles, err := deleteFolderObjects(ctx, fs.s3Client, bucket, folderPrefix, req.Recursive)
+	// 执行删除操作；非递归删除时先确认其他 bucket 中没有该文件夹的内容，默认 bucket 由 deleteFolderObjects 检查
+	// 文件夹下有受保护的文件时拒绝整个删除操作
+	var deletedFiles int
+
+	err = fs.checkHoldPrefix(ctx, user, folderPrefix, holdActionDeleteFolder)
+	if err == nil {
+		err = fs.forEachBucket(func(b string) error {
+			if req.Recursive || b == bucket {
+				return nil
+			}
+
+			return checkFolderEmpty(ctx, fs.s3Client, b, folderPrefix)
+		})
+	}
+
+	if err == nil {
+		err = fs.forEachBucket(func(b string) error {
+			n, err := deleteFolderObjects(ctx, fs.s3Client, b, folderPrefix, req.Recursive)
+			deletedFiles += n
+
+			return err
+		})
+	}
+
+	RecordAudit(ctx, user, types.AuditActionFolderDelete, []string{folderPrefix}, err,
+		fmt.Sprintf("recursive=%t deleted=%d", req.Recursive, deletedFiles))
+
 	if err != nil {
 		return &types.DeleteFolderResponse{
 			FolderID: folderID,
@@ -168,3 +207,53 @@ func (fs *FileService) DeleteFolder(ctx context.Context, user string, folderID s
 		Success:      true,
 	}, nil
 }
+
+// ListFolderObjects 列出文件夹（含子文件夹）下的全部文件对象，返回文件夹的完整路径（相对用户目录）.
+func (fs *FileService) ListFolderObjects(ctx context.Context, user, folderID string) (string, []types.ObjectInfo, error) {
+	bucket, err := fs.defaultBucket()
+	if err != nil {
+		return "", nil, err
+	}
+
+	parent, name, err := findFolderPath(ctx, fs.s3Client, bucket, user, folderID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fullPath := name
+	if parent != "" {
+		fullPath = parent + "/" + name
+	}
+
+	prefix := user + "/" + fullPath + "/"
+
+	var objects []types.ObjectInfo
+
+	err = fs.forEachBucket(func(b string) error {
+		for obj := range fs.s3Client.ListObjects(ctx, b, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+			if obj.Err != nil {
+				return fmt.Errorf("list objects: %w", obj.Err)
+			}
+
+			// 跳过文件夹标记对象
+			if strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
+
+			objects = append(objects, types.ObjectInfo{
+				ObjectKey:    obj.Key,
+				Size:         obj.Size,
+				ETag:         strings.Trim(obj.ETag, "\""),
+				LastModified: obj.LastModified.UTC().Format(time.RFC3339),
+				Bucket:       b,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fullPath, objects, nil
+}
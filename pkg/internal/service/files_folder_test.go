@@ -0,0 +1,107 @@
+package service_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// putObject 直接写入对象（用于在文件夹下放置文件）.
+func putObject(t *testing.T, ctx context.Context, key, body string) {
+	t.Helper()
+
+	bucket := configs.GetConfig().S3.Buckets[0]
+	if _, err := ctxPkg.GetS3Client(ctx).PutObject(ctx, bucket, key, strings.NewReader(body), int64(len(body)),
+		minio.PutObjectOptions{}); err != nil {
+		t.Fatalf("put %s: %v", key, err)
+	}
+}
+
+func folderKeys(t *testing.T, ctx context.Context, folderID string) []string {
+	t.Helper()
+
+	_, objects, err := service.NewFileService(ctx).ListFolderObjects(ctx, testUser, folderID)
+	if err != nil {
+		t.Fatalf("list folder: %v", err)
+	}
+
+	keys := make([]string, 0, len(objects))
+	for _, o := range objects {
+		keys = append(keys, strings.TrimPrefix(o.ObjectKey, testUser+"/"))
+	}
+
+	return keys
+}
+
+// TestFolderLifecycle 验证文件夹的创建、列举、重命名（含嵌套文件夹）与删除.
+func TestFolderLifecycle(t *testing.T) {
+	ctx := newTestContext(t, false)
+	svc := service.NewFileService(ctx)
+
+	docs, err := svc.CreateFolder(ctx, testUser, &types.CreateFolderRequest{Name: "docs"})
+	if err != nil || !docs.Success {
+		t.Fatalf("create docs: %+v, %v", docs, err)
+	}
+
+	sub, err := svc.CreateFolder(ctx, testUser, &types.CreateFolderRequest{Name: "drafts", Path: "docs"})
+	if err != nil || sub.FullPath != "docs/drafts" {
+		t.Fatalf("create docs/drafts: %+v, %v", sub, err)
+	}
+
+	putObject(t, ctx, testUser+"/docs/a.md", "a")
+	putObject(t, ctx, testUser+"/docs/drafts/b.md", "b")
+	putObject(t, ctx, testUser+"/other.txt", "c")
+
+	if got, want := folderKeys(t, ctx, docs.FolderID), []string{"docs/a.md", "docs/drafts/b.md"}; !slices.Equal(got, want) {
+		t.Fatalf("docs = %v, want %v", got, want)
+	}
+
+	// 重命名嵌套文件夹只影响该文件夹
+	renamed, err := svc.RenameFolder(ctx, testUser, sub.FolderID, &types.RenameFolderRequest{NewName: "final"})
+	if err != nil || !renamed.Success || renamed.Path != "docs" {
+		t.Fatalf("rename: %+v, %v", renamed, err)
+	}
+
+	if got, want := folderKeys(t, ctx, docs.FolderID), []string{"docs/a.md", "docs/final/b.md"}; !slices.Equal(got, want) {
+		t.Fatalf("docs after rename = %v, want %v", got, want)
+	}
+
+	deleteTests := []struct {
+		name      string
+		recursive bool
+		wantErr   bool
+		wantCount int
+	}{
+		{name: "not empty", recursive: false, wantErr: true},
+		{name: "recursive", recursive: true, wantCount: 4},
+	}
+
+	for _, tt := range deleteTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.DeleteFolder(ctx, testUser, docs.FolderID, &types.DeleteFolderRequest{Recursive: tt.recursive})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("delete: %+v, %v", resp, err)
+			}
+
+			if !tt.wantErr && resp.DeletedFiles != tt.wantCount {
+				t.Fatalf("deleted %d, want %d", resp.DeletedFiles, tt.wantCount)
+			}
+		})
+	}
+
+	if _, _, err := svc.ListFolderObjects(ctx, testUser, docs.FolderID); err == nil {
+		t.Fatal("folder still exists after delete")
+	}
+
+	if _, err := svc.StatObject(ctx, testUser, testUser+"/other.txt"); err != nil {
+		t.Fatalf("object outside folder removed: %v", err)
+	}
+}
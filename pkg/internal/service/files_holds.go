@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// 保护审计记录中的动作：设置保护，以及因保护被拒绝的操作.
+const (
+	holdActionSet            = "set_hold"
+	holdActionDelete         = "delete"
+	holdActionMove           = "move"
+	holdActionOverwrite      = "overwrite"
+	holdActionUpdateMetadata = "update_metadata"
+	holdActionTrash          = "trash"
+	holdActionPurgeTrash     = "purge_trash"
+	holdActionDeleteVersion  = "delete_version"
+	holdActionRestoreVersion = "restore_version"
+	holdActionDeleteFolder   = "delete_folder"
+	holdActionRenameFolder   = "rename_folder"
+)
+
+// activeHoldCondition 生效中保护的查询条件，参数依次为 true 与当前时间（UTC）.
+const activeHoldCondition = "(legal_hold = ? OR retain_until > ?)"
+
+var (
+	// ErrObjectHeld 对象处于法律保留或保留期内，拒绝删除、移动与修改.
+	ErrObjectHeld = errors.New("object is under legal hold or retention")
+	// ErrInvalidHold 保护参数不合法（保留期已过、缩短保留期、超过最大天数等）.
+	ErrInvalidHold = errors.New("invalid hold")
+)
+
+// SetObjectHold 设置文件的法律保留或保留截止时间。保留期只能延长；
+// bucket 启用了 S3 对象锁定时同步设置到对象的当前版本，否则由 notevault 在删除、移动、修改前拦截.
+func (fs *FileService) SetObjectHold(ctx context.Context, user string, req *types.SetObjectHoldRequest) (_ *types.ObjectHoldInfo, err error) {
+	var detail string
+	defer func() { RecordAudit(ctx, user, types.AuditActionHoldSet, []string{req.ObjectKey}, err, detail) }()
+
+	if !strings.HasPrefix(req.ObjectKey, user+"/") {
+		return nil, fmt.Errorf("%w: object does not belong to user", ErrObjectAccessDenied)
+	}
+
+	bucket, err := fs.objectBucket(ctx, req.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := fs.s3Client.StatObject(ctx, bucket, req.ObjectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if isNoSuchKey(err) {
+			return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, req.ObjectKey)
+		}
+
+		return nil, fmt.Errorf("stat object: %w", err)
+	}
+
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	var hold model.ObjectHold
+	if err := dbx.Where("object_key = ?", req.ObjectKey).Limit(1).Find(&hold).Error; err != nil {
+		return nil, fmt.Errorf("load hold: %w", err)
+	}
+
+	now := time.Now().UTC()
+
+	until, err := holdRetainUntil(req, &hold, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.LegalHold == nil && until == nil {
+		return nil, fmt.Errorf("%w: legal_hold, retain_until or retain_days is required", ErrInvalidHold)
+	}
+
+	hold.User = user
+	hold.Bucket = bucket
+	hold.ObjectKey = req.ObjectKey
+	hold.SetBy = user
+
+	if req.LegalHold != nil {
+		hold.LegalHold = *req.LegalHold
+	}
+
+	if until != nil {
+		hold.RetainUntil = until
+	}
+
+	if req.Reason != "" {
+		hold.Reason = req.Reason
+	}
+
+	locked, err := fs.applyObjectLock(ctx, bucket, req.ObjectKey, info.VersionID, &hold)
+	if err != nil {
+		return nil, fmt.Errorf("apply object lock: %w", err)
+	}
+
+	hold.ObjectLock = locked
+
+	if err := dbx.Save(&hold).Error; err != nil {
+		return nil, fmt.Errorf("save hold: %w", err)
+	}
+
+	detail = holdDetail(&hold)
+	fs.recordHoldEvent(ctx, &hold, user, holdActionSet, types.HoldOutcomeApplied, detail)
+
+	result := toHoldInfo(&hold, now)
+
+	return &result, nil
+}
+
+// ListObjectHolds 列出当前用户文件的保护状态.
+func (fs *FileService) ListObjectHolds(ctx context.Context, user string,
+	req *types.ListObjectHoldsRequest) (*types.ListObjectHoldsResponse, error) {
+	now := time.Now().UTC()
+
+	q := fs.dbClient.GetDB().WithContext(ctx).Model(&model.ObjectHold{}).Where("user = ?", user)
+	if req.Prefix != "" {
+		q = q.Where("object_key LIKE ?", user+"/"+strings.TrimPrefix(req.Prefix, "/")+"%")
+	}
+
+	if req.Active {
+		q = q.Where(activeHoldCondition, true, now)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("count holds: %w", err)
+	}
+
+	page, size := normalizeJobPage(req.Page, req.PageSize)
+
+	var rows []model.ObjectHold
+	if err := q.Order("object_key").Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list holds: %w", err)
+	}
+
+	items := make([]types.ObjectHoldInfo, 0, len(rows))
+	for i := range rows {
+		items = append(items, toHoldInfo(&rows[i], now))
+	}
+
+	return &types.ListObjectHoldsResponse{Total: int(total), Page: page, Size: size, Items: items}, nil
+}
+
+// ListObjectHoldEvents 列出当前用户文件的保护审计记录（保护变更与被拒绝的操作），按时间倒序.
+func (fs *FileService) ListObjectHoldEvents(ctx context.Context, user string,
+	req *types.ListObjectHoldEventsRequest) (*types.ListObjectHoldEventsResponse, error) {
+	q := fs.dbClient.GetDB().WithContext(ctx).Model(&model.ObjectHoldEvent{}).Where("user = ?", user)
+	if req.ObjectKey != "" {
+		q = q.Where("object_key = ?", req.ObjectKey)
+	}
+
+	if req.Outcome != "" {
+		q = q.Where("outcome = ?", req.Outcome)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("count hold events: %w", err)
+	}
+
+	page, size := normalizeJobPage(req.Page, req.PageSize)
+
+	var rows []model.ObjectHoldEvent
+	if err := q.Order("id DESC").Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list hold events: %w", err)
+	}
+
+	items := make([]types.ObjectHoldEventInfo, 0, len(rows))
+	for i := range rows {
+		e := &rows[i]
+		items = append(items, types.ObjectHoldEventInfo{
+			ObjectKey: e.ObjectKey,
+			Actor:     e.Actor,
+			Action:    e.Action,
+			Outcome:   e.Outcome,
+			Detail:    e.Detail,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+
+	return &types.ListObjectHoldEventsResponse{Total: int(total), Page: page, Size: size, Items: items}, nil
+}
+
+// checkHold 对象处于生效的保护中时记录被拒绝的操作并返回 ErrObjectHeld.
+func (fs *FileService) checkHold(ctx context.Context, actor, objectKey, action string) error {
+	var hold model.ObjectHold
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("object_key = ?", objectKey).Limit(1).Find(&hold).Error; err != nil {
+		return fmt.Errorf("load hold: %w", err)
+	}
+
+	if hold.ID == 0 || !hold.Active(time.Now().UTC()) {
+		return nil
+	}
+
+	fs.denyHeld(ctx, &hold, actor, action)
+
+	return fmt.Errorf("%w: %s", ErrObjectHeld, objectKey)
+}
+
+// checkHoldPrefix 前缀下存在生效保护的对象时拒绝整个操作（删除、重命名文件夹）.
+func (fs *FileService) checkHoldPrefix(ctx context.Context, actor, prefix, action string) error {
+	var hold model.ObjectHold
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("object_key LIKE ?", prefix+"%").Where(activeHoldCondition, true, time.Now().UTC()).
+		Order("object_key").Limit(1).Find(&hold).Error; err != nil {
+		return fmt.Errorf("load hold: %w", err)
+	}
+
+	// LIKE 中的 '_' / '%' 为通配符，按真实前缀再确认一次
+	if hold.ID == 0 || !strings.HasPrefix(hold.ObjectKey, prefix) {
+		return nil
+	}
+
+	fs.denyHeld(ctx, &hold, actor, action)
+
+	return fmt.Errorf("%w: %s", ErrObjectHeld, hold.ObjectKey)
+}
+
+// heldObjectKeys 返回生效中保护的对象键子查询，供后台任务（生命周期过期、版本清理、回收站清理）排除受保护的对象.
+func (fs *FileService) heldObjectKeys(ctx context.Context) *gorm.DB {
+	return fs.dbClient.GetDB().WithContext(ctx).Model(&model.ObjectHold{}).
+		Select("object_key").Where(activeHoldCondition, true, time.Now().UTC())
+}
+
+// denyHeld 记录因保护被拒绝的操作.
+func (fs *FileService) denyHeld(ctx context.Context, hold *model.ObjectHold, actor, action string) {
+	nlog.Logger().Warn().Str("object_key", hold.ObjectKey).Str("actor", actor).Str("action", action).
+		Msg("operation denied: object is held")
+
+	fs.recordHoldEvent(ctx, hold, actor, action, types.HoldOutcomeDenied, holdDetail(hold))
+}
+
+// recordHoldEvent 写入保护审计记录，失败只记录日志.
+func (fs *FileService) recordHoldEvent(ctx context.Context, hold *model.ObjectHold, actor, action, outcome, detail string) {
+	event := &model.ObjectHoldEvent{
+		User:      hold.User,
+		ObjectKey: hold.ObjectKey,
+		Actor:     actor,
+		Action:    action,
+		Outcome:   outcome,
+		Detail:    detail,
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Create(event).Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", hold.ObjectKey).Msg("record hold event failed")
+	}
+}
+
+// applyObjectLock bucket 启用了 S3 对象锁定时将法律保留与保留期设置到对象版本，返回是否已同步.
+func (fs *FileService) applyObjectLock(ctx context.Context, bucket, objectKey, versionID string, hold *model.ObjectHold) (bool, error) {
+	cfg := configs.GetConfig().Holds
+	if !cfg.ObjectLock {
+		return false, nil
+	}
+
+	enabled, err := fs.s3Client.ObjectLockEnabled(ctx, bucket)
+	if err != nil || !enabled {
+		return false, err
+	}
+
+	status := minio.LegalHoldDisabled
+	if hold.LegalHold {
+		status = minio.LegalHoldEnabled
+	}
+
+	if err := fs.s3Client.PutObjectLegalHold(ctx, bucket, objectKey, minio.PutObjectLegalHoldOptions{
+		VersionID: versionID,
+		Status:    &status,
+	}); err != nil {
+		return false, fmt.Errorf("put legal hold: %w", err)
+	}
+
+	if hold.RetainUntil != nil && hold.RetainUntil.After(time.Now().UTC()) {
+		mode := minio.RetentionMode(strings.ToUpper(cfg.RetentionMode))
+		if err := fs.s3Client.PutObjectRetention(ctx, bucket, objectKey, minio.PutObjectRetentionOptions{
+			Mode:            &mode,
+			RetainUntilDate: hold.RetainUntil,
+			VersionID:       versionID,
+		}); err != nil {
+			return false, fmt.Errorf("put retention: %w", err)
+		}
+	}
+
+	return true, nil
+}
+
+// holdRetainUntil 计算请求的保留截止时间：不能早于当前时间、超过最大天数或早于已生效的截止时间.
+func holdRetainUntil(req *types.SetObjectHoldRequest, hold *model.ObjectHold, now time.Time) (*time.Time, error) {
+	var until time.Time
+
+	switch {
+	case req.RetainDays > 0:
+		until = now.AddDate(0, 0, req.RetainDays)
+	case req.RetainUntil != nil:
+		until = req.RetainUntil.UTC()
+	default:
+		return nil, nil
+	}
+
+	if !until.After(now) {
+		return nil, fmt.Errorf("%w: retain_until must be in the future", ErrInvalidHold)
+	}
+
+	if maxDays := configs.GetConfig().Holds.MaxRetentionDays; maxDays > 0 && until.After(now.AddDate(0, 0, maxDays)) {
+		return nil, fmt.Errorf("%w: retention exceeds %d days", ErrInvalidHold, maxDays)
+	}
+
+	if hold.RetainUntil != nil && until.Before(*hold.RetainUntil) && hold.RetainUntil.After(now) {
+		return nil, fmt.Errorf("%w: retention can only be extended (current %s)", ErrInvalidHold,
+			hold.RetainUntil.Format(time.RFC3339))
+	}
+
+	return &until, nil
+}
+
+func holdDetail(hold *model.ObjectHold) string {
+	detail := fmt.Sprintf("legal_hold=%t", hold.LegalHold)
+	if hold.RetainUntil != nil {
+		detail += " retain_until=" + hold.RetainUntil.Format(time.RFC3339)
+	}
+
+	return detail
+}
+
+func toHoldInfo(hold *model.ObjectHold, now time.Time) types.ObjectHoldInfo {
+	return types.ObjectHoldInfo{
+		ObjectKey:   hold.ObjectKey,
+		Bucket:      hold.Bucket,
+		LegalHold:   hold.LegalHold,
+		RetainUntil: hold.RetainUntil,
+		Reason:      hold.Reason,
+		SetBy:       hold.SetBy,
+		ObjectLock:  hold.ObjectLock,
+		Active:      hold.Active(now),
+		UpdatedAt:   hold.UpdatedAt,
+	}
+}
@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// TestObjectHolds 验证法律保留与保留期拦截删除、移动、修改与覆盖，并记录被拒绝的操作.
+func TestObjectHolds(t *testing.T) {
+	ctx := newTestContext(t, true)
+	svc := service.NewFileService(ctx)
+
+	held := uploadText(t, ctx, "contract.txt", "signed")
+	free := uploadText(t, ctx, "draft.txt", "draft")
+
+	on := true
+	if _, err := svc.SetObjectHold(ctx, testUser, &types.SetObjectHoldRequest{ObjectKey: held, LegalHold: &on}); err != nil {
+		t.Fatalf("set legal hold: %v", err)
+	}
+
+	del, err := svc.DeleteFiles(ctx, testUser, &types.DeleteFilesRequest{ObjectKeys: []string{held}})
+	if err != nil || del.Failed != 1 {
+		t.Fatalf("delete held = %+v, %v", del, err)
+	}
+
+	moved, err := svc.MoveFiles(ctx, testUser, &types.MoveFilesRequest{
+		Items: []types.MoveFileItem{{SourceKey: held, DestinationKey: held + ".moved"}},
+	})
+	if err != nil || moved.Failed != 1 {
+		t.Fatalf("move held = %+v, %v", moved, err)
+	}
+
+	// 受保护的目标不能被移动覆盖
+	moved, err = svc.MoveFiles(ctx, testUser, &types.MoveFilesRequest{
+		Items: []types.MoveFileItem{{SourceKey: free, DestinationKey: held}},
+	})
+	if err != nil || moved.Failed != 1 {
+		t.Fatalf("overwrite held by move = %+v, %v", moved, err)
+	}
+
+	meta, err := svc.UpdateFilesMetadata(ctx, testUser, &types.UpdateFilesMetadataRequest{
+		Items: []types.UpdateFileMetadataItem{{ObjectKey: held, ContentType: "text/markdown"}},
+	})
+	if err != nil || meta.Failed != 1 {
+		t.Fatalf("update metadata of held = %+v, %v", meta, err)
+	}
+
+	trashed, err := svc.TrashFiles(ctx, testUser, []string{held}, types.TrashReasonUser)
+	if err != nil || trashed.Failed != 1 {
+		t.Fatalf("trash held = %+v, %v", trashed, err)
+	}
+
+	if _, err := svc.DeleteFileVersion(ctx, testUser, held, "v1"); !errors.Is(err, service.ErrObjectHeld) {
+		t.Fatalf("delete version of held: %v", err)
+	}
+
+	if _, err := svc.UploadSingleFile(ctx, testUser, "contract.txt", strings.NewReader("forged"), 6, nil); !errors.Is(err, service.ErrObjectHeld) {
+		t.Fatalf("overwrite held by upload: %v", err)
+	}
+
+	// 创建新版本会替换元数据，同样被拦截
+	if _, err := svc.CreateFileVersion(ctx, testUser, &types.CreateFileVersionRequest{
+		ObjectKey: held, ContentType: "text/markdown",
+	}); !errors.Is(err, service.ErrObjectHeld) {
+		t.Fatalf("create version of held: %v", err)
+	}
+
+	events, err := svc.ListObjectHoldEvents(ctx, testUser, &types.ListObjectHoldEventsRequest{Outcome: types.HoldOutcomeDenied})
+	if err != nil || events.Total != 8 {
+		t.Fatalf("denied events = %+v, %v", events, err)
+	}
+
+	// 保留期只能延长；解除法律保留后保留期仍然生效
+	if _, err := svc.SetObjectHold(ctx, testUser, &types.SetObjectHoldRequest{ObjectKey: held, RetainDays: 30}); err != nil {
+		t.Fatalf("set retention: %v", err)
+	}
+
+	if _, err := svc.SetObjectHold(ctx, testUser, &types.SetObjectHoldRequest{ObjectKey: held, RetainDays: 1}); !errors.Is(err, service.ErrInvalidHold) {
+		t.Fatalf("shorten retention: %v", err)
+	}
+
+	off := false
+	info, err := svc.SetObjectHold(ctx, testUser, &types.SetObjectHoldRequest{ObjectKey: held, LegalHold: &off})
+	if err != nil || info.LegalHold || !info.Active || info.RetainUntil == nil {
+		t.Fatalf("release legal hold = %+v, %v", info, err)
+	}
+
+	if del, err = svc.DeleteFiles(ctx, testUser, &types.DeleteFilesRequest{ObjectKeys: []string{held}}); err != nil || del.Failed != 1 {
+		t.Fatalf("delete retained = %+v, %v", del, err)
+	}
+
+	// 解除法律保留后可以删除
+	if _, err := svc.SetObjectHold(ctx, testUser, &types.SetObjectHoldRequest{ObjectKey: free, LegalHold: &on}); err != nil {
+		t.Fatalf("hold free: %v", err)
+	}
+
+	if _, err := svc.SetObjectHold(ctx, testUser, &types.SetObjectHoldRequest{ObjectKey: free, LegalHold: &off}); err != nil {
+		t.Fatalf("release free: %v", err)
+	}
+
+	if del, err = svc.DeleteFiles(ctx, testUser, &types.DeleteFilesRequest{ObjectKeys: []string{free}}); err != nil || del.Success != 1 {
+		t.Fatalf("delete released = %+v, %v", del, err)
+	}
+
+	holds, err := svc.ListObjectHolds(ctx, testUser, &types.ListObjectHoldsRequest{Active: true})
+	if err != nil || holds.Total != 1 || holds.Items[0].ObjectKey != held {
+		t.Fatalf("active holds = %+v, %v", holds, err)
+	}
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/metrics"
+	"github.com/yeisme/notevault/pkg/queue"
+)
+
+const (
+	// lifecycleBatchSize 生命周期任务每批加载的文件记录数.
+	lifecycleBatchSize = 500
+	// maxLifecycleActions 响应中最多列出的动作数，超出部分只计入统计.
+	maxLifecycleActions = 1000
+	// defaultStorageClass 对象未返回存储类型时视为 STANDARD.
+	defaultStorageClass = "STANDARD"
+)
+
+// 生命周期指标的结果标签.
+const (
+	lifecycleResultOK     = "ok"
+	lifecycleResultFailed = "failed"
+	lifecycleResultDryRun = "dry_run"
+)
+
+// RunLifecycle 执行一次对象生命周期：expiry_days 到期的文件移入回收站，按迁移规则迁移长期未修改的文件，
+// 中止超时未完成的分片上传；user 为空时处理所有用户。dryRun 为 true 时只返回将执行的动作.
+func (fs *FileService) RunLifecycle(ctx context.Context, user string, dryRun bool) (*types.LifecycleRunResponse, error) {
+	cfg := configs.GetConfig().Lifecycle
+	resp := &types.LifecycleRunResponse{DryRun: dryRun, Actions: []types.LifecycleActionResult{}}
+	now := time.Now().UTC()
+
+	if cfg.Expire {
+		if err := fs.expireFiles(ctx, user, now, dryRun, resp); err != nil {
+			return resp, err
+		}
+	}
+
+	if len(cfg.Transitions) > 0 {
+		if err := fs.transitionFiles(ctx, user, cfg.Transitions, now, dryRun, resp); err != nil {
+			return resp, err
+		}
+	}
+
+	if cfg.AbortIncompleteUploadDays > 0 {
+		if err := fs.abortIncompleteUploads(ctx, user, now.Add(-cfg.GetAbortIncompleteUploadAge()), dryRun, resp); err != nil {
+			return resp, err
+		}
+	}
+
+	return resp, nil
+}
+
+// expireFiles 将过期时间已到的文件移入回收站；对象已不存在时只清理文件记录.
+func (fs *FileService) expireFiles(ctx context.Context, user string, now time.Time, dryRun bool,
+	resp *types.LifecycleRunResponse) error {
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	// 受保护的文件保留到保护解除后再过期
+	q := dbx.Where("expire_at IS NOT NULL AND expire_at <= ? AND object_key NOT IN (?)", now, fs.heldObjectKeys(ctx))
+	if user != "" {
+		q = q.Where("user = ?", user)
+	}
+
+	var rows []model.Files
+
+	res := q.Order("id").FindInBatches(&rows, lifecycleBatchSize, func(_ *gorm.DB, _ int) error {
+		for i := range rows {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			fs.expireFile(ctx, &rows[i], dryRun, resp)
+		}
+
+		return nil
+	})
+	if res.Error != nil {
+		return fmt.Errorf("query expired files: %w", res.Error)
+	}
+
+	return nil
+}
+
+// expireFile 将单个到期文件移入回收站并清除记录中的过期时间（从回收站恢复后不再过期）.
+func (fs *FileService) expireFile(ctx context.Context, rec *model.Files, dryRun bool, resp *types.LifecycleRunResponse) {
+	result := types.LifecycleActionResult{
+		Action:    types.LifecycleActionExpire,
+		ObjectKey: rec.ObjectKey,
+		Bucket:    rec.Bucket,
+		Size:      rec.Size,
+	}
+
+	if dryRun {
+		fs.recordLifecycleAction(resp, &result, true)
+		return
+	}
+
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	item, err := fs.trashObject(ctx, rec.User, rec.ObjectKey, types.TrashReasonExpired)
+	switch {
+	case errors.Is(err, ErrObjectNotFound):
+		// 对象已被外部删除，只清理过期的文件记录
+		if err := dbx.Delete(rec).Error; err != nil {
+			result.Error = err.Error()
+		}
+	case err != nil:
+		result.Error = err.Error()
+	default:
+		result.Bucket = item.Bucket
+	}
+
+	if result.Error == "" {
+		if err := dbx.Unscoped().Model(&model.Files{}).Where("user = ? AND object_key = ?", rec.User, rec.ObjectKey).
+			Update("expire_at", nil).Error; err != nil {
+			nlog.Logger().Warn().Err(err).Str("object_key", rec.ObjectKey).Msg("clear expire_at failed")
+		}
+
+		publishEvent(ctx, fs.mqClient, queue.TopicObjectExpired, queue.ObjectLifecyclePayload{
+			Object:     queue.ObjectRef{Bucket: result.Bucket, ObjectKey: rec.ObjectKey, ETag: rec.ETag, Size: rec.Size},
+			Action:     types.LifecycleActionExpire,
+			FromBucket: result.Bucket,
+		})
+	}
+
+	fs.recordLifecycleAction(resp, &result, false)
+}
+
+// transitionFiles 按迁移规则迁移最后修改时间足够久的文件；规则按顺序匹配，每个文件最多应用一条规则.
+func (fs *FileService) transitionFiles(ctx context.Context, user string, rules []configs.LifecycleTransition,
+	now time.Time, dryRun bool, resp *types.LifecycleRunResponse) error {
+	def, err := fs.defaultBucket()
+	if err != nil {
+		return err
+	}
+
+	buckets := fs.s3Client.GetConfig().Buckets
+	active := make([]configs.LifecycleTransition, 0, len(rules))
+	minAfter := time.Duration(-1)
+
+	for _, r := range rules {
+		// 未在 buckets 中声明的 bucket 不会在启动时创建，忽略该规则
+		if r.TargetBucket != "" && !slices.Contains(buckets, r.TargetBucket) {
+			nlog.Logger().Warn().Str("rule", r.Name).Str("bucket", r.TargetBucket).
+				Msg("lifecycle rule targets unknown bucket, skipped")
+
+			continue
+		}
+
+		active = append(active, r)
+
+		if minAfter < 0 || r.GetAfter() < minAfter {
+			minAfter = r.GetAfter()
+		}
+	}
+
+	if len(active) == 0 {
+		return nil
+	}
+
+	// 受保护的文件不迁移：迁移会改写或移走对象
+	q := fs.dbClient.GetDB().WithContext(ctx).Where("last_modified <= ? AND object_key NOT IN (?)", now.Add(-minAfter), fs.heldObjectKeys(ctx))
+	if user != "" {
+		q = q.Where("user = ?", user)
+	}
+
+	var rows []model.Files
+
+	res := q.Order("id").FindInBatches(&rows, lifecycleBatchSize, func(_ *gorm.DB, _ int) error {
+		for i := range rows {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			rec := &rows[i]
+			if rec.Bucket == "" {
+				rec.Bucket = def
+			}
+
+			// 已到期的文件由过期动作处理
+			if rec.ExpireAt != nil && !rec.ExpireAt.After(now) {
+				continue
+			}
+
+			if rule := matchTransition(active, rec, now); rule != nil {
+				fs.transitionFile(ctx, rec, rule, dryRun, resp)
+			}
+		}
+
+		return nil
+	})
+	if res.Error != nil {
+		return fmt.Errorf("query files for transition: %w", res.Error)
+	}
+
+	return nil
+}
+
+// transitionFile 迁移单个文件并记录结果.
+func (fs *FileService) transitionFile(ctx context.Context, rec *model.Files, rule *configs.LifecycleTransition,
+	dryRun bool, resp *types.LifecycleRunResponse) {
+	result := types.LifecycleActionResult{
+		Action:       types.LifecycleActionTransition,
+		Rule:         rule.Name,
+		ObjectKey:    rec.ObjectKey,
+		Bucket:       rec.Bucket,
+		StorageClass: rule.StorageClass,
+		Size:         rec.Size,
+	}
+
+	if rule.TargetBucket != rec.Bucket {
+		result.TargetBucket = rule.TargetBucket
+	}
+
+	if dryRun {
+		fs.recordLifecycleAction(resp, &result, true)
+		return
+	}
+
+	info, class, err := fs.transitionObject(ctx, rec, rule)
+	if err != nil {
+		result.Error = err.Error()
+		nlog.Logger().Warn().Err(err).Str("rule", rule.Name).Str("object_key", rec.ObjectKey).Msg("lifecycle transition failed")
+	} else {
+		publishEvent(ctx, fs.mqClient, queue.TopicObjectTransitioned, queue.ObjectLifecyclePayload{
+			Object: queue.ObjectRef{
+				Bucket:      info.Bucket,
+				ObjectKey:   rec.ObjectKey,
+				VersionID:   info.VersionID,
+				ETag:        strings.Trim(info.ETag, "\""),
+				Size:        info.Size,
+				ContentType: rec.ContentType,
+			},
+			Action:           types.LifecycleActionTransition,
+			Rule:             rule.Name,
+			FromBucket:       rec.Bucket,
+			FromStorageClass: rec.StorageClass,
+			ToStorageClass:   class,
+		})
+	}
+
+	fs.recordLifecycleAction(resp, &result, false)
+}
+
+// transitionObject 将对象复制到目标 bucket（或原位复制）并设置目标存储类型；跨 bucket 时一并移动模拟版本归档，
+// 完成后删除源对象并更新文件与版本记录，返回新对象信息与存储类型。文件记录的 last_modified 保持不变.
+func (fs *FileService) transitionObject(ctx context.Context, rec *model.Files,
+	rule *configs.LifecycleTransition) (minio.UploadInfo, string, error) {
+	src := rec.Bucket
+
+	dst := rule.TargetBucket
+	if dst == "" {
+		dst = src
+	}
+
+	info, err := fs.s3Client.StatObject(ctx, src, rec.ObjectKey, minio.StatObjectOptions{})
+	if err != nil {
+		return minio.UploadInfo{}, "", fmt.Errorf("stat object: %w", err)
+	}
+
+	class := rule.StorageClass
+	if class == "" {
+		// 只迁移 bucket 时保留原存储类型
+		class = info.StorageClass
+	}
+
+	meta := maps.Clone(info.UserMetadata)
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+
+	if class != "" {
+		meta[s3.StorageClassMetaKey] = class
+	}
+
+	ui, err := fs.s3Client.CopyObject(ctx, minio.CopyDestOptions{
+		Bucket:          dst,
+		Object:          rec.ObjectKey,
+		ReplaceMetadata: true,
+		UserMetadata:    meta,
+		ContentType:     info.ContentType,
+	}, minio.CopySrcOptions{Bucket: src, Object: rec.ObjectKey, MatchETag: info.ETag})
+	if err != nil {
+		return minio.UploadInfo{}, "", fmt.Errorf("copy object: %w", err)
+	}
+
+	ui.Bucket, ui.Size = dst, info.Size
+
+	if dst != src {
+		if err := fs.moveObjectToBucket(ctx, rec, src, dst); err != nil {
+			return minio.UploadInfo{}, "", err
+		}
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Model(&model.Files{}).Where("id = ?", rec.ID).
+		Updates(map[string]any{"bucket": dst, "storage_class": class, "version_id": ui.VersionID}).Error; err != nil {
+		return minio.UploadInfo{}, "", fmt.Errorf("update file record: %w", err)
+	}
+
+	return ui, class, nil
+}
+
+// moveObjectToBucket 在当前版本已复制到目标 bucket 后移动模拟版本归档并删除源对象.
+// 原生版本化 bucket 中的历史版本无法复制，保留在原 bucket.
+func (fs *FileService) moveObjectToBucket(ctx context.Context, rec *model.Files, src, dst string) error {
+	emulated := fs.emulatedVersioning(ctx, src)
+
+	if emulated {
+		prefix := versionsPrefix + rec.ObjectKey + "/"
+		for obj := range fs.s3Client.ListObjects(ctx, src, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+			if obj.Err != nil {
+				return fmt.Errorf("list archived versions: %w", obj.Err)
+			}
+
+			if err := fs.copyObject(ctx, src, dst, obj.Key, obj.Key); err != nil {
+				return fmt.Errorf("copy archived version %s: %w", obj.Key, err)
+			}
+
+			if err := fs.s3Client.RemoveObject(ctx, src, obj.Key, minio.RemoveObjectOptions{}); err != nil {
+				nlog.Logger().Warn().Err(err).Str("object_key", obj.Key).Msg("remove moved archive failed")
+			}
+		}
+	}
+
+	if err := fs.s3Client.RemoveObject(ctx, src, rec.ObjectKey, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("remove source object: %w", err)
+	}
+
+	if emulated {
+		if err := fs.dbClient.GetDB().WithContext(ctx).Model(&model.FileVersion{}).
+			Where("user = ? AND object_key = ? AND bucket = ?", rec.User, rec.ObjectKey, src).
+			Update("bucket", dst).Error; err != nil {
+			nlog.Logger().Warn().Err(err).Str("object_key", rec.ObjectKey).Msg("update version bucket failed")
+		}
+	}
+
+	return nil
+}
+
+// matchTransition 返回第一条命中的迁移规则，该规则已应用到文件时返回 nil.
+func matchTransition(rules []configs.LifecycleTransition, rec *model.Files, now time.Time) *configs.LifecycleTransition {
+	rel := strings.TrimPrefix(rec.ObjectKey, rec.User+"/")
+	ct := strings.ToLower(rec.ContentType)
+	class := rec.StorageClass
+
+	if class == "" {
+		class = defaultStorageClass
+	}
+
+	for i := range rules {
+		r := &rules[i]
+
+		if now.Sub(rec.LastModified) < r.GetAfter() || !strings.HasPrefix(rel, r.Prefix) {
+			continue
+		}
+
+		if len(r.ContentTypes) > 0 &&
+			!slices.ContainsFunc(r.ContentTypes, func(p string) bool { return strings.HasPrefix(ct, strings.ToLower(p)) }) {
+			continue
+		}
+
+		if len(r.Buckets) > 0 && !slices.Contains(r.Buckets, rec.Bucket) {
+			continue
+		}
+
+		// 第一条命中的规则决定文件的去向，已位于目标 bucket 且存储类型一致时不再匹配后续规则
+		if (r.TargetBucket == "" || r.TargetBucket == rec.Bucket) &&
+			(r.StorageClass == "" || strings.EqualFold(r.StorageClass, class)) {
+			return nil
+		}
+
+		return r
+	}
+
+	return nil
+}
+
+// abortIncompleteUploads 中止所有 bucket 中发起时间早于 before 的分片上传；
+// 同一对象键仍有较新的上传时跳过（S3 按对象键中止全部上传）.
+func (fs *FileService) abortIncompleteUploads(ctx context.Context, user string, before time.Time, dryRun bool,
+	resp *types.LifecycleRunResponse) error {
+	prefix := ""
+	if user != "" {
+		prefix = user + "/"
+	}
+
+	return fs.forEachBucket(func(bucket string) error {
+		var (
+			keys  []string
+			stale = make(map[string]minio.ObjectMultipartInfo)
+			fresh = make(map[string]bool)
+		)
+
+		for u := range fs.s3Client.ListIncompleteUploads(ctx, bucket, prefix, true) {
+			if u.Err != nil {
+				return fmt.Errorf("list incomplete uploads in %s: %w", bucket, u.Err)
+			}
+
+			if !u.Initiated.Before(before) {
+				fresh[u.Key] = true
+				continue
+			}
+
+			if _, ok := stale[u.Key]; !ok {
+				keys = append(keys, u.Key)
+			}
+
+			stale[u.Key] = u
+		}
+
+		for _, key := range keys {
+			if fresh[key] {
+				continue
+			}
+
+			u := stale[key]
+			result := types.LifecycleActionResult{
+				Action:    types.LifecycleActionAbortUpload,
+				ObjectKey: key,
+				Bucket:    bucket,
+				Size:      u.Size,
+			}
+
+			if !dryRun {
+				if err := fs.s3Client.RemoveIncompleteUpload(ctx, bucket, key); err != nil {
+					result.Error = err.Error()
+				} else {
+					publishEvent(ctx, fs.mqClient, queue.TopicObjectUploadAborted, queue.ObjectLifecyclePayload{
+						Object:     queue.ObjectRef{Bucket: bucket, ObjectKey: key, Size: u.Size},
+						Action:     types.LifecycleActionAbortUpload,
+						FromBucket: bucket,
+						UploadID:   u.UploadID,
+					})
+				}
+			}
+
+			fs.recordLifecycleAction(resp, &result, dryRun)
+		}
+
+		return nil
+	})
+}
+
+// recordLifecycleAction 汇总动作结果并更新指标.
+func (fs *FileService) recordLifecycleAction(resp *types.LifecycleRunResponse, result *types.LifecycleActionResult, dryRun bool) {
+	label := lifecycleResultOK
+
+	switch {
+	case result.Error != "":
+		label = lifecycleResultFailed
+		resp.Failed++
+	case result.Action == types.LifecycleActionTransition:
+		resp.Transitioned++
+	case result.Action == types.LifecycleActionExpire:
+		resp.Expired++
+	case result.Action == types.LifecycleActionAbortUpload:
+		resp.Aborted++
+	}
+
+	if dryRun {
+		label = lifecycleResultDryRun
+	}
+
+	metrics.LifecycleActions.WithLabelValues(result.Action, label).Inc()
+
+	if label == lifecycleResultOK {
+		metrics.LifecycleBytes.WithLabelValues(result.Action).Add(float64(result.Size))
+	}
+
+	if len(resp.Actions) < maxLifecycleActions {
+		resp.Actions = append(resp.Actions, *result)
+	}
+}
@@ -0,0 +1,95 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// TestRunLifecycle 验证迁移到冷 bucket、原位修改存储类型与 expiry_days 过期，以及 dry_run 不修改对象.
+func TestRunLifecycle(t *testing.T) {
+	ctx := newTestContext(t, false, "cold")
+	svc := service.NewFileService(ctx)
+
+	cfg := configs.GetConfig()
+	def, cold := cfg.S3.Buckets[0], cfg.S3.Buckets[1]
+	cfg.Lifecycle.Transitions = []configs.LifecycleTransition{
+		{Name: "videos-cold", ContentTypes: []string{"video/"}, AfterDays: 30, TargetBucket: cold},
+		{Name: "ia", AfterDays: 30, StorageClass: "STANDARD_IA"},
+	}
+
+	upload := func(name, contentType string, expiryDays int) string {
+		resp, err := svc.UploadSingleFile(ctx, testUser, name, strings.NewReader(name), int64(len(name)),
+			&types.UploadFileMetadata{ContentType: contentType, ExpiryDays: expiryDays})
+		if err != nil {
+			t.Fatalf("upload %s: %v", name, err)
+		}
+
+		return resp.ObjectKey
+	}
+
+	video := upload("clip.mp4", "video/mp4", 0)
+	doc := upload("notes.txt", "text/plain", 0)
+	tmp := upload("tmp.txt", "text/plain", 1)
+	recent := upload("recent.txt", "text/plain", 0)
+
+	// 模拟长期未修改的文件与已到期的文件
+	dbx := ctxPkg.GetDBClient(ctx).GetDB()
+	old := time.Now().UTC().AddDate(0, 0, -60)
+
+	if err := dbx.Model(&model.Files{}).Where("object_key IN ?", []string{video, doc, tmp}).
+		Update("last_modified", old).Error; err != nil {
+		t.Fatalf("age files: %v", err)
+	}
+
+	if err := dbx.Model(&model.Files{}).Where("object_key = ?", tmp).Update("expire_at", old).Error; err != nil {
+		t.Fatalf("expire file: %v", err)
+	}
+
+	dry, err := svc.RunLifecycle(ctx, testUser, true)
+	if err != nil || dry.Transitioned != 2 || dry.Expired != 1 || dry.Failed != 0 {
+		t.Fatalf("dry run = %+v, %v", dry, err)
+	}
+
+	if info, err := svc.StatObject(ctx, testUser, video); err != nil || info.Bucket != def {
+		t.Fatalf("dry run moved object: %+v, %v", info, err)
+	}
+
+	resp, err := svc.RunLifecycle(ctx, testUser, false)
+	if err != nil || resp.Transitioned != 2 || resp.Expired != 1 || resp.Failed != 0 {
+		t.Fatalf("run = %+v, %v", resp, err)
+	}
+
+	if info, err := svc.StatObject(ctx, testUser, video); err != nil || info.Bucket != cold {
+		t.Fatalf("video = %+v, %v; want bucket %s", info, err, cold)
+	}
+
+	if info, err := svc.StatObject(ctx, testUser, doc); err != nil || info.Bucket != def || info.StorageClass != "STANDARD_IA" {
+		t.Fatalf("doc = %+v, %v; want STANDARD_IA in %s", info, err, def)
+	}
+
+	if info, err := svc.StatObject(ctx, testUser, recent); err != nil || info.StorageClass == "STANDARD_IA" {
+		t.Fatalf("recent file transitioned: %+v, %v", info, err)
+	}
+
+	if _, err := svc.StatObject(ctx, testUser, tmp); err == nil {
+		t.Fatalf("expired object still exists")
+	}
+
+	trash, err := svc.ListTrash(ctx, testUser, &types.ListTrashRequest{Reason: types.TrashReasonExpired})
+	if err != nil || trash.Total != 1 || trash.Items[0].ObjectKey != tmp {
+		t.Fatalf("trash = %+v, %v", trash, err)
+	}
+
+	// 规则已应用的文件不会重复迁移
+	again, err := svc.RunLifecycle(ctx, testUser, false)
+	if err != nil || len(again.Actions) != 0 {
+		t.Fatalf("second run = %+v, %v", again, err)
+	}
+}
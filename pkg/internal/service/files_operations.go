@@ -2,12 +2,22 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/minio/minio-go/v7"
 
+	"github.com/yeisme/notevault/pkg/internal/model"
 	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/queue"
+)
+
+// 复制、移动文件写入目标对象时在 ObjectStoredPayload.Source 中的来源标识.
+const (
+	copySource = "copy"
+	moveSource = "move"
 )
 
 // DeleteFiles 删除文件（支持单个/批量）.
@@ -17,39 +27,19 @@ func (fs *FileService) DeleteFiles(ctx context.Context, user string, req *types.
 	success := 0
 	failed := 0
 
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return nil, err
-	}
-
 	for _, objectKey := range req.ObjectKeys {
-		result := types.DeleteFileResult{
-			ObjectKey: objectKey,
-			Success:   false,
-		}
-
-		// 验证对象键是否属于当前用户
-		if !strings.HasPrefix(objectKey, user+"/") {
-			result.Error = "access denied: object does not belong to user"
-			results = append(results, result)
+		result := fs.deleteFile(ctx, user, objectKey)
+		if result.Success {
+			success++
+		} else {
 			failed++
-
-			continue
 		}
 
-		// 删除对象
-		err := fs.s3Client.RemoveObject(ctx, bucket, objectKey, minio.RemoveObjectOptions{})
-		if err != nil {
-			result.Error = err.Error()
-			results = append(results, result)
-			failed++
-		} else {
-			result.Success = true
-			results = append(results, result)
-			success++
-		}
+		results = append(results, result)
 	}
 
+	RecordAuditBatch(ctx, user, types.AuditActionFileDelete, req.ObjectKeys, failed)
+
 	return &types.DeleteFilesResponse{
 		Results: results,
 		Total:   total,
@@ -66,61 +56,15 @@ func (fs *FileService) UpdateFilesMetadata(ctx context.Context, user string,
 	success := 0
 	failed := 0
 
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return nil, err
-	}
-
 	for _, item := range req.Items {
-		result := types.UpdateFileMetadataResult{
-			ObjectKey: item.ObjectKey,
-			Success:   false,
-		}
-
-		// 验证对象键是否属于当前用户
-		if !strings.HasPrefix(item.ObjectKey, user+"/") {
-			result.Error = "access denied: object does not belong to user"
-			results = append(results, result)
+		result := fs.updateFileMetadata(ctx, user, item)
+		if result.Success {
+			success++
+		} else {
 			failed++
-
-			continue
 		}
 
-		// 准备复制选项
-		copyOpts := minio.CopyDestOptions{
-			Bucket:          bucket,
-			Object:          item.ObjectKey,
-			ReplaceMetadata: true,
-		}
-
-		// 设置元数据
-		if len(item.Tags) > 0 {
-			copyOpts.UserMetadata = make(map[string]string)
-			for k, v := range item.Tags {
-				copyOpts.UserMetadata[k] = v
-			}
-		}
-
-		if item.ContentType != "" {
-			copyOpts.ContentType = item.ContentType
-		}
-
-		// 执行复制操作来更新元数据
-		srcOpts := minio.CopySrcOptions{
-			Bucket: bucket,
-			Object: item.ObjectKey,
-		}
-
-		_, err = fs.s3Client.CopyObject(ctx, copyOpts, srcOpts)
-		if err != nil {
-			result.Error = err.Error()
-			results = append(results, result)
-			failed++
-		} else {
-			result.Success = true
-			results = append(results, result)
-			success++
-		}
+		results = append(results, result)
 	}
 
 	return &types.UpdateFilesMetadataResponse{
@@ -138,57 +82,15 @@ func (fs *FileService) CopyFiles(ctx context.Context, user string, req *types.Co
 	success := 0
 	failed := 0
 
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return nil, err
-	}
-
 	for _, item := range req.Items {
-		result := types.CopyFileResult{
-			SourceKey:      item.SourceKey,
-			DestinationKey: item.DestinationKey,
-			Success:        false,
-		}
-
-		// 验证源对象键是否属于当前用户
-		if !strings.HasPrefix(item.SourceKey, user+"/") {
-			result.Error = "access denied: source object does not belong to user"
-			results = append(results, result)
-			failed++
-
-			continue
-		}
-
-		// 验证目标对象键是否属于当前用户
-		if !strings.HasPrefix(item.DestinationKey, user+"/") {
-			result.Error = "access denied: destination object does not belong to user"
-			results = append(results, result)
+		result := fs.copyFile(ctx, user, item)
+		if result.Success {
+			success++
+		} else {
 			failed++
-
-			continue
 		}
 
-		// 执行复制操作
-		srcOpts := minio.CopySrcOptions{
-			Bucket: bucket,
-			Object: item.SourceKey,
-		}
-
-		dstOpts := minio.CopyDestOptions{
-			Bucket: bucket,
-			Object: item.DestinationKey,
-		}
-
-		_, err := fs.s3Client.CopyObject(ctx, dstOpts, srcOpts)
-		if err != nil {
-			result.Error = err.Error()
-			results = append(results, result)
-			failed++
-		} else {
-			result.Success = true
-			results = append(results, result)
-			success++
-		}
+		results = append(results, result)
 	}
 
 	return &types.CopyFilesResponse{
@@ -206,73 +108,312 @@ func (fs *FileService) MoveFiles(ctx context.Context, user string, req *types.Mo
 	success := 0
 	failed := 0
 
-	bucket, err := fs.defaultBucket()
+	for _, item := range req.Items {
+		result := fs.moveFile(ctx, user, item)
+		if result.Success {
+			success++
+		} else {
+			failed++
+		}
+
+		results = append(results, result)
+	}
+
+	return &types.MoveFilesResponse{
+		Results: results,
+		Total:   total,
+		Success: success,
+		Failed:  failed,
+	}, nil
+}
+
+// deleteFile 删除单个对象，同步接口与异步任务共用.
+func (fs *FileService) deleteFile(ctx context.Context, user, objectKey string) types.DeleteFileResult {
+	result := types.DeleteFileResult{
+		ObjectKey: objectKey,
+		Success:   false,
+	}
+
+	// 验证对象键是否属于当前用户
+	if !strings.HasPrefix(objectKey, user+"/") {
+		result.Error = "access denied: object does not belong to user"
+		return result
+	}
+
+	if err := fs.checkHold(ctx, user, objectKey, holdActionDelete); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	bucket, err := fs.objectBucket(ctx, objectKey)
 	if err != nil {
-		return nil, err
+		result.Error = err.Error()
+		return result
 	}
 
-	for _, item := range req.Items {
-		result := types.MoveFileResult{
-			SourceKey:      item.SourceKey,
-			DestinationKey: item.DestinationKey,
-			Success:        false,
-		}
+	// 删除对象
+	if err := fs.s3Client.RemoveObject(ctx, bucket, objectKey, minio.RemoveObjectOptions{}); err != nil {
+		result.Error = err.Error()
+		return result
+	}
 
-		// 验证源对象键是否属于当前用户
-		if !strings.HasPrefix(item.SourceKey, user+"/") {
-			result.Error = "access denied: source object does not belong to user"
-			results = append(results, result)
-			failed++
+	fs.forgetObject(ctx, user, bucket, objectKey)
 
-			continue
-		}
+	result.Success = true
 
-		// 验证目标对象键是否属于当前用户
-		if !strings.HasPrefix(item.DestinationKey, user+"/") {
-			result.Error = "access denied: destination object does not belong to user"
-			results = append(results, result)
-			failed++
+	return result
+}
 
-			continue
-		}
+// updateFileMetadata 通过自拷贝替换单个对象的元数据.
+func (fs *FileService) updateFileMetadata(ctx context.Context, user string,
+	item types.UpdateFileMetadataItem) types.UpdateFileMetadataResult {
+	result := types.UpdateFileMetadataResult{
+		ObjectKey: item.ObjectKey,
+		Success:   false,
+	}
 
-		// 执行复制操作
-		srcOpts := minio.CopySrcOptions{
-			Bucket: bucket,
-			Object: item.SourceKey,
-		}
+	// 验证对象键是否属于当前用户
+	if !strings.HasPrefix(item.ObjectKey, user+"/") {
+		result.Error = "access denied: object does not belong to user"
+		return result
+	}
 
-		dstOpts := minio.CopyDestOptions{
-			Bucket: bucket,
-			Object: item.DestinationKey,
-		}
+	if err := fs.checkHold(ctx, user, item.ObjectKey, holdActionUpdateMetadata); err != nil {
+		result.Error = err.Error()
+		return result
+	}
 
-		_, err := fs.s3Client.CopyObject(ctx, dstOpts, srcOpts)
-		if err != nil {
-			result.Error = err.Error()
-			results = append(results, result)
-			failed++
+	bucket, err := fs.objectBucket(ctx, item.ObjectKey)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
 
-			continue
-		}
+	// 准备复制选项
+	copyOpts := minio.CopyDestOptions{
+		Bucket:          bucket,
+		Object:          item.ObjectKey,
+		ReplaceMetadata: true,
+	}
 
-		// 删除源对象
-		err = fs.s3Client.RemoveObject(ctx, bucket, item.SourceKey, minio.RemoveObjectOptions{})
-		if err != nil {
-			result.Error = fmt.Sprintf("copy succeeded but failed to remove source: %v", err)
-			results = append(results, result)
-			failed++
-		} else {
-			result.Success = true
-			results = append(results, result)
-			success++
+	// 设置元数据
+	if len(item.Tags) > 0 {
+		copyOpts.UserMetadata = make(map[string]string)
+		for k, v := range item.Tags {
+			copyOpts.UserMetadata[k] = v
 		}
 	}
 
-	return &types.MoveFilesResponse{
-		Results: results,
-		Total:   total,
-		Success: success,
-		Failed:  failed,
-	}, nil
+	if item.ContentType != "" {
+		copyOpts.ContentType = item.ContentType
+	}
+
+	// 执行复制操作来更新元数据；模拟版本化时先归档当前版本
+	srcOpts := minio.CopySrcOptions{
+		Bucket: bucket,
+		Object: item.ObjectKey,
+	}
+
+	if err := fs.archiveCurrentVersion(ctx, bucket, user, item.ObjectKey); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	ui, err := fs.s3Client.CopyObject(ctx, copyOpts, srcOpts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	fs.publishObjectUpdated(ctx, bucket, item.ObjectKey, ui.VersionID)
+
+	result.Success = true
+
+	return result
+}
+
+// copyFile 复制单个对象.
+func (fs *FileService) copyFile(ctx context.Context, user string, item types.CopyFileItem) types.CopyFileResult {
+	result := types.CopyFileResult{
+		SourceKey:      item.SourceKey,
+		DestinationKey: item.DestinationKey,
+		Success:        false,
+	}
+
+	if err := checkCopyKeys(user, item.SourceKey, item.DestinationKey); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// 受保护的目标不能被覆盖
+	if err := fs.checkHold(ctx, user, item.DestinationKey, holdActionOverwrite); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	srcBucket, dstBucket, err := fs.copyBuckets(ctx, item.SourceKey, item.DestinationKey)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// 目标已存在时会被覆盖，模拟版本化时先归档
+	if err := fs.archiveCurrentVersion(ctx, dstBucket, user, item.DestinationKey); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := fs.copyObject(ctx, srcBucket, dstBucket, item.SourceKey, item.DestinationKey); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// 目标沿用源记录的分类、描述与标签
+	meta := fs.fileRecordMetadata(ctx, user, item.SourceKey)
+	fs.recordObject(ctx, user, dstBucket, item.DestinationKey, lastPathComponent(item.DestinationKey), copySource, "", meta)
+
+	result.Success = true
+
+	return result
+}
+
+// moveFile 移动单个对象：先复制再删除源对象.
+func (fs *FileService) moveFile(ctx context.Context, user string, item types.MoveFileItem) types.MoveFileResult {
+	result := types.MoveFileResult{
+		SourceKey:      item.SourceKey,
+		DestinationKey: item.DestinationKey,
+		Success:        false,
+	}
+
+	if err := checkCopyKeys(user, item.SourceKey, item.DestinationKey); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// 受保护的源对象不能移走，受保护的目标不能被覆盖
+	if err := fs.checkHold(ctx, user, item.SourceKey, holdActionMove); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := fs.checkHold(ctx, user, item.DestinationKey, holdActionOverwrite); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	srcBucket, dstBucket, err := fs.copyBuckets(ctx, item.SourceKey, item.DestinationKey)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// 目标已存在时会被覆盖，模拟版本化时先归档
+	if err := fs.archiveCurrentVersion(ctx, dstBucket, user, item.DestinationKey); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if err := fs.copyObject(ctx, srcBucket, dstBucket, item.SourceKey, item.DestinationKey); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// 目标沿用源记录的分类、描述与标签
+	meta := fs.fileRecordMetadata(ctx, user, item.SourceKey)
+
+	// 删除源对象
+	if err := fs.s3Client.RemoveObject(ctx, srcBucket, item.SourceKey, minio.RemoveObjectOptions{}); err != nil {
+		result.Error = fmt.Sprintf("copy succeeded but failed to remove source: %v", err)
+		return result
+	}
+
+	fs.forgetObject(ctx, user, srcBucket, item.SourceKey)
+	fs.recordObject(ctx, user, dstBucket, item.DestinationKey, lastPathComponent(item.DestinationKey), moveSource, "", meta)
+
+	result.Success = true
+
+	return result
+}
+
+// forgetObject 对象被删除或移走后删除文件记录，并发布 deleted 事件通知下游清理缩略图、媒体元数据等派生数据.
+func (fs *FileService) forgetObject(ctx context.Context, user, bucket, objectKey string) {
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("user = ? AND object_key = ?", user, objectKey).Delete(&model.Files{}).Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Msg("delete file record failed")
+	}
+
+	publishEvent(ctx, fs.mqClient, queue.TopicObjectDeleted, queue.ObjectDeletedPayload{
+		Object: queue.ObjectRef{Bucket: bucket, ObjectKey: objectKey},
+	})
+}
+
+// publishObjectUpdated 对象内容或元数据被原位替换（修改元数据、创建或恢复版本）后发布 updated 事件，
+// 通知复制等下游同步新的当前版本.
+func (fs *FileService) publishObjectUpdated(ctx context.Context, bucket, objectKey, versionID string) {
+	publishEvent(ctx, fs.mqClient, queue.TopicObjectUpdated, queue.ObjectUpdatedPayload{
+		Object: queue.ObjectRef{Bucket: bucket, ObjectKey: objectKey, VersionID: versionID},
+	})
+}
+
+// fileRecordMetadata 读取文件记录中的分类、描述与标签，用于复制、移动后写入目标记录；没有记录时返回 nil.
+func (fs *FileService) fileRecordMetadata(ctx context.Context, user, objectKey string) *types.UploadFileMetadata {
+	var rec model.Files
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("user = ? AND object_key = ?", user, objectKey).Limit(1).Find(&rec).Error; err != nil || rec.ID == 0 {
+		return nil
+	}
+
+	meta := &types.UploadFileMetadata{Category: rec.Category, Description: rec.Description}
+	if rec.TagsJSON != "" {
+		_ = json.Unmarshal([]byte(rec.TagsJSON), &meta.Tags)
+	}
+
+	return meta
+}
+
+// checkCopyKeys 验证源/目标对象键都属于当前用户.
+func checkCopyKeys(user, sourceKey, destinationKey string) error {
+	if !strings.HasPrefix(sourceKey, user+"/") {
+		return fmt.Errorf("access denied: source object does not belong to user")
+	}
+
+	if !strings.HasPrefix(destinationKey, user+"/") {
+		return fmt.Errorf("access denied: destination object does not belong to user")
+	}
+
+	return nil
+}
+
+// copyBuckets 返回复制/移动的源与目标 bucket：目标已存在时保持在原 bucket 覆盖，否则与源对象放在同一 bucket.
+func (fs *FileService) copyBuckets(ctx context.Context, sourceKey, destinationKey string) (src, dst string, err error) {
+	if src, err = fs.objectBucket(ctx, sourceKey); err != nil {
+		return "", "", err
+	}
+
+	if dst, err = fs.recordedBucket(ctx, destinationKey); err != nil {
+		return "", "", err
+	}
+
+	if dst == "" {
+		dst = src
+	}
+
+	return src, dst, nil
+}
+
+// copyObject 执行服务端复制，源与目标可以位于不同存储桶.
+func (fs *FileService) copyObject(ctx context.Context, srcBucket, dstBucket, sourceKey, destinationKey string) error {
+	srcOpts := minio.CopySrcOptions{
+		Bucket: srcBucket,
+		Object: sourceKey,
+	}
+
+	dstOpts := minio.CopyDestOptions{
+		Bucket: dstBucket,
+		Object: destinationKey,
+	}
+
+	_, err := fs.s3Client.CopyObject(ctx, dstOpts, srcOpts)
+
+	return err
 }
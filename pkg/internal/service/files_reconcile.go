@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+const (
+	// maxReconcileEntries 同步接口返回的差异条目上限（统计不受影响）.
+	maxReconcileEntries = 1000
+	// reconcileLoadBatch 分批加载数据库记录的批大小.
+	reconcileLoadBatch = 1000
+)
+
+// reconcileRow 对账所需的数据库记录字段.
+type reconcileRow struct {
+	ID           uint
+	ObjectKey    string
+	ETag         string
+	Size         int64
+	Bucket       string
+	ContentType  string
+	VersionID    string
+	StorageClass string
+	LastModified time.Time
+}
+
+// ReconcileObjects 比对对象存储与数据库记录并生成差异报告；req.Fix 为 true 时同时修复差异.
+func (fs *FileService) ReconcileObjects(ctx context.Context, user string, req *types.ReconcileRequest) (*types.ReconcileResponse, error) {
+	resp := newReconcileResponse(user, req)
+
+	err := fs.reconcile(ctx, user, req, resp, func(e types.ReconcileEntry) error {
+		if len(resp.Entries) >= maxReconcileEntries {
+			resp.Truncated = true
+			return nil
+		}
+
+		resp.Entries = append(resp.Entries, e)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ReconcileAllUsers 对所有用户执行对账：用户来自存储桶顶层目录与数据库记录的并集.
+// 返回的报告仅包含统计信息，差异条目通过日志输出.
+func (fs *FileService) ReconcileAllUsers(ctx context.Context, req *types.ReconcileRequest) ([]*types.ReconcileResponse, error) {
+	users, err := fs.listKnownUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l := nlog.Logger()
+	reports := make([]*types.ReconcileResponse, 0, len(users))
+
+	for _, user := range users {
+		// 全量对账时前缀统一为用户目录
+		userReq := &types.ReconcileRequest{Fix: req.Fix, Deep: req.Deep}
+		resp := newReconcileResponse(user, userReq)
+
+		err := fs.reconcile(ctx, user, userReq, resp, func(e types.ReconcileEntry) error {
+			l.Info().Str("user", user).Str("key", e.ObjectKey).Str("kind", e.Kind).
+				Str("detail", e.Detail).Bool("fixed", e.Fixed).Str("error", e.Error).Msg("reconcile drift")
+
+			return nil
+		})
+		if err != nil {
+			return reports, fmt.Errorf("reconcile user %s: %w", user, err)
+		}
+
+		reports = append(reports, resp)
+	}
+
+	return reports, nil
+}
+
+// reconcile 对账核心流程：加载范围内的数据库记录，遍历对象列表逐一比对，剩余记录即对象已不存在.
+// 每个差异条目通过 emit 回调输出，emit 返回错误将中断对账.
+func (fs *FileService) reconcile(ctx context.Context, user string, req *types.ReconcileRequest,
+	stats *types.ReconcileResponse, emit func(types.ReconcileEntry) error) error {
+	if user == "" {
+		return fmt.Errorf("user is required")
+	}
+
+	prefix := req.Prefix
+	if prefix == "" {
+		prefix = user + "/"
+	}
+
+	if !strings.HasPrefix(prefix, user+"/") {
+		return fmt.Errorf("access denied: prefix does not belong to user")
+	}
+
+	stats.Prefix = prefix
+
+	buckets, err := fs.allBuckets()
+	if err != nil {
+		return err
+	}
+
+	rows, err := fs.loadReconcileRows(ctx, user, prefix)
+	if err != nil {
+		return err
+	}
+
+	stats.DBRecords = len(rows)
+
+	record := func(e types.ReconcileEntry) error {
+		stats.Counts[e.Kind]++
+		if e.Fixed {
+			stats.Fixed++
+		}
+
+		return emit(e)
+	}
+
+	// 路由规则可能把对象写入不同 bucket，逐个列举
+	for _, bucket := range buckets {
+		ch := fs.s3Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true})
+
+		for obj := range ch {
+			// 列举失败时必须中止，否则剩余记录会被误判为 missing_in_s3
+			if obj.Err != nil {
+				return fmt.Errorf("list objects: %v", obj.Err)
+			}
+
+			if strings.HasSuffix(obj.Key, "/") {
+				continue
+			}
+
+			stats.Scanned++
+
+			row, ok := rows[obj.Key]
+			delete(rows, obj.Key)
+
+			entry, drift := fs.compareObject(ctx, bucket, obj, row, ok, req)
+			if !drift {
+				continue
+			}
+
+			if req.Fix {
+				fs.fixObjectDrift(ctx, bucket, user, obj, &entry)
+			}
+
+			if err := record(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 剩余记录：对象已不存在（按键排序保证输出稳定）
+	keys := make([]string, 0, len(rows))
+	for k := range rows {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		entry := types.ReconcileEntry{ObjectKey: k, Kind: types.DriftMissingInS3}
+
+		if req.Fix {
+			if err := fs.dbClient.GetDB().WithContext(ctx).Delete(&model.Files{}, rows[k].ID).Error; err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Fixed = true
+			}
+		}
+
+		if err := record(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compareObject 比对单个对象与数据库记录，返回差异条目及是否存在差异.
+func (fs *FileService) compareObject(ctx context.Context, bucket string, obj minio.ObjectInfo,
+	row *reconcileRow, inDB bool, req *types.ReconcileRequest) (types.ReconcileEntry, bool) {
+	entry := types.ReconcileEntry{ObjectKey: obj.Key}
+	etag := strings.Trim(obj.ETag, "\"")
+
+	if !inDB {
+		entry.Kind = types.DriftMissingInDB
+		return entry, true
+	}
+
+	if row.ETag != etag {
+		entry.Kind = types.DriftStaleETag
+		entry.Detail = fmt.Sprintf("etag %q -> %q", row.ETag, etag)
+
+		return entry, true
+	}
+
+	diffs := make([]string, 0)
+
+	if row.Size != obj.Size {
+		diffs = append(diffs, fmt.Sprintf("size %d -> %d", row.Size, obj.Size))
+	}
+
+	if row.Bucket != bucket {
+		diffs = append(diffs, fmt.Sprintf("bucket %q -> %q", row.Bucket, bucket))
+	}
+
+	if row.VersionID != obj.VersionID {
+		diffs = append(diffs, fmt.Sprintf("version_id %q -> %q", row.VersionID, obj.VersionID))
+	}
+
+	if obj.StorageClass != "" && row.StorageClass != obj.StorageClass {
+		diffs = append(diffs, fmt.Sprintf("storage_class %q -> %q", row.StorageClass, obj.StorageClass))
+	}
+
+	if !row.LastModified.UTC().Truncate(time.Second).Equal(obj.LastModified.UTC().Truncate(time.Second)) {
+		diffs = append(diffs, "last_modified changed")
+	}
+
+	// ContentType 只能通过 Stat 获得：记录为空时总是检查，Deep 模式下检查全部对象
+	if row.ContentType == "" || req.Deep {
+		info, err := fs.s3Client.StatObject(ctx, bucket, obj.Key, minio.StatObjectOptions{})
+		if err == nil && info.ContentType != "" && info.ContentType != row.ContentType {
+			diffs = append(diffs, fmt.Sprintf("content_type %q -> %q", row.ContentType, info.ContentType))
+		}
+	}
+
+	if len(diffs) == 0 {
+		return entry, false
+	}
+
+	entry.Kind = types.DriftStaleMetadata
+	entry.Detail = strings.Join(diffs, "; ")
+
+	return entry, true
+}
+
+// fixObjectDrift 通过 Stat 获取完整元数据后 upsert 数据库记录.
+func (fs *FileService) fixObjectDrift(ctx context.Context, bucket, user string, obj minio.ObjectInfo, entry *types.ReconcileEntry) {
+	info, err := fs.s3Client.StatObject(ctx, bucket, obj.Key, minio.StatObjectOptions{})
+	if err != nil {
+		entry.Error = fmt.Sprintf("stat object: %v", err)
+		return
+	}
+
+	rec := model.Files{
+		User:         user,
+		ObjectKey:    obj.Key,
+		FileName:     lastPathComponent(obj.Key),
+		Size:         obj.Size,
+		ETag:         strings.Trim(obj.ETag, "\""),
+		ContentType:  info.ContentType,
+		Bucket:       bucket,
+		VersionID:    obj.VersionID,
+		StorageClass: obj.StorageClass,
+		LastModified: obj.LastModified.UTC(),
+		UpdatedAt:    time.Now().UTC(),
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Clauses(onConflictUserKeyUpdate()).Create(&rec).Error; err != nil {
+		entry.Error = err.Error()
+		return
+	}
+
+	entry.Fixed = true
+}
+
+// loadReconcileRows 分批加载 prefix 范围内的数据库记录.
+func (fs *FileService) loadReconcileRows(ctx context.Context, user, prefix string) (map[string]*reconcileRow, error) {
+	rows := make(map[string]*reconcileRow)
+
+	var batch []reconcileRow
+
+	err := fs.dbClient.GetDB().WithContext(ctx).Model(&model.Files{}).
+		Select("id", "object_key", "e_tag", "size", "bucket", "content_type", "version_id", "storage_class", "last_modified").
+		Where("user = ? AND object_key LIKE ?", user, prefix+"%").
+		FindInBatches(&batch, reconcileLoadBatch, func(_ *gorm.DB, _ int) error {
+			for i := range batch {
+				r := batch[i]
+				// LIKE 中的 '_' / '%' 为通配符，这里按真实前缀再过滤一次
+				if strings.HasPrefix(r.ObjectKey, prefix) {
+					rows[r.ObjectKey] = &r
+				}
+			}
+
+			return nil
+		}).Error
+	if err != nil {
+		return nil, fmt.Errorf("load file records: %w", err)
+	}
+
+	return rows, nil
+}
+
+// listKnownUsers 返回各存储桶顶层目录与数据库记录中出现过的用户（去重、排序）.
+func (fs *FileService) listKnownUsers(ctx context.Context) ([]string, error) {
+	buckets, err := fs.allBuckets()
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]struct{})
+
+	for _, bucket := range buckets {
+		for obj := range fs.s3Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Recursive: false}) {
+			if obj.Err != nil {
+				return nil, fmt.Errorf("list objects: %v", obj.Err)
+			}
+
+			// 顶层目录即用户目录；以 "." 开头的为系统目录
+			if strings.HasSuffix(obj.Key, "/") && !strings.HasPrefix(obj.Key, ".") {
+				set[strings.TrimSuffix(obj.Key, "/")] = struct{}{}
+			}
+		}
+	}
+
+	var dbUsers []string
+	if err := fs.dbClient.GetDB().WithContext(ctx).Model(&model.Files{}).
+		Distinct("user").Pluck("user", &dbUsers).Error; err != nil {
+		return nil, fmt.Errorf("list users: %w", err)
+	}
+
+	for _, u := range dbUsers {
+		set[u] = struct{}{}
+	}
+
+	users := make([]string, 0, len(set))
+	for u := range set {
+		users = append(users, u)
+	}
+
+	slices.Sort(users)
+
+	return users, nil
+}
+
+func newReconcileResponse(user string, req *types.ReconcileRequest) *types.ReconcileResponse {
+	return &types.ReconcileResponse{
+		User:    user,
+		Prefix:  req.Prefix,
+		Fix:     req.Fix,
+		Counts:  make(map[string]int),
+		Entries: make([]types.ReconcileEntry, 0),
+	}
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/metrics"
+)
+
+const (
+	// replicationBatchSize 回填与重试每批加载的记录数.
+	replicationBatchSize = 500
+	// maxReplicationErrors 响应中最多列出的失败对象数，超出部分只计入统计.
+	maxReplicationErrors = 100
+)
+
+// replicationLocks 按 bucket 与对象键分段加锁，串行化同一对象的同步：worker 并发消费各主题，
+// 同一对象的存储与删除事件可能同时处理，未串行化时删除一方可能读到尚未保存的状态而跳过删除副本.
+var replicationLocks [64]sync.Mutex
+
+// 复制指标的结果标签.
+const (
+	replicationResultOK     = "ok"
+	replicationResultFailed = "failed"
+)
+
+var (
+	// ErrReplicationDisabled 未启用复制或副本存储未初始化.
+	ErrReplicationDisabled = errors.New("replication is not enabled")
+	// ErrReplicationFailed 同步到副本失败，失败状态已记录，由重试任务按退避时间重新复制.
+	ErrReplicationFailed = errors.New("replication failed")
+)
+
+// ReplicateObject 将对象的当前状态同步到副本：源对象存在时复制内容（已复制的 ETag 与元数据均未变化时跳过），
+// 不存在时删除副本。按源对象的当前状态而非事件内容同步，事件乱序或重复投递时结果一致.
+func (fs *FileService) ReplicateObject(ctx context.Context, bucket, objectKey string) error {
+	_, err := fs.replicateObject(ctx, bucket, objectKey, false)
+	return err
+}
+
+// BackfillReplication 将已有文件同步到副本，用于启用复制之前写入的数据或事件丢失后的补齐；
+// user 为空时处理所有用户。dryRun 为 true 时只统计需要复制的文件.
+func (fs *FileService) BackfillReplication(ctx context.Context, user string, dryRun bool) (*types.ReplicationRunResponse, error) {
+	if fs.replica == nil {
+		return nil, ErrReplicationDisabled
+	}
+
+	def, err := fs.defaultBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &types.ReplicationRunResponse{DryRun: dryRun, Errors: []types.ReplicationError{}}
+
+	q := fs.dbClient.GetDB().WithContext(ctx).Model(&model.Files{})
+	if user != "" {
+		q = q.Where("user = ?", user)
+	}
+
+	var rows []model.Files
+
+	res := q.Order("id").FindInBatches(&rows, replicationBatchSize, func(_ *gorm.DB, _ int) error {
+		for i := range rows {
+			bucket := rows[i].Bucket
+			if bucket == "" {
+				bucket = def
+			}
+
+			fs.runReplication(ctx, bucket, rows[i].ObjectKey, dryRun, resp)
+		}
+
+		return ctx.Err()
+	})
+
+	return resp, res.Error
+}
+
+// RetryReplication 重新复制已到重试时间且未超过最多尝试次数的失败对象.
+func (fs *FileService) RetryReplication(ctx context.Context) (*types.ReplicationRunResponse, error) {
+	if fs.replica == nil {
+		return nil, ErrReplicationDisabled
+	}
+
+	resp := &types.ReplicationRunResponse{Errors: []types.ReplicationError{}}
+
+	var rows []model.ReplicationStatus
+
+	res := fs.dbClient.GetDB().WithContext(ctx).
+		Where("status = ? AND attempts < ? AND next_attempt_at <= ?",
+			types.ReplicationStatusFailed, configs.GetConfig().Replication.MaxAttempts, time.Now().UTC()).
+		Order("id").
+		FindInBatches(&rows, replicationBatchSize, func(_ *gorm.DB, _ int) error {
+			for i := range rows {
+				fs.runReplication(ctx, rows[i].Bucket, rows[i].ObjectKey, false, resp)
+			}
+
+			return ctx.Err()
+		})
+
+	return resp, res.Error
+}
+
+// ListReplicationStatus 列出用户对象的复制状态.
+func (fs *FileService) ListReplicationStatus(ctx context.Context, user string,
+	req *types.ListReplicationRequest) (*types.ListReplicationResponse, error) {
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	var groups []struct {
+		Status string
+		Count  int
+	}
+
+	if err := dbx.Model(&model.ReplicationStatus{}).Select("status, COUNT(*) AS count").
+		Where("user = ?", user).Group("status").Scan(&groups).Error; err != nil {
+		return nil, fmt.Errorf("count replication status: %w", err)
+	}
+
+	counts := make(map[string]int, len(groups))
+	for _, g := range groups {
+		counts[g.Status] = g.Count
+	}
+
+	q := dbx.Model(&model.ReplicationStatus{}).Where("user = ?", user)
+	if req.Status != "" {
+		q = q.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("count replication status: %w", err)
+	}
+
+	page, size := normalizeJobPage(req.Page, req.PageSize)
+
+	var rows []model.ReplicationStatus
+	if err := q.Order("updated_at DESC").Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list replication status: %w", err)
+	}
+
+	items := make([]types.ReplicationStatusInfo, 0, len(rows))
+	for i := range rows {
+		r := &rows[i]
+		items = append(items, types.ReplicationStatusInfo{
+			Bucket:        r.Bucket,
+			ObjectKey:     r.ObjectKey,
+			Operation:     r.Operation,
+			Status:        r.Status,
+			ETag:          r.ETag,
+			Size:          r.Size,
+			Attempts:      r.Attempts,
+			LastError:     r.LastError,
+			NextAttemptAt: r.NextAttemptAt,
+			ReplicatedAt:  r.ReplicatedAt,
+			UpdatedAt:     r.UpdatedAt,
+		})
+	}
+
+	return &types.ListReplicationResponse{Total: int(total), Page: page, Size: size, Counts: counts, Items: items}, nil
+}
+
+// runReplication 同步单个对象并汇总结果.
+func (fs *FileService) runReplication(ctx context.Context, bucket, objectKey string, dryRun bool,
+	resp *types.ReplicationRunResponse) {
+	resp.Scanned++
+
+	changed, err := fs.replicateObject(ctx, bucket, objectKey, dryRun)
+
+	switch {
+	case err != nil:
+		resp.Failed++
+		if len(resp.Errors) < maxReplicationErrors {
+			resp.Errors = append(resp.Errors, types.ReplicationError{Bucket: bucket, ObjectKey: objectKey, Error: err.Error()})
+		}
+	case changed:
+		resp.Replicated++
+	default:
+		resp.Skipped++
+	}
+}
+
+// replicateObject 同步单个对象，返回副本是否（将要）被修改；副本已是最新时返回 false.
+func (fs *FileService) replicateObject(ctx context.Context, bucket, objectKey string, dryRun bool) (bool, error) {
+	if fs.replica == nil {
+		return false, ErrReplicationDisabled
+	}
+
+	mu := replicationLock(bucket, objectKey)
+	mu.Lock()
+	defer mu.Unlock()
+
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	var rec model.ReplicationStatus
+	if err := dbx.Where("bucket = ? AND object_key = ?", bucket, objectKey).Limit(1).Find(&rec).Error; err != nil {
+		return false, fmt.Errorf("load replication status: %w", err)
+	}
+
+	op := types.ReplicationOpPut
+
+	info, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
+	if isNoSuchKey(err) {
+		op = types.ReplicationOpDelete
+	} else if err != nil {
+		return false, fmt.Errorf("stat %s: %w", objectKey, err)
+	}
+
+	etag, metaHash := strings.Trim(info.ETag, `"`), replicationMetaHash(&info)
+
+	// 副本已是最新（内容与元数据均未变化）；从未复制过的对象被删除时副本中也不存在
+	if (rec.Status == types.ReplicationStatusReplicated && rec.Operation == op && rec.ETag == etag && rec.MetaHash == metaHash) ||
+		(rec.ID == 0 && op == types.ReplicationOpDelete) {
+		return false, nil
+	}
+
+	if dryRun {
+		return true, nil
+	}
+
+	if op == types.ReplicationOpPut {
+		err = fs.copyToReplica(ctx, bucket, objectKey, &info)
+	} else {
+		err = fs.removeFromReplica(ctx, bucket, objectKey)
+	}
+
+	fs.recordReplication(&rec, bucket, objectKey, op, etag, metaHash, info.Size, err)
+
+	if saveErr := dbx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bucket"}, {Name: "object_key"}},
+		UpdateAll: true,
+	}).Save(&rec).Error; saveErr != nil {
+		return false, fmt.Errorf("save replication status: %w", saveErr)
+	}
+
+	if err != nil {
+		nlog.Logger().Warn().Err(err).Str("bucket", bucket).Str("object_key", objectKey).
+			Int("attempts", rec.Attempts).Msg("replicate object failed")
+
+		return false, fmt.Errorf("%w: %s: %w", ErrReplicationFailed, objectKey, err)
+	}
+
+	return true, nil
+}
+
+// recordReplication 按同步结果更新状态记录与指标；失败时按尝试次数计算下次重试时间.
+func (fs *FileService) recordReplication(rec *model.ReplicationStatus, bucket, objectKey, op, etag, metaHash string,
+	size int64, err error) {
+	now := time.Now().UTC()
+
+	user, _, _ := strings.Cut(objectKey, "/")
+	if strings.HasPrefix(user, ".") {
+		user = ""
+	}
+
+	rec.User, rec.Bucket, rec.ObjectKey, rec.Operation = user, bucket, objectKey, op
+
+	if err != nil {
+		next := now.Add(configs.GetConfig().Replication.GetBackoff(rec.Attempts + 1))
+
+		rec.Status = types.ReplicationStatusFailed
+		rec.Attempts++
+		rec.LastError = err.Error()
+		rec.NextAttemptAt = &next
+
+		metrics.ReplicationOps.WithLabelValues(op, replicationResultFailed).Inc()
+
+		return
+	}
+
+	rec.Status = types.ReplicationStatusReplicated
+	rec.ETag, rec.MetaHash, rec.Size = etag, metaHash, size
+	rec.Attempts, rec.LastError, rec.NextAttemptAt = 0, "", nil
+	rec.ReplicatedAt = &now
+
+	metrics.ReplicationOps.WithLabelValues(op, replicationResultOK).Inc()
+
+	if op == types.ReplicationOpPut {
+		metrics.ReplicationBytes.Add(float64(size))
+	}
+}
+
+// replicationLock 返回对象所在的同步锁分段.
+func replicationLock(bucket, objectKey string) *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(bucket + "/" + objectKey))
+
+	return &replicationLocks[h.Sum32()%uint32(len(replicationLocks))]
+}
+
+// replicationMetaHash 返回复制到副本的 Content-Type 与用户元数据的摘要；对象不存在（删除）时返回空串.
+func replicationMetaHash(info *minio.ObjectInfo) string {
+	if info.Key == "" && info.ETag == "" {
+		return ""
+	}
+
+	keys := slices.Sorted(maps.Keys(info.UserMetadata))
+
+	h := sha256.New()
+	h.Write([]byte(info.ContentType))
+
+	for _, k := range keys {
+		h.Write([]byte("\n" + k + "=" + info.UserMetadata[k]))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// copyToReplica 将源对象的内容、Content-Type 与用户元数据写入副本；读取时校验 ETag，避免复制到复制途中被覆盖的内容.
+func (fs *FileService) copyToReplica(ctx context.Context, bucket, objectKey string, info *minio.ObjectInfo) error {
+	opts := minio.GetObjectOptions{}
+	_ = opts.SetMatchETag(info.ETag)
+
+	obj, err := fs.s3Client.GetObject(ctx, bucket, objectKey, opts)
+	if err != nil {
+		return fmt.Errorf("read source: %w", err)
+	}
+	defer obj.Close()
+
+	_, err = fs.replica.PutObject(ctx, fs.replicaBucket(bucket), objectKey, obj, info.Size, minio.PutObjectOptions{
+		ContentType:  info.ContentType,
+		UserMetadata: info.UserMetadata,
+	})
+	if err != nil {
+		return fmt.Errorf("write replica: %w", err)
+	}
+
+	return nil
+}
+
+// removeFromReplica 删除副本中的对象；副本中已不存在时视为成功.
+func (fs *FileService) removeFromReplica(ctx context.Context, bucket, objectKey string) error {
+	err := fs.replica.RemoveObject(ctx, fs.replicaBucket(bucket), objectKey, minio.RemoveObjectOptions{})
+	if err != nil && !isNoSuchKey(err) {
+		return fmt.Errorf("remove replica: %w", err)
+	}
+
+	return nil
+}
+
+// replicaBucket 返回主存储 bucket 对应的副本 bucket.
+func (fs *FileService) replicaBucket(bucket string) string {
+	cfg := configs.GetConfig().Replication
+	return cfg.TargetBucket(fs.s3Client.GetConfig().Buckets, bucket)
+}
@@ -0,0 +1,184 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// replicaBody 读取副本中的对象内容，对象不存在时返回错误.
+func replicaBody(ctx context.Context, replica s3.ObjectStore, bucket, key string) (string, error) {
+	obj, err := replica.GetObject(ctx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer obj.Close()
+
+	b, err := io.ReadAll(obj)
+
+	return string(b), err
+}
+
+// TestReplication 验证回填、删除同步、失败重试与事件驱动的复制.
+func TestReplication(t *testing.T) {
+	ctx := newTestContext(t, false)
+	cfg := configs.GetConfig()
+	bucket := cfg.S3.Buckets[0]
+
+	replica := s3.NewMemoryStore(false, bucket, "dr-"+bucket)
+	ctxPkg.GetManager(ctx).WithReplica(&s3.Client{ObjectStore: replica})
+
+	svc := service.NewFileService(ctx)
+
+	a := uploadText(t, ctx, "a.txt", "alpha")
+	b := uploadText(t, ctx, "b.txt", "bravo")
+
+	dry, err := svc.BackfillReplication(ctx, testUser, true)
+	if err != nil || dry.Replicated != 2 {
+		t.Fatalf("dry run backfill = %+v, %v", dry, err)
+	}
+
+	if _, err := replicaBody(ctx, replica, bucket, a); err == nil {
+		t.Fatalf("dry run wrote to replica")
+	}
+
+	resp, err := svc.BackfillReplication(ctx, testUser, false)
+	if err != nil || resp.Replicated != 2 || resp.Failed != 0 {
+		t.Fatalf("backfill = %+v, %v", resp, err)
+	}
+
+	if body, err := replicaBody(ctx, replica, bucket, a); err != nil || body != "alpha" {
+		t.Fatalf("replica a = %q, %v", body, err)
+	}
+
+	// 已复制且未修改的文件被跳过
+	if again, err := svc.BackfillReplication(ctx, testUser, false); err != nil || again.Skipped != 2 {
+		t.Fatalf("second backfill = %+v, %v", again, err)
+	}
+
+	// 源对象删除后删除副本
+	if _, err := svc.DeleteFiles(ctx, testUser, &types.DeleteFilesRequest{ObjectKeys: []string{b}}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if err := svc.ReplicateObject(ctx, bucket, b); err != nil {
+		t.Fatalf("replicate delete: %v", err)
+	}
+
+	if _, err := replicaBody(ctx, replica, bucket, b); err == nil {
+		t.Fatalf("replica of deleted object still exists")
+	}
+
+	// 副本 bucket 不存在时复制失败并记录，修正后由重试任务补齐
+	cfg.Replication.Target.Buckets = []string{"missing"}
+	c := uploadText(t, ctx, "c.txt", "charlie")
+
+	if err := svc.ReplicateObject(ctx, bucket, c); !errors.Is(err, service.ErrReplicationFailed) {
+		t.Fatalf("replicate to missing bucket = %v", err)
+	}
+
+	status, err := svc.ListReplicationStatus(ctx, testUser, &types.ListReplicationRequest{Status: types.ReplicationStatusFailed})
+	if err != nil || status.Total != 1 || status.Items[0].ObjectKey != c || status.Items[0].Attempts != 1 ||
+		status.Counts[types.ReplicationStatusReplicated] != 2 {
+		t.Fatalf("status = %+v, %v", status, err)
+	}
+
+	cfg.Replication.Target.Buckets = []string{"dr-" + bucket}
+
+	if early, err := svc.RetryReplication(ctx); err != nil || early.Scanned != 0 {
+		t.Fatalf("retry before backoff = %+v, %v", early, err)
+	}
+
+	dbx := ctxPkg.GetDBClient(ctx).GetDB()
+	if err := dbx.Model(&model.ReplicationStatus{}).Where("object_key = ?", c).
+		Update("next_attempt_at", time.Now().UTC().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if retry, err := svc.RetryReplication(ctx); err != nil || retry.Replicated != 1 {
+		t.Fatalf("retry = %+v, %v", retry, err)
+	}
+
+	if body, err := replicaBody(ctx, replica, "dr-"+bucket, c); err != nil || body != "charlie" {
+		t.Fatalf("replica c = %q, %v", body, err)
+	}
+
+	// 事件驱动：worker 订阅 nv.object.stored 后复制新上传的文件
+	worker := service.NewReplicationWorker(ctxPkg.GetManager(ctx))
+	if err := worker.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer worker.Stop()
+
+	d := uploadText(t, ctx, "d.txt", "delta")
+
+	inReplica := func(key string) bool {
+		body, err := replicaBody(ctx, replica, "dr-"+bucket, key)
+		return err == nil && body == "delta"
+	}
+
+	waitReplica(t, "object was not replicated from stored event", func() bool { return inReplica(d) })
+
+	// 移动后副本中新位置写入、旧位置删除
+	fs := service.NewFileService(ctx)
+	moved := testUser + "/archive/d.txt"
+
+	mv, err := fs.MoveFiles(ctx, testUser, &types.MoveFilesRequest{Items: []types.MoveFileItem{{SourceKey: d, DestinationKey: moved}}})
+	if err != nil || mv.Failed != 0 {
+		t.Fatalf("move = %+v, %v", mv, err)
+	}
+
+	waitReplica(t, "move was not replicated", func() bool { return inReplica(moved) && !inReplica(d) })
+
+	// 移入回收站后从副本删除，恢复后重新写入
+	trash, err := fs.TrashFiles(ctx, testUser, []string{moved}, "")
+	if err != nil || trash.Failed != 0 {
+		t.Fatalf("trash = %+v, %v", trash, err)
+	}
+
+	waitReplica(t, "trashed object still in replica", func() bool { return !inReplica(moved) })
+
+	if restored, err := fs.RestoreTrash(ctx, testUser, []string{trash.Results[0].TrashID}); err != nil || restored.Failed != 0 {
+		t.Fatalf("restore = %+v, %v", restored, err)
+	}
+
+	waitReplica(t, "restored object was not replicated", func() bool { return inReplica(moved) })
+
+	// 只替换元数据的原位复制不改变 ETag，副本的 Content-Type 与用户元数据同样更新
+	upd, err := fs.UpdateFilesMetadata(ctx, testUser, &types.UpdateFilesMetadataRequest{Items: []types.UpdateFileMetadataItem{
+		{ObjectKey: moved, ContentType: "text/markdown", Tags: map[string]string{"Reviewed": "yes"}},
+	}})
+	if err != nil || upd.Failed != 0 {
+		t.Fatalf("update metadata = %+v, %v", upd, err)
+	}
+
+	waitReplica(t, "metadata update was not replicated", func() bool {
+		info, err := replica.StatObject(ctx, "dr-"+bucket, moved, minio.StatObjectOptions{})
+		return err == nil && info.ContentType == "text/markdown" && info.UserMetadata["Reviewed"] == "yes"
+	})
+}
+
+// waitReplica 等待 worker 处理事件后副本满足条件，超时则失败.
+func waitReplica(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// TestSearchFiles 验证关键字、前缀、内容类型与大小过滤.
+func TestSearchFiles(t *testing.T) {
+	ctx := newTestContext(t, false)
+	svc := service.NewFileService(ctx)
+
+	uploadText(t, ctx, "meeting notes.md", "# meeting")
+	uploadText(t, ctx, "todo.txt", "buy milk and eggs")
+	uploadText(t, ctx, "photo.png", "not really a png")
+
+	prefix := testUser + "/" + time.Now().UTC().Format("2006/01") + "/"
+
+	tests := []struct {
+		name string
+		req  types.SearchFilesRequest
+		want int
+	}{
+		{name: "all", req: types.SearchFilesRequest{}, want: 3},
+		{name: "keyword", req: types.SearchFilesRequest{Keyword: "meeting"}, want: 1},
+		{name: "prefix", req: types.SearchFilesRequest{Prefix: prefix + "t"}, want: 1},
+		{name: "content type", req: types.SearchFilesRequest{ContentType: "image/"}, want: 1},
+		{name: "min size", req: types.SearchFilesRequest{MinSize: 10}, want: 2},
+		{name: "page size", req: types.SearchFilesRequest{PageSize: 2}, want: 3},
+		{name: "no match", req: types.SearchFilesRequest{Keyword: "missing"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.SearchFiles(ctx, testUser, &tt.req)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+
+			if resp.Total != tt.want {
+				t.Fatalf("total = %d, want %d", resp.Total, tt.want)
+			}
+
+			if want := min(tt.want, max(tt.req.PageSize, 0)); tt.req.PageSize > 0 && len(resp.Files) != want {
+				t.Fatalf("page = %d files, want %d", len(resp.Files), want)
+			}
+		})
+	}
+
+	if resp, err := svc.SearchFiles(ctx, "bob@example.com", &types.SearchFilesRequest{}); err != nil || resp.Total != 0 {
+		t.Fatalf("other user search = %+v, %v", resp, err)
+	}
+}
+
+// TestMoveFileRecord 验证移动后文件记录随对象迁移到目标键，并保留源记录的描述.
+func TestMoveFileRecord(t *testing.T) {
+	ctx := newTestContext(t, false)
+	svc := service.NewFileService(ctx)
+
+	src := uploadText(t, ctx, "draft.txt", "first draft")
+
+	dbx := ctxPkg.GetDBClient(ctx).GetDB()
+	if err := dbx.Model(&model.Files{}).Where("object_key = ?", src).Update("description", "chapter one").Error; err != nil {
+		t.Fatalf("set description: %v", err)
+	}
+
+	dst := testUser + "/archive/final.txt"
+
+	moved, err := svc.MoveFiles(ctx, testUser, &types.MoveFilesRequest{
+		Items: []types.MoveFileItem{{SourceKey: src, DestinationKey: dst}},
+	})
+	if err != nil || moved.Success != 1 {
+		t.Fatalf("move = %+v, %v", moved, err)
+	}
+
+	var recs []model.Files
+	if err := dbx.Where("user = ?", testUser).Find(&recs).Error; err != nil || len(recs) != 1 {
+		t.Fatalf("records after move = %+v, %v", recs, err)
+	}
+
+	if r := recs[0]; r.ObjectKey != dst || r.FileName != "final.txt" || r.Description != "chapter one" {
+		t.Fatalf("moved record = %+v", r)
+	}
+}
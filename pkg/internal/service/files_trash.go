@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/queue"
+)
+
+// trashPrefix 回收站对象在存储桶中的根目录（以 "." 开头的系统目录，不参与同步与对账）.
+const trashPrefix = ".trash/"
+
+// restoreSource 从回收站恢复的对象在 ObjectStoredPayload.Source 中的来源标识.
+const restoreSource = "trash_restore"
+
+// ErrTrashItemNotFound 回收站条目不存在或不属于当前用户.
+var ErrTrashItemNotFound = errors.New("trash item not found")
+
+// TrashFiles 将文件移入回收站：对象移动到 .trash/ 下并删除文件记录，保留期内可恢复.
+func (fs *FileService) TrashFiles(ctx context.Context, user string, objectKeys []string, reason string) (*types.TrashOpResponse, error) {
+	resp := &types.TrashOpResponse{Results: make([]types.TrashOpResult, 0, len(objectKeys)), Total: len(objectKeys)}
+
+	for _, key := range objectKeys {
+		result := types.TrashOpResult{ObjectKey: key}
+
+		item, err := fs.trashObject(ctx, user, key, reason)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.TrashID = item.TrashID
+		}
+
+		addTrashResult(resp, result)
+	}
+
+	auditTrashOp(ctx, user, types.AuditActionFileTrash, resp)
+
+	return resp, nil
+}
+
+// ListTrash 分页列出回收站条目，按移入时间倒序.
+func (fs *FileService) ListTrash(ctx context.Context, user string, req *types.ListTrashRequest) (*types.ListTrashResponse, error) {
+	q := fs.dbClient.GetDB().WithContext(ctx).Model(&model.TrashItem{}).Where("user = ?", user)
+
+	if kw := strings.TrimSpace(req.Keyword); kw != "" {
+		like := "%" + kw + "%"
+		q = q.Where("file_name LIKE ? OR object_key LIKE ?", like, like)
+	}
+
+	if req.Reason != "" {
+		q = q.Where("reason = ?", req.Reason)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("count trash: %w", err)
+	}
+
+	page, size := normalizeJobPage(req.Page, req.PageSize)
+
+	var rows []model.TrashItem
+	if err := q.Order("trashed_at DESC").Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+
+	items := make([]types.TrashItemInfo, 0, len(rows))
+	for i := range rows {
+		items = append(items, toTrashItemInfo(&rows[i]))
+	}
+
+	return &types.ListTrashResponse{Total: int(total), Page: page, Size: size, Items: items}, nil
+}
+
+// GetTrashItem 获取回收站条目详情.
+func (fs *FileService) GetTrashItem(ctx context.Context, user, trashID string) (*types.TrashItemInfo, error) {
+	item, err := fs.getTrashItem(ctx, user, trashID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := toTrashItemInfo(item)
+
+	return &info, nil
+}
+
+// RestoreTrash 将回收站条目移回原对象键；原位置已有对象时该条目恢复失败.
+func (fs *FileService) RestoreTrash(ctx context.Context, user string, trashIDs []string) (*types.TrashOpResponse, error) {
+	return fs.eachTrashItem(ctx, user, trashIDs, fs.restoreTrashItem), nil
+}
+
+// DeleteTrash 永久删除回收站条目.
+func (fs *FileService) DeleteTrash(ctx context.Context, user string, trashIDs []string) (*types.TrashOpResponse, error) {
+	resp := fs.eachTrashItem(ctx, user, trashIDs, fs.purgeTrashItem)
+	auditTrashOp(ctx, user, types.AuditActionTrashDelete, resp)
+
+	return resp, nil
+}
+
+// EmptyTrash 永久删除用户回收站中的全部条目.
+func (fs *FileService) EmptyTrash(ctx context.Context, user string) (*types.TrashOpResponse, error) {
+	resp, err := fs.purgeTrashWhere(ctx, fs.dbClient.GetDB().Where("user = ?", user))
+	auditTrashOp(ctx, user, types.AuditActionTrashEmpty, resp)
+
+	return resp, err
+}
+
+// CleanExpiredTrash 永久删除已过保留期的回收站条目，原对象键处于保护中的条目保留到保护解除；user 为空时处理全部用户.
+func (fs *FileService) CleanExpiredTrash(ctx context.Context, user string) (*types.TrashOpResponse, error) {
+	q := fs.dbClient.GetDB().Where("expire_at <= ? AND object_key NOT IN (?)", time.Now().UTC(), fs.heldObjectKeys(ctx))
+	if user != "" {
+		q = q.Where("user = ?", user)
+	}
+
+	resp, err := fs.purgeTrashWhere(ctx, q)
+
+	// 定时清理没有过期条目时不记录
+	if resp.Total > 0 {
+		actor := user
+		if actor == "" {
+			actor = types.AuditActorSystem
+		}
+
+		auditTrashOp(ctx, actor, types.AuditActionTrashDelete, resp)
+	}
+
+	return resp, err
+}
+
+// trashObject 复制对象到回收站目录，写入条目后删除原对象与文件记录.
+func (fs *FileService) trashObject(ctx context.Context, user, objectKey, reason string) (*model.TrashItem, error) {
+	if !strings.HasPrefix(objectKey, user+"/") {
+		return nil, fmt.Errorf("access denied: object does not belong to user")
+	}
+
+	if err := fs.checkHold(ctx, user, objectKey, holdActionTrash); err != nil {
+		return nil, err
+	}
+
+	// 回收站对象与原对象位于同一 bucket，恢复时移回 item.Bucket
+	bucket, err := fs.objectBucket(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if isNoSuchKey(err) {
+			return nil, ErrObjectNotFound
+		}
+
+		return nil, fmt.Errorf("stat object: %w", err)
+	}
+
+	now := time.Now().UTC()
+	id := "tr_" + newULID(now)
+	item := &model.TrashItem{
+		TrashID:     id,
+		User:        user,
+		ObjectKey:   objectKey,
+		TrashKey:    trashPrefix + id + "/" + objectKey,
+		Bucket:      bucket,
+		FileName:    lastPathComponent(objectKey),
+		Size:        info.Size,
+		ETag:        strings.Trim(info.ETag, "\""),
+		ContentType: info.ContentType,
+		Reason:      reason,
+		TrashedAt:   now,
+		ExpireAt:    now.Add(configs.GetConfig().Trash.GetRetention()),
+	}
+
+	if err := fs.copyObject(ctx, bucket, bucket, objectKey, item.TrashKey); err != nil {
+		return nil, fmt.Errorf("copy to trash: %w", err)
+	}
+
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	if err := dbx.Create(item).Error; err != nil {
+		_ = fs.s3Client.RemoveObject(ctx, bucket, item.TrashKey, minio.RemoveObjectOptions{})
+		return nil, fmt.Errorf("create trash item: %w", err)
+	}
+
+	if err := fs.s3Client.RemoveObject(ctx, bucket, objectKey, minio.RemoveObjectOptions{}); err != nil {
+		_ = dbx.Delete(item).Error
+		_ = fs.s3Client.RemoveObject(ctx, bucket, item.TrashKey, minio.RemoveObjectOptions{})
+
+		return nil, fmt.Errorf("remove object: %w", err)
+	}
+
+	if err := dbx.Where("user = ? AND object_key = ?", user, objectKey).Delete(&model.Files{}).Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Msg("delete file record failed")
+	}
+
+	publishEvent(ctx, fs.mqClient, queue.TopicObjectDeleted, queue.ObjectDeletedPayload{
+		Object: queue.ObjectRef{Bucket: bucket, ObjectKey: objectKey, ETag: item.ETag},
+	})
+
+	return item, nil
+}
+
+// restoreTrashItem 将对象移回原位置，恢复文件记录（保留原有分类、描述、标签）.
+func (fs *FileService) restoreTrashItem(ctx context.Context, item *model.TrashItem) error {
+	if _, err := fs.s3Client.StatObject(ctx, item.Bucket, item.ObjectKey, minio.StatObjectOptions{}); err == nil {
+		return fmt.Errorf("object already exists at %s", item.ObjectKey)
+	} else if !isNoSuchKey(err) {
+		return fmt.Errorf("stat object: %w", err)
+	}
+
+	if err := fs.copyObject(ctx, item.Bucket, item.Bucket, item.TrashKey, item.ObjectKey); err != nil {
+		return fmt.Errorf("copy from trash: %w", err)
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Delete(item).Error; err != nil {
+		return fmt.Errorf("delete trash item: %w", err)
+	}
+
+	if err := fs.s3Client.RemoveObject(ctx, item.Bucket, item.TrashKey, minio.RemoveObjectOptions{}); err != nil {
+		nlog.Logger().Warn().Err(err).Str("trash_key", item.TrashKey).Msg("remove restored trash object failed")
+	}
+
+	fs.recordObject(ctx, item.User, item.Bucket, item.ObjectKey, item.FileName, restoreSource, "", nil)
+
+	return nil
+}
+
+// purgeTrashItem 删除回收站对象与条目；原对象键处于保护中时拒绝.
+func (fs *FileService) purgeTrashItem(ctx context.Context, item *model.TrashItem) error {
+	if err := fs.checkHold(ctx, item.User, item.ObjectKey, holdActionPurgeTrash); err != nil {
+		return err
+	}
+
+	err := fs.s3Client.RemoveObject(ctx, item.Bucket, item.TrashKey, minio.RemoveObjectOptions{})
+	if err != nil && !isNoSuchKey(err) {
+		return fmt.Errorf("remove trash object: %w", err)
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Delete(item).Error; err != nil {
+		return fmt.Errorf("delete trash item: %w", err)
+	}
+
+	return nil
+}
+
+// purgeTrashWhere 分批永久删除满足条件的条目.
+func (fs *FileService) purgeTrashWhere(ctx context.Context, cond *gorm.DB) (*types.TrashOpResponse, error) {
+	resp := &types.TrashOpResponse{Results: []types.TrashOpResult{}}
+
+	var rows []model.TrashItem
+
+	// 失败的条目保留在表中，按 trash_id 游标推进避免重复读取
+	last := ""
+
+	for {
+		rows = rows[:0]
+		if err := fs.dbClient.GetDB().WithContext(ctx).Where(cond).Where("trash_id > ?", last).
+			Order("trash_id ASC").Limit(DefaultSliceCapacity).Find(&rows).Error; err != nil {
+			return resp, fmt.Errorf("list trash: %w", err)
+		}
+
+		if len(rows) == 0 {
+			return resp, nil
+		}
+
+		for i := range rows {
+			result := types.TrashOpResult{TrashID: rows[i].TrashID, ObjectKey: rows[i].ObjectKey}
+			if err := fs.purgeTrashItem(ctx, &rows[i]); err != nil {
+				result.Error = err.Error()
+			}
+
+			resp.Total++
+			addTrashResult(resp, result)
+		}
+
+		last = rows[len(rows)-1].TrashID
+	}
+}
+
+// eachTrashItem 对每个条目执行操作并汇总结果.
+func (fs *FileService) eachTrashItem(ctx context.Context, user string, trashIDs []string,
+	op func(context.Context, *model.TrashItem) error) *types.TrashOpResponse {
+	resp := &types.TrashOpResponse{Results: make([]types.TrashOpResult, 0, len(trashIDs)), Total: len(trashIDs)}
+
+	for _, id := range trashIDs {
+		result := types.TrashOpResult{TrashID: id}
+
+		item, err := fs.getTrashItem(ctx, user, id)
+		if err == nil {
+			result.ObjectKey = item.ObjectKey
+			err = op(ctx, item)
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		addTrashResult(resp, result)
+	}
+
+	return resp
+}
+
+// auditTrashOp 将回收站批量操作汇总为一条审计记录，目标为条目的原对象键（条目不存在时为回收站 ID）.
+func auditTrashOp(ctx context.Context, actor, action string, resp *types.TrashOpResponse) {
+	targets := make([]string, 0, len(resp.Results))
+	for _, r := range resp.Results {
+		if r.ObjectKey != "" {
+			targets = append(targets, r.ObjectKey)
+		} else {
+			targets = append(targets, r.TrashID)
+		}
+	}
+
+	RecordAuditBatch(ctx, actor, action, targets, resp.Failed)
+}
+
+// addTrashResult 追加单条结果并更新成功/失败计数.
+func addTrashResult(resp *types.TrashOpResponse, result types.TrashOpResult) {
+	result.Success = result.Error == ""
+	if result.Success {
+		resp.Success++
+	} else {
+		resp.Failed++
+	}
+
+	resp.Results = append(resp.Results, result)
+}
+
+func (fs *FileService) getTrashItem(ctx context.Context, user, trashID string) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("trash_id = ? AND user = ?", trashID, user).Take(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTrashItemNotFound
+		}
+
+		return nil, fmt.Errorf("load trash item: %w", err)
+	}
+
+	return &item, nil
+}
+
+func toTrashItemInfo(it *model.TrashItem) types.TrashItemInfo {
+	return types.TrashItemInfo{
+		TrashID:     it.TrashID,
+		ObjectKey:   it.ObjectKey,
+		FileName:    it.FileName,
+		Size:        it.Size,
+		ETag:        it.ETag,
+		ContentType: it.ContentType,
+		Reason:      it.Reason,
+		TrashedAt:   it.TrashedAt,
+		ExpireAt:    it.ExpireAt,
+	}
+}
@@ -2,29 +2,58 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/minio/minio-go/v7"
 
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
 	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/queue"
 )
 
+// uploadSource 经服务端上传接口写入的对象在 ObjectStoredPayload.Source 中的来源标识.
+const uploadSource = "upload"
+
 // PresignedPostURLsPolicy 生成预签名 POST URLs，用于客户端批量直接上传，使用策略控制.
 func (fs *FileService) PresignedPostURLsPolicy(ctx context.Context, user string,
 	req *types.UploadFilesRequestPolicy,
-) (*types.UploadFilesResponsePolicy, error) {
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return nil, err
-	}
-
+) (_ *types.UploadFilesResponsePolicy, err error) {
 	var results = make([]types.PresignedUploadItem, 0, len(req.Files))
 
+	keys := make([]string, 0, len(req.Files))
+	defer func() { RecordAudit(ctx, user, types.AuditActionFileUploadURL, keys, err, "post policy") }()
+
 	for _, file := range req.Files {
 		// 构建对象键
 		objectKey := buildObjectKey(user, &file)
+		keys = append(keys, objectKey)
+
+		// 受保护的文件不能被覆盖
+		if err := fs.checkHold(ctx, user, objectKey, holdActionOverwrite); err != nil {
+			return nil, err
+		}
+
+		// 直传时大小未知，按声明的 Content-Type 路由
+		bucket, err := fs.writeBucket(ctx, objectKey, routeTarget{User: user, ContentType: file.ContentType, Size: -1})
+		if err != nil {
+			return nil, err
+		}
+
+		// 需要服务端加密内容的对象不能由客户端直传
+		if err := s3.CheckClientUpload(bucket, objectKey); err != nil {
+			return nil, err
+		}
+
+		// 客户端直传无法在写入前介入，模拟版本化时在签发时归档当前内容
+		if err := fs.archiveCurrentVersion(ctx, bucket, user, objectKey); err != nil {
+			return nil, fmt.Errorf("archive %s: %w", file.FileName, err)
+		}
 
 		// 为每个文件创建新的策略对象，避免条件累积
 		policy := minio.NewPostPolicy()
@@ -57,17 +86,33 @@ func (fs *FileService) PresignedPostURLsPolicy(ctx context.Context, user string,
 // PresignedPutURLs 生成预签名 PUT URLs，用于客户端批量直接上传，不使用策略.
 func (fs *FileService) PresignedPutURLs(ctx context.Context, user string,
 	req *types.UploadFilesRequest,
-) (*types.UploadFilesResponse, error) {
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return nil, err
-	}
-
+) (_ *types.UploadFilesResponse, err error) {
 	var results = make([]types.PresignedPutItem, 0, len(req.Files))
 
+	keys := make([]string, 0, len(req.Files))
+	defer func() { RecordAudit(ctx, user, types.AuditActionFileUploadURL, keys, err, "put") }()
+
 	for _, file := range req.Files {
 		// 构建对象键
 		objectKey := buildObjectKey(user, &file)
+		keys = append(keys, objectKey)
+
+		if err := fs.checkHold(ctx, user, objectKey, holdActionOverwrite); err != nil {
+			return nil, err
+		}
+
+		bucket, err := fs.writeBucket(ctx, objectKey, routeTarget{User: user, ContentType: file.ContentType, Size: -1})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s3.CheckClientUpload(bucket, objectKey); err != nil {
+			return nil, err
+		}
+
+		if err := fs.archiveCurrentVersion(ctx, bucket, user, objectKey); err != nil {
+			return nil, fmt.Errorf("archive %s: %w", file.FileName, err)
+		}
 
 		// 生成预签名 PUT URL
 		url, err := fs.s3Client.PresignedPutObject(ctx, bucket, objectKey, DefaultPresignedOpTimeout)
@@ -89,12 +134,7 @@ func (fs *FileService) PresignedPutURLs(ctx context.Context, user string,
 
 // UploadSingleFile 上传单个小文件.
 func (fs *FileService) UploadSingleFile(ctx context.Context, user string,
-	fileName string, fileReader io.Reader, size int64, metadata *types.UploadFileMetadata) (*types.UploadFileResponse, error) {
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return nil, err
-	}
-
+	fileName string, fileReader io.Reader, size int64, metadata *types.UploadFileMetadata) (_ *types.UploadFileResponse, err error) {
 	// 使用提供的文件名或原始文件名
 	actualFileName := fileName
 	if metadata != nil && metadata.FileName != "" {
@@ -104,7 +144,23 @@ func (fs *FileService) UploadSingleFile(ctx context.Context, user string,
 	// 构建对象键
 	objectKey := buildObjectKey(user, &types.UploadFileItem{FileName: actualFileName})
 
-	// 计算 hash 和上传
+	defer recordAuditResult(ctx, user, types.AuditActionFileUpload, []string{objectKey}, fmt.Sprintf("size=%d", size), &err)
+
+	// 受保护的文件不能被覆盖
+	if err := fs.checkHold(ctx, user, objectKey, holdActionOverwrite); err != nil {
+		return &types.UploadFileResponse{ObjectKey: objectKey, Success: false, Error: err.Error()}, err
+	}
+
+	bucket, err := fs.writeBucket(ctx, objectKey, uploadRouteTarget(user, objectKey, size, metadata))
+	if err != nil {
+		return nil, err
+	}
+
+	// 模拟版本化时先归档当前内容，再计算 hash 和上传
+	if err := fs.archiveCurrentVersion(ctx, bucket, user, objectKey); err != nil {
+		return &types.UploadFileResponse{ObjectKey: objectKey, Success: false, Error: err.Error()}, err
+	}
+
 	hash, uploadInfo, err := fs.uploadFile(ctx, bucket, objectKey, fileReader, size, metadata)
 	if err != nil {
 		return &types.UploadFileResponse{
@@ -114,6 +170,8 @@ func (fs *FileService) UploadSingleFile(ctx context.Context, user string,
 		}, err
 	}
 
+	fs.recordObject(ctx, user, uploadInfo.Bucket, objectKey, actualFileName, uploadSource, hash, metadata)
+
 	// 构建响应
 	response := fs.buildUploadResponse(objectKey, hash, actualFileName, size, uploadInfo, metadata)
 
@@ -124,15 +182,11 @@ func (fs *FileService) UploadSingleFile(ctx context.Context, user string,
 func (fs *FileService) UploadBatchFiles(ctx context.Context, user string,
 	files map[string]io.Reader, sizes map[string]int64, metadata map[string]*types.UploadFileMetadata) (*types.UploadBatchFilesResponse, error) {
 	results := make([]types.UploadFileResponse, 0, len(files))
+	keys := make([]string, 0, len(files))
 	total := len(files)
 	successful := 0
 	failed := 0
 
-	bucket, err := fs.defaultBucket()
-	if err != nil {
-		return nil, err
-	}
-
 	for fileName, fileReader := range files {
 		size := sizes[fileName]
 		meta := metadata[fileName]
@@ -144,8 +198,28 @@ func (fs *FileService) UploadBatchFiles(ctx context.Context, user string,
 		}
 
 		objectKey := buildObjectKey(user, &types.UploadFileItem{FileName: actualFileName})
+		keys = append(keys, objectKey)
+
+		var (
+			bucket     string
+			hash       string
+			uploadInfo minio.UploadInfo
+		)
+
+		// 受保护的文件不能被覆盖
+		err := fs.checkHold(ctx, user, objectKey, holdActionOverwrite)
+		if err == nil {
+			bucket, err = fs.writeBucket(ctx, objectKey, uploadRouteTarget(user, objectKey, size, meta))
+		}
+
+		if err == nil {
+			err = fs.archiveCurrentVersion(ctx, bucket, user, objectKey)
+		}
+
+		if err == nil {
+			hash, uploadInfo, err = fs.uploadFile(ctx, bucket, objectKey, fileReader, size, meta)
+		}
 
-		hash, uploadInfo, err := fs.uploadFile(ctx, bucket, objectKey, fileReader, size, meta)
 		if err != nil {
 			results = append(results, types.UploadFileResponse{
 				ObjectKey: objectKey,
@@ -154,12 +228,16 @@ func (fs *FileService) UploadBatchFiles(ctx context.Context, user string,
 			})
 			failed++
 		} else {
+			fs.recordObject(ctx, user, uploadInfo.Bucket, objectKey, actualFileName, uploadSource, hash, meta)
+
 			response := fs.buildUploadResponse(objectKey, hash, actualFileName, size, uploadInfo, meta)
 			results = append(results, response)
 			successful++
 		}
 	}
 
+	RecordAuditBatch(ctx, user, types.AuditActionFileUpload, keys, failed)
+
 	return &types.UploadBatchFilesResponse{
 		Results:    results,
 		Total:      total,
@@ -167,3 +245,81 @@ func (fs *FileService) UploadBatchFiles(ctx context.Context, user string,
 		Failed:     failed,
 	}, nil
 }
+
+// recordObject 对象写入（上传、从回收站恢复）后 upsert 文件记录并发布 stored 事件（驱动缩略图、媒体元数据等后续处理）.
+// 已有记录中的分类、描述、标签不会被覆盖；记录或发布失败只记录日志，可由同步/对账补齐.
+func (fs *FileService) recordObject(ctx context.Context, user, bucket, objectKey, fileName, source, hash string,
+	meta *types.UploadFileMetadata) {
+	stat, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Msg("stat stored object failed")
+		return
+	}
+
+	etag := strings.Trim(stat.ETag, "\"")
+	rec := model.Files{
+		User:         user,
+		ObjectKey:    objectKey,
+		FileName:     fileName,
+		Size:         stat.Size,
+		ETag:         etag,
+		ContentType:  stat.ContentType,
+		Bucket:       bucket,
+		VersionID:    stat.VersionID,
+		StorageClass: stat.StorageClass,
+		LastModified: stat.LastModified.UTC(),
+	}
+
+	if meta != nil {
+		rec.Category = meta.Category
+		rec.Description = meta.Description
+
+		if meta.ExpiryDays > 0 {
+			expireAt := time.Now().UTC().AddDate(0, 0, meta.ExpiryDays)
+			rec.ExpireAt = &expireAt
+		}
+
+		if len(meta.Tags) > 0 {
+			if b, err := json.Marshal(meta.Tags); err == nil {
+				rec.TagsJSON = string(b)
+			}
+		}
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Clauses(onConflictUserKeyUpdate()).Create(&rec).Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Msg("upsert file record failed")
+	}
+
+	fs.saveVersion(ctx, &model.FileVersion{
+		User:        user,
+		ObjectKey:   objectKey,
+		VersionID:   stat.VersionID,
+		Bucket:      bucket,
+		Action:      source,
+		Creator:     user,
+		Size:        stat.Size,
+		ETag:        etag,
+		Checksum:    versionChecksum(hash, etag),
+		ContentType: stat.ContentType,
+	})
+
+	stored := queue.ObjectStoredPayload{
+		Object: queue.ObjectRef{
+			Bucket:      bucket,
+			ObjectKey:   objectKey,
+			VersionID:   stat.VersionID,
+			ETag:        etag,
+			Size:        stat.Size,
+			Hash:        hash,
+			ContentType: stat.ContentType,
+		},
+		Source:   source,
+		FileName: fileName,
+	}
+
+	publishEvent(ctx, fs.mqClient, queue.TopicObjectStored, stored)
+
+	if topic := storedTopicByContentType(stat.ContentType); topic != "" {
+		publishEvent(ctx, fs.mqClient, topic, stored)
+	}
+}
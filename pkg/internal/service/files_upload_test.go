@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// TestUploadSingleFile 验证上传后的对象内容、推断或指定的内容类型以及文件记录.
+func TestUploadSingleFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileName        string
+		body            string
+		meta            *types.UploadFileMetadata
+		wantContentType string
+	}{
+		{name: "infer content type", fileName: "notes.md", body: "# notes", wantContentType: "text/markdown"},
+		{
+			name:            "explicit metadata",
+			fileName:        "raw.bin",
+			body:            "data",
+			meta:            &types.UploadFileMetadata{ContentType: "text/plain", Tags: map[string]string{"project": "nv"}},
+			wantContentType: "text/plain",
+		},
+		{name: "rename via metadata", fileName: "upload.tmp", body: "x", meta: &types.UploadFileMetadata{FileName: "final.txt"},
+			wantContentType: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, false)
+			svc := service.NewFileService(ctx)
+
+			resp, err := svc.UploadSingleFile(ctx, testUser, tt.fileName, strings.NewReader(tt.body), int64(len(tt.body)), tt.meta)
+			if err != nil || !resp.Success {
+				t.Fatalf("upload: %+v, %v", resp, err)
+			}
+
+			wantName := tt.fileName
+			if tt.meta != nil && tt.meta.FileName != "" {
+				wantName = tt.meta.FileName
+			}
+
+			if !strings.HasPrefix(resp.ObjectKey, testUser+"/") || !strings.HasSuffix(resp.ObjectKey, "/"+wantName) {
+				t.Fatalf("object key = %q", resp.ObjectKey)
+			}
+
+			info, err := svc.StatObject(ctx, testUser, resp.ObjectKey)
+			if err != nil {
+				t.Fatalf("stat: %v", err)
+			}
+
+			if info.Size != int64(len(tt.body)) || !strings.HasPrefix(info.ContentType, tt.wantContentType) {
+				t.Fatalf("stat = %+v", info)
+			}
+
+			found, err := svc.SearchFiles(ctx, testUser, &types.SearchFilesRequest{Keyword: wantName})
+			if err != nil || found.Total != 1 || found.Files[0].ObjectKey != resp.ObjectKey {
+				t.Fatalf("search = %+v, %v", found, err)
+			}
+		})
+	}
+}
+
+// TestUploadSingleFileAccess 验证其他用户无法读取对象.
+func TestUploadSingleFileAccess(t *testing.T) {
+	ctx := newTestContext(t, false)
+	key := uploadText(t, ctx, "private.txt", "secret")
+
+	if _, _, err := service.NewFileService(ctx).OpenObject(ctx, "bob@example.com", key); err == nil {
+		t.Fatal("expected access denied for another user")
+	}
+}
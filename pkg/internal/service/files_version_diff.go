@@ -0,0 +1,324 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/textdiff"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// defaultDiffContext 版本对比默认的上下文行数.
+const defaultDiffContext = 3
+
+// textExtensions 内容类型缺失或为 application/octet-stream 时按扩展名识别的常见文本格式
+// （mime 包的内置表不包含 Markdown 等格式）.
+var textExtensions = []string{
+	".md", ".markdown", ".txt", ".csv", ".tsv", ".log", ".json", ".yaml", ".yml", ".toml", ".ini",
+	".xml", ".html", ".htm", ".css", ".js", ".ts", ".go", ".py", ".sh", ".sql", ".tex", ".rst", ".org",
+}
+
+// DiffFileVersions 对比同一对象的两个版本；To 为空时与当前版本对比.
+// 两个版本均为文本且不超过大小上限时逐行对比，否则只比较大小、校验和与元数据.
+func (fs *FileService) DiffFileVersions(ctx context.Context, user, objectKey string,
+	q *types.DiffFileVersionsQuery) (*types.FileVersionDiff, error) {
+	if user == "" || !strings.HasPrefix(objectKey, user+"/") {
+		return nil, ErrObjectAccessDenied
+	}
+
+	bucket, err := fs.objectBucket(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := fs.statVersion(ctx, user, bucket, objectKey, q.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := fs.statVersion(ctx, user, bucket, objectKey, q.To)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &types.FileVersionDiff{
+		ObjectKey:       objectKey,
+		From:            *from,
+		To:              *to,
+		SizeDelta:       to.Size - from.Size,
+		MetadataChanges: compareVersionMetadata(from, to),
+		SameContent:     sameVersionContent(from, to),
+	}
+
+	limit := configs.GetConfig().Versions.GetDiffMaxSize()
+
+	switch {
+	case !isTextContent(from.ContentType, objectKey) || !isTextContent(to.ContentType, objectKey):
+		diff.Reason = types.DiffReasonBinary
+		return diff, nil
+	case from.Size > limit || to.Size > limit:
+		diff.Reason = types.DiffReasonTooLarge
+		return diff, nil
+	}
+
+	a, ok, err := fs.readVersionLines(ctx, bucket, objectKey, from.VersionID, limit)
+	if err != nil || !ok {
+		diff.Reason = types.DiffReasonBinary
+		return diff, err
+	}
+
+	b, ok, err := fs.readVersionLines(ctx, bucket, objectKey, to.VersionID, limit)
+	if err != nil || !ok {
+		diff.Reason = types.DiffReasonBinary
+		return diff, err
+	}
+
+	contextLines := defaultDiffContext
+	if q.Context != nil {
+		contextLines = *q.Context
+	}
+
+	diff.Text = true
+	diff.SameContent = slices.Equal(a, b)
+	diff.Hunks = toDiffHunks(textdiff.Hunks(textdiff.Lines(a, b, 0), contextLines), a, b)
+
+	for _, h := range diff.Hunks {
+		for _, l := range h.Lines {
+			switch l.Op {
+			case types.DiffOpInsert:
+				diff.Added++
+			case types.DiffOpDelete:
+				diff.Removed++
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+// WriteUnifiedDiff 以 unified diff 格式逐块写出对比结果；未进行文本对比时写出一行说明.
+func WriteUnifiedDiff(w io.Writer, diff *types.FileVersionDiff) error {
+	bw := bufio.NewWriter(w)
+	fromName := "a/" + diff.ObjectKey + "@" + diff.From.VersionID
+	toName := "b/" + diff.ObjectKey + "@" + diff.To.VersionID
+
+	switch {
+	case diff.SameContent:
+	case diff.Reason == types.DiffReasonBinary:
+		fmt.Fprintf(bw, "Binary files %s and %s differ\n", fromName, toName)
+	case diff.Reason == types.DiffReasonTooLarge:
+		fmt.Fprintf(bw, "Files %s and %s differ (too large to compare)\n", fromName, toName)
+	case len(diff.Hunks) > 0:
+		fmt.Fprintf(bw, "--- %s\n+++ %s\n", fromName, toName)
+
+		for _, h := range diff.Hunks {
+			fmt.Fprintf(bw, "@@ -%s +%s @@\n", unifiedRange(h.FromStart, h.FromCount), unifiedRange(h.ToStart, h.ToCount))
+
+			for _, l := range h.Lines {
+				prefix := " "
+
+				switch l.Op {
+				case types.DiffOpDelete:
+					prefix = "-"
+				case types.DiffOpInsert:
+					prefix = "+"
+				}
+
+				bw.WriteString(prefix + l.Text + "\n")
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// statVersion 读取指定版本（为空时为当前版本）的对象信息；校验和优先取版本记录中的值.
+func (fs *FileService) statVersion(ctx context.Context, user, bucket, objectKey, versionID string) (*types.FileVersionSide, error) {
+	key, nativeID := fs.versionObject(ctx, bucket, objectKey, versionID)
+
+	info, err := fs.s3Client.StatObject(ctx, bucket, key, minio.StatObjectOptions{VersionID: nativeID})
+	if err != nil {
+		if isNoSuchKey(err) || minio.ToErrorResponse(err).Code == "NoSuchVersion" {
+			return nil, fmt.Errorf("%w: %s@%s", ErrVersionNotFound, objectKey, versionID)
+		}
+
+		return nil, fmt.Errorf("stat version: %w", err)
+	}
+
+	if key != objectKey {
+		info.VersionID = versionID
+	}
+
+	side := &types.FileVersionSide{
+		VersionID:    normalizeVersionID(info.VersionID),
+		Size:         info.Size,
+		ETag:         strings.Trim(info.ETag, "\""),
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified.UTC().Format(time.RFC3339),
+		UserMetadata: info.UserMetadata,
+	}
+
+	var row model.FileVersion
+	if err := fs.dbClient.GetDB().WithContext(ctx).Select("checksum").
+		Where("user = ? AND object_key = ? AND version_id = ?", user, objectKey, side.VersionID).
+		Take(&row).Error; err == nil {
+		side.Checksum = row.Checksum
+	}
+
+	side.Checksum = versionChecksum(side.Checksum, side.ETag)
+
+	return side, nil
+}
+
+// readVersionLines 读取版本内容并按行拆分，最多读取 limit 字节；内容不是合法 UTF-8 时返回 false.
+func (fs *FileService) readVersionLines(ctx context.Context, bucket, objectKey, versionID string,
+	limit int64) ([]string, bool, error) {
+	key, nativeID := fs.versionObject(ctx, bucket, objectKey, versionID)
+
+	obj, err := fs.s3Client.GetObject(ctx, bucket, key, minio.GetObjectOptions{VersionID: nativeID})
+	if err != nil {
+		return nil, false, fmt.Errorf("get version %s@%s: %w", objectKey, versionID, err)
+	}
+	defer obj.Close()
+
+	data, err := io.ReadAll(io.LimitReader(obj, limit))
+	if err != nil {
+		return nil, false, fmt.Errorf("read version %s@%s: %w", objectKey, versionID, err)
+	}
+
+	if !utf8.Valid(data) {
+		return nil, false, nil
+	}
+
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil, true, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+
+	return lines, true, nil
+}
+
+// toDiffHunks 将差异块转换为带行文本的对外结构.
+func toDiffHunks(hunks []textdiff.Hunk, a, b []string) []types.DiffHunk {
+	out := make([]types.DiffHunk, 0, len(hunks))
+
+	for _, h := range hunks {
+		dh := types.DiffHunk{
+			FromStart: h.AStart,
+			FromCount: h.ALen,
+			ToStart:   h.BStart,
+			ToCount:   h.BLen,
+			Lines:     make([]types.DiffLine, 0, len(h.Edits)),
+		}
+
+		for _, e := range h.Edits {
+			switch e.Kind {
+			case textdiff.Equal:
+				dh.Lines = append(dh.Lines, types.DiffLine{Op: types.DiffOpEqual, FromLine: e.A + 1, ToLine: e.B + 1, Text: a[e.A]})
+			case textdiff.Delete:
+				dh.Lines = append(dh.Lines, types.DiffLine{Op: types.DiffOpDelete, FromLine: e.A + 1, Text: a[e.A]})
+			case textdiff.Insert:
+				dh.Lines = append(dh.Lines, types.DiffLine{Op: types.DiffOpInsert, ToLine: e.B + 1, Text: b[e.B]})
+			}
+		}
+
+		out = append(out, dh)
+	}
+
+	return out
+}
+
+// compareVersionMetadata 比较内容类型与用户元数据.
+func compareVersionMetadata(from, to *types.FileVersionSide) []types.MetadataChange {
+	var changes []types.MetadataChange
+
+	if from.ContentType != to.ContentType {
+		changes = append(changes, types.MetadataChange{Field: "content_type", From: from.ContentType, To: to.ContentType})
+	}
+
+	keys := slices.Collect(maps.Keys(from.UserMetadata))
+	for k := range to.UserMetadata {
+		if _, ok := from.UserMetadata[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if a, b := from.UserMetadata[k], to.UserMetadata[k]; a != b {
+			changes = append(changes, types.MetadataChange{Field: "user_metadata." + k, From: a, To: b})
+		}
+	}
+
+	return changes
+}
+
+// sameVersionContent 按校验和（没有时按 ETag）判断两个版本内容是否相同.
+func sameVersionContent(from, to *types.FileVersionSide) bool {
+	if from.Size != to.Size {
+		return false
+	}
+
+	if from.Checksum != "" && to.Checksum != "" {
+		return from.Checksum == to.Checksum
+	}
+
+	return from.ETag != "" && from.ETag == to.ETag
+}
+
+// isTextContent 判断内容类型是否为文本；类型缺失或为通用二进制类型时按扩展名判断.
+func isTextContent(contentType, objectKey string) bool {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	ct = strings.TrimSpace(ct)
+
+	if ct == "" || ct == "application/octet-stream" {
+		ext := strings.ToLower(path.Ext(objectKey))
+		if slices.Contains(textExtensions, ext) {
+			return true
+		}
+
+		ct, _, _ = strings.Cut(mime.TypeByExtension(ext), ";")
+	}
+
+	switch {
+	case strings.HasPrefix(ct, "text/"), strings.HasSuffix(ct, "+json"), strings.HasSuffix(ct, "+xml"):
+		return true
+	}
+
+	switch ct {
+	case "application/json", "application/xml", "application/yaml", "application/x-yaml", "application/toml",
+		"application/javascript", "application/x-sh", "application/sql":
+		return true
+	default:
+		return false
+	}
+}
+
+// unifiedRange 格式化 @@ 行中的范围，长度为 1 时省略长度.
+func unifiedRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+
+	return strconv.Itoa(start) + "," + strconv.Itoa(count)
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// versionsPrefix 模拟版本化时历史版本的存放目录：.versions/<object_key>/<version_id>.
+const versionsPrefix = ".versions/"
+
+// archiveVersionLayout 模拟版本的 ID 格式（归档时间，按字典序即时间序）.
+const archiveVersionLayout = "20060102T150405.000000000Z"
+
+// bucketVersioning 缓存 auto 模式下各存储桶是否启用了原生版本化（进程生命周期内不变）.
+var bucketVersioning sync.Map
+
+// emulatedVersioning 判断存储桶是否使用模拟版本化；auto 模式下查询失败时按原生版本处理且不缓存.
+func (fs *FileService) emulatedVersioning(ctx context.Context, bucket string) bool {
+	switch configs.GetConfig().Versions.Mode {
+	case configs.VersionsModeEmulated:
+		return true
+	case configs.VersionsModeNative:
+		return false
+	}
+
+	if enabled, ok := bucketVersioning.Load(bucket); ok {
+		return !enabled.(bool)
+	}
+
+	cfg, err := fs.s3Client.GetBucketVersioning(ctx, bucket)
+	if err != nil {
+		nlog.Logger().Warn().Err(err).Str("bucket", bucket).Msg("get bucket versioning failed")
+		return false
+	}
+
+	bucketVersioning.Store(bucket, cfg.Enabled())
+
+	return !cfg.Enabled()
+}
+
+// versionObject 返回读取指定版本时使用的对象键与原生 VersionID：模拟版本化时历史版本位于 .versions/ 下.
+func (fs *FileService) versionObject(ctx context.Context, bucket, objectKey, versionID string) (key, nativeID string) {
+	if isCurrentVersionID(versionID) || !fs.emulatedVersioning(ctx, bucket) {
+		return objectKey, versionID
+	}
+
+	return archiveKey(objectKey, versionID), ""
+}
+
+// archiveCurrentVersion 模拟版本化时在覆盖写入前将当前内容复制为历史版本，并把当前版本记录改为归档的版本 ID.
+// 对象不存在、使用原生版本化或最新历史版本与当前内容相同时不做任何操作.
+func (fs *FileService) archiveCurrentVersion(ctx context.Context, bucket, user, objectKey string) error {
+	if !fs.emulatedVersioning(ctx, bucket) {
+		return nil
+	}
+
+	info, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		if isNoSuchKey(err) {
+			return nil
+		}
+
+		return fmt.Errorf("stat current version: %w", err)
+	}
+
+	etag := strings.Trim(info.ETag, "\"")
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	var latest model.FileVersion
+	if err := dbx.Where("user = ? AND object_key = ? AND version_id <> ''", user, objectKey).
+		Order("created_at DESC, id DESC").Take(&latest).Error; err == nil && latest.ETag == etag {
+		return nil
+	}
+
+	id := time.Now().UTC().Format(archiveVersionLayout)
+	if err := fs.copyObject(ctx, bucket, bucket, objectKey, archiveKey(objectKey, id)); err != nil {
+		return fmt.Errorf("archive current version: %w", err)
+	}
+
+	res := dbx.Model(&model.FileVersion{}).Where("user = ? AND object_key = ? AND version_id = ''", user, objectKey).
+		Update("version_id", id)
+	if res.Error != nil {
+		nlog.Logger().Warn().Err(res.Error).Str("object_key", objectKey).Msg("rename archived version record failed")
+	}
+
+	if res.Error == nil && res.RowsAffected == 0 {
+		fs.saveVersion(ctx, &model.FileVersion{
+			User:        user,
+			ObjectKey:   objectKey,
+			VersionID:   id,
+			Bucket:      bucket,
+			Action:      versionActionImported,
+			Size:        info.Size,
+			ETag:        etag,
+			Checksum:    versionChecksum("", etag),
+			ContentType: info.ContentType,
+			CreatedAt:   info.LastModified.UTC(),
+		})
+	}
+
+	return nil
+}
+
+// deleteEmulatedVersion 删除模拟版本：删除历史版本只移除归档对象；
+// 删除当前版本时移除对象并把最新的历史版本提升为当前版本（与原生版本化删除最新版本的行为一致）.
+func (fs *FileService) deleteEmulatedVersion(ctx context.Context, bucket, user, objectKey, versionID string) error {
+	if !isCurrentVersionID(versionID) {
+		err := fs.s3Client.RemoveObject(ctx, bucket, archiveKey(objectKey, versionID), minio.RemoveObjectOptions{})
+		if err != nil {
+			return fmt.Errorf("remove archived version: %w", err)
+		}
+
+		fs.markVersionDeleted(ctx, user, objectKey, versionID)
+
+		return nil
+	}
+
+	if err := fs.s3Client.RemoveObject(ctx, bucket, objectKey, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("remove current version: %w", err)
+	}
+
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+	if err := dbx.Unscoped().Where("user = ? AND object_key = ? AND version_id = ''", user, objectKey).
+		Delete(&model.FileVersion{}).Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Msg("delete current version record failed")
+	}
+
+	prev, err := fs.latestArchivedVersion(ctx, bucket, objectKey)
+	if err != nil || prev == "" {
+		return err
+	}
+
+	if err := fs.copyObject(ctx, bucket, bucket, archiveKey(objectKey, prev), objectKey); err != nil {
+		return fmt.Errorf("promote version %s: %w", prev, err)
+	}
+
+	if err := fs.s3Client.RemoveObject(ctx, bucket, archiveKey(objectKey, prev), minio.RemoveObjectOptions{}); err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Str("version_id", prev).
+			Msg("remove promoted archive failed")
+	}
+
+	if err := dbx.Model(&model.FileVersion{}).Where("user = ? AND object_key = ? AND version_id = ?", user, objectKey, prev).
+		Update("version_id", "").Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Msg("promote version record failed")
+	}
+
+	return nil
+}
+
+// latestArchivedVersion 返回对象最新的模拟历史版本 ID，没有历史版本时返回空串.
+func (fs *FileService) latestArchivedVersion(ctx context.Context, bucket, objectKey string) (string, error) {
+	prefix := versionsPrefix + objectKey + "/"
+	latest := ""
+
+	for obj := range fs.s3Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if obj.Err != nil {
+			return "", fmt.Errorf("list archived versions: %w", obj.Err)
+		}
+
+		if id := strings.TrimPrefix(obj.Key, prefix); id > latest {
+			latest = id
+		}
+	}
+
+	return latest, nil
+}
+
+// listEmulatedVersions 列出当前对象与 .versions/ 下的历史版本，用于导入版本记录.
+func (fs *FileService) listEmulatedVersions(ctx context.Context, bucket, objectKey string) ([]types.FileVersionInfo, error) {
+	versions := make([]types.FileVersionInfo, 0, defaultVersionsCap)
+
+	info, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
+	if err == nil {
+		versions = append(versions, emulatedVersionInfo(bucket, objectKey, "", true, &info))
+	} else if !isNoSuchKey(err) {
+		return nil, fmt.Errorf("stat object %s: %w", objectKey, err)
+	}
+
+	prefix := versionsPrefix + objectKey + "/"
+	for obj := range fs.s3Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("list archived versions for %s: %w", objectKey, obj.Err)
+		}
+
+		st, err := fs.s3Client.StatObject(ctx, bucket, obj.Key, minio.StatObjectOptions{})
+		if err != nil {
+			continue
+		}
+
+		versions = append(versions, emulatedVersionInfo(bucket, objectKey, strings.TrimPrefix(obj.Key, prefix), false, &st))
+	}
+
+	return versions, nil
+}
+
+func emulatedVersionInfo(bucket, objectKey, versionID string, latest bool, info *minio.ObjectInfo) types.FileVersionInfo {
+	return types.FileVersionInfo{
+		ObjectKey:    objectKey,
+		VersionID:    versionID,
+		IsLatest:     latest,
+		Size:         info.Size,
+		ETag:         strings.Trim(info.ETag, "\""),
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified.UTC().Format(time.RFC3339),
+		StorageClass: info.StorageClass,
+		Bucket:       bucket,
+		UserMetadata: info.UserMetadata,
+	}
+}
+
+// ensureCurrentVersion 模拟版本化时补齐缺失的当前版本记录（如预签名上传后未收到存储桶事件）.
+func (fs *FileService) ensureCurrentVersion(ctx context.Context, bucket, user, objectKey string,
+	rows []model.FileVersion) ([]model.FileVersion, error) {
+	if len(rows) == 0 || rows[0].VersionID == "" || !fs.emulatedVersioning(ctx, bucket) {
+		return rows, nil
+	}
+
+	if _, err := fs.findVersion(ctx, user, objectKey, ""); err != nil {
+		if errors.Is(err, ErrVersionNotFound) {
+			return rows, nil
+		}
+
+		return nil, err
+	}
+
+	return fs.loadVersions(ctx, user, objectKey)
+}
+
+// archiveKey 返回模拟历史版本的对象键.
+func archiveKey(objectKey, versionID string) string {
+	return versionsPrefix + objectKey + "/" + versionID
+}
+
+// isCurrentVersionID 判断版本 ID 是否指向当前版本（为空或对象存储的 "null"）.
+func isCurrentVersionID(versionID string) bool {
+	return versionID == "" || versionID == "null"
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// 版本记录中的来源（上传类来源沿用 ObjectStoredPayload.Source 的取值）.
+const (
+	versionActionCreate   = "create"
+	versionActionRestore  = "restore"
+	versionActionImported = "imported" // 版本记录之前已存在、从对象存储导入的版本
+)
+
+// ErrVersionNotFound 版本不存在或已删除.
+var ErrVersionNotFound = errors.New("version not found")
+
+// SetFileVersionLabels 替换版本的标签并可选更新说明；同一对象内标签唯一，已被其他版本使用的标签移到该版本.
+// 没有版本记录的历史版本会先从对象存储导入.
+func (fs *FileService) SetFileVersionLabels(ctx context.Context, user, objectKey, versionID string,
+	req *types.SetFileVersionLabelsRequest) (*types.FileVersionInfo, error) {
+	if user == "" || !strings.HasPrefix(objectKey, user+"/") {
+		return nil, ErrObjectAccessDenied
+	}
+
+	row, err := fs.findVersion(ctx, user, objectKey, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fs.assignVersionLabels(ctx, row, req.Labels); err != nil {
+		return nil, err
+	}
+
+	if req.Message != nil && *req.Message != row.Message {
+		if err := fs.dbClient.GetDB().WithContext(ctx).Model(row).Update("message", *req.Message).Error; err != nil {
+			return nil, fmt.Errorf("update version message: %w", err)
+		}
+	}
+
+	latest, err := fs.loadVersions(ctx, user, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	info := toVersionInfo(row, len(latest) > 0 && latest[0].ID == row.ID)
+
+	return &info, nil
+}
+
+// trackVersion 读取新写入版本的对象信息并保存版本记录，labels 不为空时同时设置标签；失败只记录日志.
+func (fs *FileService) trackVersion(ctx context.Context, bucket string, v *model.FileVersion, labels []string) {
+	info, err := fs.s3Client.StatObject(ctx, bucket, v.ObjectKey, minio.StatObjectOptions{VersionID: v.VersionID})
+	if err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", v.ObjectKey).Str("version_id", v.VersionID).
+			Msg("stat new version failed")
+
+		return
+	}
+
+	etag := strings.Trim(info.ETag, "\"")
+	v.Bucket, v.VersionID = bucket, info.VersionID
+	v.Size, v.ETag, v.Checksum, v.ContentType = info.Size, etag, versionChecksum("", etag), info.ContentType
+
+	if !fs.saveVersion(ctx, v) || len(labels) == 0 {
+		return
+	}
+
+	if err := fs.assignVersionLabels(ctx, v, labels); err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", v.ObjectKey).Msg("set version labels failed")
+	}
+}
+
+// saveVersion 写入或更新版本记录，返回是否成功.
+// 同一版本重复记录（如上传后又收到存储桶事件）时保留已有的作者、说明与标签；
+// 未启用版本化的对象被覆盖写入（或版本删除后重建）时以新内容替换原记录.
+func (fs *FileService) saveVersion(ctx context.Context, v *model.FileVersion) bool {
+	if fs.dbClient == nil || fs.dbClient.GetDB() == nil {
+		return false
+	}
+
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+	v.VersionID = normalizeVersionID(v.VersionID)
+
+	var prev model.FileVersion
+
+	err := dbx.Unscoped().Where("user = ? AND object_key = ? AND version_id = ?", v.User, v.ObjectKey, v.VersionID).
+		Take(&prev).Error
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = dbx.Create(v).Error
+	case err != nil:
+	case prev.ETag == v.ETag && !prev.DeletedAt.Valid:
+		checksum := v.Checksum
+		*v = prev
+
+		if v.Checksum == "" && checksum != "" {
+			v.Checksum = checksum
+			err = dbx.Model(&prev).Update("checksum", checksum).Error
+		}
+	default:
+		v.ID, v.CreatedAt = prev.ID, time.Now().UTC()
+		err = dbx.Unscoped().Save(v).Error
+	}
+
+	if err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", v.ObjectKey).Str("version_id", v.VersionID).
+			Msg("save version record failed")
+
+		return false
+	}
+
+	return true
+}
+
+// markVersionDeleted 软删除版本记录；失败只记录日志.
+func (fs *FileService) markVersionDeleted(ctx context.Context, user, objectKey, versionID string) {
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("user = ? AND object_key = ? AND version_id = ?", user, objectKey, normalizeVersionID(versionID)).
+		Delete(&model.FileVersion{}).Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("object_key", objectKey).Str("version_id", versionID).
+			Msg("mark version deleted failed")
+	}
+}
+
+// loadVersions 按创建时间倒序读取对象未删除的版本记录，第一条即当前版本.
+func (fs *FileService) loadVersions(ctx context.Context, user, objectKey string) ([]model.FileVersion, error) {
+	var rows []model.FileVersion
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("user = ? AND object_key = ?", user, objectKey).
+		Order("created_at DESC, id DESC").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("load versions: %w", err)
+	}
+
+	return rows, nil
+}
+
+// importVersions 从对象存储读取对象的现有版本并写入版本记录（已存在的记录保持不变），返回导入后的记录.
+func (fs *FileService) importVersions(ctx context.Context, user, objectKey string) ([]model.FileVersion, error) {
+	bucket, err := fs.objectBucket(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := fs.listStoreVersions(ctx, bucket, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(infos) == 0 {
+		return nil, nil
+	}
+
+	rows := make([]model.FileVersion, 0, len(infos))
+	for i := range infos {
+		created, _ := time.Parse(time.RFC3339, infos[i].LastModified)
+		rows = append(rows, model.FileVersion{
+			User:        user,
+			ObjectKey:   objectKey,
+			VersionID:   normalizeVersionID(infos[i].VersionID),
+			Bucket:      bucket,
+			Action:      versionActionImported,
+			Size:        infos[i].Size,
+			ETag:        infos[i].ETag,
+			Checksum:    versionChecksum("", infos[i].ETag),
+			ContentType: infos[i].ContentType,
+			CreatedAt:   created,
+		})
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("import versions: %w", err)
+	}
+
+	return fs.loadVersions(ctx, user, objectKey)
+}
+
+// findVersion 读取指定版本的记录，没有记录时从对象存储导入该版本.
+func (fs *FileService) findVersion(ctx context.Context, user, objectKey, versionID string) (*model.FileVersion, error) {
+	var row model.FileVersion
+
+	err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("user = ? AND object_key = ? AND version_id = ?", user, objectKey, normalizeVersionID(versionID)).
+		Take(&row).Error
+	if err == nil {
+		return &row, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("load version: %w", err)
+	}
+
+	bucket, err := fs.objectBucket(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, nativeID := fs.versionObject(ctx, bucket, objectKey, versionID)
+
+	info, err := fs.s3Client.StatObject(ctx, bucket, key, minio.StatObjectOptions{VersionID: nativeID})
+	if err != nil {
+		if isNoSuchKey(err) || minio.ToErrorResponse(err).Code == "NoSuchVersion" {
+			return nil, fmt.Errorf("%w: %s@%s", ErrVersionNotFound, objectKey, versionID)
+		}
+
+		return nil, fmt.Errorf("stat version: %w", err)
+	}
+
+	if key != objectKey {
+		info.VersionID = versionID
+	}
+
+	etag := strings.Trim(info.ETag, "\"")
+	row = model.FileVersion{
+		User:        user,
+		ObjectKey:   objectKey,
+		VersionID:   info.VersionID,
+		Bucket:      bucket,
+		Action:      versionActionImported,
+		Size:        info.Size,
+		ETag:        etag,
+		Checksum:    versionChecksum("", etag),
+		ContentType: info.ContentType,
+		CreatedAt:   info.LastModified.UTC(),
+	}
+
+	if !fs.saveVersion(ctx, &row) {
+		return nil, fmt.Errorf("import version %s@%s failed", objectKey, versionID)
+	}
+
+	return &row, nil
+}
+
+// assignVersionLabels 在事务中设置版本标签，并从同一对象的其他版本中移除这些标签.
+func (fs *FileService) assignVersionLabels(ctx context.Context, row *model.FileVersion, labels []string) error {
+	labels = compactLabels(labels)
+
+	return fs.dbClient.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var others []model.FileVersion
+		if err := tx.Where("user = ? AND object_key = ? AND id <> ? AND labels_json <> ''",
+			row.User, row.ObjectKey, row.ID).Find(&others).Error; err != nil {
+			return fmt.Errorf("load labelled versions: %w", err)
+		}
+
+		for i := range others {
+			kept := slices.DeleteFunc(decodeLabels(others[i].LabelsJSON), func(l string) bool {
+				return slices.Contains(labels, l)
+			})
+
+			if enc := encodeLabels(kept); enc != others[i].LabelsJSON {
+				if err := tx.Model(&others[i]).Update("labels_json", enc).Error; err != nil {
+					return fmt.Errorf("move version labels: %w", err)
+				}
+			}
+		}
+
+		row.LabelsJSON = encodeLabels(labels)
+
+		return tx.Model(row).Update("labels_json", row.LabelsJSON).Error
+	})
+}
+
+// toVersionInfo 将版本记录转换为对外结构.
+func toVersionInfo(v *model.FileVersion, latest bool) types.FileVersionInfo {
+	return types.FileVersionInfo{
+		ObjectKey:    v.ObjectKey,
+		VersionID:    v.VersionID,
+		IsLatest:     latest,
+		Size:         v.Size,
+		ETag:         v.ETag,
+		ContentType:  v.ContentType,
+		LastModified: v.CreatedAt.UTC().Format(time.RFC3339),
+		Bucket:       v.Bucket,
+		Action:       v.Action,
+		Creator:      v.Creator,
+		Message:      v.Message,
+		Labels:       decodeLabels(v.LabelsJSON),
+		Checksum:     v.Checksum,
+		RestoredFrom: v.RestoredFrom,
+		CreatedAt:    v.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// versionChecksum 返回版本内容的 MD5：优先使用上传时计算的值，否则使用非分片上传对象的 ETag.
+func versionChecksum(hash, etag string) string {
+	if hash != "" {
+		return hash
+	}
+
+	if etag != "" && !strings.Contains(etag, "-") {
+		return etag
+	}
+
+	return ""
+}
+
+// normalizeVersionID 未启用版本化时对象存储可能返回 "null"，统一记为空串.
+func normalizeVersionID(id string) string {
+	if id == "null" {
+		return ""
+	}
+
+	return id
+}
+
+// compactLabels 去除空白与重复的标签.
+func compactLabels(labels []string) []string {
+	out := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if l = strings.TrimSpace(l); l != "" && !slices.Contains(out, l) {
+			out = append(out, l)
+		}
+	}
+
+	return out
+}
+
+func encodeLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	b, _ := json.Marshal(labels)
+
+	return string(b)
+}
+
+func decodeLabels(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var labels []string
+	_ = json.Unmarshal([]byte(s), &labels)
+
+	return labels
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// ErrRetentionRuleNotFound 版本保留规则不存在.
+var ErrRetentionRuleNotFound = errors.New("version retention rule not found")
+
+// SetVersionRetention 设置用户级（FolderID 为空）或文件夹级版本保留规则，已存在时覆盖.
+func (fs *FileService) SetVersionRetention(ctx context.Context, user string,
+	req *types.SetVersionRetentionRequest) (*types.VersionRetentionRule, error) {
+	if user == "" {
+		return nil, fmt.Errorf("user is required")
+	}
+
+	prefix := user + "/"
+
+	if req.FolderID != "" {
+		bucket, err := fs.defaultBucket()
+		if err != nil {
+			return nil, err
+		}
+
+		parent, name, err := findFolderPath(ctx, fs.s3Client, bucket, user, req.FolderID)
+		if err != nil {
+			return nil, fmt.Errorf("folder %s: %w", req.FolderID, err)
+		}
+
+		if parent != "" {
+			name = parent + "/" + name
+		}
+
+		prefix += name + "/"
+	}
+
+	rule := model.VersionRetention{
+		User:      user,
+		FolderID:  req.FolderID,
+		Prefix:    prefix,
+		KeepLast:  req.KeepLast,
+		KeepDays:  req.KeepDays,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := fs.dbClient.GetDB().WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user"}, {Name: "folder_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"prefix", "keep_last", "keep_days", "updated_at"}),
+	}).Create(&rule).Error; err != nil {
+		return nil, fmt.Errorf("save retention rule: %w", err)
+	}
+
+	info := toRetentionRule(&rule)
+
+	return &info, nil
+}
+
+// ListVersionRetention 列出用户的版本保留规则及服务端默认规则.
+func (fs *FileService) ListVersionRetention(ctx context.Context, user string) (*types.ListVersionRetentionResponse, error) {
+	if user == "" {
+		return nil, fmt.Errorf("user is required")
+	}
+
+	var rules []model.VersionRetention
+	if err := fs.dbClient.GetDB().WithContext(ctx).Where("user = ?", user).
+		Order("prefix").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("list retention rules: %w", err)
+	}
+
+	resp := &types.ListVersionRetentionResponse{Rules: make([]types.VersionRetentionRule, 0, len(rules))}
+	for i := range rules {
+		resp.Rules = append(resp.Rules, toRetentionRule(&rules[i]))
+	}
+
+	def := defaultRetention(user)
+	resp.Default = types.VersionRetentionRule{Prefix: def.Prefix, KeepLast: def.KeepLast, KeepDays: def.KeepDays}
+
+	return resp, nil
+}
+
+// DeleteVersionRetention 删除用户级（folderID 为空）或文件夹级版本保留规则.
+func (fs *FileService) DeleteVersionRetention(ctx context.Context, user, folderID string) error {
+	if user == "" {
+		return fmt.Errorf("user is required")
+	}
+
+	res := fs.dbClient.GetDB().WithContext(ctx).Where("user = ? AND folder_id = ?", user, folderID).
+		Delete(&model.VersionRetention{})
+	if res.Error != nil {
+		return fmt.Errorf("delete retention rule: %w", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrRetentionRuleNotFound
+	}
+
+	return nil
+}
+
+// PruneVersions 按保留规则删除旧版本（对每个 VersionID 调用 RemoveObject 并软删除版本记录）；user 为空时处理所有用户.
+// 对象键匹配前缀最长的规则生效，未设置规则时使用服务端默认规则；当前版本、带标签的版本
+// 以及未启用版本化的对象总是保留。dryRun 为 true 时只返回将被删除的版本.
+func (fs *FileService) PruneVersions(ctx context.Context, user string, dryRun bool) (*types.PruneVersionsResponse, error) {
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	var rules []model.VersionRetention
+
+	q := dbx.Model(&model.VersionRetention{})
+	if user != "" {
+		q = q.Where("user = ?", user)
+	}
+
+	if err := q.Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("load retention rules: %w", err)
+	}
+
+	rulesByUser := make(map[string][]model.VersionRetention)
+	for _, r := range rules {
+		rulesByUser[r.User] = append(rulesByUser[r.User], r)
+	}
+
+	var objects []struct {
+		User      string
+		ObjectKey string
+	}
+
+	// 受保护对象的版本保留到保护解除
+	q = dbx.Model(&model.FileVersion{}).Select("user, object_key").Where("object_key NOT IN (?)", fs.heldObjectKeys(ctx))
+	if user != "" {
+		q = q.Where("user = ?", user)
+	}
+
+	if err := q.Group("user, object_key").Having("COUNT(*) > 1").Order("user, object_key").
+		Scan(&objects).Error; err != nil {
+		return nil, fmt.Errorf("query versioned objects: %w", err)
+	}
+
+	resp := &types.PruneVersionsResponse{DryRun: dryRun, Objects: len(objects), Versions: []types.PrunedVersion{}}
+	now := time.Now().UTC()
+
+	for _, o := range objects {
+		if err := ctx.Err(); err != nil {
+			return resp, err
+		}
+
+		rule := matchRetention(rulesByUser[o.User], o.User, o.ObjectKey)
+
+		rows, err := fs.loadVersions(ctx, o.User, o.ObjectKey)
+		if err != nil {
+			return resp, err
+		}
+
+		fs.pruneObjectVersions(ctx, o.User, selectPrunable(rows, rule, now), dryRun, resp)
+	}
+
+	return resp, nil
+}
+
+// pruneObjectVersions 删除单个对象的待清理版本并汇总到 resp.
+func (fs *FileService) pruneObjectVersions(ctx context.Context, user string, victims []model.FileVersion,
+	dryRun bool, resp *types.PruneVersionsResponse) {
+	if len(victims) == 0 {
+		return
+	}
+
+	var (
+		pruned    int
+		reclaimed int64
+	)
+
+	for i := range victims {
+		v := &victims[i]
+		item := types.PrunedVersion{
+			ObjectKey: v.ObjectKey,
+			VersionID: v.VersionID,
+			Size:      v.Size,
+			CreatedAt: v.CreatedAt.UTC().Format(time.RFC3339),
+		}
+
+		if !dryRun {
+			if err := fs.removeVersion(ctx, v); err != nil {
+				item.Error = err.Error()
+				resp.Failed++
+				resp.Versions = append(resp.Versions, item)
+
+				continue
+			}
+
+			fs.markVersionDeleted(ctx, user, v.ObjectKey, v.VersionID)
+		}
+
+		pruned++
+		reclaimed += v.Size
+		resp.Versions = append(resp.Versions, item)
+	}
+
+	resp.Pruned += pruned
+	resp.ReclaimedBytes += reclaimed
+
+	if !dryRun && pruned > 0 {
+		nlog.Logger().Info().Str("user", user).Str("object_key", victims[0].ObjectKey).
+			Int("versions", pruned).Int64("reclaimed_bytes", reclaimed).Msg("old versions pruned")
+	}
+}
+
+// removeVersion 从对象存储删除指定版本.
+func (fs *FileService) removeVersion(ctx context.Context, v *model.FileVersion) error {
+	bucket := v.Bucket
+	if bucket == "" {
+		b, err := fs.objectBucket(ctx, v.ObjectKey)
+		if err != nil {
+			return err
+		}
+
+		bucket = b
+	}
+
+	key, nativeID := fs.versionObject(ctx, bucket, v.ObjectKey, v.VersionID)
+
+	err := fs.s3Client.RemoveObject(ctx, bucket, key, minio.RemoveObjectOptions{VersionID: nativeID})
+	if err != nil && !isNoSuchKey(err) && minio.ToErrorResponse(err).Code != "NoSuchVersion" {
+		return fmt.Errorf("remove version: %w", err)
+	}
+
+	return nil
+}
+
+// matchRetention 返回对象键匹配前缀最长的规则，没有匹配时返回服务端默认规则.
+func matchRetention(rules []model.VersionRetention, user, objectKey string) *model.VersionRetention {
+	var best *model.VersionRetention
+
+	for i := range rules {
+		if strings.HasPrefix(objectKey, rules[i].Prefix) && (best == nil || len(rules[i].Prefix) > len(best.Prefix)) {
+			best = &rules[i]
+		}
+	}
+
+	if best == nil {
+		best = defaultRetention(user)
+	}
+
+	return best
+}
+
+// defaultRetention 返回配置中的默认保留规则.
+func defaultRetention(user string) *model.VersionRetention {
+	cfg := configs.GetConfig().Versions
+
+	return &model.VersionRetention{User: user, Prefix: user + "/", KeepLast: cfg.DefaultKeepLast, KeepDays: cfg.DefaultKeepDays}
+}
+
+// selectPrunable 从按创建时间倒序排列的版本中选出可删除的版本：
+// 保留当前版本、带标签的版本、无 VersionID 的记录，以及满足任一保留条件（最近 N 个 / D 天内）的版本.
+func selectPrunable(rows []model.FileVersion, rule *model.VersionRetention, now time.Time) []model.FileVersion {
+	if rule.KeepLast <= 0 && rule.KeepDays <= 0 {
+		return nil
+	}
+
+	minAge := time.Duration(rule.KeepDays) * 24 * time.Hour
+
+	var out []model.FileVersion
+
+	for i := range rows {
+		r := &rows[i]
+
+		switch {
+		case i == 0, r.VersionID == "", r.LabelsJSON != "":
+			continue
+		case rule.KeepLast > 0 && i < rule.KeepLast:
+			continue
+		case rule.KeepDays > 0 && now.Sub(r.CreatedAt) < minAge:
+			continue
+		}
+
+		out = append(out, *r)
+	}
+
+	return out
+}
+
+func toRetentionRule(r *model.VersionRetention) types.VersionRetentionRule {
+	return types.VersionRetentionRule{
+		FolderID:  r.FolderID,
+		Prefix:    r.Prefix,
+		KeepLast:  r.KeepLast,
+		KeepDays:  r.KeepDays,
+		UpdatedAt: r.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -8,50 +8,65 @@ import (
 
 	"github.com/minio/minio-go/v7"
 
+	"github.com/yeisme/notevault/pkg/internal/model"
 	"github.com/yeisme/notevault/pkg/internal/types"
 )
 
 const defaultVersionsCap = 8
 
-// ListFileVersions 根据 scope 列出对象版本：
-//   - scope = "all"：返回所有版本（需要后端启用版本化）
+// ListFileVersions 根据 scope 列出对象版本（由版本元数据表提供，按创建时间倒序）：
+//   - scope = "all"：返回所有未删除的版本
 //   - scope = "current"（默认）：仅返回当前可见版本
+//
+// 版本记录之前写入的对象首次列出时从对象存储读取版本并导入.
 func (fs *FileService) ListFileVersions(ctx context.Context, user, objectKey, scope string) (*types.ListFileVersionsResponse, error) { //nolint:ireturn
 	if user == "" || !strings.HasPrefix(objectKey, user+"/") {
 		return nil, fmt.Errorf("access denied: object does not belong to user")
 	}
 
-	bucket, err := fs.defaultBucket()
+	// 标准化 scope
+	scope = strings.ToLower(strings.TrimSpace(scope))
+
+	bucket, err := fs.objectBucket(ctx, objectKey)
 	if err != nil {
 		return nil, err
 	}
 
-	// 标准化 scope
-	scope = strings.ToLower(strings.TrimSpace(scope))
+	rows, err := fs.loadVersions(ctx, user, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		rows, err = fs.importVersions(ctx, user, objectKey)
+	} else {
+		rows, err = fs.ensureCurrentVersion(ctx, bucket, user, objectKey, rows)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, objectKey)
+	}
 
-	// 当前版本：直接 StatObject
 	if scope == "current" || scope == "" {
-		info, err := fs.s3Client.StatObject(ctx, bucket, objectKey, minio.StatObjectOptions{})
-		if err != nil {
-			return nil, fmt.Errorf("stat object %s: %w", objectKey, err)
-		}
+		rows = rows[:1]
+	}
 
-		versions := []types.FileVersionInfo{
-			{
-				ObjectKey:    objectKey,
-				VersionID:    info.VersionID,
-				IsLatest:     true,
-				Size:         info.Size,
-				ETag:         strings.Trim(info.ETag, "\""),
-				ContentType:  info.ContentType,
-				LastModified: info.LastModified.UTC().Format(time.RFC3339),
-				StorageClass: info.StorageClass,
-				Bucket:       bucket,
-				UserMetadata: info.UserMetadata,
-			},
-		}
+	versions := make([]types.FileVersionInfo, 0, len(rows))
+	for i := range rows {
+		versions = append(versions, toVersionInfo(&rows[i], i == 0))
+	}
+
+	return &types.ListFileVersionsResponse{FileID: objectKey, Versions: versions, Total: len(versions)}, nil
+}
 
-		return &types.ListFileVersionsResponse{FileID: objectKey, Versions: versions, Total: len(versions)}, nil
+// listStoreVersions 直接从对象存储列出对象的全部版本，用于导入版本记录；模拟版本化时列出 .versions/ 下的历史版本.
+func (fs *FileService) listStoreVersions(ctx context.Context, bucket, objectKey string) ([]types.FileVersionInfo, error) {
+	if fs.emulatedVersioning(ctx, bucket) {
+		return fs.listEmulatedVersions(ctx, bucket, objectKey)
 	}
 
 	// 全部版本：ListObjects(WithVersions=true) + StatObject(VersionID)
@@ -67,7 +82,7 @@ func (fs *FileService) ListFileVersions(ctx context.Context, user, objectKey, sc
 
 	for obj := range ch {
 		if obj.Err != nil {
-			return nil, fmt.Errorf("list object versions for %s: %v", objectKey, obj.Err)
+			return nil, fmt.Errorf("list object versions for %s: %w", objectKey, obj.Err)
 		}
 
 		// 仅关注完全匹配该对象键的版本，且跳过删除标记
@@ -128,24 +143,35 @@ func (fs *FileService) ListFileVersions(ctx context.Context, user, objectKey, sc
 		}
 	}
 
-	return &types.ListFileVersionsResponse{FileID: objectKey, Versions: versions, Total: len(versions)}, nil
+	return versions, nil
 }
 
 // CreateFileVersion 基于现有对象创建一个新版本（通过拷贝到自身来触发新版本）。
-func (fs *FileService) CreateFileVersion(ctx context.Context, user string, req *types.CreateFileVersionRequest) (*types.CreateFileVersionResponse, error) {
+// 新版本会替换对象的当前内容或元数据，受保护的对象不能创建新版本.
+func (fs *FileService) CreateFileVersion(ctx context.Context, user string,
+	req *types.CreateFileVersionRequest) (_ *types.CreateFileVersionResponse, err error) {
 	if user == "" || !strings.HasPrefix(req.ObjectKey, user+"/") {
 		return nil, fmt.Errorf("access denied: object does not belong to user")
 	}
 
-	bucket, err := fs.defaultBucket()
+	defer recordAuditResult(ctx, user, types.AuditActionVersionCreate, []string{req.ObjectKey}, "base="+req.BaseVersion, &err)
+
+	if err := fs.checkHold(ctx, user, req.ObjectKey, holdActionOverwrite); err != nil {
+		return nil, err
+	}
+
+	bucket, err := fs.objectBucket(ctx, req.ObjectKey)
 	if err != nil {
 		return nil, err
 	}
 
 	// 源选项：可选指定基线版本
-	src := minio.CopySrcOptions{Bucket: bucket, Object: req.ObjectKey}
-	if req.BaseVersion != "" {
-		src.VersionID = req.BaseVersion
+	src := minio.CopySrcOptions{Bucket: bucket}
+	src.Object, src.VersionID = fs.versionObject(ctx, bucket, req.ObjectKey, req.BaseVersion)
+
+	// 模拟版本化时先归档当前内容
+	if err := fs.archiveCurrentVersion(ctx, bucket, user, req.ObjectKey); err != nil {
+		return nil, err
 	}
 
 	// 目标选项：复制到相同 key，以触发新版本
@@ -164,6 +190,16 @@ func (fs *FileService) CreateFileVersion(ctx context.Context, user string, req *
 		return nil, fmt.Errorf("create new version by copy: %w", err)
 	}
 
+	fs.trackVersion(ctx, bucket, &model.FileVersion{
+		User:      user,
+		ObjectKey: req.ObjectKey,
+		VersionID: ui.VersionID,
+		Action:    versionActionCreate,
+		Creator:   user,
+		Message:   req.Message,
+	}, req.Labels)
+	fs.publishObjectUpdated(ctx, bucket, req.ObjectKey, ui.VersionID)
+
 	return &types.CreateFileVersionResponse{
 		ObjectKey: req.ObjectKey,
 		VersionID: ui.VersionID,
@@ -172,43 +208,86 @@ func (fs *FileService) CreateFileVersion(ctx context.Context, user string, req *
 }
 
 // DeleteFileVersion 删除指定版本。
+// 删除失败通过响应的 Success/Error 返回，审计记录同样记为失败.
 func (fs *FileService) DeleteFileVersion(ctx context.Context, user, objectKey, versionID string) (*types.DeleteFileVersionResponse, error) {
 	if user == "" || !strings.HasPrefix(objectKey, user+"/") {
 		return nil, fmt.Errorf("access denied: object does not belong to user")
 	}
 
-	bucket, err := fs.defaultBucket()
+	var auditErr error
+
+	defer recordAuditResult(ctx, user, types.AuditActionVersionDelete, []string{objectKey}, "version="+versionID, &auditErr)
+
+	// 受保护对象的全部版本都不能删除
+	if auditErr = fs.checkHold(ctx, user, objectKey, holdActionDeleteVersion); auditErr != nil {
+		return nil, auditErr
+	}
+
+	bucket, err := fs.objectBucket(ctx, objectKey)
 	if err != nil {
+		auditErr = err
 		return nil, err
 	}
 
-	err = fs.s3Client.RemoveObject(ctx, bucket, objectKey, minio.RemoveObjectOptions{VersionID: versionID})
-	if err != nil {
-		return &types.DeleteFileVersionResponse{ObjectKey: objectKey, VersionID: versionID, Success: false, Error: err.Error()}, nil
+	if fs.emulatedVersioning(ctx, bucket) {
+		auditErr = fs.deleteEmulatedVersion(ctx, bucket, user, objectKey, versionID)
+	} else if auditErr = fs.s3Client.RemoveObject(ctx, bucket, objectKey, minio.RemoveObjectOptions{VersionID: versionID}); auditErr == nil {
+		fs.markVersionDeleted(ctx, user, objectKey, versionID)
+	}
+
+	if auditErr != nil {
+		return &types.DeleteFileVersionResponse{ObjectKey: objectKey, VersionID: versionID, Success: false, Error: auditErr.Error()}, nil
 	}
 
 	return &types.DeleteFileVersionResponse{ObjectKey: objectKey, VersionID: versionID, Success: true}, nil
 }
 
 // RestoreFileVersion 基于指定版本恢复为最新版本（复制该版本到同一 key）。
+// 恢复失败通过响应的 Success/Error 返回，审计记录同样记为失败.
 func (fs *FileService) RestoreFileVersion(ctx context.Context, user, objectKey, versionID string) (*types.RestoreFileVersionResponse, error) {
 	if user == "" || !strings.HasPrefix(objectKey, user+"/") {
 		return nil, fmt.Errorf("access denied: object does not belong to user")
 	}
 
-	bucket, err := fs.defaultBucket()
+	var auditErr error
+
+	defer recordAuditResult(ctx, user, types.AuditActionVersionRestore, []string{objectKey}, "version="+versionID, &auditErr)
+
+	if auditErr = fs.checkHold(ctx, user, objectKey, holdActionRestoreVersion); auditErr != nil {
+		return nil, auditErr
+	}
+
+	bucket, err := fs.objectBucket(ctx, objectKey)
 	if err != nil {
+		auditErr = err
 		return nil, err
 	}
 
-	// 从指定版本拷贝到自身，得到一个新的最新版本
-	src := minio.CopySrcOptions{Bucket: bucket, Object: objectKey, VersionID: versionID}
+	// 从指定版本拷贝到自身，得到一个新的最新版本；模拟版本化时先归档当前内容
+	src := minio.CopySrcOptions{Bucket: bucket}
+	src.Object, src.VersionID = fs.versionObject(ctx, bucket, objectKey, versionID)
 	dst := minio.CopyDestOptions{Bucket: bucket, Object: objectKey}
 
+	if auditErr = fs.archiveCurrentVersion(ctx, bucket, user, objectKey); auditErr != nil {
+		return &types.RestoreFileVersionResponse{ObjectKey: objectKey, FromVersion: versionID, Success: false, Error: auditErr.Error()}, nil
+	}
+
 	ui, err := fs.s3Client.CopyObject(ctx, dst, src)
 	if err != nil {
+		auditErr = err
 		return &types.RestoreFileVersionResponse{ObjectKey: objectKey, FromVersion: versionID, Success: false, Error: err.Error()}, nil
 	}
 
+	fs.trackVersion(ctx, bucket, &model.FileVersion{
+		User:         user,
+		ObjectKey:    objectKey,
+		VersionID:    ui.VersionID,
+		Action:       versionActionRestore,
+		Creator:      user,
+		Message:      "restored from version " + versionID,
+		RestoredFrom: versionID,
+	}, nil)
+	fs.publishObjectUpdated(ctx, bucket, objectKey, ui.VersionID)
+
 	return &types.RestoreFileVersionResponse{ObjectKey: objectKey, FromVersion: versionID, RestoredAs: ui.VersionID, Success: true}, nil
 }
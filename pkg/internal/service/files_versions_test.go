@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+func readObject(t *testing.T, svc *service.FileService, key string) string {
+	t.Helper()
+
+	obj, _, err := svc.OpenObject(t.Context(), testUser, key)
+	if err != nil {
+		t.Fatalf("open %s: %v", key, err)
+	}
+	defer obj.Close()
+
+	b, err := io.ReadAll(obj)
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+
+	return string(b)
+}
+
+// TestFileVersions 分别在原生版本化与模拟版本化的存储上验证版本列举、恢复与删除.
+func TestFileVersions(t *testing.T) {
+	tests := []struct {
+		name      string
+		versioned bool
+	}{
+		{name: "native", versioned: true},
+		{name: "emulated", versioned: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := newTestContext(t, tt.versioned)
+			svc := service.NewFileService(ctx)
+
+			key := uploadText(t, ctx, "plan.md", "v1")
+			uploadText(t, ctx, "plan.md", "v2")
+
+			list, err := svc.ListFileVersions(ctx, testUser, key, "all")
+			if err != nil || list.Total != 2 {
+				t.Fatalf("list = %+v, %v", list, err)
+			}
+
+			if !list.Versions[0].IsLatest || list.Versions[0].Size != 2 {
+				t.Fatalf("latest = %+v", list.Versions[0])
+			}
+
+			first := list.Versions[1].VersionID
+
+			restored, err := svc.RestoreFileVersion(ctx, testUser, key, first)
+			if err != nil || !restored.Success {
+				t.Fatalf("restore: %+v, %v", restored, err)
+			}
+
+			if got := readObject(t, svc, key); got != "v1" {
+				t.Fatalf("content after restore = %q", got)
+			}
+
+			if list, err = svc.ListFileVersions(ctx, testUser, key, "all"); err != nil || list.Total != 3 {
+				t.Fatalf("list after restore = %+v, %v", list, err)
+			}
+
+			deleted, err := svc.DeleteFileVersion(ctx, testUser, key, first)
+			if err != nil || !deleted.Success {
+				t.Fatalf("delete version: %+v, %v", deleted, err)
+			}
+
+			if list, err = svc.ListFileVersions(ctx, testUser, key, "all"); err != nil || list.Total != 2 {
+				t.Fatalf("list after delete = %+v, %v", list, err)
+			}
+
+			for _, v := range list.Versions {
+				if v.VersionID == first {
+					t.Fatalf("deleted version %s still listed", first)
+				}
+			}
+
+			if _, err := svc.ListFileVersions(ctx, "bob@example.com", key, "all"); err == nil {
+				t.Fatal("expected access denied for another user")
+			}
+		})
+	}
+}
+
+// TestDiffFileVersions 验证两个文本版本之间的逐行差异.
+func TestDiffFileVersions(t *testing.T) {
+	ctx := newTestContext(t, true)
+	svc := service.NewFileService(ctx)
+
+	key := uploadText(t, ctx, "list.txt", "a\nb\nc\n")
+	uploadText(t, ctx, "list.txt", "a\nB\nc\n")
+
+	list, err := svc.ListFileVersions(ctx, testUser, key, "all")
+	if err != nil || list.Total != 2 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	diff, err := svc.DiffFileVersions(ctx, testUser, key, &types.DiffFileVersionsQuery{From: list.Versions[1].VersionID})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+
+	if diff.Added != 1 || diff.Removed != 1 {
+		t.Fatalf("diff = %+v", diff)
+	}
+}
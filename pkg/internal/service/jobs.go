@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/storage/db"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+var (
+	// ErrJobNotFound 任务不存在或不属于当前用户.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobFinished 任务已结束，无法取消.
+	ErrJobFinished = errors.New("job already finished")
+	// ErrJobsDisabled 异步任务未启用.
+	ErrJobsDisabled = errors.New("async jobs are disabled")
+)
+
+const (
+	defaultJobPageSize = 50
+	maxJobPageSize     = 1000
+)
+
+// jobWakeup 提交任务后唤醒本进程内空闲的 worker，避免等待下一次轮询.
+var jobWakeup = make(chan struct{}, 1)
+
+// JobService 负责异步任务的提交、查询与取消；执行由 JobWorker 负责.
+type JobService struct {
+	dbc *db.Client
+}
+
+// NewJobService 创建并返回一个新的 JobService 实例.
+func NewJobService(c context.Context) *JobService {
+	svc := &JobService{dbc: ctxPkg.GetDBClient(c)}
+
+	if svc.dbc == nil {
+		nlog.Logger().Warn().Msg("DB client not initialized, JobService unavailable")
+	}
+
+	return svc
+}
+
+// SubmitJob 以 payload 作为参数创建一个待执行任务.
+func (s *JobService) SubmitJob(ctx context.Context, user, jobType string, payload any) (*types.SubmitJobResponse, error) {
+	if user == "" {
+		return nil, fmt.Errorf("user is required")
+	}
+
+	if !configs.GetConfig().Jobs.Enabled {
+		return nil, ErrJobsDisabled
+	}
+
+	if _, ok := jobExecutors[jobType]; !ok {
+		return nil, fmt.Errorf("unknown job type: %s", jobType)
+	}
+
+	dbx, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	job := &model.Job{
+		JobID:       newJobID(now),
+		Owner:       user,
+		Type:        jobType,
+		Status:      model.JobStatusPending,
+		PayloadJSON: string(data),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := dbx.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+
+	select {
+	case jobWakeup <- struct{}{}:
+	default:
+	}
+
+	return &types.SubmitJobResponse{Job: toJobInfo(job)}, nil
+}
+
+// ListJobs 分页列出当前用户的任务（按创建时间倒序）.
+func (s *JobService) ListJobs(ctx context.Context, user string, req *types.ListJobsRequest) (*types.ListJobsResponse, error) {
+	if user == "" {
+		return nil, fmt.Errorf("user is required")
+	}
+
+	dbx, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := dbx.Model(&model.Job{}).Where("owner = ?", user)
+	if req.Status != "" {
+		q = q.Where("status = ?", req.Status)
+	}
+
+	if req.Type != "" {
+		q = q.Where("type = ?", req.Type)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("count jobs: %w", err)
+	}
+
+	page, size := normalizeJobPage(req.Page, req.PageSize)
+
+	var rows []model.Job
+	if err := q.Order("created_at DESC").Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+
+	jobs := make([]types.JobInfo, 0, len(rows))
+	for i := range rows {
+		jobs = append(jobs, toJobInfo(&rows[i]))
+	}
+
+	return &types.ListJobsResponse{Total: int(total), Page: page, Size: size, Jobs: jobs}, nil
+}
+
+// GetJob 获取任务详情与进度.
+func (s *JobService) GetJob(ctx context.Context, user, jobID string) (*types.JobInfo, error) {
+	job, err := s.getOwnedJob(ctx, user, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := toJobInfo(job)
+
+	return &info, nil
+}
+
+// ListJobResults 分页获取任务条目结果（按提交顺序）.
+func (s *JobService) ListJobResults(ctx context.Context, user, jobID string, page, size int) (*types.ListJobResultsResponse, error) {
+	job, err := s.getOwnedJob(ctx, user, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbx, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, size = normalizeJobPage(page, size)
+
+	var rows []model.JobItem
+	if err := dbx.Where("job_id = ?", job.JobID).Order("seq ASC").
+		Offset((page - 1) * size).Limit(size).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("list job items: %w", err)
+	}
+
+	items := make([]types.JobItemResult, 0, len(rows))
+	for i := range rows {
+		items = append(items, toJobItemResult(&rows[i]))
+	}
+
+	return &types.ListJobResultsResponse{
+		JobID: job.JobID,
+		Total: job.Processed,
+		Page:  page,
+		Size:  size,
+		Items: items,
+	}, nil
+}
+
+// StreamJobResults 按提交顺序分批读取全部条目结果并逐条回调（用于 NDJSON 导出）.
+func (s *JobService) StreamJobResults(ctx context.Context, user, jobID string, fn func(types.JobItemResult) error) error {
+	job, err := s.getOwnedJob(ctx, user, jobID)
+	if err != nil {
+		return err
+	}
+
+	dbx, err := s.db(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 以 seq 作为游标分批读取，避免大任务一次性加载全部结果
+	lastSeq := 0
+
+	for {
+		var rows []model.JobItem
+		if err := dbx.Where("job_id = ? AND seq > ?", job.JobID, lastSeq).
+			Order("seq ASC").Limit(maxJobPageSize).Find(&rows).Error; err != nil {
+			return fmt.Errorf("list job items: %w", err)
+		}
+
+		for i := range rows {
+			if err := fn(toJobItemResult(&rows[i])); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < maxJobPageSize {
+			return nil
+		}
+
+		lastSeq = rows[len(rows)-1].Seq
+	}
+}
+
+// CancelJob 取消任务：待执行的任务直接标记为已取消，执行中的任务设置取消标记由 worker 尽快停止.
+func (s *JobService) CancelJob(ctx context.Context, user, jobID string) (*types.JobInfo, error) {
+	job, err := s.getOwnedJob(ctx, user, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.IsFinished() {
+		return nil, ErrJobFinished
+	}
+
+	dbx, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	// 先尝试直接取消尚未被领取的任务（条件更新，避免与 worker 领取竞争）
+	res := dbx.Model(&model.Job{}).
+		Where("job_id = ? AND status = ?", job.JobID, model.JobStatusPending).
+		Updates(map[string]any{
+			"status":           model.JobStatusCanceled,
+			"cancel_requested": true,
+			"finished_at":      now,
+			"updated_at":       now,
+		})
+	if res.Error != nil {
+		return nil, fmt.Errorf("cancel job: %w", res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		if err := dbx.Model(&model.Job{}).
+			Where("job_id = ? AND status = ?", job.JobID, model.JobStatusRunning).
+			Updates(map[string]any{"cancel_requested": true, "updated_at": now}).Error; err != nil {
+			return nil, fmt.Errorf("cancel job: %w", err)
+		}
+	}
+
+	return s.GetJob(ctx, user, jobID)
+}
+
+// getOwnedJob 读取属于 user 的任务.
+func (s *JobService) getOwnedJob(ctx context.Context, user, jobID string) (*model.Job, error) {
+	if user == "" {
+		return nil, fmt.Errorf("user is required")
+	}
+
+	dbx, err := s.db(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var job model.Job
+	if err := dbx.Where("job_id = ? AND owner = ?", jobID, user).Take(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+
+		return nil, fmt.Errorf("get job: %w", err)
+	}
+
+	return &job, nil
+}
+
+func (s *JobService) db(ctx context.Context) (*gorm.DB, error) {
+	if s.dbc == nil || s.dbc.GetDB() == nil {
+		return nil, errors.New("db not initialized")
+	}
+
+	return s.dbc.GetDB().WithContext(ctx), nil
+}
+
+// newJobID 生成带前缀的 ULID 字符串，形如 "job_01H..."。
+func newJobID(t time.Time) string {
+	return "job_" + newULID(t)
+}
+
+func normalizeJobPage(page, size int) (int, int) {
+	if page <= 0 {
+		page = 1
+	}
+
+	if size <= 0 {
+		size = defaultJobPageSize
+	}
+
+	if size > maxJobPageSize {
+		size = maxJobPageSize
+	}
+
+	return page, size
+}
+
+func toJobInfo(j *model.Job) types.JobInfo {
+	return types.JobInfo{
+		JobID:           j.JobID,
+		Type:            j.Type,
+		Status:          j.Status,
+		Total:           j.Total,
+		Processed:       j.Processed,
+		Success:         j.Success,
+		Failed:          j.Failed,
+		Error:           j.Error,
+		CancelRequested: j.CancelRequested,
+		CreatedAt:       j.CreatedAt,
+		StartedAt:       j.StartedAt,
+		FinishedAt:      j.FinishedAt,
+	}
+}
+
+func toJobItemResult(it *model.JobItem) types.JobItemResult {
+	return types.JobItemResult{
+		Seq:     it.Seq,
+		Key:     it.Key,
+		Target:  it.Target,
+		Detail:  it.Detail,
+		Success: it.Success,
+		Error:   it.Error,
+	}
+}
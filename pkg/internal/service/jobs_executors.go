@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// errJobCanceled 执行过程中检测到取消标记.
+var errJobCanceled = errors.New("job canceled")
+
+// jobExecutor 执行一种类型的任务，逐条通过 reporter 回报结果；reporter 返回错误时应立即停止.
+type jobExecutor func(ctx context.Context, fs *FileService, job *model.Job, r *jobReporter) error
+
+// jobExecutors 任务类型 -> 执行器.
+var jobExecutors = map[string]jobExecutor{
+	model.JobTypeDeleteFiles:    runDeleteFilesJob,
+	model.JobTypeCopyFiles:      runCopyFilesJob,
+	model.JobTypeMoveFiles:      runMoveFilesJob,
+	model.JobTypeUpdateMetadata: runUpdateMetadataJob,
+	model.JobTypeSyncObjects:    runSyncObjectsJob,
+	model.JobTypeReconcile:      runReconcileJob,
+}
+
+func runDeleteFilesJob(ctx context.Context, fs *FileService, job *model.Job, r *jobReporter) error {
+	var req types.DeleteFilesRequest
+	if err := decodeJobPayload(job, &req); err != nil {
+		return err
+	}
+
+	r.setTotal(len(req.ObjectKeys))
+
+	for _, key := range req.ObjectKeys {
+		res := fs.deleteFile(ctx, job.Owner, key)
+		if err := r.report(ctx, key, "", res.Error); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runCopyFilesJob(ctx context.Context, fs *FileService, job *model.Job, r *jobReporter) error {
+	var req types.CopyFilesRequest
+	if err := decodeJobPayload(job, &req); err != nil {
+		return err
+	}
+
+	r.setTotal(len(req.Items))
+
+	for _, item := range req.Items {
+		res := fs.copyFile(ctx, job.Owner, item)
+		if err := r.report(ctx, item.SourceKey, item.DestinationKey, res.Error); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runMoveFilesJob(ctx context.Context, fs *FileService, job *model.Job, r *jobReporter) error {
+	var req types.MoveFilesRequest
+	if err := decodeJobPayload(job, &req); err != nil {
+		return err
+	}
+
+	r.setTotal(len(req.Items))
+
+	for _, item := range req.Items {
+		res := fs.moveFile(ctx, job.Owner, item)
+		if err := r.report(ctx, item.SourceKey, item.DestinationKey, res.Error); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runUpdateMetadataJob(ctx context.Context, fs *FileService, job *model.Job, r *jobReporter) error {
+	var req types.UpdateFilesMetadataRequest
+	if err := decodeJobPayload(job, &req); err != nil {
+		return err
+	}
+
+	r.setTotal(len(req.Items))
+
+	for _, item := range req.Items {
+		res := fs.updateFileMetadata(ctx, job.Owner, item)
+		if err := r.report(ctx, item.ObjectKey, "", res.Error); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func runSyncObjectsJob(ctx context.Context, fs *FileService, job *model.Job, r *jobReporter) error {
+	var req types.SyncObjectsRequest
+	if err := decodeJobPayload(job, &req); err != nil {
+		return err
+	}
+
+	prefix, filter := syncScope(job.Owner, req.Year, req.Month, req.Day)
+
+	// 同步任务事先不知道对象总数，Total 随扫描递增
+	return fs.syncObjects(ctx, job.Owner, prefix, filter, func(key string, err error) error {
+		msg := ""
+		if err != nil {
+			msg = err.Error()
+		}
+
+		return r.report(ctx, key, "", msg)
+	})
+}
+
+// runReconcileJob 对账任务：每个差异作为一个条目，Detail 记录差异类型与详情.
+func runReconcileJob(ctx context.Context, fs *FileService, job *model.Job, r *jobReporter) error {
+	var req types.ReconcileRequest
+	if err := decodeJobPayload(job, &req); err != nil {
+		return err
+	}
+
+	stats := newReconcileResponse(job.Owner, &req)
+
+	return fs.reconcile(ctx, job.Owner, &req, stats, func(e types.ReconcileEntry) error {
+		detail := e.Kind
+		if e.Detail != "" {
+			detail += ": " + e.Detail
+		}
+
+		return r.add(ctx, model.JobItem{Key: e.ObjectKey, Detail: detail, Error: e.Error})
+	})
+}
+
+func decodeJobPayload(job *model.Job, v any) error {
+	if err := json.Unmarshal([]byte(job.PayloadJSON), v); err != nil {
+		return fmt.Errorf("decode job payload: %w", err)
+	}
+
+	return nil
+}
+
+// jobReporter 缓冲条目结果，每 flushSize 条落盘一次并同步进度、检查取消标记.
+type jobReporter struct {
+	db        *gorm.DB
+	jobID     string
+	flushSize int
+	pending   []model.JobItem
+
+	total   int
+	seq     int
+	success int
+	failed  int
+}
+
+func newJobReporter(dbx *gorm.DB, jobID string, flushSize int) *jobReporter {
+	if flushSize <= 0 {
+		flushSize = 1
+	}
+
+	return &jobReporter{
+		db:        dbx,
+		jobID:     jobID,
+		flushSize: flushSize,
+		pending:   make([]model.JobItem, 0, flushSize),
+	}
+}
+
+// setTotal 设置条目总数（已知总数的任务在开始前调用）.
+func (r *jobReporter) setTotal(n int) {
+	r.total = n
+}
+
+// report 记录一个条目结果；errMsg 为空表示成功.
+func (r *jobReporter) report(ctx context.Context, key, target, errMsg string) error {
+	return r.add(ctx, model.JobItem{Key: key, Target: target, Error: errMsg})
+}
+
+// add 记录一个条目结果（item.Error 为空表示成功），缓冲满时落盘并检查取消标记.
+func (r *jobReporter) add(ctx context.Context, item model.JobItem) error {
+	r.seq++
+	if r.seq > r.total {
+		r.total = r.seq
+	}
+
+	if item.Error == "" {
+		r.success++
+	} else {
+		r.failed++
+	}
+
+	item.JobID = r.jobID
+	item.Seq = r.seq
+	item.Success = item.Error == ""
+	item.CreatedAt = time.Now().UTC()
+	r.pending = append(r.pending, item)
+
+	if len(r.pending) < r.flushSize {
+		return ctx.Err()
+	}
+
+	if err := r.flush(ctx); err != nil {
+		return err
+	}
+
+	return r.checkCanceled(ctx)
+}
+
+// flush 写入缓冲的条目结果并更新任务进度.
+func (r *jobReporter) flush(ctx context.Context) error {
+	dbx := r.db.WithContext(ctx)
+
+	if len(r.pending) > 0 {
+		if err := dbx.CreateInBatches(r.pending, r.flushSize).Error; err != nil {
+			return fmt.Errorf("save job items: %w", err)
+		}
+
+		r.pending = r.pending[:0]
+	}
+
+	if err := dbx.Model(&model.Job{}).Where("job_id = ?", r.jobID).Updates(map[string]any{
+		"total":      r.total,
+		"processed":  r.seq,
+		"success":    r.success,
+		"failed":     r.failed,
+		"updated_at": time.Now().UTC(),
+	}).Error; err != nil {
+		return fmt.Errorf("update job progress: %w", err)
+	}
+
+	return nil
+}
+
+func (r *jobReporter) checkCanceled(ctx context.Context) error {
+	var job model.Job
+	if err := r.db.WithContext(ctx).Select("cancel_requested").
+		Where("job_id = ?", r.jobID).Take(&job).Error; err != nil {
+		return fmt.Errorf("check job cancel: %w", err)
+	}
+
+	if job.CancelRequested {
+		return errJobCanceled
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/storage"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+const (
+	// jobMaintenanceInterval 清理过期任务、回收僵死任务的周期.
+	jobMaintenanceInterval = 10 * time.Minute
+	// jobStaleAfter 执行中任务超过该时长未更新进度即视为进程已中断.
+	jobStaleAfter = 30 * time.Minute
+	// jobCleanupBatch 每批清理的任务数量.
+	jobCleanupBatch = 500
+)
+
+// JobWorker 轮询数据库中的待执行任务并按类型分发给执行器.
+// 任务以数据库为真源，多实例部署时通过条件更新领取任务，互不重复执行.
+type JobWorker struct {
+	mgr    *storage.Manager
+	cfg    configs.JobsConfig
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewJobWorker 创建任务 worker，需要 DB 与 S3 均已初始化.
+func NewJobWorker(mgr *storage.Manager, cfg configs.JobsConfig) *JobWorker {
+	return &JobWorker{mgr: mgr, cfg: cfg}
+}
+
+// Start 启动 worker 协程与维护协程.
+func (w *JobWorker) Start() {
+	ctx, cancel := context.WithCancel(ctxPkg.WithStorageManager(context.Background(), w.mgr))
+	w.cancel = cancel
+
+	w.maintain(ctx)
+
+	for range w.cfg.Workers {
+		w.wg.Add(1)
+
+		go w.loop(ctx)
+	}
+
+	w.wg.Add(1)
+
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(jobMaintenanceInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.maintain(ctx)
+			}
+		}
+	}()
+
+	nlog.Logger().Info().Int("workers", w.cfg.Workers).Msg("job worker started")
+}
+
+// Stop 停止领取新任务并等待执行中的任务退出（执行中的任务将被标记为中断）.
+func (w *JobWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+
+	w.cancel()
+	w.wg.Wait()
+}
+
+func (w *JobWorker) loop(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.cfg.GetPollInterval())
+	defer ticker.Stop()
+
+	for {
+		if w.runNext(ctx) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-jobWakeup:
+		}
+	}
+}
+
+// runNext 领取并执行一个待执行任务，返回是否应继续尝试领取.
+func (w *JobWorker) runNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	dbx := w.mgr.GetDBClient().GetDB().WithContext(ctx)
+
+	var job model.Job
+	if err := dbx.Where("status = ?", model.JobStatusPending).
+		Order("created_at ASC").Take(&job).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) && ctx.Err() == nil {
+			nlog.Logger().Warn().Err(err).Msg("poll jobs failed")
+		}
+
+		return false
+	}
+
+	now := time.Now().UTC()
+	job.ClaimID = newULID(now)
+
+	// 条件更新领取任务，未命中说明已被其它 worker 领取或已取消
+	res := dbx.Model(&model.Job{}).
+		Where("job_id = ? AND status = ?", job.JobID, model.JobStatusPending).
+		Updates(map[string]any{"status": model.JobStatusRunning, "claim_id": job.ClaimID, "started_at": now, "updated_at": now})
+	if res.Error != nil {
+		nlog.Logger().Warn().Err(res.Error).Str("job_id", job.JobID).Msg("claim job failed")
+		return false
+	}
+
+	if res.RowsAffected == 0 {
+		return true
+	}
+
+	w.run(ctx, &job)
+
+	return true
+}
+
+// run 执行已领取的任务并写入终态.
+func (w *JobWorker) run(ctx context.Context, job *model.Job) {
+	l := nlog.Logger().With().Str("job_id", job.JobID).Str("type", job.Type).Logger()
+	dbx := w.mgr.GetDBClient().GetDB()
+	r := newJobReporter(dbx, job.JobID, w.cfg.FlushSize)
+
+	var err error
+
+	if exec, ok := jobExecutors[job.Type]; ok {
+		err = exec(ctx, NewFileService(ctx), job, r)
+	} else {
+		err = fmt.Errorf("unknown job type: %s", job.Type)
+	}
+
+	// 终态写入不受 worker 关闭影响
+	finishCtx := context.WithoutCancel(ctx)
+	if flushErr := r.flush(finishCtx); flushErr != nil {
+		l.Error().Err(flushErr).Msg("flush job results failed")
+	}
+
+	status := model.JobStatusCompleted
+	errMsg := ""
+
+	switch {
+	case errors.Is(err, errJobCanceled):
+		status = model.JobStatusCanceled
+	case ctx.Err() != nil:
+		status = model.JobStatusFailed
+		errMsg = "interrupted: worker stopped"
+	case err != nil:
+		status = model.JobStatusFailed
+		errMsg = err.Error()
+	}
+
+	// 仅在任务仍由本次领取执行时写入终态，避免覆盖过期恢复已写入的 failed
+	now := time.Now().UTC()
+	res := dbx.WithContext(finishCtx).Model(&model.Job{}).
+		Where("job_id = ? AND status = ? AND claim_id = ?", job.JobID, model.JobStatusRunning, job.ClaimID).
+		Updates(map[string]any{
+			"status":      status,
+			"error":       errMsg,
+			"finished_at": now,
+			"updated_at":  now,
+		})
+
+	switch {
+	case res.Error != nil:
+		l.Error().Err(res.Error).Msg("update job status failed")
+	case res.RowsAffected == 0:
+		l.Warn().Str("status", status).Msg("job no longer held by this worker, final status not written")
+
+		return
+	}
+
+	l.Info().Str("status", status).Int("processed", r.seq).Int("failed", r.failed).Msg("job finished")
+}
+
+// maintain 清理超过保留期的已结束任务，并把长时间无进度的执行中任务标记为失败.
+func (w *JobWorker) maintain(ctx context.Context) {
+	l := nlog.Logger()
+	dbx := w.mgr.GetDBClient().GetDB().WithContext(ctx)
+	now := time.Now().UTC()
+
+	if err := dbx.Model(&model.Job{}).
+		Where("status = ? AND updated_at < ?", model.JobStatusRunning, now.Add(-jobStaleAfter)).
+		Updates(map[string]any{
+			"status":      model.JobStatusFailed,
+			"error":       "interrupted: no progress reported",
+			"finished_at": now,
+			"updated_at":  now,
+		}).Error; err != nil {
+		l.Warn().Err(err).Msg("recover stale jobs failed")
+	}
+
+	cutoff := now.Add(-w.cfg.GetRetention())
+
+	for ctx.Err() == nil {
+		var ids []string
+		if err := dbx.Model(&model.Job{}).Where("finished_at IS NOT NULL AND finished_at < ?", cutoff).
+			Limit(jobCleanupBatch).Pluck("job_id", &ids).Error; err != nil {
+			l.Warn().Err(err).Msg("list expired jobs failed")
+			return
+		}
+
+		if len(ids) == 0 {
+			return
+		}
+
+		if err := dbx.Where("job_id IN ?", ids).Delete(&model.JobItem{}).Error; err != nil {
+			l.Warn().Err(err).Msg("delete expired job items failed")
+			return
+		}
+
+		if err := dbx.Where("job_id IN ?", ids).Delete(&model.Job{}).Error; err != nil {
+			l.Warn().Err(err).Msg("delete expired jobs failed")
+			return
+		}
+
+		l.Info().Int("count", len(ids)).Msg("expired jobs cleaned")
+	}
+}
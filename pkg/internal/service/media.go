@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/media"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// ExtractMediaMeta 读取对象内容提取媒体元数据并写入 file_media；对象 ETag 未变化时跳过.
+// 没有对应提取器或内容无法解析时返回 media.ErrUnsupported.
+func (fs *FileService) ExtractMediaMeta(ctx context.Context, user, objectKey string) error {
+	src, err := fs.StatObject(ctx, user, objectKey)
+	if err != nil {
+		return err
+	}
+
+	kind := media.KindOf(src.ContentType)
+	if kind == "" {
+		return media.ErrUnsupported
+	}
+
+	maxBytes := int64(configs.GetConfig().Media.MaxSourceMB) << 20
+	if kind == media.KindPDF && src.Size > maxBytes {
+		return fmt.Errorf("%w: pdf larger than %d MB", media.ErrUnsupported, configs.GetConfig().Media.MaxSourceMB)
+	}
+
+	dbx := fs.dbClient.GetDB().WithContext(ctx)
+
+	var prev model.FileMedia
+	if err := dbx.Where("user = ? AND object_key = ?", user, objectKey).Take(&prev).Error; err == nil {
+		// 早期提取的图片记录没有感知哈希，需要重新提取补齐
+		if prev.SourceETag == src.ETag && (prev.Kind != media.KindImage || prev.PHash != nil) {
+			return nil
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("load media record: %w", err)
+	}
+
+	obj, err := fs.s3Client.GetObject(ctx, src.Bucket, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("get object: %w", err)
+	}
+
+	defer func() { _ = obj.Close() }()
+
+	meta, err := media.Extract(obj, src.Size, src.ContentType)
+	if err != nil {
+		return err
+	}
+
+	rec := newFileMedia(user, objectKey, src.ETag, meta)
+
+	if meta.Kind == media.KindImage {
+		if err := hashImage(obj, meta, &rec); err != nil {
+			nlog.Logger().Debug().Err(err).Str("object_key", objectKey).Msg("skip perceptual hash")
+		}
+	}
+
+	if err := dbx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user"}, {Name: "object_key"}},
+		UpdateAll: true,
+	}).Create(&rec).Error; err != nil {
+		return fmt.Errorf("upsert media record: %w", err)
+	}
+
+	return nil
+}
+
+// hashImage 完整解码图片并计算感知哈希；像素数超过配置上限时跳过.
+func hashImage(r io.ReadSeeker, meta *media.Metadata, rec *model.FileMedia) error {
+	maxMegapixels := configs.GetConfig().Media.MaxMegapixels
+	if int64(meta.Width)*int64(meta.Height) > int64(maxMegapixels)*1_000_000 {
+		return fmt.Errorf("image exceeds %d megapixels", maxMegapixels)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek: %w", err)
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+
+	h := media.HashImage(img)
+	phash, dhash := int64(h.PHash), int64(h.DHash) //nolint:gosec // 按位存储
+
+	rec.PHash, rec.DHash = &phash, &dhash
+
+	return nil
+}
+
+// DeleteMediaMeta 删除对象的媒体元数据（对象删除后调用）.
+func (fs *FileService) DeleteMediaMeta(ctx context.Context, user, objectKey string) error {
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("user = ? AND object_key = ?", user, objectKey).Delete(&model.FileMedia{}).Error; err != nil {
+		return fmt.Errorf("delete media record: %w", err)
+	}
+
+	return nil
+}
+
+// applyMediaFilters 将搜索请求中的媒体过滤条件转换为 file_media 上的 EXISTS 子查询.
+func (fs *FileService) applyMediaFilters(dbx *gorm.DB, user string, req *types.SearchFilesRequest) *gorm.DB {
+	sub := fs.dbClient.GetDB().Model(&model.FileMedia{}).Select("1").
+		Where("file_media.user = ? AND file_media.object_key = files.object_key", user)
+	filtered := false
+
+	if r := req.TakenBetween; r != nil {
+		sub = sub.Where("file_media.taken_at IS NOT NULL")
+		if !r.From.IsZero() {
+			sub = sub.Where("file_media.taken_at >= ?", r.From)
+		}
+
+		if !r.To.IsZero() {
+			sub = sub.Where("file_media.taken_at <= ?", r.To)
+		}
+
+		filtered = true
+	}
+
+	if req.MinWidth > 0 {
+		sub, filtered = sub.Where("file_media.width >= ?", req.MinWidth), true
+	}
+
+	if req.MinHeight > 0 {
+		sub, filtered = sub.Where("file_media.height >= ?", req.MinHeight), true
+	}
+
+	if req.HasGPS != nil {
+		sub, filtered = sub.Where("file_media.has_gps = ?", *req.HasGPS), true
+	}
+
+	if req.Camera != "" {
+		like := "%" + req.Camera + "%"
+		sub, filtered = sub.Where("file_media.camera_model LIKE ? OR file_media.camera_make LIKE ?", like, like), true
+	}
+
+	if req.MinPages > 0 {
+		sub, filtered = sub.Where("file_media.page_count >= ?", req.MinPages), true
+	}
+
+	if req.Artist != "" {
+		sub, filtered = sub.Where("file_media.artist LIKE ?", "%"+req.Artist+"%"), true
+	}
+
+	if req.Album != "" {
+		sub, filtered = sub.Where("file_media.album LIKE ?", "%"+req.Album+"%"), true
+	}
+
+	if !filtered {
+		return dbx
+	}
+
+	return dbx.Where("EXISTS (?)", sub)
+}
+
+// loadMediaInfos 批量读取对象的媒体元数据，按对象键索引.
+func (fs *FileService) loadMediaInfos(ctx context.Context, user string, keys []string) (map[string]*types.MediaInfo, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var rows []model.FileMedia
+	if err := fs.dbClient.GetDB().WithContext(ctx).
+		Where("user = ? AND object_key IN ?", user, keys).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("query media: %w", err)
+	}
+
+	out := make(map[string]*types.MediaInfo, len(rows))
+	for i := range rows {
+		out[rows[i].ObjectKey] = toMediaInfo(&rows[i])
+	}
+
+	return out, nil
+}
+
+func newFileMedia(user, objectKey, etag string, m *media.Metadata) model.FileMedia {
+	now := time.Now().UTC()
+
+	return model.FileMedia{
+		User:        user,
+		ObjectKey:   objectKey,
+		Kind:        m.Kind,
+		Width:       m.Width,
+		Height:      m.Height,
+		TakenAt:     m.TakenAt,
+		CameraMake:  m.CameraMake,
+		CameraModel: m.CameraModel,
+		Latitude:    m.Latitude,
+		Longitude:   m.Longitude,
+		HasGPS:      m.HasGPS(),
+		PageCount:   m.PageCount,
+		Title:       truncate(m.Title, 512),
+		Author:      truncate(m.Author, 255),
+		Subject:     truncate(m.Subject, 512),
+		Producer:    truncate(m.Producer, 255),
+		Artist:      truncate(m.Artist, 255),
+		Album:       truncate(m.Album, 255),
+		Genre:       truncate(m.Genre, 128),
+		Year:        m.Year,
+		Track:       m.Track,
+		SourceETag:  etag,
+		ExtractedAt: now,
+		UpdatedAt:   now,
+	}
+}
+
+func toMediaInfo(r *model.FileMedia) *types.MediaInfo {
+	return &types.MediaInfo{
+		Kind:        r.Kind,
+		Width:       r.Width,
+		Height:      r.Height,
+		TakenAt:     r.TakenAt,
+		CameraMake:  r.CameraMake,
+		CameraModel: r.CameraModel,
+		Latitude:    r.Latitude,
+		Longitude:   r.Longitude,
+		PageCount:   r.PageCount,
+		Title:       r.Title,
+		Author:      r.Author,
+		Subject:     r.Subject,
+		Artist:      r.Artist,
+		Album:       r.Album,
+		Genre:       r.Genre,
+		Year:        r.Year,
+		Track:       r.Track,
+	}
+}
+
+// truncate 按字符截断到 n 个字符以内，适配列宽.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n])
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/media"
+	"github.com/yeisme/notevault/pkg/internal/storage"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/queue"
+)
+
+// MediaWorker 订阅对象存储/更新事件提取媒体元数据，并在对象删除后清理.
+type MediaWorker struct {
+	mgr    *storage.Manager
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMediaWorker 创建媒体元数据 worker，需要 DB、S3 与 MQ 均已初始化.
+func NewMediaWorker(mgr *storage.Manager) *MediaWorker {
+	return &MediaWorker{mgr: mgr}
+}
+
+// Start 订阅 nv.object.stored、nv.object.updated 与 nv.object.deleted.
+func (w *MediaWorker) Start() error {
+	ctx, cancel := context.WithCancel(ctxPkg.WithStorageManager(context.Background(), w.mgr))
+	mqc := w.mgr.GetMQClient()
+
+	subs := make(map[string]<-chan *message.Message, 3)
+
+	for _, topic := range []string{queue.TopicObjectStored, queue.TopicObjectUpdated, queue.TopicObjectDeleted} {
+		ch, err := mqc.Subscribe(ctx, topic)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("subscribe %s: %w", topic, err)
+		}
+
+		subs[topic] = ch
+	}
+
+	w.cancel = cancel
+	fs := NewFileService(ctx)
+
+	extract := func(objectKey string) error {
+		user, _, _ := strings.Cut(objectKey, "/")
+
+		err := fs.ExtractMediaMeta(ctx, user, objectKey)
+		if errors.Is(err, media.ErrUnsupported) || errors.Is(err, ErrObjectNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	consumeMessages(ctx, &w.wg, "media", subs[queue.TopicObjectStored], func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectStoredPayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		return extract(m.Payload.Object.ObjectKey)
+	})
+
+	consumeMessages(ctx, &w.wg, "media", subs[queue.TopicObjectUpdated], func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectUpdatedPayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		return extract(m.Payload.Object.ObjectKey)
+	})
+
+	consumeMessages(ctx, &w.wg, "media", subs[queue.TopicObjectDeleted], func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectDeletedPayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		user, _, _ := strings.Cut(m.Payload.Object.ObjectKey, "/")
+
+		return fs.DeleteMediaMeta(ctx, user, m.Payload.Object.ObjectKey)
+	})
+
+	nlog.Logger().Info().Msg("media worker started")
+
+	return nil
+}
+
+// Stop 取消订阅并等待当前消息处理完成.
+func (w *MediaWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+
+	w.cancel()
+	w.wg.Wait()
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/minio/minio-go/v7"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/sync/singleflight"
+
+	// 纯 Go 图片解码器.
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+const (
+	// previewPrefix 缩略图在存储桶中的根目录（以 "." 开头的系统目录，不参与同步与对账），
+	// 其下按原对象键分目录，加密规则据此按原对象的用户匹配.
+	previewPrefix = ".previews/"
+	// previewGenerateTimeout 按需生成缩略图的超时时间，生成不随发起请求的取消而中断.
+	previewGenerateTimeout = 2 * time.Minute
+)
+
+var (
+	// ErrPreviewDisabled 预览功能未启用.
+	ErrPreviewDisabled = errors.New("preview is disabled")
+	// ErrPreviewUnsupported 对象不是可解码的图片.
+	ErrPreviewUnsupported = errors.New("preview not supported for this object")
+	// ErrPreviewSize 请求的尺寸不在配置的尺寸列表中.
+	ErrPreviewSize = errors.New("unsupported preview size")
+)
+
+// previewGroup 合并同一缩略图的并发生成请求.
+var previewGroup singleflight.Group
+
+// GetPreview 返回对象指定尺寸的缩略图；缩略图不存在时同步生成并写入对象存储.
+// 缩略图键包含原图 ETag，原图内容变化后自动生成新的缩略图.
+func (fs *FileService) GetPreview(ctx context.Context, user, objectKey string, size int) (*types.ObjectInfo, io.ReadCloser, error) {
+	cfg := configs.GetConfig().Preview
+	if !cfg.Enabled {
+		return nil, nil, ErrPreviewDisabled
+	}
+
+	if !slices.Contains(cfg.Sizes, size) {
+		return nil, nil, fmt.Errorf("%w: allowed sizes are %v", ErrPreviewSize, cfg.Sizes)
+	}
+
+	src, err := fs.StatObject(ctx, user, objectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !isPreviewable(src.ContentType) {
+		return nil, nil, ErrPreviewUnsupported
+	}
+
+	key := previewKey(objectKey, src.ETag, size, cfg.Format)
+
+	info, err := fs.s3Client.StatObject(ctx, src.Bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		if !isNoSuchKey(err) {
+			return nil, nil, fmt.Errorf("stat preview: %w", err)
+		}
+
+		// 生成由多个等待者共享，不能使用首个请求的 ctx：其客户端断开会使所有等待者失败
+		ch := previewGroup.DoChan(key, func() (any, error) {
+			genCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), previewGenerateTimeout)
+			defer cancel()
+
+			return nil, fs.generatePreviews(genCtx, src, []int{size})
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case res := <-ch:
+			if res.Err != nil {
+				return nil, nil, res.Err
+			}
+		}
+
+		if info, err = fs.s3Client.StatObject(ctx, src.Bucket, key, minio.StatObjectOptions{}); err != nil {
+			return nil, nil, fmt.Errorf("stat preview: %w", err)
+		}
+	}
+
+	obj, err := fs.s3Client.GetObject(ctx, src.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("get preview: %w", err)
+	}
+
+	return &types.ObjectInfo{
+		ObjectKey:    key,
+		Size:         info.Size,
+		ETag:         strings.Trim(info.ETag, "\""),
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified.UTC().Format(time.RFC3339),
+		Bucket:       src.Bucket,
+	}, obj, nil
+}
+
+// GeneratePreviews 为图片对象预生成所有配置尺寸的缩略图（已存在的跳过）.
+func (fs *FileService) GeneratePreviews(ctx context.Context, user, objectKey string) error {
+	cfg := configs.GetConfig().Preview
+	if !cfg.Enabled {
+		return ErrPreviewDisabled
+	}
+
+	src, err := fs.StatObject(ctx, user, objectKey)
+	if err != nil {
+		return err
+	}
+
+	if !isPreviewable(src.ContentType) {
+		return ErrPreviewUnsupported
+	}
+
+	missing := make([]int, 0, len(cfg.Sizes))
+
+	for _, size := range cfg.Sizes {
+		key := previewKey(objectKey, src.ETag, size, cfg.Format)
+
+		_, err := fs.s3Client.StatObject(ctx, src.Bucket, key, minio.StatObjectOptions{})
+		if err == nil {
+			continue
+		}
+
+		if !isNoSuchKey(err) {
+			return fmt.Errorf("stat preview: %w", err)
+		}
+
+		missing = append(missing, size)
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fs.generatePreviews(ctx, src, missing)
+}
+
+// DeletePreviews 删除对象的全部缩略图（原图删除后调用）.
+func (fs *FileService) DeletePreviews(ctx context.Context, bucket, objectKey string) error {
+	prefix := previewPrefix + objectKey + "/"
+
+	for obj := range fs.s3Client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return fmt.Errorf("list previews: %w", obj.Err)
+		}
+
+		if err := fs.s3Client.RemoveObject(ctx, bucket, obj.Key, minio.RemoveObjectOptions{}); err != nil {
+			return fmt.Errorf("remove preview %s: %w", obj.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// generatePreviews 读取并解码一次原图，生成指定尺寸的缩略图并写入对象存储.
+func (fs *FileService) generatePreviews(ctx context.Context, src *types.ObjectInfo, sizes []int) error {
+	cfg := configs.GetConfig().Preview
+
+	maxBytes := int64(cfg.MaxSourceMB) << 20
+	if src.Size > maxBytes {
+		return fmt.Errorf("%w: source larger than %d MB", ErrPreviewUnsupported, cfg.MaxSourceMB)
+	}
+
+	obj, err := fs.s3Client.GetObject(ctx, src.Bucket, src.ObjectKey, minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("get object: %w", err)
+	}
+
+	defer func() { _ = obj.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(obj, maxBytes+1))
+	if err != nil {
+		return fmt.Errorf("read object: %w", err)
+	}
+
+	img, err := decodePreviewSource(data, cfg.MaxMegapixels)
+	if err != nil {
+		return err
+	}
+
+	for _, size := range sizes {
+		out, contentType, err := renderPreview(img, size, cfg.Format, cfg.Quality)
+		if err != nil {
+			return err
+		}
+
+		key := previewKey(src.ObjectKey, src.ETag, size, cfg.Format)
+
+		if _, err := fs.s3Client.PutObject(ctx, src.Bucket, key, bytes.NewReader(out), int64(len(out)),
+			minio.PutObjectOptions{ContentType: contentType}); err != nil {
+			return fmt.Errorf("put preview: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// decodePreviewSource 解码图片，先读取尺寸以拒绝像素数超限的图片.
+func decodePreviewSource(data []byte, maxMegapixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPreviewUnsupported, err)
+	}
+
+	if int64(cfg.Width)*int64(cfg.Height) > int64(maxMegapixels)*1_000_000 {
+		return nil, fmt.Errorf("%w: image exceeds %d megapixels", ErrPreviewUnsupported, maxMegapixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPreviewUnsupported, err)
+	}
+
+	return img, nil
+}
+
+// renderPreview 等比缩放到长边不超过 size（不放大），按 format 编码.
+func renderPreview(img image.Image, size int, format string, quality int) ([]byte, string, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	if format != "webp" {
+		// JPEG 不支持透明通道，以白色作为背景
+		xdraw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, xdraw.Src)
+	}
+
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, b, xdraw.Over, nil)
+
+	var buf bytes.Buffer
+
+	if format == "webp" {
+		if err := nativewebp.Encode(&buf, dst, nil); err != nil {
+			return nil, "", fmt.Errorf("encode webp: %w", err)
+		}
+
+		return buf.Bytes(), "image/webp", nil
+	}
+
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, "", fmt.Errorf("encode jpeg: %w", err)
+	}
+
+	return buf.Bytes(), "image/jpeg", nil
+}
+
+// previewKey 缩略图对象键：.previews/<原对象键>/<ETag>-<尺寸>.<格式>.
+func previewKey(objectKey, etag string, size int, format string) string {
+	ext := "jpg"
+	if format == "webp" {
+		ext = "webp"
+	}
+
+	return previewPrefix + objectKey + "/" + etag + "-" + strconv.Itoa(size) + "." + ext
+}
+
+// isPreviewable 判断内容类型是否有可用的纯 Go 解码器.
+func isPreviewable(contentType string) bool {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+
+	switch strings.TrimSpace(ct) {
+	case "image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp", "image/bmp", "image/x-ms-bmp", "image/tiff":
+		return true
+	default:
+		return false
+	}
+}
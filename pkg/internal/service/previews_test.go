@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/envelope"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+)
+
+// TestPreviewEncryption 验证按租户规则加密的图片，其缩略图同样加密存储.
+func TestPreviewEncryption(t *testing.T) {
+	ctx := newTestContext(t, false)
+	cfg := configs.GetConfig()
+	bucket := cfg.S3.Buckets[0]
+
+	key := make([]byte, envelope.KeySize)
+	_, _ = rand.Read(key)
+	t.Setenv("NV_TEST_PREVIEW_KEY", base64.StdEncoding.EncodeToString(key))
+
+	cfg.Preview.Enabled = true
+	cfg.Encryption = configs.EncryptionConfig{
+		Mode:        configs.EncryptionModeNone,
+		Rules:       []configs.EncryptionRule{{Mode: configs.EncryptionModeEnvelope, Tenants: []string{"example.com"}}},
+		MasterKeys:  []configs.MasterKeyConfig{{ID: "k1", Env: "NV_TEST_PREVIEW_KEY"}},
+		ChunkSizeKB: 64,
+	}
+
+	client := ctxPkg.GetManager(ctx).GetS3Client()
+	raw := client.ObjectStore
+
+	st, err := s3.NewEncryptedStore(raw, &cfg.Encryption)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.ObjectStore = st
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 64, 48))); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := service.NewFileService(ctx)
+
+	up, err := fs.UploadSingleFile(ctx, testUser, "photo.png", bytes.NewReader(img.Bytes()), int64(img.Len()), nil)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if err := fs.GeneratePreviews(ctx, testUser, up.ObjectKey); err != nil {
+		t.Fatalf("generate previews: %v", err)
+	}
+
+	n := 0
+
+	for obj := range raw.ListObjects(ctx, bucket, minio.ListObjectsOptions{Prefix: ".previews/", Recursive: true}) {
+		if obj.Err != nil {
+			t.Fatal(obj.Err)
+		}
+
+		stored := readAll(t, raw, bucket, obj.Key)
+		if plain := readAll(t, st, bucket, obj.Key); len(plain) == 0 || bytes.Equal(stored, plain) {
+			t.Fatalf("preview %s stored unencrypted", obj.Key)
+		}
+
+		n++
+	}
+
+	if n != len(cfg.Preview.Sizes) {
+		t.Fatalf("previews = %d, want %d", n, len(cfg.Preview.Sizes))
+	}
+}
+
+// readAll 读取对象的全部内容.
+func readAll(t *testing.T, st s3.ObjectStore, bucket, key string) []byte {
+	t.Helper()
+
+	obj, err := st.GetObject(t.Context(), bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		t.Fatalf("get %s: %v", key, err)
+	}
+	defer obj.Close()
+
+	b, err := io.ReadAll(obj)
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+
+	return b
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/storage"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/queue"
+)
+
+// PreviewWorker 订阅图片存储事件预生成缩略图，并在对象删除后清理缩略图.
+type PreviewWorker struct {
+	mgr    *storage.Manager
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPreviewWorker 创建缩略图 worker，需要 DB、S3 与 MQ 均已初始化.
+func NewPreviewWorker(mgr *storage.Manager) *PreviewWorker {
+	return &PreviewWorker{mgr: mgr}
+}
+
+// Start 订阅 nv.object.image.stored 与 nv.object.deleted.
+func (w *PreviewWorker) Start() error {
+	ctx, cancel := context.WithCancel(ctxPkg.WithStorageManager(context.Background(), w.mgr))
+	mqc := w.mgr.GetMQClient()
+
+	stored, err := mqc.Subscribe(ctx, queue.TopicObjectImageStored)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("subscribe %s: %w", queue.TopicObjectImageStored, err)
+	}
+
+	deleted, err := mqc.Subscribe(ctx, queue.TopicObjectDeleted)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("subscribe %s: %w", queue.TopicObjectDeleted, err)
+	}
+
+	w.cancel = cancel
+	fs := NewFileService(ctx)
+
+	consumeMessages(ctx, &w.wg, "preview", stored, func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectStoredPayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		user, _, _ := strings.Cut(m.Payload.Object.ObjectKey, "/")
+
+		err = fs.GeneratePreviews(ctx, user, m.Payload.Object.ObjectKey)
+		if errors.Is(err, ErrPreviewUnsupported) || errors.Is(err, ErrObjectNotFound) {
+			return nil
+		}
+
+		return err
+	})
+
+	consumeMessages(ctx, &w.wg, "preview", deleted, func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectDeletedPayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		return fs.DeletePreviews(ctx, m.Payload.Object.Bucket, m.Payload.Object.ObjectKey)
+	})
+
+	nlog.Logger().Info().Msg("preview worker started")
+
+	return nil
+}
+
+// Stop 取消订阅并等待当前消息处理完成.
+func (w *PreviewWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+
+	w.cancel()
+	w.wg.Wait()
+}
+
+// consumeMessages 逐条处理消息：成功 Ack，失败 Nack 以便重投.
+func consumeMessages(ctx context.Context, wg *sync.WaitGroup, task string,
+	ch <-chan *message.Message, handle func(*message.Message) error) {
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for msg := range ch {
+			if err := handle(msg); err != nil && ctx.Err() == nil {
+				nlog.Logger().Warn().Err(err).Str("message_id", msg.UUID).Msgf("%s task failed", task)
+				msg.Nack()
+
+				continue
+			}
+
+			msg.Ack()
+		}
+	}()
+}
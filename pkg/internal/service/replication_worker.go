@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/storage"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/queue"
+)
+
+// ReplicationWorker 订阅对象存储/更新/删除与生命周期迁移事件，将变化同步到副本存储.
+type ReplicationWorker struct {
+	mgr    *storage.Manager
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewReplicationWorker 创建复制 worker，需要 DB、S3、副本 S3 与 MQ 均已初始化.
+func NewReplicationWorker(mgr *storage.Manager) *ReplicationWorker {
+	return &ReplicationWorker{mgr: mgr}
+}
+
+// Start 订阅 nv.object.stored、nv.object.updated、nv.object.deleted 与 nv.object.transitioned.
+func (w *ReplicationWorker) Start() error {
+	ctx, cancel := context.WithCancel(ctxPkg.WithStorageManager(context.Background(), w.mgr))
+	mqc := w.mgr.GetMQClient()
+
+	subs := make(map[string]<-chan *message.Message, 4)
+
+	for _, topic := range []string{queue.TopicObjectStored, queue.TopicObjectUpdated, queue.TopicObjectDeleted, queue.TopicObjectTransitioned} {
+		ch, err := mqc.Subscribe(ctx, topic)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("subscribe %s: %w", topic, err)
+		}
+
+		subs[topic] = ch
+	}
+
+	w.cancel = cancel
+	fs := NewFileService(ctx)
+
+	// 同步失败已记录在复制状态中由重试任务处理，确认消息避免立即重投
+	replicate := func(ref queue.ObjectRef) error {
+		if ref.Bucket == "" || ref.ObjectKey == "" {
+			return nil
+		}
+
+		err := fs.ReplicateObject(ctx, ref.Bucket, ref.ObjectKey)
+		if errors.Is(err, ErrReplicationFailed) {
+			return nil
+		}
+
+		return err
+	}
+
+	consumeMessages(ctx, &w.wg, "replication", subs[queue.TopicObjectStored], func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectStoredPayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		return replicate(m.Payload.Object)
+	})
+
+	consumeMessages(ctx, &w.wg, "replication", subs[queue.TopicObjectUpdated], func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectUpdatedPayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		return replicate(m.Payload.Object)
+	})
+
+	consumeMessages(ctx, &w.wg, "replication", subs[queue.TopicObjectDeleted], func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectDeletedPayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		return replicate(m.Payload.Object)
+	})
+
+	// 跨 bucket 迁移后在新 bucket 的副本写入对象，原 bucket 的副本随之删除
+	consumeMessages(ctx, &w.wg, "replication", subs[queue.TopicObjectTransitioned], func(msg *message.Message) error {
+		m, err := queue.ParseWatermillMessage[queue.ObjectLifecyclePayload](msg)
+		if err != nil {
+			return nil //nolint:nilerr // 无法解析的消息直接丢弃
+		}
+
+		if err := replicate(m.Payload.Object); err != nil {
+			return err
+		}
+
+		if m.Payload.FromBucket == "" || m.Payload.FromBucket == m.Payload.Object.Bucket {
+			return nil
+		}
+
+		return replicate(queue.ObjectRef{Bucket: m.Payload.FromBucket, ObjectKey: m.Payload.Object.ObjectKey})
+	})
+
+	nlog.Logger().Info().Msg("replication worker started")
+
+	return nil
+}
+
+// Stop 取消订阅并等待当前消息处理完成.
+func (w *ReplicationWorker) Stop() {
+	if w.cancel == nil {
+		return
+	}
+
+	w.cancel()
+	w.wg.Wait()
+}
@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	ctxPkg "github.com/yeisme/notevault/pkg/context"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/storage"
+	"github.com/yeisme/notevault/pkg/internal/storage/db"
+	"github.com/yeisme/notevault/pkg/internal/storage/kv"
+	"github.com/yeisme/notevault/pkg/internal/storage/mq"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+)
+
+const testUser = "alice@example.com"
+
+// bucketSeq 每个测试使用独立的 bucket 名称，避免进程级的版本化状态缓存互相影响.
+var bucketSeq atomic.Int64
+
+// newTestContext 构造使用内存对象存储、临时 SQLite、内存 KV 与 gochannel MQ 的上下文，无需任何外部服务.
+// extra 为默认 bucket 之外的附加 bucket 后缀（实际名称为 "<默认 bucket>-<后缀>"）.
+// 测试之间共享全局配置，不能并行运行.
+func newTestContext(t *testing.T, versioned bool, extra ...string) context.Context {
+	t.Helper()
+
+	if err := configs.InitConfig(t.TempDir()); err != nil {
+		t.Fatalf("init config: %v", err)
+	}
+
+	cfg := configs.GetConfig()
+	cfg.Log.EnableFile = false
+	cfg.Log.Level = "warn"
+	cfg.DB.Type = configs.SQLite
+	cfg.DB.Database = filepath.Join(t.TempDir(), "notevault")
+	cfg.S3.Buckets = []string{fmt.Sprintf("notevault-%d", bucketSeq.Add(1))}
+
+	for _, suffix := range extra {
+		cfg.S3.Buckets = append(cfg.S3.Buckets, cfg.S3.Buckets[0]+"-"+suffix)
+	}
+
+	ctx := context.Background()
+
+	dbc, err := db.New(ctx)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	if err := dbc.AutoMigrate(
+		&model.Files{},
+		&model.Share{},
+		&model.ShareAccess{},
+		&model.Job{},
+		&model.JobItem{},
+		&model.FileMedia{},
+		&model.TrashItem{},
+		&model.FileVersion{},
+		&model.VersionRetention{},
+		&model.ReplicationStatus{},
+		&model.ObjectHold{},
+		&model.ObjectHoldEvent{},
+		&model.AuditEvent{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	kvStore, err := kv.NewKVStore(ctx, kv.KVTypeMemory, nil)
+	if err != nil {
+		t.Fatalf("memory kv: %v", err)
+	}
+
+	pubSub := gochannel.NewGoChannel(gochannel.Config{}, watermill.NopLogger{})
+
+	mgr := storage.NewManager(
+		&s3.Client{ObjectStore: s3.NewMemoryStore(versioned, cfg.S3.Buckets...)},
+		dbc,
+		mq.NewClient(pubSub, pubSub),
+		&kv.Client{KVStore: kvStore},
+	)
+	t.Cleanup(func() { _ = mgr.Close() })
+
+	return ctxPkg.WithStorageManager(ctx, mgr)
+}
+
+// uploadText 上传文本文件并返回对象键.
+func uploadText(t *testing.T, ctx context.Context, name, body string) string {
+	t.Helper()
+
+	resp, err := service.NewFileService(ctx).UploadSingleFile(ctx, testUser, name, strings.NewReader(body), int64(len(body)), nil)
+	if err != nil {
+		t.Fatalf("upload %s: %v", name, err)
+	}
+
+	return resp.ObjectKey
+}
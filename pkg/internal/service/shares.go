@@ -3,34 +3,47 @@ package service
 import (
 	"context"
 	crand "crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/oklog/ulid"
+	"gorm.io/gorm"
 
 	ctxPkg "github.com/yeisme/notevault/pkg/context"
 	"github.com/yeisme/notevault/pkg/internal/model"
 	"github.com/yeisme/notevault/pkg/internal/storage/db"
 	"github.com/yeisme/notevault/pkg/internal/storage/kv"
+	"github.com/yeisme/notevault/pkg/internal/storage/mq"
 	"github.com/yeisme/notevault/pkg/internal/storage/s3"
 	"github.com/yeisme/notevault/pkg/internal/types"
 	nlog "github.com/yeisme/notevault/pkg/log"
 )
 
 // 全局单例的 ULID 熵源，使用单调递增策略，确保同一毫秒内生成的 ULID 具有排序稳定性。
-var ulidEntropy = ulid.Monotonic(crand.Reader, 0)
+// Monotonic 熵源本身不是并发安全的，需通过 ulidMu 串行访问。
+var (
+	ulidEntropy = ulid.Monotonic(crand.Reader, 0)
+	ulidMu      sync.Mutex
+)
+
+var (
+	// ErrShareNotFound 分享不存在.
+	ErrShareNotFound = errors.New("share not found")
+	// ErrShareExpired 分享已过期.
+	ErrShareExpired = errors.New("share expired")
+)
 
 // ShareService 负责分享相关业务（默认基于 KV 存储，可平滑切换到 DB 实现）.
 type ShareService struct {
 	dbc *db.Client
 	kvc *kv.Client
 	s3c *s3.Client
+	mqc *mq.Client
 }
 
 // NewShareService 创建并返回一个新的 ShareService 实例.
@@ -39,6 +52,7 @@ func NewShareService(c context.Context) *ShareService {
 		dbc: ctxPkg.GetDBClient(c),
 		kvc: ctxPkg.GetKVClient(c),
 		s3c: ctxPkg.GetS3Client(c),
+		mqc: ctxPkg.GetMQClient(c),
 	}
 
 	if svc.dbc == nil {
@@ -53,19 +67,58 @@ func NewShareService(c context.Context) *ShareService {
 }
 
 // CreateShare 创建一个新的分享，返回分享信息.
-func (s *ShareService) CreateShare(ctx context.Context, user string, req *types.CreateShareRequest) (*types.CreateShareResponse, error) {
+func (s *ShareService) CreateShare(ctx context.Context, user string, req *types.CreateShareRequest) (resp *types.CreateShareResponse, err error) {
+	defer func() {
+		targets := []string{}
+		if resp != nil {
+			targets = append(targets, resp.Share.ShareID)
+		}
+
+		if req != nil {
+			targets = append(targets, req.ObjectKeys...)
+		}
+
+		RecordAudit(ctx, user, types.AuditActionShareCreate, targets, err, "")
+	}()
+
 	if user == "" {
 		return nil, fmt.Errorf("user is required")
 	}
 
-	if req == nil || len(req.ObjectKeys) == 0 {
-		return nil, fmt.Errorf("object_keys is required")
+	if req == nil || (len(req.ObjectKeys) == 0 && req.FolderID == "") {
+		return nil, fmt.Errorf("object_keys or folder_id is required")
+	}
+
+	if len(req.ObjectKeys) > 0 && req.FolderID != "" {
+		return nil, fmt.Errorf("object_keys and folder_id are mutually exclusive")
+	}
+
+	if req.Upload != nil && req.FolderID == "" {
+		return nil, fmt.Errorf("folder_id is required for upload shares")
 	}
 
 	if s.dbc == nil || s.dbc.GetDB() == nil {
 		return nil, errors.New("db not initialized")
 	}
 
+	shareType := types.ShareTypeObjects
+	if req.FolderID != "" {
+		if err := s.checkFolder(ctx, user, req.FolderID); err != nil {
+			return nil, err
+		}
+
+		shareType = types.ShareTypeFolder
+	}
+
+	allowDownload := req.AllowDownload
+
+	var upload *types.ShareUploadPolicy
+	if req.Upload != nil {
+		upload = normalizeUploadPolicy(req.Upload)
+		shareType = types.ShareTypeUpload
+		allowDownload = false
+	}
+
 	now := time.Now().UTC()
 
 	var (
@@ -80,20 +133,30 @@ func (s *ShareService) CreateShare(ctx context.Context, user string, req *types.
 		ttl = max(time.Until(e), 0)
 	}
 
+	passwordHash, err := hashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	// 使用 ULID 生成 shareID，保证按时间排序且唯一；保留 "sh_" 前缀以兼容历史格式
 	shareID := newShareID(now)
 	rec := &shareRecord{
 		ShareID:       shareID,
 		Owner:         user,
+		Type:          shareType,
+		FolderID:      req.FolderID,
 		ObjectKeys:    req.ObjectKeys,
 		CreatedAt:     now,
 		ExpireAt:      expire,
-		AllowDownload: req.AllowDownload,
-		PasswordHash:  hashPassword(req.Password),
+		AllowDownload: allowDownload,
+		PasswordHash:  passwordHash,
+		MaxViews:      req.MaxViews,
+		MaxDownloads:  req.MaxDownloads,
+		Upload:        upload,
 		Permissions: types.SharePermissions{
 			// 默认允许匿名访问（若设置了密码则按密码校验）
-			AllowAnonymous: req.Password == "",
-			Users:          []string{user}, // 创建者默认在用户列表中
+			AllowAnonymous: req.AllowAnonymous == nil || *req.AllowAnonymous,
+			Users:          compactUsers(append([]string{user}, req.Users...)), // 创建者默认在用户列表中
 		},
 	}
 
@@ -146,7 +209,9 @@ func (s *ShareService) ListShares(ctx context.Context, user string) (*types.List
 }
 
 // DeleteShare 删除指定的分享（仅 owner 可操作）.
-func (s *ShareService) DeleteShare(ctx context.Context, user, shareID string) error {
+func (s *ShareService) DeleteShare(ctx context.Context, user, shareID string) (err error) {
+	defer recordAuditResult(ctx, user, types.AuditActionShareDelete, []string{shareID}, "", &err)
+
 	if user == "" || shareID == "" {
 		return fmt.Errorf("user/shareID is required")
 	}
@@ -161,7 +226,7 @@ func (s *ShareService) DeleteShare(ctx context.Context, user, shareID string) er
 	}
 
 	if sh.Owner != user {
-		return fmt.Errorf("forbidden: not owner")
+		return fmt.Errorf("%w: not owner", ErrShareForbidden)
 	}
 
 	if err := s.dbc.GetDB().Delete(&sh).Error; err != nil {
@@ -189,8 +254,10 @@ func (s *ShareService) GetShareDetail(ctx context.Context, shareID string) (*typ
 	return &info, nil
 }
 
-// AccessShare 访问分享，校验可选密码，返回分享信息（不包含密码等敏感信息）.
-func (s *ShareService) AccessShare(ctx context.Context, shareID, password string) (*types.ShareInfo, error) {
+// AccessShare 访问分享：校验访问者权限与可选密码，成功后计入访问次数并签发短期访问令牌.
+// owner 无需密码且不计次数；其余访问者的密码错误按分享与来源 IP 分别计数，达到上限后锁定并返回 *ShareLockedError；
+// 旧版 SHA-256 哈希在校验成功后透明升级为 argon2id。每次访问（含被拒绝的访问）都会写入访问日志.
+func (s *ShareService) AccessShare(ctx context.Context, shareID, password string, v ShareVisitor) (*types.AccessShareResponse, error) {
 	if shareID == "" {
 		return nil, fmt.Errorf("shareID is required")
 	}
@@ -200,63 +267,92 @@ func (s *ShareService) AccessShare(ctx context.Context, shareID, password string
 		return nil, err
 	}
 
-	if rec.PasswordHash != "" {
-		if hashPassword(password) != rec.PasswordHash {
-			return nil, fmt.Errorf("invalid password")
-		}
+	if err := s.checkShareAccess(ctx, rec, password, v); err != nil {
+		s.logShareAccess(ctx, shareID, types.ShareActionDenied, "", err.Error(), v)
+		return nil, err
 	}
 
-	info := rec.toInfo()
+	token, exp, err := issueShareToken(rec, v.User)
+	if err != nil {
+		return nil, err
+	}
 
-	return &info, nil
+	s.logShareAccess(ctx, shareID, types.ShareActionView, "", "", v)
+
+	return &types.AccessShareResponse{ShareInfo: rec.toInfo(), AccessToken: token, TokenExpireAt: exp}, nil
 }
 
-// GetShareDownloadURL 获取分享的下载直链（仅当允许下载且仅包含单个对象时有效）.
-func (s *ShareService) GetShareDownloadURL(ctx context.Context, shareID string) (string, error) {
-	if shareID == "" {
-		return "", fmt.Errorf("shareID is required")
+// checkShareAccess 依次校验白名单、锁定状态、密码与访问次数.
+func (s *ShareService) checkShareAccess(ctx context.Context, rec *shareRecord, password string, v ShareVisitor) error {
+	if err := authorizeShare(rec, v.User); err != nil {
+		return err
 	}
 
-	if s.s3c == nil {
-		return "", errors.New("s3 not initialized")
+	if v.User == rec.Owner {
+		return nil
 	}
 
-	rec, err := s.getShareCached(ctx, shareID)
-	if err != nil {
-		return "", err
-	}
+	if rec.PasswordHash != "" {
+		if err := s.checkShareLocked(ctx, rec.ShareID, v.IP); err != nil {
+			return err
+		}
 
-	if !rec.AllowDownload {
-		return "", fmt.Errorf("download not allowed")
+		ok, legacy := verifyPassword(password, rec.PasswordHash)
+		if !ok {
+			if err := s.recordShareFailure(ctx, rec.ShareID, v.IP); err != nil {
+				return err
+			}
+
+			return ErrShareInvalidPassword
+		}
+
+		s.resetShareFailures(ctx, rec.ShareID)
+
+		if legacy {
+			s.upgradePasswordHash(ctx, rec, password)
+		}
 	}
 
-	if len(rec.ObjectKeys) == 0 {
-		return "", fmt.Errorf("no object in share")
+	return s.consumeShareQuota(ctx, rec.ShareID, "view_count", "max_views")
+}
+
+// UpdateSharePassword 修改或取消分享密码（仅 owner 可操作），已签发的访问令牌随之失效.
+func (s *ShareService) UpdateSharePassword(ctx context.Context, user, shareID, password string) (err error) {
+	defer recordAuditResult(ctx, user, types.AuditActionSharePassword, []string{shareID}, fmt.Sprintf("protected=%t", password != ""), &err)
+
+	if user == "" || shareID == "" {
+		return fmt.Errorf("user/shareID is required")
 	}
 
-	if len(rec.ObjectKeys) > 1 {
-		return "", fmt.Errorf("multiple objects not supported yet")
+	rec, err := s.getShareCached(ctx, shareID)
+	if err != nil {
+		return err
 	}
 
-	// 生成预签名下载（与 FileService 保持一致的默认过期时间）
-	bucket, err := func() (string, error) {
-		cfg := s.s3c.GetConfig()
-		if len(cfg.Buckets) == 0 {
-			return "", fmt.Errorf("no bucket configured")
-		}
+	if rec.Owner != user {
+		return fmt.Errorf("%w: not owner", ErrShareForbidden)
+	}
 
-		return cfg.Buckets[0], nil
-	}()
+	hash, err := hashPassword(password)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	u, err := s.s3c.PresignedGetObject(ctx, bucket, rec.ObjectKeys[0], DefaultPresignedOpTimeout, nil)
-	if err != nil {
-		return "", fmt.Errorf("presign get: %w", err)
+	if err := s.dbc.GetDB().WithContext(ctx).Model(&model.Share{}).
+		Where("share_id = ?", shareID).
+		Updates(map[string]any{
+			"password_hash": hash,
+			"token_version": gorm.Expr("token_version + 1"),
+			"updated_at":    time.Now().UTC(),
+		}).Error; err != nil {
+		return fmt.Errorf("update share password: %w", err)
 	}
 
-	return u.String(), nil
+	rec.PasswordHash = hash
+	rec.TokenVersion++
+	_ = s.cacheShare(ctx, rec, ttlFromExpire(rec.ExpireAt))
+
+	return nil
 }
 
 // GetSharePermissions 获取分享权限.
@@ -271,14 +367,16 @@ func (s *ShareService) GetSharePermissions(ctx context.Context, user, shareID st
 	}
 
 	if rec.Owner != user {
-		return nil, fmt.Errorf("forbidden: not owner")
+		return nil, fmt.Errorf("%w: not owner", ErrShareForbidden)
 	}
 
 	return &types.GetSharePermissionsResponse{ShareID: shareID, Permissions: rec.Permissions}, nil
 }
 
 // UpdateSharePermissions 仅更新分享的权限信息（仅 owner 可操作）。
-func (s *ShareService) UpdateSharePermissions(ctx context.Context, user, shareID string, req *types.UpdateSharePermissionsRequest) error {
+func (s *ShareService) UpdateSharePermissions(ctx context.Context, user, shareID string, req *types.UpdateSharePermissionsRequest) (err error) {
+	defer recordAuditResult(ctx, user, types.AuditActionSharePermissions, []string{shareID}, "update", &err)
+
 	if user == "" || shareID == "" || req == nil {
 		return fmt.Errorf("user/shareID/req is required")
 	}
@@ -289,13 +387,13 @@ func (s *ShareService) UpdateSharePermissions(ctx context.Context, user, shareID
 	}
 
 	if rec.Owner != user {
-		return fmt.Errorf("forbidden: not owner")
+		return fmt.Errorf("%w: not owner", ErrShareForbidden)
 	}
 
 	rec.Permissions.AllowAnonymous = req.AllowAnonymous
-	rec.Permissions.Users = slices.Compact(append([]string{}, req.Users...))
+	rec.Permissions.Users = compactUsers(req.Users)
 
-	if err := s.updatePermissionsInDB(ctx, shareID, rec.Permissions); err != nil {
+	if err := s.updatePermissionsInDB(ctx, rec); err != nil {
 		return err
 	}
 	// 刷新缓存
@@ -305,7 +403,9 @@ func (s *ShareService) UpdateSharePermissions(ctx context.Context, user, shareID
 }
 
 // AddShareUser 将 newUser 添加到 shareID 的访问用户列表中（仅限 owner 操作）.
-func (s *ShareService) AddShareUser(ctx context.Context, user, shareID, newUser string) error {
+func (s *ShareService) AddShareUser(ctx context.Context, user, shareID, newUser string) (err error) {
+	defer recordAuditResult(ctx, user, types.AuditActionSharePermissions, []string{shareID}, "add user "+newUser, &err)
+
 	if user == "" || shareID == "" || newUser == "" {
 		return fmt.Errorf("user/shareID/newUser is required")
 	}
@@ -316,14 +416,14 @@ func (s *ShareService) AddShareUser(ctx context.Context, user, shareID, newUser
 	}
 
 	if rec.Owner != user {
-		return fmt.Errorf("forbidden: not owner")
+		return fmt.Errorf("%w: not owner", ErrShareForbidden)
 	}
 
 	if slices.Index(rec.Permissions.Users, newUser) < 0 {
 		rec.Permissions.Users = append(rec.Permissions.Users, newUser)
 	}
 
-	if err := s.updatePermissionsInDB(ctx, shareID, rec.Permissions); err != nil {
+	if err := s.updatePermissionsInDB(ctx, rec); err != nil {
 		return err
 	}
 
@@ -334,7 +434,9 @@ func (s *ShareService) AddShareUser(ctx context.Context, user, shareID, newUser
 
 // RemoveShareUser 从 shareID 的访问用户列表中移除 target（仅限 owner 操作）.
 // RemoveShareUser 将 target 从分享的访问用户列表中移除（仅 owner 可操作）。
-func (s *ShareService) RemoveShareUser(ctx context.Context, user, shareID, target string) error {
+func (s *ShareService) RemoveShareUser(ctx context.Context, user, shareID, target string) (err error) {
+	defer recordAuditResult(ctx, user, types.AuditActionSharePermissions, []string{shareID}, "remove user "+target, &err)
+
 	if user == "" || shareID == "" || target == "" {
 		return fmt.Errorf("user/shareID/target is required")
 	}
@@ -345,7 +447,7 @@ func (s *ShareService) RemoveShareUser(ctx context.Context, user, shareID, targe
 	}
 
 	if rec.Owner != user {
-		return fmt.Errorf("forbidden: not owner")
+		return fmt.Errorf("%w: not owner", ErrShareForbidden)
 	}
 
 	out := rec.Permissions.Users[:0]
@@ -357,7 +459,7 @@ func (s *ShareService) RemoveShareUser(ctx context.Context, user, shareID, targe
 
 	rec.Permissions.Users = out
 
-	if err := s.updatePermissionsInDB(ctx, shareID, rec.Permissions); err != nil {
+	if err := s.updatePermissionsInDB(ctx, rec); err != nil {
 		return err
 	}
 
@@ -380,14 +482,20 @@ const (
 
 // shareRecord 是 service 层使用的分享数据结构（与 model.ShareRecord 类似，但不依赖 model 包）.
 type shareRecord struct {
-	ShareID       string                 `json:"share_id"`
-	Owner         string                 `json:"owner"`
-	ObjectKeys    []string               `json:"object_keys"`
-	CreatedAt     time.Time              `json:"created_at"`
-	ExpireAt      *time.Time             `json:"expire_at,omitempty"`
-	AllowDownload bool                   `json:"allow_download"`
-	PasswordHash  string                 `json:"password_hash,omitempty"`
-	Permissions   types.SharePermissions `json:"permissions"`
+	ShareID       string                   `json:"share_id"`
+	Owner         string                   `json:"owner"`
+	Type          string                   `json:"type,omitempty"`
+	FolderID      string                   `json:"folder_id,omitempty"`
+	ObjectKeys    []string                 `json:"object_keys"`
+	CreatedAt     time.Time                `json:"created_at"`
+	ExpireAt      *time.Time               `json:"expire_at,omitempty"`
+	AllowDownload bool                     `json:"allow_download"`
+	PasswordHash  string                   `json:"password_hash,omitempty"`
+	Permissions   types.SharePermissions   `json:"permissions"`
+	TokenVersion  int                      `json:"token_version"`
+	MaxViews      int                      `json:"max_views,omitempty"`
+	MaxDownloads  int                      `json:"max_downloads,omitempty"`
+	Upload        *types.ShareUploadPolicy `json:"upload,omitempty"`
 }
 
 // toInfo 转换为对外的 ShareInfo 结构.
@@ -395,13 +503,27 @@ func (r *shareRecord) toInfo() types.ShareInfo {
 	return types.ShareInfo{
 		ShareID:       r.ShareID,
 		Owner:         r.Owner,
+		Type:          r.shareType(),
+		FolderID:      r.FolderID,
 		ObjectKeys:    r.ObjectKeys,
 		CreatedAt:     r.CreatedAt,
 		ExpireAt:      r.ExpireAt,
 		AllowDownload: r.AllowDownload,
+		MaxViews:      r.MaxViews,
+		MaxDownloads:  r.MaxDownloads,
+		Upload:        r.Upload,
 	}
 }
 
+// shareType 返回分享类型，早期创建的分享没有类型字段，视为对象分享.
+func (r *shareRecord) shareType() string {
+	if r.Type == "" {
+		return types.ShareTypeObjects
+	}
+
+	return r.Type
+}
+
 func isExpired(now time.Time, exp *time.Time) bool {
 	return exp != nil && now.After(*exp)
 }
@@ -409,23 +531,20 @@ func isExpired(now time.Time, exp *time.Time) bool {
 // newShareID 生成带前缀的 ULID 字符串，形如 "sh_01H..."。
 // 使用单例熵源以支持同一毫秒内的单调递增。
 func newShareID(t time.Time) string {
-	// 注意：ULID 使用毫秒时间戳，因此应传入 time.Now().UTC() 或同等时间。
-	id := ulid.MustNew(ulid.Timestamp(t), ulidEntropy)
-	return "sh_" + id.String()
+	return "sh_" + newULID(t)
 }
 
-func makeShareKey(shareID string) string { return shareKeyPrefix + shareID }
+// newULID 生成 ULID 字符串。
+// 注意：ULID 使用毫秒时间戳，因此应传入 time.Now().UTC() 或同等时间。
+func newULID(t time.Time) string {
+	ulidMu.Lock()
+	defer ulidMu.Unlock()
 
-func hashPassword(pw string) string {
-	if strings.TrimSpace(pw) == "" {
-		return ""
-	}
-
-	sum := sha256.Sum256([]byte(pw))
-
-	return base64.RawURLEncoding.EncodeToString(sum[:])
+	return ulid.MustNew(ulid.Timestamp(t), ulidEntropy).String()
 }
 
+func makeShareKey(shareID string) string { return shareKeyPrefix + shareID }
+
 // kvGet 通过 key 获取并反序列化值到 v，返回是否命中。
 func (s *ShareService) kvGet(ctx context.Context, key string, v any) (bool, error) {
 	if s.kvc == nil {
@@ -478,12 +597,18 @@ func fromModelRecord(mr *model.ShareRecord) *shareRecord {
 	return &shareRecord{
 		ShareID:       mr.ShareID,
 		Owner:         mr.Owner,
+		Type:          mr.Type,
+		FolderID:      mr.FolderID,
 		ObjectKeys:    append([]string{}, mr.ObjectKeys...),
 		CreatedAt:     mr.CreatedAt,
 		ExpireAt:      mr.ExpireAt,
 		AllowDownload: mr.AllowDownload,
 		PasswordHash:  mr.PasswordHash,
 		Permissions:   mr.Permissions,
+		TokenVersion:  mr.TokenVersion,
+		MaxViews:      mr.MaxViews,
+		MaxDownloads:  mr.MaxDownloads,
+		Upload:        mr.Upload,
 	}
 }
 
@@ -510,11 +635,15 @@ func (s *ShareService) getShareCached(ctx context.Context, shareID string) (*sha
 	// DB 加载
 	var sh model.Share
 	if err := s.dbc.GetDB().Where("share_id = ?", shareID).First(&sh).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrShareNotFound, shareID)
+		}
+
 		return nil, err
 	}
 
 	if isExpired(time.Now().UTC(), sh.ExpireAt) {
-		return nil, fmt.Errorf("share expired")
+		return nil, ErrShareExpired
 	}
 
 	recModel, err := sh.ToRecord()
@@ -558,21 +687,80 @@ func ttlFromExpire(exp *time.Time) time.Duration {
 	return d
 }
 
-// updatePermissionsInDB 仅更新 Share 的权限 JSON 与更新时间，避免覆盖其他字段。
-func (s *ShareService) updatePermissionsInDB(_ context.Context, shareID string, perms types.SharePermissions) error {
+// upgradePasswordHash 将旧版哈希替换为 argon2id 哈希；失败只记录日志，下次访问时重试.
+func (s *ShareService) upgradePasswordHash(ctx context.Context, rec *shareRecord, password string) {
+	l := nlog.Logger()
+
+	newHash, err := hashPassword(password)
+	if err != nil || s.dbc == nil || s.dbc.GetDB() == nil {
+		l.Warn().Err(err).Str("share_id", rec.ShareID).Msg("upgrade share password hash skipped")
+		return
+	}
+
+	if err := s.dbc.GetDB().WithContext(ctx).Model(&model.Share{}).
+		Where("share_id = ? AND password_hash = ?", rec.ShareID, rec.PasswordHash).
+		Updates(map[string]any{
+			"password_hash": newHash,
+			"updated_at":    time.Now().UTC(),
+		}).Error; err != nil {
+		l.Warn().Err(err).Str("share_id", rec.ShareID).Msg("upgrade share password hash failed")
+		return
+	}
+
+	rec.PasswordHash = newHash
+	_ = s.cacheShare(ctx, rec, ttlFromExpire(rec.ExpireAt))
+
+	l.Info().Str("share_id", rec.ShareID).Msg("share password hash upgraded")
+}
+
+// updatePermissionsInDB 仅更新 Share 的权限 JSON 与更新时间并递增令牌版本（使已签发的访问令牌失效），避免覆盖其他字段。
+func (s *ShareService) updatePermissionsInDB(ctx context.Context, rec *shareRecord) error {
 	if s.dbc == nil || s.dbc.GetDB() == nil {
 		return errors.New("db not initialized")
 	}
 
-	b, err := json.Marshal(perms)
+	b, err := json.Marshal(rec.Permissions)
 	if err != nil {
 		return err
 	}
 
-	return s.dbc.GetDB().Model(&model.Share{}).
-		Where("share_id = ?", shareID).
+	if err := s.dbc.GetDB().WithContext(ctx).Model(&model.Share{}).
+		Where("share_id = ?", rec.ShareID).
 		Updates(map[string]any{
 			"permissions_json": string(b),
+			"token_version":    gorm.Expr("token_version + 1"),
 			"updated_at":       time.Now().UTC(),
-		}).Error
+		}).Error; err != nil {
+		return err
+	}
+
+	rec.TokenVersion++
+
+	return nil
+}
+
+// getShareWithToken 加载分享并校验 caller 持有的访问令牌.
+func (s *ShareService) getShareWithToken(ctx context.Context, shareID, token, caller string) (*shareRecord, error) {
+	rec, err := s.getShareCached(ctx, shareID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyShareToken(rec, token, caller); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// compactUsers 去除空白与重复的用户.
+func compactUsers(users []string) []string {
+	out := make([]string, 0, len(users))
+	for _, u := range users {
+		if u = strings.TrimSpace(u); u != "" && !slices.Contains(out, u) {
+			out = append(out, u)
+		}
+	}
+
+	return out
 }
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+var (
+	// ErrShareDownloadNotAllowed 分享未开启下载.
+	ErrShareDownloadNotAllowed = errors.New("download not allowed")
+	// ErrShareFileNotFound 分享中不存在指定序号的文件或对象已被删除.
+	ErrShareFileNotFound = errors.New("share file not found")
+	// ErrShareUploadOnly 上传型分享不允许查看或下载文件.
+	ErrShareUploadOnly = errors.New("share is upload-only")
+)
+
+// shareFileEntry 解析后的分享文件，附带所属对象键.
+type shareFileEntry struct {
+	types.ShareFile
+
+	objectKey string
+}
+
+// ListShareFiles 列出分享中的文件并记录访问日志；文件夹分享实时列出文件夹内容.
+func (s *ShareService) ListShareFiles(ctx context.Context, shareID, token string, v ShareVisitor) (*types.ListShareFilesResponse, error) {
+	rec, err := s.getShareWithToken(ctx, shareID, token, v.User)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.resolveShareFiles(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]types.ShareFile, 0, len(entries))
+	for i := range entries {
+		files = append(files, entries[i].ShareFile)
+	}
+
+	s.logShareAccess(ctx, shareID, types.ShareActionList, "", "", v)
+
+	return &types.ListShareFilesResponse{ShareID: shareID, Type: rec.shareType(), Files: files, Total: len(files)}, nil
+}
+
+// ResolveShareFile 解析分享中序号为 index 的文件，返回对象所属用户与下载条目；caller 为持有令牌的访问者.
+func (s *ShareService) ResolveShareFile(ctx context.Context, shareID, token, caller string,
+	index int) (string, types.DownloadObjectItem, error) {
+	rec, err := s.getShareWithToken(ctx, shareID, token, caller)
+	if err != nil {
+		return "", types.DownloadObjectItem{}, err
+	}
+
+	if !rec.AllowDownload {
+		return "", types.DownloadObjectItem{}, ErrShareDownloadNotAllowed
+	}
+
+	entries, err := s.resolveShareFiles(ctx, rec)
+	if err != nil {
+		return "", types.DownloadObjectItem{}, err
+	}
+
+	if index < 0 || index >= len(entries) || !entries[index].Available {
+		return "", types.DownloadObjectItem{}, fmt.Errorf("%w: index %d", ErrShareFileNotFound, index)
+	}
+
+	e := &entries[index]
+
+	return rec.Owner, types.DownloadObjectItem{ObjectKey: e.objectKey, FileName: path.Base(e.Path)}, nil
+}
+
+// ShareDownloadRequest 构造分享整体下载（打包）的请求，返回对象所属用户；文件夹分享在打包时展开.
+func (s *ShareService) ShareDownloadRequest(ctx context.Context, shareID, token, caller string) (string, *types.DownloadFilesRequest, error) {
+	rec, err := s.getShareWithToken(ctx, shareID, token, caller)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !rec.AllowDownload {
+		return "", nil, ErrShareDownloadNotAllowed
+	}
+
+	req := &types.DownloadFilesRequest{}
+
+	if rec.shareType() == types.ShareTypeFolder {
+		req.Objects = []types.DownloadObjectItem{{FolderID: rec.FolderID}}
+		return rec.Owner, req, nil
+	}
+
+	if len(rec.ObjectKeys) == 0 {
+		return "", nil, fmt.Errorf("no object in share")
+	}
+
+	req.Objects = make([]types.DownloadObjectItem, 0, len(rec.ObjectKeys))
+	for _, k := range rec.ObjectKeys {
+		req.Objects = append(req.Objects, types.DownloadObjectItem{ObjectKey: k})
+	}
+
+	if len(req.Objects) > 1 {
+		req.ArchiveName = "share-" + rec.ShareID
+	}
+
+	return rec.Owner, req, nil
+}
+
+// resolveShareFiles 列出分享中的文件：对象分享逐个查询对象信息（已删除的标记为不可用），
+// 文件夹分享列出文件夹下的全部对象.
+func (s *ShareService) resolveShareFiles(ctx context.Context, rec *shareRecord) ([]shareFileEntry, error) {
+	if rec.shareType() == types.ShareTypeUpload {
+		return nil, ErrShareUploadOnly
+	}
+
+	files, err := s.fileService()
+	if err != nil {
+		return nil, err
+	}
+
+	if rec.shareType() == types.ShareTypeFolder {
+		fullPath, infos, err := files.ListFolderObjects(ctx, rec.Owner, rec.FolderID)
+		if err != nil {
+			return nil, fmt.Errorf("resolve shared folder: %w", err)
+		}
+
+		name := path.Base(fullPath)
+		prefix := rec.Owner + "/" + fullPath + "/"
+		entries := make([]shareFileEntry, 0, len(infos))
+
+		for i := range infos {
+			entries = append(entries, shareFileEntry{
+				ShareFile: types.ShareFile{
+					Index:        i,
+					Path:         name + "/" + strings.TrimPrefix(infos[i].ObjectKey, prefix),
+					Size:         infos[i].Size,
+					LastModified: infos[i].LastModified,
+					Available:    true,
+				},
+				objectKey: infos[i].ObjectKey,
+			})
+		}
+
+		return entries, nil
+	}
+
+	entries := make([]shareFileEntry, 0, len(rec.ObjectKeys))
+
+	for i, key := range rec.ObjectKeys {
+		e := shareFileEntry{ShareFile: types.ShareFile{Index: i, Path: lastPathComponent(key)}, objectKey: key}
+
+		info, err := files.StatObject(ctx, rec.Owner, key)
+
+		switch {
+		case err == nil:
+			e.Size, e.ContentType, e.LastModified, e.Available = info.Size, info.ContentType, info.LastModified, true
+		case !errors.Is(err, ErrObjectNotFound) && !errors.Is(err, ErrObjectAccessDenied):
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// checkFolder 确认文件夹存在且属于 user.
+func (s *ShareService) checkFolder(ctx context.Context, user, folderID string) error {
+	bucket, err := s.bucket()
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := findFolderPath(ctx, s.s3c, bucket, user, folderID); err != nil {
+		return fmt.Errorf("folder %s: %w", folderID, err)
+	}
+
+	return nil
+}
+
+// fileService 复用 FileService 的对象查询与记录能力.
+func (s *ShareService) fileService() (*FileService, error) {
+	if s.s3c == nil {
+		return nil, errors.New("s3 not initialized")
+	}
+
+	return &FileService{s3Client: s.s3c, dbClient: s.dbc, mqClient: s.mqc}, nil
+}
+
+// bucket 返回文件夹标记所在的默认存储桶.
+func (s *ShareService) bucket() (string, error) {
+	files, err := s.fileService()
+	if err != nil {
+		return "", err
+	}
+
+	return files.defaultBucket()
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+var (
+	// ErrShareInvalidPassword 分享密码错误.
+	ErrShareInvalidPassword = errors.New("invalid password")
+	// ErrShareLocked 密码错误次数过多，分享访问被临时锁定.
+	ErrShareLocked = errors.New("share access locked due to too many failed attempts")
+)
+
+// 失败计数与锁定记录的作用域.
+const (
+	lockScopeShare = "share"
+	lockScopeIP    = "ip"
+)
+
+// ShareLockedError 携带锁定解除时间的锁定错误，errors.Is(err, ErrShareLocked) 为真.
+type ShareLockedError struct {
+	Scope string
+	Until time.Time
+}
+
+func (e *ShareLockedError) Error() string {
+	return fmt.Sprintf("%s (%s), retry after %s", ErrShareLocked, e.Scope, e.Until.Format(time.RFC3339))
+}
+
+func (e *ShareLockedError) Unwrap() error { return ErrShareLocked }
+
+// RetryAfter 返回距离锁定解除的剩余时长.
+func (e *ShareLockedError) RetryAfter() time.Duration {
+	return max(time.Until(e.Until), 0)
+}
+
+// failureCounter 统计窗口内的失败次数，KV 中以窗口剩余时长为 TTL.
+type failureCounter struct {
+	Count   int       `json:"count"`
+	FirstAt time.Time `json:"first_at"`
+}
+
+// shareLockout 锁定记录，保留在 KV 中直至锁定解除.
+type shareLockout struct {
+	Scope    string    `json:"scope"`
+	Target   string    `json:"target"`
+	ShareID  string    `json:"share_id"`
+	IP       string    `json:"ip,omitempty"`
+	Failures int       `json:"failures"`
+	LockedAt time.Time `json:"locked_at"`
+	Until    time.Time `json:"until"`
+}
+
+func makeShareFailKey(scope, target string) string {
+	return shareKeyPrefix + "fail:" + scope + ":" + target
+}
+
+func makeShareLockKey(scope, target string) string {
+	return shareKeyPrefix + "lock:" + scope + ":" + target
+}
+
+// lockTargets 返回需要检查/计数的作用域与目标（分享，及已知的来源 IP）.
+func lockTargets(shareID, ip string) [][2]string {
+	targets := [][2]string{{lockScopeShare, shareID}}
+	if ip != "" {
+		targets = append(targets, [2]string{lockScopeIP, ip})
+	}
+
+	return targets
+}
+
+// checkShareLocked 检查分享或来源 IP 是否处于锁定期；未配置 KV 时不做限制.
+func (s *ShareService) checkShareLocked(ctx context.Context, shareID, ip string) error {
+	if s.kvc == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+
+	for _, t := range lockTargets(shareID, ip) {
+		var lock shareLockout
+		if ok, err := s.kvGet(ctx, makeShareLockKey(t[0], t[1]), &lock); err == nil && ok && now.Before(lock.Until) {
+			return &ShareLockedError{Scope: t[0], Until: lock.Until}
+		}
+	}
+
+	return nil
+}
+
+// recordShareFailure 累加分享与来源 IP 的失败次数，达到上限时写入锁定记录并返回锁定错误.
+// 计数基于 Get/Set，并发请求下可能少计，作为暴力破解的限速手段足够.
+func (s *ShareService) recordShareFailure(ctx context.Context, shareID, ip string) error {
+	if s.kvc == nil {
+		return nil
+	}
+
+	cfg := configs.GetConfig().Share
+	now := time.Now().UTC()
+
+	var locked error
+
+	for _, t := range lockTargets(shareID, ip) {
+		key := makeShareFailKey(t[0], t[1])
+
+		var c failureCounter
+		if ok, err := s.kvGet(ctx, key, &c); err != nil || !ok || now.Sub(c.FirstAt) >= cfg.GetFailureWindow() {
+			c = failureCounter{FirstAt: now}
+		}
+
+		c.Count++
+
+		if c.Count < cfg.MaxFailedAttempts {
+			ttl := max(cfg.GetFailureWindow()-now.Sub(c.FirstAt), time.Second)
+			if err := s.kvSet(ctx, key, c, ttl); err != nil {
+				nlog.Logger().Warn().Err(err).Str("key", key).Msg("record share failure failed")
+			}
+
+			continue
+		}
+
+		lock := shareLockout{
+			Scope:    t[0],
+			Target:   t[1],
+			ShareID:  shareID,
+			IP:       ip,
+			Failures: c.Count,
+			LockedAt: now,
+			Until:    now.Add(cfg.GetLockout()),
+		}
+		if err := s.kvSet(ctx, makeShareLockKey(t[0], t[1]), lock, cfg.GetLockout()); err != nil {
+			nlog.Logger().Warn().Err(err).Str("scope", t[0]).Msg("record share lockout failed")
+		}
+
+		_ = s.kvDel(ctx, key)
+
+		nlog.Logger().Warn().Str("scope", t[0]).Str("share_id", shareID).Str("ip", ip).
+			Int("failures", c.Count).Time("until", lock.Until).Msg("share access locked")
+
+		if locked == nil {
+			locked = &ShareLockedError{Scope: t[0], Until: lock.Until}
+		}
+	}
+
+	return locked
+}
+
+// resetShareFailures 访问成功后清除分享的失败计数.
+// 来源 IP 的计数不清除：否则攻击者可以用自己知道密码的分享反复重置计数.
+func (s *ShareService) resetShareFailures(ctx context.Context, shareID string) {
+	if s.kvc == nil {
+		return
+	}
+
+	_ = s.kvDel(ctx, makeShareFailKey(lockScopeShare, shareID))
+}
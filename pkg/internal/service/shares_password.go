@@ -0,0 +1,86 @@
+package service
+
+import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id 参数（OWASP 推荐的最低配置之一：64 MiB 内存、单次迭代）.
+const (
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+	argon2KeyLen  = 32
+	argon2SaltLen = 16
+)
+
+// argon2Prefix PHC 格式哈希的前缀；历史 SHA-256 哈希为裸 base64 字符串，不以 "$" 开头.
+const argon2Prefix = "$argon2id$"
+
+// hashPassword 生成带随机盐的 argon2id 哈希（PHC 字符串格式）；空密码返回空串.
+func hashPassword(pw string) (string, error) {
+	if strings.TrimSpace(pw) == "" {
+		return "", nil
+	}
+
+	salt := make([]byte, argon2SaltLen)
+	if _, err := crand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(pw), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifyPassword 以常量时间比较密码与存储的哈希；legacy 表示存储的是需要升级的旧版无盐 SHA-256 哈希.
+func verifyPassword(pw, stored string) (ok, legacy bool) {
+	if !strings.HasPrefix(stored, argon2Prefix) {
+		sum := sha256.Sum256([]byte(pw))
+		got := base64.RawURLEncoding.EncodeToString(sum[:])
+
+		return subtle.ConstantTimeCompare([]byte(got), []byte(stored)) == 1, true
+	}
+
+	// $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, false
+	}
+
+	var (
+		version, memory uint32
+		time            uint32
+		threads         uint8
+	)
+
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false
+	}
+
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false, false
+	}
+
+	got := argon2.IDKey([]byte(pw), salt, time, memory, threads, uint32(len(want))) //nolint:gosec // 长度来自 32 字节级别的哈希
+
+	return subtle.ConstantTimeCompare(got, want) == 1, false
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+const (
+	// defaultShareStatsDays 统计时间线默认覆盖的天数.
+	defaultShareStatsDays = 30
+	// shareStatsRecentLimit 统计中返回的最近访问记录数.
+	shareStatsRecentLimit = 50
+)
+
+// ErrShareExhausted 分享的访问或下载次数已用尽.
+var ErrShareExhausted = errors.New("share usage limit reached")
+
+// ShareVisitor 分享访问者信息，用于权限判断与访问日志.
+type ShareVisitor struct {
+	IP        string
+	UserAgent string
+	User      string // 已登录的访问者，匿名为空
+}
+
+// RecordShareDownload 在下载开始前扣减下载次数（owner 不计）并记录访问日志；次数用尽时返回 ErrShareExhausted.
+// 审计记录的操作者为访问者（匿名访问者取请求声明的用户或记为 anonymous）.
+func (s *ShareService) RecordShareDownload(ctx context.Context, shareID, action, objectKey string, v ShareVisitor) (err error) {
+	targets := []string{shareID}
+	if objectKey != "" {
+		targets = append(targets, objectKey)
+	}
+
+	defer recordAuditResult(ctx, v.User, types.AuditActionShareDownload, targets, action, &err)
+
+	rec, err := s.getShareCached(ctx, shareID)
+	if err != nil {
+		return err
+	}
+
+	if v.User != rec.Owner {
+		if err := s.consumeShareQuota(ctx, shareID, "download_count", "max_downloads"); err != nil {
+			s.logShareAccess(ctx, shareID, types.ShareActionDenied, objectKey, err.Error(), v)
+			return err
+		}
+	}
+
+	s.logShareAccess(ctx, shareID, action, objectKey, "", v)
+
+	return nil
+}
+
+// RecordShareFileDownload 下载分享中的单个文件前按 action 计次：同一会话（访问令牌，无令牌时为登录用户或来源 IP）
+// 对同一文件只有续传请求（continuation，只取不含起始字节的分段）可以免计次，且该会话此前必须已为该文件计次；
+// 其它请求（完整下载、包含起始字节的分段、首次续传）均计次并记录会话.
+func (s *ShareService) RecordShareFileDownload(ctx context.Context, shareID, session, action, objectKey string,
+	continuation bool, v ShareVisitor) error {
+	if session == "" {
+		session = v.User
+	}
+
+	if session == "" {
+		session = v.IP
+	}
+
+	sum := sha256.Sum256([]byte(session + "\x00" + objectKey))
+	key := shareKeyPrefix + "dl:" + shareID + ":" + hex.EncodeToString(sum[:])
+
+	if continuation {
+		var counted bool
+		if ok, _ := s.kvGet(ctx, key, &counted); ok && counted {
+			return nil
+		}
+	}
+
+	if err := s.RecordShareDownload(ctx, shareID, action, objectKey, v); err != nil {
+		return err
+	}
+
+	if err := s.kvSet(ctx, key, true, configs.GetConfig().Share.GetTokenTTL()); err != nil {
+		nlog.Logger().Warn().Err(err).Str("share_id", shareID).Msg("record share download session failed")
+	}
+
+	return nil
+}
+
+// GetShareStats 返回分享的访问汇总、按天时间线与最近访问记录（仅 owner 可查看）.
+func (s *ShareService) GetShareStats(ctx context.Context, user, shareID string, q *types.ShareStatsQuery) (*types.ShareStatsResponse, error) {
+	if user == "" || shareID == "" {
+		return nil, fmt.Errorf("user/shareID is required")
+	}
+
+	if s.dbc == nil || s.dbc.GetDB() == nil {
+		return nil, errors.New("db not initialized")
+	}
+
+	dbx := s.dbc.GetDB().WithContext(ctx)
+
+	var sh model.Share
+	if err := dbx.Unscoped().Where("share_id = ?", shareID).First(&sh).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrShareNotFound, shareID)
+		}
+
+		return nil, err
+	}
+
+	if sh.Owner != user {
+		return nil, fmt.Errorf("%w: not owner", ErrShareForbidden)
+	}
+
+	resp := &types.ShareStatsResponse{
+		ShareID: shareID,
+		Totals: types.ShareTotals{
+			ViewCount:     sh.ViewCount,
+			DownloadCount: sh.DownloadCount,
+			MaxViews:      sh.MaxViews,
+			MaxDownloads:  sh.MaxDownloads,
+			ByAction:      map[string]int64{},
+		},
+	}
+
+	var byAction []struct {
+		Action string
+		N      int64
+	}
+	if err := dbx.Model(&model.ShareAccess{}).Select("action, COUNT(*) AS n").
+		Where("share_id = ?", shareID).Group("action").Scan(&byAction).Error; err != nil {
+		return nil, fmt.Errorf("count share accesses: %w", err)
+	}
+
+	for _, a := range byAction {
+		resp.Totals.ByAction[a.Action] = a.N
+		resp.Totals.Accesses += a.N
+	}
+
+	if err := dbx.Model(&model.ShareAccess{}).Where("share_id = ?", shareID).
+		Distinct("ip").Count(&resp.Totals.UniqueIPs).Error; err != nil {
+		return nil, fmt.Errorf("count share ips: %w", err)
+	}
+
+	timeline, err := s.shareTimeline(ctx, shareID, q.Days)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Timeline = timeline
+
+	var recent []model.ShareAccess
+	if err := dbx.Where("share_id = ?", shareID).Order("created_at DESC, id DESC").
+		Limit(shareStatsRecentLimit).Find(&recent).Error; err != nil {
+		return nil, fmt.Errorf("query recent accesses: %w", err)
+	}
+
+	resp.Recent = make([]types.ShareAccessEntry, 0, len(recent))
+	for i := range recent {
+		a := &recent[i]
+		resp.Recent = append(resp.Recent, types.ShareAccessEntry{
+			Action:    a.Action,
+			IP:        a.IP,
+			UserAgent: a.UserAgent,
+			User:      a.User,
+			ObjectKey: a.ObjectKey,
+			Detail:    a.Detail,
+			CreatedAt: a.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+// SweepShares 删除已过期或次数用尽的分享（软删除并清理缓存），并按保留期清理访问日志.
+// 返回删除的分享数与清理的日志条数.
+func (s *ShareService) SweepShares(ctx context.Context) (int, int64, error) {
+	if s.dbc == nil || s.dbc.GetDB() == nil {
+		return 0, 0, errors.New("db not initialized")
+	}
+
+	dbx := s.dbc.GetDB().WithContext(ctx)
+	now := time.Now().UTC()
+	removed := 0
+
+	for {
+		var ids []string
+		if err := dbx.Model(&model.Share{}).
+			Where("(expire_at IS NOT NULL AND expire_at <= ?) OR "+
+				"(max_views > 0 AND view_count >= max_views) OR "+
+				"(max_downloads > 0 AND download_count >= max_downloads)", now).
+			Limit(DefaultSliceCapacity).Pluck("share_id", &ids).Error; err != nil {
+			return removed, 0, fmt.Errorf("query stale shares: %w", err)
+		}
+
+		if len(ids) == 0 {
+			break
+		}
+
+		if err := dbx.Where("share_id IN ?", ids).Delete(&model.Share{}).Error; err != nil {
+			return removed, 0, fmt.Errorf("delete stale shares: %w", err)
+		}
+
+		for _, id := range ids {
+			_ = s.kvDel(ctx, makeShareKey(id))
+		}
+
+		removed += len(ids)
+
+		if len(ids) < DefaultSliceCapacity {
+			break
+		}
+	}
+
+	var purged int64
+
+	if retention := configs.GetConfig().Share.GetAccessLogRetention(); retention > 0 {
+		res := dbx.Where("created_at < ?", now.Add(-retention)).Delete(&model.ShareAccess{})
+		if res.Error != nil {
+			return removed, 0, fmt.Errorf("purge share access logs: %w", res.Error)
+		}
+
+		purged = res.RowsAffected
+	}
+
+	return removed, purged, nil
+}
+
+// consumeShareQuota 以条件更新原子地递增计数列，limitCol 为 0 表示不限；次数用尽时返回 ErrShareExhausted.
+func (s *ShareService) consumeShareQuota(ctx context.Context, shareID, countCol, limitCol string) error {
+	if s.dbc == nil || s.dbc.GetDB() == nil {
+		return errors.New("db not initialized")
+	}
+
+	res := s.dbc.GetDB().WithContext(ctx).Model(&model.Share{}).
+		Where("share_id = ? AND ("+limitCol+" = 0 OR "+countCol+" < "+limitCol+")", shareID).
+		UpdateColumn(countCol, gorm.Expr(countCol+" + 1"))
+	if res.Error != nil {
+		return fmt.Errorf("update share %s: %w", countCol, res.Error)
+	}
+
+	if res.RowsAffected == 0 {
+		return ErrShareExhausted
+	}
+
+	return nil
+}
+
+// logShareAccess 写入访问日志；失败只记录日志，不影响访问本身.
+func (s *ShareService) logShareAccess(ctx context.Context, shareID, action, objectKey, detail string, v ShareVisitor) {
+	if s.dbc == nil || s.dbc.GetDB() == nil {
+		return
+	}
+
+	entry := &model.ShareAccess{
+		ShareID:   shareID,
+		Action:    action,
+		IP:        v.IP,
+		UserAgent: truncate(v.UserAgent, 512),
+		User:      v.User,
+		ObjectKey: truncate(objectKey, 1024),
+		Detail:    truncate(detail, 255),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.dbc.GetDB().WithContext(ctx).Create(entry).Error; err != nil {
+		nlog.Logger().Warn().Err(err).Str("share_id", shareID).Str("action", action).Msg("record share access failed")
+	}
+}
+
+// shareTimeline 按 UTC 日期汇总最近 days 天的访问；逐行聚合以避免依赖数据库的日期函数.
+func (s *ShareService) shareTimeline(ctx context.Context, shareID string, days int) ([]types.ShareTimelinePoint, error) {
+	if days <= 0 {
+		days = defaultShareStatsDays
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	points := make([]types.ShareTimelinePoint, days)
+	for i := range points {
+		points[i] = types.ShareTimelinePoint{Date: since.AddDate(0, 0, i).Format(time.DateOnly), ByAction: map[string]int64{}}
+	}
+
+	var batch []model.ShareAccess
+
+	err := s.dbc.GetDB().WithContext(ctx).Model(&model.ShareAccess{}).Select("id, action, created_at").
+		Where("share_id = ? AND created_at >= ?", shareID, since).
+		FindInBatches(&batch, DefaultSliceCapacity*10, func(_ *gorm.DB, _ int) error {
+			for i := range batch {
+				idx := int(batch[i].CreatedAt.UTC().Sub(since) / (24 * time.Hour))
+				if idx >= 0 && idx < len(points) {
+					points[idx].ByAction[batch[i].Action]++
+				}
+			}
+
+			return nil
+		}).Error
+	if err != nil {
+		return nil, fmt.Errorf("query share timeline: %w", err)
+	}
+
+	return points, nil
+}
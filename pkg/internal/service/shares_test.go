@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yeisme/notevault/pkg/internal/service"
+	"github.com/yeisme/notevault/pkg/internal/types"
+)
+
+// TestShareAccess 验证密码分享的访问校验、owner 免密访问以及凭令牌列出分享文件.
+func TestShareAccess(t *testing.T) {
+	ctx := newTestContext(t, false)
+	key := uploadText(t, ctx, "report.txt", "quarterly numbers")
+
+	svc := service.NewShareService(ctx)
+
+	created, err := svc.CreateShare(ctx, testUser, &types.CreateShareRequest{
+		ObjectKeys:    []string{key},
+		Password:      "s3cret",
+		AllowDownload: true,
+	})
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	shareID := created.Share.ShareID
+	anonymous := service.ShareVisitor{IP: "192.0.2.1"}
+
+	tests := []struct {
+		name     string
+		password string
+		visitor  service.ShareVisitor
+		wantErr  error
+	}{
+		{name: "wrong password", password: "guess", visitor: anonymous, wantErr: service.ErrShareInvalidPassword},
+		{name: "missing password", visitor: anonymous, wantErr: service.ErrShareInvalidPassword},
+		{name: "correct password", password: "s3cret", visitor: anonymous},
+		{name: "owner without password", visitor: service.ShareVisitor{IP: "192.0.2.2", User: testUser}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.AccessShare(ctx, shareID, tt.password, tt.visitor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("access err = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			files, err := svc.ListShareFiles(ctx, shareID, resp.AccessToken, tt.visitor)
+			if err != nil || files.Total != 1 || !files.Files[0].Available || files.Files[0].Size != 17 {
+				t.Fatalf("list share files = %+v, %v", files, err)
+			}
+		})
+	}
+
+	if _, err := svc.ListShareFiles(ctx, shareID, "invalid-token", anonymous); err == nil {
+		t.Fatal("expected invalid token to be rejected")
+	}
+
+	if err := svc.DeleteShare(ctx, "bob@example.com", shareID); err == nil {
+		t.Fatal("expected non-owner delete to fail")
+	}
+
+	if err := svc.DeleteShare(ctx, testUser, shareID); err != nil {
+		t.Fatalf("delete share: %v", err)
+	}
+
+	if _, err := svc.AccessShare(ctx, shareID, "s3cret", anonymous); !errors.Is(err, service.ErrShareNotFound) {
+		t.Fatalf("access deleted share: %v", err)
+	}
+}
+
+// TestShareTokenSubject 验证不允许匿名访问的分享，访问令牌只能由签发对象本人使用.
+func TestShareTokenSubject(t *testing.T) {
+	ctx := newTestContext(t, false)
+	key := uploadText(t, ctx, "plan.txt", "roadmap")
+
+	svc := service.NewShareService(ctx)
+	anonymous := false
+
+	created, err := svc.CreateShare(ctx, testUser, &types.CreateShareRequest{
+		ObjectKeys:     []string{key},
+		AllowDownload:  true,
+		AllowAnonymous: &anonymous,
+		Users:          []string{"bob@example.com", "carol@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	shareID := created.Share.ShareID
+	bob := service.ShareVisitor{IP: "192.0.2.3", User: "bob@example.com"}
+
+	resp, err := svc.AccessShare(ctx, shareID, "", bob)
+	if err != nil {
+		t.Fatalf("access: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		visitor service.ShareVisitor
+		wantErr error
+	}{
+		{name: "token subject", visitor: bob},
+		{name: "other whitelisted user", visitor: service.ShareVisitor{IP: "192.0.2.4", User: "carol@example.com"}, wantErr: service.ErrShareTokenInvalid},
+		{name: "anonymous", visitor: service.ShareVisitor{IP: "192.0.2.5"}, wantErr: service.ErrShareTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.ListShareFiles(ctx, shareID, resp.AccessToken, tt.visitor); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("list err = %v, want %v", err, tt.wantErr)
+			}
+
+			if _, _, err := svc.ResolveShareFile(ctx, shareID, resp.AccessToken, tt.visitor.User, 0); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolve err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestShareFileDownloadLimit 验证续传只在同一会话已计次后免计次，不同会话或首次续传都会扣减下载次数.
+func TestShareFileDownloadLimit(t *testing.T) {
+	ctx := newTestContext(t, false)
+	key := uploadText(t, ctx, "movie.txt", "frames")
+
+	svc := service.NewShareService(ctx)
+
+	created, err := svc.CreateShare(ctx, testUser, &types.CreateShareRequest{
+		ObjectKeys:    []string{key},
+		AllowDownload: true,
+		MaxDownloads:  2,
+	})
+	if err != nil {
+		t.Fatalf("create share: %v", err)
+	}
+
+	shareID := created.Share.ShareID
+	visitor := service.ShareVisitor{IP: "192.0.2.9"}
+
+	steps := []struct {
+		name         string
+		session      string
+		continuation bool
+		wantErr      error
+	}{
+		{name: "first download", session: "token-a"},
+		{name: "resume in same session", session: "token-a", continuation: true},
+		{name: "resume without prior download", session: "token-b", continuation: true},
+		{name: "limit reached", session: "token-c", wantErr: service.ErrShareExhausted},
+		{name: "full download in counted session", session: "token-a", wantErr: service.ErrShareExhausted},
+	}
+
+	for _, st := range steps {
+		if err := svc.RecordShareFileDownload(ctx, shareID, st.session, types.ShareActionFileDownload, key, st.continuation, visitor); !errors.Is(err, st.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", st.name, err, st.wantErr)
+		}
+	}
+}
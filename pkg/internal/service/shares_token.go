@@ -0,0 +1,138 @@
+package service
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+var (
+	// ErrShareAuthRequired 分享不允许匿名访问，需要登录用户.
+	ErrShareAuthRequired = errors.New("share requires an authenticated user")
+	// ErrShareForbidden 当前用户不在分享的访问白名单中.
+	ErrShareForbidden = errors.New("share access forbidden")
+	// ErrShareTokenInvalid 分享访问令牌缺失、无效、过期或已因权限变更失效.
+	ErrShareTokenInvalid = errors.New("invalid or expired share access token")
+)
+
+// 未配置 share.token_secret 时使用进程内随机密钥.
+var (
+	shareTokenKeyOnce sync.Once
+	shareTokenKey     []byte
+)
+
+// shareTokenClaims 访问令牌载荷.
+type shareTokenClaims struct {
+	ShareID string `json:"sid"`
+	Subject string `json:"sub,omitempty"` // 访问者（匿名访问为空）
+	Version int    `json:"ver"`
+	Expire  int64  `json:"exp"`
+}
+
+// authorizeShare 校验 caller 是否有权访问分享：owner 与白名单用户总是允许，其余用户需分享允许匿名访问.
+func authorizeShare(rec *shareRecord, caller string) error {
+	if caller != "" && (caller == rec.Owner || slices.Contains(rec.Permissions.Users, caller)) {
+		return nil
+	}
+
+	if rec.Permissions.AllowAnonymous {
+		return nil
+	}
+
+	if caller == "" {
+		return ErrShareAuthRequired
+	}
+
+	return ErrShareForbidden
+}
+
+// issueShareToken 签发分享访问令牌，有效期不超过分享本身的过期时间.
+func issueShareToken(rec *shareRecord, caller string) (string, time.Time, error) {
+	exp := time.Now().UTC().Add(configs.GetConfig().Share.GetTokenTTL())
+	if rec.ExpireAt != nil && rec.ExpireAt.Before(exp) {
+		exp = *rec.ExpireAt
+	}
+
+	payload, err := json.Marshal(shareTokenClaims{
+		ShareID: rec.ShareID,
+		Subject: caller,
+		Version: rec.TokenVersion,
+		Expire:  exp.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("marshal token: %w", err)
+	}
+
+	enc := base64.RawURLEncoding.EncodeToString(payload)
+
+	return enc + "." + base64.RawURLEncoding.EncodeToString(signShareToken(enc)), exp, nil
+}
+
+// verifyShareToken 校验令牌签名、过期时间、所属分享与令牌版本；不允许匿名访问的分享还要求令牌签发给 caller 本人.
+func verifyShareToken(rec *shareRecord, token, caller string) error {
+	enc, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrShareTokenInvalid
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, signShareToken(enc)) {
+		return ErrShareTokenInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return ErrShareTokenInvalid
+	}
+
+	var claims shareTokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ErrShareTokenInvalid
+	}
+
+	if claims.ShareID != rec.ShareID || claims.Version != rec.TokenVersion || time.Now().Unix() >= claims.Expire {
+		return ErrShareTokenInvalid
+	}
+
+	if !rec.Permissions.AllowAnonymous && claims.Subject != caller {
+		return ErrShareTokenInvalid
+	}
+
+	return nil
+}
+
+func signShareToken(payload string) []byte {
+	mac := hmac.New(sha256.New, shareTokenSecret())
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
+
+// shareTokenSecret 返回令牌签名密钥：优先使用配置，否则在进程内随机生成一次.
+func shareTokenSecret() []byte {
+	if secret := configs.GetConfig().Share.TokenSecret; secret != "" {
+		return []byte(secret)
+	}
+
+	shareTokenKeyOnce.Do(func() {
+		shareTokenKey = make([]byte, sha256.Size)
+		if _, err := crand.Read(shareTokenKey); err != nil {
+			panic(fmt.Sprintf("generate share token key: %v", err))
+		}
+
+		nlog.Logger().Warn().Msg("share.token_secret not configured, using a random key; share tokens will not survive restarts")
+	})
+
+	return shareTokenKey
+}
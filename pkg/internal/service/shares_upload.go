@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gorm.io/gorm"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/model"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+	"github.com/yeisme/notevault/pkg/internal/types"
+	nlog "github.com/yeisme/notevault/pkg/log"
+	"github.com/yeisme/notevault/pkg/queue"
+)
+
+const (
+	// shareUploadSource 经上传型分享写入的对象在 ObjectStoredPayload.Source 中的来源标识.
+	shareUploadSource = "share_upload"
+	// shareUploadMetaKey 上传型分享写入对象时附带的用户元数据键（x-amz-meta-share-id），值为分享 ID.
+	shareUploadMetaKey = "share-id"
+	// shareUploadTag 文件记录中标记来源分享的标签键.
+	shareUploadTag = "share_id"
+	// ulidLength ULID 字符串长度.
+	ulidLength = 26
+)
+
+var (
+	// ErrShareUploadNotAllowed 分享不是上传型分享，或目标文件夹已不存在.
+	ErrShareUploadNotAllowed = errors.New("share does not accept uploads")
+	// ErrShareUploadRejected 上传的文件不符合分享的上传限制（类型、大小、数量或文件名）.
+	ErrShareUploadRejected = errors.New("upload rejected by share policy")
+)
+
+// RequestShareUpload 为上传型分享的访客生成预签名 POST 表单.
+// 对象写入 owner 的目标文件夹，键名附加 ULID 前缀避免覆盖已有文件，并以用户元数据标记分享 ID；
+// 单文件大小与内容类型由上传策略在存储端强制限制.
+func (s *ShareService) RequestShareUpload(ctx context.Context, shareID, token, caller string,
+	req *types.ShareUploadRequest) (*types.ShareUploadResponse, error) {
+	rec, err := s.getShareWithToken(ctx, shareID, token, caller)
+	if err != nil {
+		return nil, err
+	}
+
+	if rec.shareType() != types.ShareTypeUpload {
+		return nil, ErrShareUploadNotAllowed
+	}
+
+	if limit := configs.GetConfig().Share.UploadMaxFiles; len(req.Files) > limit {
+		return nil, fmt.Errorf("%w: at most %d files per request", ErrShareUploadRejected, limit)
+	}
+
+	prefix, err := s.uploadPrefix(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := s.fileService()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	expires := now.Add(DefaultPresignedOpTimeout)
+	if rec.ExpireAt != nil && rec.ExpireAt.Before(expires) {
+		expires = *rec.ExpireAt
+	}
+
+	maxSize := uploadMaxFileSize(rec.Upload)
+	results := make([]types.PresignedUploadItem, 0, len(req.Files))
+
+	for i := range req.Files {
+		f := &req.Files[i]
+
+		name, err := checkShareUploadFile(rec.Upload, maxSize, f)
+		if err != nil {
+			return nil, err
+		}
+
+		objectKey := prefix + newULID(now) + "_" + name
+
+		// 访客声明的大小仅用于路由，未声明时视为未知
+		size := f.Size
+		if size <= 0 {
+			size = -1
+		}
+
+		bucket, err := files.routeBucket(routeTarget{User: rec.Owner, ContentType: f.ContentType, Size: size})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s3.CheckClientUpload(bucket, objectKey); err != nil {
+			return nil, err
+		}
+
+		item := types.UploadFileItem{
+			FileName:     name,
+			ContentType:  f.ContentType,
+			MaxSize:      maxSize,
+			UserMetadata: map[string]string{shareUploadMetaKey: rec.ShareID},
+		}
+
+		policy := minio.NewPostPolicy()
+		_ = policy.SetBucket(bucket)
+		_ = policy.SetKey(objectKey)
+		_ = policy.SetExpires(expires)
+
+		applyFilePolicies(policy, &item)
+
+		u, formData, err := s.s3c.PresignedPostPolicy(ctx, policy)
+		if err != nil {
+			return nil, fmt.Errorf("presign post policy for %s: %w", name, err)
+		}
+
+		results = append(results, types.PresignedUploadItem{
+			ObjectKey: objectKey,
+			PutURL:    u.String(),
+			FormData:  formData,
+			ExpiresIn: int(time.Until(expires).Seconds()),
+		})
+	}
+
+	return &types.ShareUploadResponse{ShareID: shareID, Results: results}, nil
+}
+
+// CompleteShareUpload 访客上传完成后确认对象：校验对象位于目标文件夹且带有本分享的标记，
+// 写入 owner 的文件记录（标签 share_id）、记录访问日志并通知 owner。
+// 已由存储桶事件同步过的对象视为成功，不重复通知.
+func (s *ShareService) CompleteShareUpload(ctx context.Context, shareID, token string, keys []string,
+	v ShareVisitor) (*types.ShareUploadCompleteResponse, error) {
+	rec, err := s.getShareWithToken(ctx, shareID, token, v.User)
+	if err != nil {
+		return nil, err
+	}
+
+	if rec.shareType() != types.ShareTypeUpload {
+		return nil, ErrShareUploadNotAllowed
+	}
+
+	prefix, err := s.uploadPrefix(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := s.fileService()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &types.ShareUploadCompleteResponse{ShareID: shareID, Results: make([]types.ShareUploadResult, 0, len(keys))}
+
+	for _, key := range keys {
+		r := types.ShareUploadResult{ObjectKey: key}
+
+		bucket, info, err := s.checkShareUploadObject(ctx, files, prefix, rec.ShareID, key)
+		if err != nil {
+			r.Error = err.Error()
+			resp.Results = append(resp.Results, r)
+
+			continue
+		}
+
+		r.FileName, r.Size, r.Success = shareUploadFileName(key), info.Size, true
+		resp.Results = append(resp.Results, r)
+
+		if s.uploadRecorded(ctx, rec.Owner, key, info.ETag) {
+			continue
+		}
+
+		files.recordObject(ctx, rec.Owner, bucket, key, r.FileName, shareUploadSource, "",
+			&types.UploadFileMetadata{Tags: map[string]string{shareUploadTag: rec.ShareID}})
+
+		s.logShareAccess(ctx, shareID, types.ShareActionUpload, key, "", v)
+		publishEvent(ctx, s.mqc, queue.TopicShareUploadReceived, queue.ShareUploadReceivedPayload{
+			ShareID:      rec.ShareID,
+			Owner:        rec.Owner,
+			Object:       shareUploadObjectRef(bucket, key, &info),
+			FileName:     r.FileName,
+			UploaderUser: v.User,
+			UploaderIP:   v.IP,
+		})
+	}
+
+	return resp, nil
+}
+
+// uploadPrefix 返回上传型分享目标文件夹的对象键前缀（以 / 结尾）.
+func (s *ShareService) uploadPrefix(ctx context.Context, rec *shareRecord) (string, error) {
+	bucket, err := s.bucket()
+	if err != nil {
+		return "", err
+	}
+
+	parent, name, err := findFolderPath(ctx, s.s3c, bucket, rec.Owner, rec.FolderID)
+	if err != nil {
+		return "", fmt.Errorf("%w: target folder %s: %v", ErrShareUploadNotAllowed, rec.FolderID, err)
+	}
+
+	if parent != "" {
+		name = parent + "/" + name
+	}
+
+	return rec.Owner + "/" + name + "/", nil
+}
+
+// checkShareUploadObject 确认对象存在、位于目标文件夹下且由本分享上传，返回对象所在的 bucket.
+// 签发上传时按路由规则选择 bucket，因此确认时在所有 bucket 中查找对象.
+func (s *ShareService) checkShareUploadObject(ctx context.Context, files *FileService,
+	prefix, shareID, key string) (string, minio.ObjectInfo, error) {
+	rest, ok := strings.CutPrefix(key, prefix)
+	if !ok || rest == "" || strings.Contains(rest, "/") {
+		return "", minio.ObjectInfo{}, errors.New("object is not part of this share")
+	}
+
+	bucket, info, err := files.locateObject(ctx, key)
+	if err != nil {
+		if isNoSuchKey(err) {
+			return "", minio.ObjectInfo{}, errors.New("object not uploaded")
+		}
+
+		return "", minio.ObjectInfo{}, fmt.Errorf("stat object: %w", err)
+	}
+
+	if shareUploadID(&info) != shareID {
+		return "", minio.ObjectInfo{}, errors.New("object is not part of this share")
+	}
+
+	return bucket, info, nil
+}
+
+// uploadRecorded 判断对象当前内容是否已有文件记录（例如已由存储桶事件同步）.
+func (s *ShareService) uploadRecorded(ctx context.Context, owner, key, etag string) bool {
+	if s.dbc == nil || s.dbc.GetDB() == nil {
+		return false
+	}
+
+	var rec model.Files
+
+	err := s.dbc.GetDB().WithContext(ctx).Select("e_tag").
+		Where("user = ? AND object_key = ?", owner, key).Take(&rec).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			nlog.Logger().Warn().Err(err).Str("object_key", key).Msg("load share upload record failed")
+		}
+
+		return false
+	}
+
+	return rec.ETag == strings.Trim(etag, "\"")
+}
+
+// normalizeUploadPolicy 规范化 owner 设置的上传限制：内容类型转小写去重，大小不超过服务端上限.
+func normalizeUploadPolicy(p *types.ShareUploadPolicy) *types.ShareUploadPolicy {
+	out := &types.ShareUploadPolicy{MaxFileSize: p.MaxFileSize}
+
+	if limit := configs.GetConfig().Share.GetUploadMaxFileSize(); out.MaxFileSize <= 0 || out.MaxFileSize > limit {
+		out.MaxFileSize = limit
+	}
+
+	for _, ct := range p.AllowedContentTypes {
+		ct = strings.ToLower(strings.TrimSpace(ct))
+		if ct != "" && !slices.Contains(out.AllowedContentTypes, ct) {
+			out.AllowedContentTypes = append(out.AllowedContentTypes, ct)
+		}
+	}
+
+	return out
+}
+
+// uploadMaxFileSize 返回分享允许的单文件字节上限.
+func uploadMaxFileSize(p *types.ShareUploadPolicy) int64 {
+	limit := configs.GetConfig().Share.GetUploadMaxFileSize()
+	if p != nil && p.MaxFileSize > 0 && p.MaxFileSize < limit {
+		return p.MaxFileSize
+	}
+
+	return limit
+}
+
+// checkShareUploadFile 校验访客声明的文件，返回清理后的文件名.
+func checkShareUploadFile(p *types.ShareUploadPolicy, maxSize int64, f *types.ShareUploadFile) (string, error) {
+	name := path.Base(strings.ReplaceAll(strings.TrimSpace(f.FileName), "\\", "/"))
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "", fmt.Errorf("%w: invalid file name %q", ErrShareUploadRejected, f.FileName)
+	}
+
+	if f.Size > maxSize {
+		return "", fmt.Errorf("%w: %s exceeds %d bytes", ErrShareUploadRejected, name, maxSize)
+	}
+
+	if p != nil && len(p.AllowedContentTypes) > 0 && !contentTypeAllowed(p.AllowedContentTypes, f.ContentType) {
+		return "", fmt.Errorf("%w: content type %q of %s is not allowed", ErrShareUploadRejected, f.ContentType, name)
+	}
+
+	return name, nil
+}
+
+// contentTypeAllowed 判断内容类型是否在允许列表中，支持 "image/*" 形式的通配.
+func contentTypeAllowed(allowed []string, contentType string) bool {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	ct = strings.TrimSpace(ct)
+
+	if ct == "" {
+		return false
+	}
+
+	for _, a := range allowed {
+		if a == ct || a == "*/*" {
+			return true
+		}
+
+		if major, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(ct, major+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shareUploadID 返回对象元数据中标记的来源分享 ID，非分享上传的对象返回空串.
+func shareUploadID(info *minio.ObjectInfo) string {
+	for k, v := range info.UserMetadata {
+		if strings.EqualFold(k, shareUploadMetaKey) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// shareUploadFileName 去掉上传型分享对象键中的 ULID 前缀，还原访客上传时的文件名.
+func shareUploadFileName(objectKey string) string {
+	name := lastPathComponent(objectKey)
+
+	if id, rest, ok := strings.Cut(name, "_"); ok && len(id) == ulidLength && rest != "" {
+		return rest
+	}
+
+	return name
+}
+
+// shareUploadObjectRef 由对象信息构造事件中的对象引用.
+func shareUploadObjectRef(bucket, key string, info *minio.ObjectInfo) queue.ObjectRef {
+	return queue.ObjectRef{
+		Bucket:      bucket,
+		ObjectKey:   key,
+		VersionID:   info.VersionID,
+		ETag:        strings.Trim(info.ETag, "\""),
+		Size:        info.Size,
+		ContentType: info.ContentType,
+	}
+}
@@ -78,6 +78,11 @@ type Client struct {
 	closeFunc  func() // 用于关闭metrics服务器
 }
 
+// NewClient 使用已创建的 Publisher 与 Subscriber 构造客户端（如测试中使用 watermill 的 gochannel）.
+func NewClient(pub message.Publisher, sub message.Subscriber) *Client {
+	return &Client{publisher: pub, subscriber: sub}
+}
+
 // Publish 便捷发布.
 func (c *Client) Publish(ctx context.Context, topic string, msgs ...*message.Message) error {
 	if c == nil || c.publisher == nil {
@@ -0,0 +1,709 @@
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/envelope"
+)
+
+// 信封加密写入对象 UserMetadata 的键（minio-go 发送时加 X-Amz-Meta- 前缀）.
+const (
+	metaEncryption   = "Nv-Encryption"
+	metaEncKeyID     = "Nv-Encryption-Key-Id"
+	metaEncKey       = "Nv-Encryption-Key"
+	metaEncNonce     = "Nv-Encryption-Nonce"
+	metaEncChunkSize = "Nv-Encryption-Chunk-Size"
+
+	// envelopeAlgorithm 分块 AES-256-GCM 格式标识.
+	envelopeAlgorithm = "aes-256-gcm-chunked-v1"
+)
+
+// ErrPresignEncrypted 由 notevault 加密（信封加密或 SSE-C）的对象无法通过预签名 URL 直接读写，需经服务端接口上传下载.
+var ErrPresignEncrypted = errors.New("presigned URLs are not available for objects encrypted by notevault")
+
+// KeyRotator 由加密层实现：将对象的数据密钥（信封加密）或 SSE-C 密钥轮换到当前主密钥.
+type KeyRotator interface {
+	// RotateObjectKey 返回对象是否使用了旧主密钥；dryRun 为 false 时完成轮换.
+	RotateObjectKey(ctx context.Context, bucket, objectKey string, dryRun bool) (bool, error)
+}
+
+// EncryptedStore 在 ObjectStore 之上按加密规则加密新写入的对象，并对读取透明解密：
+// SSE-S3/SSE-C 通过 PutObjectOptions.ServerSideEncryption 交给对象存储完成；
+// 信封加密的对象以分块 AES-GCM 格式存储，GetObject 按 Range 只读取和解密覆盖的分块，StatObject 返回明文大小.
+type EncryptedStore struct {
+	ObjectStore
+
+	// keys 主密钥环，未配置主密钥时为 nil（只能使用 SSE-S3）
+	keys *envelope.Keyring
+
+	// ruleBuckets 匹配加密规则时使用的 bucket 名称映射（副本 bucket -> 主存储 bucket），为 nil 时按实际 bucket 匹配
+	ruleBuckets map[string]string
+}
+
+// NewEncryptedStore 加载主密钥并创建加密层；规则中使用 sse-c 或 envelope 时必须配置主密钥.
+func NewEncryptedStore(store ObjectStore, cfg *configs.EncryptionConfig) (*EncryptedStore, error) {
+	s := &EncryptedStore{ObjectStore: store}
+
+	if len(cfg.MasterKeys) == 0 {
+		if needsMasterKey(cfg) {
+			return nil, fmt.Errorf("encryption: sse-c and envelope modes require master_keys")
+		}
+
+		return s, nil
+	}
+
+	keys := make(map[string][]byte, len(cfg.MasterKeys))
+	for _, mk := range cfg.MasterKeys {
+		key, err := loadMasterKey(mk)
+		if err != nil {
+			return nil, fmt.Errorf("encryption: master key %s: %w", mk.ID, err)
+		}
+
+		keys[mk.ID] = key
+	}
+
+	active := cfg.ActiveKeyID
+	if active == "" {
+		active = cfg.MasterKeys[0].ID
+	}
+
+	kr, err := envelope.NewKeyring(active, keys)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: %w", err)
+	}
+
+	s.keys = kr
+
+	return s, nil
+}
+
+// SetRuleBuckets 设置匹配加密规则时的 bucket 名称映射：副本存储的 bucket 改名后仍按对应的主存储 bucket 匹配规则，
+// 使副本与主存储使用相同的加密方式.
+func (s *EncryptedStore) SetRuleBuckets(buckets map[string]string) {
+	s.ruleBuckets = buckets
+}
+
+// encryptionMode 返回新写入对象的加密模式，bucket 先按 ruleBuckets 映射.
+func (s *EncryptedStore) encryptionMode(bucket, objectKey string) string {
+	if b, ok := s.ruleBuckets[bucket]; ok {
+		bucket = b
+	}
+
+	return EncryptionMode(bucket, objectKey)
+}
+
+// Unwrap 返回被包装的对象存储驱动.
+func (s *EncryptedStore) Unwrap() ObjectStore { //nolint:ireturn
+	return s.ObjectStore
+}
+
+// PutObject 按加密规则写入对象.
+func (s *EncryptedStore) PutObject(ctx context.Context, bucket, objectKey string, reader io.Reader, size int64,
+	opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	switch s.encryptionMode(bucket, objectKey) {
+	case configs.EncryptionModeSSES3:
+		if opts.ServerSideEncryption == nil {
+			opts.ServerSideEncryption = encrypt.NewSSE()
+		}
+	case configs.EncryptionModeSSEC:
+		sse, err := s.customerKey("", bucket)
+		if err != nil {
+			return minio.UploadInfo{}, err
+		}
+
+		opts.ServerSideEncryption = sse
+	case configs.EncryptionModeEnvelope:
+		return s.putEnvelope(ctx, bucket, objectKey, reader, size, opts)
+	}
+
+	return s.ObjectStore.PutObject(ctx, bucket, objectKey, reader, size, opts)
+}
+
+// putEnvelope 生成数据密钥并以分块格式写入密文，返回的大小为明文大小.
+func (s *EncryptedStore) putEnvelope(ctx context.Context, bucket, objectKey string, reader io.Reader, size int64,
+	opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	if s.keys == nil {
+		return minio.UploadInfo{}, fmt.Errorf("encryption: envelope mode requires master_keys")
+	}
+
+	params, err := envelope.NewParams(configs.GetConfig().Encryption.GetChunkSize())
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	keyID, wrapped, err := s.keys.Wrap(params.DataKey)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	meta := maps.Clone(opts.UserMetadata)
+	if meta == nil {
+		meta = make(map[string]string, 5)
+	}
+
+	meta[metaEncryption] = envelopeAlgorithm
+	meta[metaEncKeyID] = keyID
+	meta[metaEncKey] = base64.StdEncoding.EncodeToString(wrapped)
+	meta[metaEncNonce] = base64.StdEncoding.EncodeToString(params.NoncePrefix)
+	meta[metaEncChunkSize] = strconv.Itoa(params.ChunkSize)
+	opts.UserMetadata = meta
+
+	counter := &countingReader{r: reader}
+
+	enc, err := params.Encrypt(counter)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	info, err := s.ObjectStore.PutObject(ctx, bucket, objectKey, enc, envelope.CiphertextSize(size, params.ChunkSize), opts)
+	if err != nil {
+		return info, err
+	}
+
+	info.Size = counter.n
+
+	return info, nil
+}
+
+// GetObject 读取对象；信封加密的对象按 Range 只读取覆盖的分块并解密.
+func (s *EncryptedStore) GetObject(ctx context.Context, bucket, objectKey string, opts minio.GetObjectOptions) (Object, error) { //nolint:ireturn
+	st, err := s.stat(ctx, bucket, objectKey, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isEnvelope(st.info.UserMetadata) {
+		if opts.ServerSideEncryption == nil {
+			opts.ServerSideEncryption = st.sse
+		}
+
+		return s.ObjectStore.GetObject(ctx, bucket, objectKey, opts)
+	}
+
+	params, _, err := s.envelopeParams(st.info.UserMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", bucket, objectKey, err)
+	}
+
+	info, err := plainInfo(st.info)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", bucket, objectKey, err)
+	}
+
+	start, length, err := parseRange(opts.Header().Get("Range"), info.Size)
+	if err != nil {
+		return nil, errorResponse(http.StatusRequestedRangeNotSatisfiable, "InvalidRange", bucket, objectKey, err.Error())
+	}
+
+	// 密文整体打开（Range 由解密层换算为分块偏移），并固定为 Stat 时的内容
+	inner := baseOptions(opts)
+	if inner.Header().Get("If-Match") == "" {
+		_ = inner.SetMatchETag(st.info.ETag)
+	}
+
+	obj, err := s.ObjectStore.GetObject(ctx, bucket, objectKey, inner)
+	if err != nil {
+		return nil, err
+	}
+
+	ra, err := params.NewReaderAt(obj, st.info.Size)
+	if err != nil {
+		_ = obj.Close()
+		return nil, fmt.Errorf("%s/%s: %w", bucket, objectKey, err)
+	}
+
+	return &decryptedObject{SectionReader: io.NewSectionReader(ra, start, length), inner: obj, info: info}, nil
+}
+
+// StatObject 返回对象信息，信封加密的对象返回明文大小并隐藏加密元数据.
+func (s *EncryptedStore) StatObject(ctx context.Context, bucket, objectKey string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	st, err := s.stat(ctx, bucket, objectKey, opts)
+	if err != nil {
+		return st.info, err
+	}
+
+	return plainInfo(st.info)
+}
+
+// ListObjects 列举对象；信封加密对象的大小换算为明文大小（需要列举结果带元数据，MinIO 与本地驱动支持）.
+func (s *EncryptedStore) ListObjects(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	withMetadata := opts.WithMetadata
+	if !opts.WithVersions {
+		opts.WithMetadata = true
+	}
+
+	in := s.ObjectStore.ListObjects(ctx, bucket, opts)
+	out := make(chan minio.ObjectInfo)
+
+	go func() {
+		defer close(out)
+
+		for info := range in {
+			if info.Err == nil {
+				if plain, err := plainInfo(info); err == nil {
+					info = plain
+				}
+
+				if !withMetadata {
+					info.UserMetadata = nil
+				}
+			}
+
+			select {
+			case out <- info:
+			case <-ctx.Done():
+				for range in { //nolint:revive // 排空上游，避免其 goroutine 阻塞
+				}
+
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// CopyObject 服务端复制：源对象为 SSE-C 时提供源密钥，目标沿用源对象的服务端加密或按目标规则加密；
+// 信封加密的对象按密文复制，替换元数据时保留加密元数据.
+func (s *EncryptedStore) CopyObject(ctx context.Context, dst minio.CopyDestOptions, src minio.CopySrcOptions) (minio.UploadInfo, error) {
+	st, err := s.stat(ctx, src.Bucket, src.Object, minio.StatObjectOptions{VersionID: src.VersionID})
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	if src.Encryption == nil {
+		src.Encryption = st.sse
+	}
+
+	if dst.Encryption == nil && !isEnvelope(st.info.UserMetadata) {
+		sse, err := s.copyEncryption(dst.Bucket, dst.Object, &st)
+		if err != nil {
+			return minio.UploadInfo{}, err
+		}
+
+		dst.Encryption = sse
+	}
+
+	if dst.ReplaceMetadata && isEnvelope(st.info.UserMetadata) {
+		meta := maps.Clone(dst.UserMetadata)
+		if meta == nil {
+			meta = make(map[string]string, 5)
+		}
+
+		for _, k := range []string{metaEncryption, metaEncKeyID, metaEncKey, metaEncNonce, metaEncChunkSize} {
+			meta[k] = metaValue(st.info.UserMetadata, k)
+		}
+
+		dst.UserMetadata = meta
+	}
+
+	return s.ObjectStore.CopyObject(ctx, dst, src)
+}
+
+// copyEncryption 返回复制目标的服务端加密：SSE-C 源对象保持 SSE-C（使用当前主密钥），
+// 其余按目标规则，规则不要求加密时沿用源对象的 SSE-S3.
+func (s *EncryptedStore) copyEncryption(bucket, objectKey string, src *objectState) (encrypt.ServerSide, error) { //nolint:ireturn
+	mode := s.encryptionMode(bucket, objectKey)
+	if src.sse != nil || mode == configs.EncryptionModeSSEC {
+		return s.customerKey("", bucket)
+	}
+
+	if mode == configs.EncryptionModeSSES3 || src.info.Metadata.Get("X-Amz-Server-Side-Encryption") == "AES256" {
+		return encrypt.NewSSE(), nil
+	}
+
+	return nil, nil //nolint:nilnil // 不加密
+}
+
+// PresignedGetObject 由 notevault 加密的对象无法直接下载，返回 ErrPresignEncrypted.
+func (s *EncryptedStore) PresignedGetObject(ctx context.Context, bucket, objectKey string, expires time.Duration,
+	reqParams url.Values) (*url.URL, error) {
+	if st, err := s.stat(ctx, bucket, objectKey, minio.StatObjectOptions{}); err == nil &&
+		(st.sse != nil || isEnvelope(st.info.UserMetadata)) {
+		return nil, ErrPresignEncrypted
+	}
+
+	return s.ObjectStore.PresignedGetObject(ctx, bucket, objectKey, expires, reqParams)
+}
+
+// PresignedPutObject 规则要求由 notevault 加密的对象不允许直传.
+func (s *EncryptedStore) PresignedPutObject(ctx context.Context, bucket, objectKey string, expires time.Duration) (*url.URL, error) {
+	if err := CheckClientUpload(bucket, objectKey); err != nil {
+		return nil, err
+	}
+
+	return s.ObjectStore.PresignedPutObject(ctx, bucket, objectKey, expires)
+}
+
+// RotateObjectKey 实现 KeyRotator：信封加密的对象只重新包装数据密钥（内容不变），SSE-C 对象在服务端用新密钥重新加密.
+// 原生版本化 bucket 中的历史版本无法原位修改，仍由旧主密钥保护.
+func (s *EncryptedStore) RotateObjectKey(ctx context.Context, bucket, objectKey string, dryRun bool) (bool, error) {
+	if s.keys == nil {
+		return false, nil
+	}
+
+	st, err := s.stat(ctx, bucket, objectKey, minio.StatObjectOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	active := s.keys.ActiveID()
+	src := minio.CopySrcOptions{Bucket: bucket, Object: objectKey, MatchETag: st.info.ETag}
+	dst := minio.CopyDestOptions{Bucket: bucket, Object: objectKey}
+
+	switch {
+	case isEnvelope(st.info.UserMetadata):
+		if metaValue(st.info.UserMetadata, metaEncKeyID) == active {
+			return false, nil
+		}
+
+		if dryRun {
+			return true, nil
+		}
+
+		params, _, err := s.envelopeParams(st.info.UserMetadata)
+		if err != nil {
+			return false, err
+		}
+
+		keyID, wrapped, err := s.keys.Wrap(params.DataKey)
+		if err != nil {
+			return false, err
+		}
+
+		meta := make(map[string]string, len(st.info.UserMetadata)+1)
+		for k, v := range st.info.UserMetadata {
+			meta[strings.TrimPrefix(k, "X-Amz-Meta-")] = v
+		}
+
+		meta[metaEncKeyID] = keyID
+		meta[metaEncKey] = base64.StdEncoding.EncodeToString(wrapped)
+
+		if st.info.StorageClass != "" {
+			meta[StorageClassMetaKey] = st.info.StorageClass
+		}
+
+		dst.ReplaceMetadata, dst.UserMetadata, dst.ContentType = true, meta, st.info.ContentType
+	case st.sse != nil:
+		if st.keyID == active {
+			return false, nil
+		}
+
+		if dryRun {
+			return true, nil
+		}
+
+		sse, err := s.customerKey("", bucket)
+		if err != nil {
+			return false, err
+		}
+
+		src.Encryption, dst.Encryption = st.sse, sse
+	default:
+		return false, nil
+	}
+
+	if _, err := s.ObjectStore.CopyObject(ctx, dst, src); err != nil {
+		return false, fmt.Errorf("rewrite %s/%s: %w", bucket, objectKey, err)
+	}
+
+	return true, nil
+}
+
+// objectState 对象信息（含加密元数据）及读取时需要提供的 SSE-C 密钥.
+type objectState struct {
+	info minio.ObjectInfo
+	// sse 对象为 SSE-C 时读取所需的密钥，keyID 为派生该密钥的主密钥
+	sse   encrypt.ServerSide
+	keyID string
+}
+
+// stat 查询对象信息；对象为 SSE-C（未提供密钥时对象存储返回 400）时依次尝试由各主密钥派生的密钥.
+func (s *EncryptedStore) stat(ctx context.Context, bucket, objectKey string, opts minio.GetObjectOptions) (objectState, error) {
+	opts = baseOptions(opts)
+
+	info, err := s.ObjectStore.StatObject(ctx, bucket, objectKey, opts)
+	if err == nil || opts.ServerSideEncryption != nil || s.keys == nil ||
+		minio.ToErrorResponse(err).StatusCode != http.StatusBadRequest {
+		return objectState{info: info, sse: opts.ServerSideEncryption}, err
+	}
+
+	for _, id := range s.keys.IDs() {
+		sse, kerr := s.customerKey(id, bucket)
+		if kerr != nil {
+			return objectState{}, kerr
+		}
+
+		opts.ServerSideEncryption = sse
+		if ki, kerr := s.ObjectStore.StatObject(ctx, bucket, objectKey, opts); kerr == nil {
+			return objectState{info: ki, sse: sse, keyID: id}, nil
+		}
+	}
+
+	return objectState{info: info}, err
+}
+
+// customerKey 返回由主密钥（为空时使用当前主密钥）为 bucket 派生的 SSE-C 密钥.
+func (s *EncryptedStore) customerKey(keyID, bucket string) (encrypt.ServerSide, error) { //nolint:ireturn
+	if s.keys == nil {
+		return nil, fmt.Errorf("encryption: sse-c requires master_keys")
+	}
+
+	if keyID == "" {
+		keyID = s.keys.ActiveID()
+	}
+
+	key, err := s.keys.DeriveKey(keyID, "sse-c/"+bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return encrypt.NewSSEC(key)
+}
+
+// envelopeParams 从对象元数据解析加密参数并解包数据密钥.
+func (s *EncryptedStore) envelopeParams(meta minio.StringMap) (*envelope.Params, string, error) {
+	if s.keys == nil {
+		return nil, "", fmt.Errorf("object is envelope-encrypted but no master key is configured")
+	}
+
+	if alg := metaValue(meta, metaEncryption); alg != envelopeAlgorithm {
+		return nil, "", fmt.Errorf("unsupported encryption %q", alg)
+	}
+
+	chunkSize, err := strconv.Atoi(metaValue(meta, metaEncChunkSize))
+	if err != nil || chunkSize <= 0 {
+		return nil, "", fmt.Errorf("invalid encryption chunk size")
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(metaValue(meta, metaEncNonce))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid encryption nonce: %w", err)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(metaValue(meta, metaEncKey))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid wrapped key: %w", err)
+	}
+
+	keyID := metaValue(meta, metaEncKeyID)
+
+	dataKey, err := s.keys.Unwrap(keyID, wrapped)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &envelope.Params{DataKey: dataKey, NoncePrefix: nonce, ChunkSize: chunkSize}, keyID, nil
+}
+
+// EncryptionMode 返回新写入对象使用的加密模式：第一条命中的规则生效，都不命中时使用默认模式.
+// 用户取对象键的第一级目录，系统目录下的派生对象（缩略图、历史版本、回收站）按其中嵌入的原对象键取用户，
+// 与原对象使用相同的规则.
+func EncryptionMode(bucket, objectKey string) string {
+	cfg := configs.GetConfig().Encryption
+	user := objectUser(objectKey)
+
+	for i := range cfg.Rules {
+		if matchEncryptionRule(&cfg.Rules[i], bucket, user) {
+			return cfg.Rules[i].Mode
+		}
+	}
+
+	if cfg.Mode == "" {
+		return configs.EncryptionModeNone
+	}
+
+	return cfg.Mode
+}
+
+// objectUser 返回对象所属用户：普通对象取第一级目录；.previews/<key>/...、.versions/<key>/... 与 .trash/<id>/<key>
+// 取嵌入的原对象键的第一级目录；其他系统目录（以 "." 开头）返回空串，只按 bucket 条件匹配.
+func objectUser(objectKey string) string {
+	first, rest, _ := strings.Cut(objectKey, "/")
+	if !strings.HasPrefix(first, ".") {
+		return first
+	}
+
+	switch first {
+	case ".previews", ".versions":
+	case ".trash":
+		_, rest, _ = strings.Cut(rest, "/")
+	default:
+		return ""
+	}
+
+	user, _, _ := strings.Cut(rest, "/")
+
+	return user
+}
+
+// CheckClientUpload 检查对象是否允许客户端通过预签名 URL 直传：需要由 notevault 加密（envelope、sse-c）时返回 ErrPresignEncrypted.
+func CheckClientUpload(bucket, objectKey string) error {
+	switch EncryptionMode(bucket, objectKey) {
+	case configs.EncryptionModeEnvelope, configs.EncryptionModeSSEC:
+		return fmt.Errorf("%s: %w", objectKey, ErrPresignEncrypted)
+	}
+
+	return nil
+}
+
+// matchEncryptionRule 判断对象是否满足加密规则中的所有非空条件.
+func matchEncryptionRule(rule *configs.EncryptionRule, bucket, user string) bool {
+	if len(rule.Buckets) > 0 && !slices.Contains(rule.Buckets, bucket) {
+		return false
+	}
+
+	if len(rule.Users) > 0 && !slices.Contains(rule.Users, user) {
+		return false
+	}
+
+	if len(rule.Tenants) > 0 {
+		_, tenant, ok := strings.Cut(user, "@")
+		if !ok || !slices.ContainsFunc(rule.Tenants, func(d string) bool { return strings.EqualFold(d, tenant) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// needsMasterKey 判断默认模式或规则是否使用需要主密钥的加密模式.
+func needsMasterKey(cfg *configs.EncryptionConfig) bool {
+	modes := []string{cfg.Mode}
+	for _, r := range cfg.Rules {
+		modes = append(modes, r.Mode)
+	}
+
+	return slices.ContainsFunc(modes, func(m string) bool {
+		return m == configs.EncryptionModeSSEC || m == configs.EncryptionModeEnvelope
+	})
+}
+
+// loadMasterKey 从环境变量（优先）或文件读取 base64 编码的主密钥.
+func loadMasterKey(mk configs.MasterKeyConfig) ([]byte, error) {
+	raw, ok := "", false
+	if mk.Env != "" {
+		raw, ok = os.LookupEnv(mk.Env)
+	}
+
+	if !ok || raw == "" {
+		if mk.File == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", mk.Env)
+		}
+
+		b, err := os.ReadFile(mk.File)
+		if err != nil {
+			return nil, err
+		}
+
+		raw = string(b)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+
+	return key, nil
+}
+
+// isEnvelope 判断对象是否为信封加密.
+func isEnvelope(meta minio.StringMap) bool {
+	return metaValue(meta, metaEncryption) != ""
+}
+
+// plainInfo 将信封加密对象的信息换算为明文大小并移除加密元数据.
+func plainInfo(info minio.ObjectInfo) (minio.ObjectInfo, error) {
+	if !isEnvelope(info.UserMetadata) {
+		return info, nil
+	}
+
+	chunkSize, err := strconv.Atoi(metaValue(info.UserMetadata, metaEncChunkSize))
+	if err != nil || chunkSize <= 0 {
+		return info, fmt.Errorf("invalid encryption chunk size")
+	}
+
+	size, err := envelope.PlaintextSize(info.Size, chunkSize)
+	if err != nil {
+		return info, err
+	}
+
+	meta := make(minio.StringMap, len(info.UserMetadata))
+	for k, v := range info.UserMetadata {
+		if !strings.HasPrefix(strings.ToLower(strings.TrimPrefix(k, "X-Amz-Meta-")), "nv-encryption") {
+			meta[k] = v
+		}
+	}
+
+	info.Size, info.UserMetadata = size, meta
+
+	return info, nil
+}
+
+// metaValue 不区分大小写读取 UserMetadata（列举结果中的键可能带 X-Amz-Meta- 前缀）.
+func metaValue(meta minio.StringMap, key string) string {
+	for k, v := range meta {
+		if strings.EqualFold(strings.TrimPrefix(k, "X-Amz-Meta-"), key) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// baseOptions 复制读取选项中的版本、SSE-C 与条件请求头，不包含 Range.
+func baseOptions(opts minio.GetObjectOptions) minio.GetObjectOptions {
+	out := minio.GetObjectOptions{VersionID: opts.VersionID, ServerSideEncryption: opts.ServerSideEncryption}
+
+	h := opts.Header()
+	if v := h.Get("If-Match"); v != "" {
+		_ = out.SetMatchETag(strings.Trim(v, "\""))
+	}
+
+	if v := h.Get("If-None-Match"); v != "" {
+		_ = out.SetMatchETagExcept(strings.Trim(v, "\""))
+	}
+
+	return out
+}
+
+// decryptedObject 信封加密对象解密后的内容.
+type decryptedObject struct {
+	*io.SectionReader
+
+	inner Object
+	info  minio.ObjectInfo
+}
+
+func (o *decryptedObject) Stat() (minio.ObjectInfo, error) { return o.info, nil }
+
+func (o *decryptedObject) Close() error { return o.inner.Close() }
+
+// countingReader 统计读取的明文字节数.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,199 @@
+package s3_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	minio "github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	"github.com/yeisme/notevault/pkg/internal/envelope"
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+)
+
+// setMasterKey 生成随机主密钥并写入环境变量.
+func setMasterKey(t *testing.T, env string) {
+	t.Helper()
+
+	key := make([]byte, envelope.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(env, base64.StdEncoding.EncodeToString(key))
+}
+
+// TestEncryptedStoreEnvelope 验证信封加密的读写、Range、复制、预签名限制与主密钥轮换.
+func TestEncryptedStoreEnvelope(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := configs.GetConfig()
+	saved := cfg.Encryption
+
+	t.Cleanup(func() { cfg.Encryption = saved })
+
+	setMasterKey(t, "NV_TEST_KEY_1")
+	setMasterKey(t, "NV_TEST_KEY_2")
+
+	cfg.Encryption = configs.EncryptionConfig{
+		Mode:        configs.EncryptionModeEnvelope,
+		Rules:       []configs.EncryptionRule{{Mode: configs.EncryptionModeNone, Users: []string{"plain"}}},
+		MasterKeys:  []configs.MasterKeyConfig{{ID: "k1", Env: "NV_TEST_KEY_1"}},
+		ChunkSizeKB: 1,
+	}
+
+	mem := s3.NewMemoryStore(false, "b")
+
+	st, err := s3.NewEncryptedStore(mem, &cfg.Encryption)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain := make([]byte, 3000)
+	_, _ = rand.Read(plain)
+	body := string(plain)
+
+	put(t, st, "u/a.bin", body)
+	put(t, st, "plain/b.txt", body)
+
+	if raw, _ := read(t, mem, "u/a.bin", minio.GetObjectOptions{}); raw == body ||
+		int64(len(raw)) != envelope.CiphertextSize(int64(len(body)), 1024) {
+		t.Fatalf("stored object is not chunk-encrypted: %d bytes", len(raw))
+	}
+
+	if raw, _ := read(t, mem, "plain/b.txt", minio.GetObjectOptions{}); raw != body {
+		t.Fatalf("rule with mode none encrypted the object")
+	}
+
+	if got, err := read(t, st, "u/a.bin", minio.GetObjectOptions{}); err != nil || got != body {
+		t.Fatalf("read = %d bytes, %v", len(got), err)
+	}
+
+	info, err := st.StatObject(ctx, "b", "u/a.bin", minio.StatObjectOptions{})
+	if err != nil || info.Size != int64(len(body)) {
+		t.Fatalf("stat = %d, %v", info.Size, err)
+	}
+
+	for k := range info.UserMetadata {
+		if strings.HasPrefix(k, "Nv-Encryption") {
+			t.Fatalf("stat exposes %s", k)
+		}
+	}
+
+	for obj := range st.ListObjects(ctx, "b", minio.ListObjectsOptions{Prefix: "u/"}) {
+		if obj.Err != nil || obj.Size != int64(len(body)) {
+			t.Fatalf("list %s = %d, %v", obj.Key, obj.Size, obj.Err)
+		}
+	}
+
+	// 跨越分块边界的 Range 读取
+	var opts minio.GetObjectOptions
+	_ = opts.SetRange(1000, 2100)
+
+	if got, err := read(t, st, "u/a.bin", opts); err != nil || got != body[1000:2101] {
+		t.Fatalf("range read = %d bytes, %v", len(got), err)
+	}
+
+	// 替换元数据的复制保留数据密钥
+	if _, err := st.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: "b", Object: "u/c.bin", ReplaceMetadata: true, UserMetadata: map[string]string{"Note": "x"}},
+		minio.CopySrcOptions{Bucket: "b", Object: "u/a.bin"}); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	if got, err := read(t, st, "u/c.bin", minio.GetObjectOptions{}); err != nil || got != body {
+		t.Fatalf("read copy = %d bytes, %v", len(got), err)
+	}
+
+	if _, err := st.PresignedGetObject(ctx, "b", "u/a.bin", 0, nil); !errors.Is(err, s3.ErrPresignEncrypted) {
+		t.Fatalf("presign get = %v", err)
+	}
+
+	if !errors.Is(s3.CheckClientUpload("b", "u/new.bin"), s3.ErrPresignEncrypted) || s3.CheckClientUpload("b", "plain/new.bin") != nil {
+		t.Fatalf("client upload check mismatch")
+	}
+
+	// 轮换：追加 k2 并设为当前主密钥，重新包装后只保留 k2 仍可读取
+	cfg.Encryption.MasterKeys = append(cfg.Encryption.MasterKeys, configs.MasterKeyConfig{ID: "k2", Env: "NV_TEST_KEY_2"})
+	cfg.Encryption.ActiveKeyID = "k2"
+
+	rotating, err := s3.NewEncryptedStore(mem, &cfg.Encryption)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rotated, err := rotating.RotateObjectKey(ctx, "b", "u/a.bin", true); err != nil || !rotated {
+		t.Fatalf("dry run rotate = %v, %v", rotated, err)
+	}
+
+	for _, key := range []string{"u/a.bin", "plain/b.txt"} {
+		if _, err := rotating.RotateObjectKey(ctx, "b", key, false); err != nil {
+			t.Fatalf("rotate %s: %v", key, err)
+		}
+	}
+
+	if rotated, err := rotating.RotateObjectKey(ctx, "b", "u/a.bin", false); err != nil || rotated {
+		t.Fatalf("second rotate = %v, %v", rotated, err)
+	}
+
+	cfg.Encryption.MasterKeys = cfg.Encryption.MasterKeys[1:]
+
+	latest, err := s3.NewEncryptedStore(mem, &cfg.Encryption)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := read(t, latest, "u/a.bin", minio.GetObjectOptions{}); err != nil || !bytes.Equal([]byte(got), plain) {
+		t.Fatalf("read after rotation = %d bytes, %v", len(got), err)
+	}
+
+	if _, err := read(t, latest, "u/c.bin", minio.GetObjectOptions{}); err == nil {
+		t.Fatalf("object wrapped by removed key decrypted")
+	}
+}
+
+// TestEncryptedStoreRuleBuckets 验证副本 bucket 改名后仍按主存储 bucket 匹配加密规则.
+func TestEncryptedStoreRuleBuckets(t *testing.T) {
+	cfg := configs.GetConfig()
+	saved := cfg.Encryption
+
+	t.Cleanup(func() { cfg.Encryption = saved })
+
+	setMasterKey(t, "NV_TEST_KEY_1")
+
+	cfg.Encryption = configs.EncryptionConfig{
+		Mode:        configs.EncryptionModeNone,
+		Rules:       []configs.EncryptionRule{{Mode: configs.EncryptionModeEnvelope, Buckets: []string{"primary"}}},
+		MasterKeys:  []configs.MasterKeyConfig{{ID: "k1", Env: "NV_TEST_KEY_1"}},
+		ChunkSizeKB: 1,
+	}
+
+	mem := s3.NewMemoryStore(false, "b")
+
+	st, err := s3.NewEncryptedStore(mem, &cfg.Encryption)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	put(t, st, "u/before.txt", "secret")
+
+	if raw, _ := read(t, mem, "u/before.txt", minio.GetObjectOptions{}); raw != "secret" {
+		t.Fatalf("unmapped bucket matched primary bucket rule")
+	}
+
+	st.SetRuleBuckets(map[string]string{"b": "primary"})
+	put(t, st, "u/after.txt", "secret")
+
+	if raw, _ := read(t, mem, "u/after.txt", minio.GetObjectOptions{}); raw == "secret" {
+		t.Fatalf("replica bucket did not use primary bucket rule")
+	}
+
+	if got, err := read(t, st, "u/after.txt", minio.GetObjectOptions{}); err != nil || got != "secret" {
+		t.Fatalf("read = %q, %v", got, err)
+	}
+}
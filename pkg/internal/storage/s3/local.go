@@ -0,0 +1,739 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// 本地驱动中对象数据与元数据文件的后缀：对象键 a/b.md 存为 <root>/<bucket>/a/b.md.nvdata 与 b.md.nvmeta，
+// 以 "/" 结尾的目录标记对象 a/ 存为 a/.nvdata；加后缀避免 a/b 与 a/b/c 同时存在时文件与目录冲突.
+const (
+	localDataSuffix = ".nvdata"
+	localMetaSuffix = ".nvmeta"
+	localTempSuffix = ".nvtmp"
+)
+
+// LocalStore 基于本地磁盘的对象存储实现，不支持原生版本化（由服务层模拟）.
+// 预签名 URL 指向 notevault 的签名接口，由 ServeObject / ServePostPolicy 处理.
+type LocalStore struct {
+	root      string
+	publicURL string
+	secret    []byte
+
+	mu sync.RWMutex // 保证数据与元数据文件成对替换
+}
+
+var _ ObjectStore = (*LocalStore)(nil)
+
+// localMeta 对象元数据文件内容.
+type localMeta struct {
+	ContentType  string            `json:"content_type"`
+	ETag         string            `json:"etag"`
+	Size         int64             `json:"size"`
+	LastModified time.Time         `json:"last_modified"`
+	StorageClass string            `json:"storage_class,omitempty"`
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+}
+
+// NewLocalStore 创建本地磁盘存储并确保所有 bucket 目录存在.
+func NewLocalStore(cfg configs.S3Config) (*LocalStore, error) {
+	root, err := filepath.Abs(cfg.Local.RootDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve local store root: %w", err)
+	}
+
+	secret := []byte(cfg.Local.SigningSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := crand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generate signing secret: %w", err)
+		}
+
+		nlog.Logger().Warn().Msg("s3.local.signing_secret not set, presigned URLs become invalid after restart")
+	}
+
+	for _, bkt := range cfg.Buckets {
+		if bkt == "" {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Join(root, bkt), 0o750); err != nil {
+			return nil, fmt.Errorf("create bucket %s: %w", bkt, err)
+		}
+	}
+
+	nlog.Logger().Info().Str("root", root).Int("bucket_count", len(cfg.Buckets)).Msg("local object store ready")
+
+	return &LocalStore{root: root, publicURL: strings.TrimRight(cfg.Local.PublicURL, "/"), secret: secret}, nil
+}
+
+// PutObject 写入对象：先写临时文件再重命名，写入过程中计算 MD5 作为 ETag.
+func (l *LocalStore) PutObject(ctx context.Context, bucket, objectKey string, reader io.Reader, size int64,
+	opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	if reader == nil { // 目录标记等空对象
+		reader = bytes.NewReader(nil)
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	meta := localMeta{ContentType: contentType, StorageClass: opts.StorageClass, UserMetadata: canonicalMetadata(opts.UserMetadata)}
+
+	return l.write(ctx, bucket, objectKey, reader, size, &meta)
+}
+
+// GetObject 读取对象，支持 Range 与 If-Match.
+func (l *LocalStore) GetObject(ctx context.Context, bucket, objectKey string, opts minio.GetObjectOptions) (Object, error) {
+	if err := checkVersion(bucket, objectKey, opts.VersionID); err != nil {
+		return nil, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	dataPath, metaPath, err := l.paths(bucket, objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := readMeta(metaPath)
+	if err != nil {
+		return nil, notFoundOr(err, bucket, objectKey)
+	}
+
+	header := opts.Header()
+	if m := strings.Trim(header.Get("If-Match"), "\""); m != "" && m != meta.ETag {
+		return nil, errorResponse(http.StatusPreconditionFailed, "PreconditionFailed", bucket, objectKey,
+			"At least one of the pre-conditions you specified did not hold")
+	}
+
+	start, length, err := parseRange(header.Get("Range"), meta.Size)
+	if err != nil {
+		return nil, errorResponse(http.StatusRequestedRangeNotSatisfiable, "InvalidRange", bucket, objectKey, err.Error())
+	}
+
+	f, err := os.Open(dataPath)
+	if err != nil {
+		return nil, notFoundOr(err, bucket, objectKey)
+	}
+
+	return &localObject{SectionReader: io.NewSectionReader(f, start, length), f: f, info: meta.objectInfo(objectKey)}, nil
+}
+
+// StatObject 获取对象信息.
+func (l *LocalStore) StatObject(ctx context.Context, bucket, objectKey string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	if err := checkVersion(bucket, objectKey, opts.VersionID); err != nil {
+		return minio.ObjectInfo{}, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, metaPath, err := l.paths(bucket, objectKey)
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+
+	meta, err := readMeta(metaPath)
+	if err != nil {
+		return minio.ObjectInfo{}, notFoundOr(err, bucket, objectKey)
+	}
+
+	return meta.objectInfo(objectKey), nil
+}
+
+// ListObjects 按前缀列举对象；非递归时将下一级目录合并为以 "/" 结尾的公共前缀.
+func (l *LocalStore) ListObjects(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	ch := make(chan minio.ObjectInfo, 1)
+
+	go func() {
+		defer close(ch)
+
+		infos, err := l.list(bucket, opts)
+		if err != nil {
+			infos = []minio.ObjectInfo{{Err: err}}
+		}
+
+		for _, info := range infos {
+			select {
+			case ch <- info:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// CopyObject 复制对象；ReplaceMetadata 或指定了 UserMetadata 时使用目标元数据.
+func (l *LocalStore) CopyObject(ctx context.Context, dst minio.CopyDestOptions, src minio.CopySrcOptions) (minio.UploadInfo, error) {
+	obj, err := l.GetObject(ctx, src.Bucket, src.Object, minio.GetObjectOptions{VersionID: src.VersionID})
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	defer obj.Close()
+
+	info, _ := obj.Stat()
+	if src.MatchETag != "" && strings.Trim(src.MatchETag, "\"") != info.ETag {
+		return minio.UploadInfo{}, errorResponse(http.StatusPreconditionFailed, "PreconditionFailed", src.Bucket, src.Object,
+			"At least one of the pre-conditions you specified did not hold")
+	}
+
+	// 与 S3 一致：复制时未指定存储类型则使用默认存储类型
+	meta := localMeta{ContentType: info.ContentType, UserMetadata: info.UserMetadata}
+	if dst.ReplaceMetadata || len(dst.UserMetadata) > 0 {
+		var userMeta map[string]string
+
+		meta.StorageClass, userMeta = splitStorageClass(dst.UserMetadata)
+		meta.UserMetadata = canonicalMetadata(userMeta)
+
+		if dst.ContentType != "" {
+			meta.ContentType = dst.ContentType
+		}
+	}
+
+	return l.write(ctx, dst.Bucket, dst.Object, obj, info.Size, &meta)
+}
+
+// RemoveObject 删除对象，对象不存在时不报错（与 S3 一致）.
+func (l *LocalStore) RemoveObject(ctx context.Context, bucket, objectKey string, opts minio.RemoveObjectOptions) error {
+	if err := checkVersion(bucket, objectKey, opts.VersionID); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	dataPath, metaPath, err := l.paths(bucket, objectKey)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range []string{metaPath, dataPath} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove %s: %w", objectKey, err)
+		}
+	}
+
+	l.pruneEmptyDirs(bucket, filepath.Dir(dataPath))
+
+	return nil
+}
+
+// ListIncompleteUploads 本地存储没有分片上传，将写入中断后残留的临时文件作为未完成上传返回，
+// Key 与 UploadID 均为临时文件相对 bucket 的路径，Initiated 为文件修改时间；总是递归列举.
+func (l *LocalStore) ListIncompleteUploads(ctx context.Context, bucket, prefix string,
+	recursive bool) <-chan minio.ObjectMultipartInfo {
+	ch := make(chan minio.ObjectMultipartInfo, 1)
+
+	go func() {
+		defer close(ch)
+
+		uploads, err := l.listTemp(bucket, prefix)
+		if err != nil {
+			uploads = []minio.ObjectMultipartInfo{{Err: err}}
+		}
+
+		for _, u := range uploads {
+			select {
+			case ch <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// RemoveIncompleteUpload 删除 ListIncompleteUploads 返回的残留临时文件.
+func (l *LocalStore) RemoveIncompleteUpload(ctx context.Context, bucket, objectKey string) error {
+	dir, err := l.bucketDir(bucket)
+	if err != nil {
+		return err
+	}
+
+	p := filepath.Join(dir, filepath.FromSlash(objectKey))
+	if !strings.HasSuffix(objectKey, localTempSuffix) || !strings.HasPrefix(p, dir+string(filepath.Separator)) {
+		return errorResponse(http.StatusNotFound, "NoSuchUpload", bucket, objectKey, "The specified multipart upload does not exist.")
+	}
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove %s: %w", objectKey, err)
+	}
+
+	l.pruneEmptyDirs(bucket, filepath.Dir(p))
+
+	return nil
+}
+
+// listTemp 列举 bucket 中前缀下的残留临时文件.
+func (l *LocalStore) listTemp(bucket, prefix string) ([]minio.ObjectMultipartInfo, error) {
+	dir, err := l.bucketDir(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	base := dir
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		base = filepath.Join(dir, filepath.FromSlash(prefix[:i]))
+	}
+
+	var uploads []minio.ObjectMultipartInfo
+
+	err = filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if d.IsDir() || !strings.HasSuffix(p, localTempSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return nil //nolint:nilerr // 文件在遍历期间被写入完成并重命名
+		}
+
+		uploads = append(uploads, minio.ObjectMultipartInfo{
+			Key: key, UploadID: key, Initiated: fi.ModTime().UTC(), Size: fi.Size(), StorageClass: "STANDARD",
+		})
+
+		return nil
+	})
+
+	return uploads, err
+}
+
+// GetBucketVersioning 本地存储不支持版本化，返回未启用.
+func (l *LocalStore) GetBucketVersioning(ctx context.Context, bucket string) (minio.BucketVersioningConfiguration, error) {
+	if _, err := l.bucketDir(bucket); err != nil {
+		return minio.BucketVersioningConfiguration{}, err
+	}
+
+	return minio.BucketVersioningConfiguration{}, nil
+}
+
+// ObjectLockEnabled 本地存储不支持对象锁定.
+func (l *LocalStore) ObjectLockEnabled(ctx context.Context, bucket string) (bool, error) {
+	if _, err := l.bucketDir(bucket); err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+// PutObjectLegalHold 本地存储不支持对象锁定.
+func (l *LocalStore) PutObjectLegalHold(ctx context.Context, bucket, objectKey string, opts minio.PutObjectLegalHoldOptions) error {
+	return errorResponse(http.StatusNotImplemented, "NotImplemented", bucket, objectKey, "object lock is not supported by local storage")
+}
+
+// PutObjectRetention 本地存储不支持对象锁定.
+func (l *LocalStore) PutObjectRetention(ctx context.Context, bucket, objectKey string, opts minio.PutObjectRetentionOptions) error {
+	return errorResponse(http.StatusNotImplemented, "NotImplemented", bucket, objectKey, "object lock is not supported by local storage")
+}
+
+// HealthCheck 检查根目录可访问.
+func (l *LocalStore) HealthCheck(ctx context.Context) error {
+	_, err := os.Stat(l.root)
+	return err
+}
+
+// Close 无需释放资源.
+func (l *LocalStore) Close() error {
+	return nil
+}
+
+// write 将 reader 的内容与元数据写入对象；size >= 0 时要求读取的字节数一致.
+func (l *LocalStore) write(ctx context.Context, bucket, objectKey string, reader io.Reader, size int64,
+	meta *localMeta) (minio.UploadInfo, error) {
+	dataPath, metaPath, err := l.paths(bucket, objectKey)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0o750); err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("create object dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dataPath), "*"+localTempSuffix)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("create temp file: %w", err)
+	}
+
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	h := md5.New()
+
+	n, err := io.Copy(io.MultiWriter(tmp, h), ctxReader{ctx: ctx, r: reader})
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("write %s: %w", objectKey, err)
+	}
+
+	if size >= 0 && n != size {
+		return minio.UploadInfo{}, errorResponse(http.StatusBadRequest, "IncompleteBody", bucket, objectKey,
+			fmt.Sprintf("expected %d bytes, got %d", size, n))
+	}
+
+	meta.Size, meta.ETag, meta.LastModified = n, hex.EncodeToString(h.Sum(nil)), time.Now().UTC()
+
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	metaTmp := metaPath + localTempSuffix
+	if err := os.WriteFile(metaTmp, metaBytes, 0o640); err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("write metadata: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.Rename(tmp.Name(), dataPath); err != nil {
+		_ = os.Remove(metaTmp)
+		return minio.UploadInfo{}, fmt.Errorf("commit %s: %w", objectKey, err)
+	}
+
+	if err := os.Rename(metaTmp, metaPath); err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("commit metadata: %w", err)
+	}
+
+	return minio.UploadInfo{
+		Bucket:       bucket,
+		Key:          objectKey,
+		ETag:         meta.ETag,
+		Size:         n,
+		LastModified: meta.LastModified,
+	}, nil
+}
+
+// list 收集 bucket 中匹配前缀的对象并按键排序.
+func (l *LocalStore) list(bucket string, opts minio.ListObjectsOptions) ([]minio.ObjectInfo, error) {
+	dir, err := l.bucketDir(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只遍历前缀所在的目录
+	base := dir
+	if i := strings.LastIndex(opts.Prefix, "/"); i >= 0 {
+		base = filepath.Join(dir, filepath.FromSlash(opts.Prefix[:i]))
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var infos []minio.ObjectInfo
+
+	err = filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if d.IsDir() || !strings.HasSuffix(p, localDataSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, strings.TrimSuffix(p, localDataSuffix))
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if strings.HasSuffix(p, string(filepath.Separator)+localDataSuffix) {
+			key += "/"
+		}
+
+		if !strings.HasPrefix(key, opts.Prefix) || (opts.StartAfter != "" && key <= opts.StartAfter) {
+			return nil
+		}
+
+		meta, err := readMeta(strings.TrimSuffix(p, localDataSuffix) + localMetaSuffix)
+		if err != nil {
+			return nil //nolint:nilerr // 写入中或残缺的对象跳过
+		}
+
+		info := meta.objectInfo(key)
+		info.IsLatest = true
+		infos = append(infos, info)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list %s/%s: %w", bucket, opts.Prefix, err)
+	}
+
+	slices.SortFunc(infos, func(a, b minio.ObjectInfo) int { return strings.Compare(a.Key, b.Key) })
+
+	if !opts.Recursive {
+		infos = collapsePrefixes(infos, opts.Prefix)
+	}
+
+	return infos, nil
+}
+
+// collapsePrefixes 将前缀之后还包含 "/" 的对象合并为公共前缀.
+func collapsePrefixes(infos []minio.ObjectInfo, prefix string) []minio.ObjectInfo {
+	out := infos[:0]
+	last := ""
+
+	for _, info := range infos {
+		rest := strings.TrimPrefix(info.Key, prefix)
+
+		i := strings.Index(rest, "/")
+		if i < 0 {
+			out = append(out, info)
+			continue
+		}
+
+		if p := prefix + rest[:i+1]; p != last {
+			out = append(out, minio.ObjectInfo{Key: p})
+			last = p
+		}
+	}
+
+	return out
+}
+
+// paths 返回对象的数据与元数据文件路径.
+func (l *LocalStore) paths(bucket, objectKey string) (dataPath, metaPath string, err error) {
+	dir, err := l.bucketDir(bucket)
+	if err != nil {
+		return "", "", err
+	}
+
+	segments := strings.Split(objectKey, "/")
+	for i, s := range segments {
+		last := i == len(segments)-1
+		if (s == "" && !(last && i > 0)) || s == "." || s == ".." || strings.ContainsRune(s, 0) ||
+			strings.HasSuffix(s, localDataSuffix) || strings.HasSuffix(s, localMetaSuffix) || strings.HasSuffix(s, localTempSuffix) {
+			return "", "", errorResponse(http.StatusBadRequest, "XMinioInvalidObjectName", bucket, objectKey, "Object name contains unsupported characters.")
+		}
+	}
+
+	base := filepath.Join(dir, filepath.FromSlash(objectKey))
+	if strings.HasSuffix(objectKey, "/") {
+		base += string(filepath.Separator)
+	}
+
+	return base + localDataSuffix, base + localMetaSuffix, nil
+}
+
+// bucketDir 返回 bucket 目录，不存在时返回 NoSuchBucket.
+func (l *LocalStore) bucketDir(bucket string) (string, error) {
+	if bucket == "" || strings.ContainsAny(bucket, `/\`) || bucket == "." || bucket == ".." {
+		return "", errorResponse(http.StatusBadRequest, "InvalidBucketName", bucket, "", "The specified bucket is not valid.")
+	}
+
+	dir := filepath.Join(l.root, bucket)
+	if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+		return "", errorResponse(http.StatusNotFound, "NoSuchBucket", bucket, "", "The specified bucket does not exist")
+	}
+
+	return dir, nil
+}
+
+// pruneEmptyDirs 删除对象后清理空目录，直到 bucket 目录为止.
+func (l *LocalStore) pruneEmptyDirs(bucket, dir string) {
+	stop := filepath.Join(l.root, bucket)
+
+	for dir != stop && strings.HasPrefix(dir, stop) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+
+		dir = filepath.Dir(dir)
+	}
+}
+
+func (m *localMeta) objectInfo(key string) minio.ObjectInfo {
+	return minio.ObjectInfo{
+		Key:          key,
+		Size:         m.Size,
+		ETag:         m.ETag,
+		ContentType:  m.ContentType,
+		LastModified: m.LastModified,
+		StorageClass: m.StorageClass,
+		UserMetadata: maps.Clone(m.UserMetadata),
+		Metadata:     http.Header{"Content-Type": []string{m.ContentType}},
+	}
+}
+
+func readMeta(p string) (*localMeta, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var m localMeta
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("decode metadata %s: %w", p, err)
+	}
+
+	return &m, nil
+}
+
+// canonicalMetadata 按 HTTP 头规范化用户元数据的键（与 S3 返回的格式一致）.
+func canonicalMetadata(in map[string]string) map[string]string {
+	if len(in) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		k = strings.TrimPrefix(strings.ToLower(k), "x-amz-meta-")
+		out[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+
+	return out
+}
+
+// splitStorageClass 取出目标元数据中的存储类型（StorageClassMetaKey），返回存储类型与其余元数据.
+func splitStorageClass(in map[string]string) (string, map[string]string) {
+	var class string
+
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		if strings.EqualFold(k, StorageClassMetaKey) {
+			class = v
+			continue
+		}
+
+		out[k] = v
+	}
+
+	return class, out
+}
+
+// checkVersion 本地存储只有当前版本，其他版本 ID 返回 NoSuchVersion.
+func checkVersion(bucket, objectKey, versionID string) error {
+	if versionID == "" || versionID == "null" {
+		return nil
+	}
+
+	return errorResponse(http.StatusNotFound, "NoSuchVersion", bucket, objectKey, "The specified version does not exist.")
+}
+
+// parseRange 解析单个 bytes 区间，返回起始位置与长度；未指定时返回整个对象.
+func parseRange(header string, size int64) (start, length int64, err error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, size, nil
+	}
+
+	from, to, _ := strings.Cut(spec, "-")
+
+	switch {
+	case from == "":
+		n, err := strconv.ParseInt(to, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+
+		n = min(n, size)
+
+		return size - n, n, nil
+	default:
+		start, err = strconv.ParseInt(from, 10, 64)
+		if err != nil || start < 0 || start >= size {
+			return 0, 0, fmt.Errorf("invalid range %q", header)
+		}
+
+		end := size - 1
+		if to != "" {
+			if end, err = strconv.ParseInt(to, 10, 64); err != nil || end < start {
+				return 0, 0, fmt.Errorf("invalid range %q", header)
+			}
+
+			end = min(end, size-1)
+		}
+
+		return start, end - start + 1, nil
+	}
+}
+
+func notFoundOr(err error, bucket, objectKey string) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return errorResponse(http.StatusNotFound, "NoSuchKey", bucket, objectKey, "The specified key does not exist.")
+	}
+
+	return err
+}
+
+func errorResponse(status int, code, bucket, objectKey, msg string) minio.ErrorResponse {
+	return minio.ErrorResponse{StatusCode: status, Code: code, Message: msg, BucketName: bucket, Key: objectKey}
+}
+
+// localObject GetObject 返回的对象内容.
+type localObject struct {
+	*io.SectionReader
+	f    *os.File
+	info minio.ObjectInfo
+}
+
+func (o *localObject) Stat() (minio.ObjectInfo, error) { return o.info, nil }
+
+func (o *localObject) Close() error { return o.f.Close() }
+
+// ctxReader 在读取时检查 context 取消.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return c.r.Read(p)
+}
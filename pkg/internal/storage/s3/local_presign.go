@@ -0,0 +1,456 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// LocalObjectsPath 本地驱动签名接口的路径（相对服务根地址），对象地址为 <path>/<bucket>/<key>.
+const LocalObjectsPath = "/api/v1/storage/objects"
+
+// 本地驱动预签名 URL 的查询参数与 POST 表单字段.
+const (
+	localExpiresParam   = "X-Nv-Expires"
+	localSignatureParam = "X-Nv-Signature"
+	localPolicyField    = "policy"
+	localSignatureField = "x-nv-signature"
+)
+
+var (
+	errSignatureMismatch = errors.New("signature does not match")
+	errRequestExpired    = errors.New("request has expired")
+)
+
+// PresignedGetObject 生成指向签名接口的下载 URL.
+func (l *LocalStore) PresignedGetObject(ctx context.Context, bucket, objectKey string, expires time.Duration,
+	reqParams url.Values) (*url.URL, error) {
+	return l.presign(http.MethodGet, bucket, objectKey, expires, reqParams)
+}
+
+// PresignedPutObject 生成指向签名接口的上传 URL.
+func (l *LocalStore) PresignedPutObject(ctx context.Context, bucket, objectKey string, expires time.Duration) (*url.URL, error) {
+	return l.presign(http.MethodPut, bucket, objectKey, expires, nil)
+}
+
+// PresignedPostPolicy 生成表单上传地址：表单中携带 base64 编码的策略及其签名，由 ServePostPolicy 校验.
+func (l *LocalStore) PresignedPostPolicy(ctx context.Context, policy *minio.PostPolicy) (*url.URL, map[string]string, error) {
+	p, err := parsePostPolicy(policy.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bucket := p.value("bucket")
+	if bucket == "" {
+		return nil, nil, errors.New("bucket name must be specified")
+	}
+
+	if _, ok := p.fields["key"]; !ok {
+		return nil, nil, errors.New("object key must be specified")
+	}
+
+	if _, err := l.bucketDir(bucket); err != nil {
+		return nil, nil, err
+	}
+
+	u, err := url.Parse(l.publicURL + LocalObjectsPath + "/" + url.PathEscape(bucket))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(policy.String()))
+
+	formData := make(map[string]string, len(p.fields)+2)
+	for field, cond := range p.fields {
+		if field != "bucket" {
+			formData[cond.name] = cond.value
+		}
+	}
+
+	formData[localPolicyField] = encoded
+	formData[localSignatureField] = l.sign(encoded)
+
+	return u, formData, nil
+}
+
+// ServeObject 处理预签名 URL 的请求：GET/HEAD 下载（支持 Range 与条件请求），PUT 上传.
+func (l *LocalStore) ServeObject(w http.ResponseWriter, r *http.Request, bucket, objectKey string) {
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+
+	if method != http.MethodGet && method != http.MethodPut {
+		writeError(w, errorResponse(http.StatusMethodNotAllowed, "MethodNotAllowed", bucket, objectKey,
+			"The specified method is not allowed against this resource."))
+		return
+	}
+
+	if err := l.verifyQuery(method, bucket, objectKey, r.URL.Query()); err != nil {
+		writeError(w, errorResponse(http.StatusForbidden, "AccessDenied", bucket, objectKey, err.Error()))
+		return
+	}
+
+	if method == http.MethodPut {
+		l.servePut(w, r, bucket, objectKey)
+		return
+	}
+
+	obj, err := l.GetObject(r.Context(), bucket, objectKey, minio.GetObjectOptions{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer obj.Close()
+
+	info, _ := obj.Stat()
+	h := w.Header()
+	h.Set("ETag", `"`+info.ETag+`"`)
+	h.Set("Content-Type", info.ContentType)
+
+	for k, v := range info.UserMetadata {
+		h.Set("X-Amz-Meta-"+k, v)
+	}
+
+	// response-* 参数覆盖响应头（与 S3 预签名下载一致）
+	q := r.URL.Query()
+	for param, header := range responseHeaderParams {
+		if v := q.Get(param); v != "" {
+			h.Set(header, v)
+		}
+	}
+
+	http.ServeContent(w, r, "", info.LastModified, obj)
+}
+
+// ServePostPolicy 处理 PresignedPostPolicy 生成的表单上传：校验签名、过期时间与策略条件后写入对象.
+// 文件字段必须位于表单最后（与 S3 一致），内容以流的方式写入.
+func (l *LocalStore) ServePostPolicy(w http.ResponseWriter, r *http.Request, bucket string) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, errorResponse(http.StatusBadRequest, "MalformedPOSTRequest", bucket, "", err.Error()))
+		return
+	}
+
+	fields := make(map[string]string)
+
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			msg := "POST requires exactly one file upload per request."
+			if !errors.Is(err, io.EOF) {
+				msg = err.Error()
+			}
+
+			writeError(w, errorResponse(http.StatusBadRequest, "MalformedPOSTRequest", bucket, "", msg))
+
+			return
+		}
+
+		name := strings.ToLower(part.FormName())
+		if name != "file" {
+			b, err := io.ReadAll(io.LimitReader(part, 1<<20))
+			_ = part.Close()
+
+			if err != nil {
+				writeError(w, errorResponse(http.StatusBadRequest, "MalformedPOSTRequest", bucket, "", err.Error()))
+				return
+			}
+
+			fields[name] = string(b)
+
+			continue
+		}
+
+		l.servePostFile(w, r, bucket, fields, part)
+		_ = part.Close()
+
+		return
+	}
+}
+
+func (l *LocalStore) servePostFile(w http.ResponseWriter, r *http.Request, bucket string, fields map[string]string, file io.Reader) {
+	objectKey := fields["key"]
+
+	p, err := l.verifyPolicy(bucket, fields)
+	if err != nil {
+		writeError(w, errorResponse(http.StatusForbidden, "AccessDenied", bucket, objectKey, err.Error()))
+		return
+	}
+
+	contentType := fields["content-type"]
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	meta := make(map[string]string)
+
+	for k, v := range fields {
+		if name, ok := strings.CutPrefix(k, "x-amz-meta-"); ok {
+			meta[name] = v
+		}
+	}
+
+	body := &rangeReader{r: file, min: p.minSize, max: p.maxSize}
+
+	info, err := l.PutObject(r.Context(), bucket, objectKey, body, -1,
+		minio.PutObjectOptions{ContentType: contentType, UserMetadata: meta})
+	if err != nil {
+		if body.err != nil {
+			err = errorResponse(http.StatusBadRequest, "EntityTooSmall", bucket, objectKey, body.err.Error())
+			if p.maxSize >= 0 && body.n > p.maxSize {
+				err = errorResponse(http.StatusBadRequest, "EntityTooLarge", bucket, objectKey, body.err.Error())
+			}
+		}
+
+		writeError(w, err)
+
+		return
+	}
+
+	w.Header().Set("ETag", `"`+info.ETag+`"`)
+	w.Header().Set("Location", l.publicURL+LocalObjectsPath+"/"+url.PathEscape(bucket)+"/"+escapeKey(objectKey))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (l *LocalStore) servePut(w http.ResponseWriter, r *http.Request, bucket, objectKey string) {
+	meta := make(map[string]string)
+
+	for k, v := range r.Header {
+		if name, ok := strings.CutPrefix(strings.ToLower(k), "x-amz-meta-"); ok && len(v) > 0 {
+			meta[name] = v[0]
+		}
+	}
+
+	info, err := l.PutObject(r.Context(), bucket, objectKey, r.Body, r.ContentLength,
+		minio.PutObjectOptions{ContentType: r.Header.Get("Content-Type"), UserMetadata: meta})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+info.ETag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// presign 生成带过期时间与签名的对象 URL.
+func (l *LocalStore) presign(method, bucket, objectKey string, expires time.Duration, reqParams url.Values) (*url.URL, error) {
+	if _, _, err := l.paths(bucket, objectKey); err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	for k, v := range reqParams {
+		q[k] = append([]string(nil), v...)
+	}
+
+	q.Set(localExpiresParam, strconv.FormatInt(time.Now().Add(expires).Unix(), 10))
+	q.Set(localSignatureParam, l.sign(method, bucket, objectKey, q.Encode()))
+
+	u, err := url.Parse(l.publicURL + LocalObjectsPath + "/" + url.PathEscape(bucket) + "/" + escapeKey(objectKey))
+	if err != nil {
+		return nil, err
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u, nil
+}
+
+// verifyQuery 校验预签名 URL 的签名与过期时间.
+func (l *LocalStore) verifyQuery(method, bucket, objectKey string, q url.Values) error {
+	sig := q.Get(localSignatureParam)
+	q.Del(localSignatureParam)
+
+	if !hmac.Equal([]byte(sig), []byte(l.sign(method, bucket, objectKey, q.Encode()))) {
+		return errSignatureMismatch
+	}
+
+	exp, err := strconv.ParseInt(q.Get(localExpiresParam), 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return errRequestExpired
+	}
+
+	return nil
+}
+
+// verifyPolicy 校验表单中的策略签名、过期时间以及策略中的全部条件.
+func (l *LocalStore) verifyPolicy(bucket string, fields map[string]string) (*postPolicy, error) {
+	encoded := fields[localPolicyField]
+	if !hmac.Equal([]byte(fields[localSignatureField]), []byte(l.sign(encoded))) {
+		return nil, errSignatureMismatch
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode policy: %w", err)
+	}
+
+	p, err := parsePostPolicy(string(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(p.expiration) {
+		return nil, errRequestExpired
+	}
+
+	if p.value("bucket") != bucket {
+		return nil, errors.New("policy condition failed: bucket")
+	}
+
+	for field, cond := range p.fields {
+		if field == "bucket" {
+			continue
+		}
+
+		v := fields[field]
+		if (cond.match == "eq" && v != cond.value) || (cond.match == "starts-with" && !strings.HasPrefix(v, cond.value)) {
+			return nil, fmt.Errorf("policy condition failed: %s", cond.name)
+		}
+	}
+
+	if fields["key"] == "" {
+		return nil, errors.New("object key must be specified")
+	}
+
+	return p, nil
+}
+
+// sign 计算 HMAC-SHA256 签名，各部分以换行分隔.
+func (l *LocalStore) sign(parts ...string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(strings.Join(parts, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// responseHeaderParams 预签名下载支持的响应头覆盖参数.
+var responseHeaderParams = map[string]string{
+	"response-content-type":        "Content-Type",
+	"response-content-disposition": "Content-Disposition",
+	"response-content-language":    "Content-Language",
+	"response-content-encoding":    "Content-Encoding",
+	"response-cache-control":       "Cache-Control",
+	"response-expires":             "Expires",
+}
+
+// postPolicy 解析后的表单上传策略.
+type postPolicy struct {
+	expiration time.Time
+	fields     map[string]policyCondition // 以小写字段名为键
+	minSize    int64
+	maxSize    int64
+}
+
+type policyCondition struct {
+	match string
+	name  string
+	value string
+}
+
+func (p *postPolicy) value(field string) string {
+	return p.fields[field].value
+}
+
+// parsePostPolicy 解析 minio.PostPolicy 序列化的 JSON 策略.
+func parsePostPolicy(s string) (*postPolicy, error) {
+	var raw struct {
+		Expiration time.Time `json:"expiration"`
+		Conditions [][]any   `json:"conditions"`
+	}
+
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, fmt.Errorf("parse post policy: %w", err)
+	}
+
+	if raw.Expiration.IsZero() {
+		return nil, errors.New("expiration time must be specified")
+	}
+
+	p := &postPolicy{expiration: raw.Expiration, fields: make(map[string]policyCondition), maxSize: -1}
+
+	for _, c := range raw.Conditions {
+		if len(c) != 3 {
+			return nil, fmt.Errorf("invalid policy condition: %v", c)
+		}
+
+		match, _ := c[0].(string)
+		if match == "content-length-range" {
+			lo, _ := c[1].(float64)
+			hi, _ := c[2].(float64)
+			p.minSize, p.maxSize = int64(lo), int64(hi)
+
+			continue
+		}
+
+		name, _ := c[1].(string)
+		value, _ := c[2].(string)
+		name = strings.TrimPrefix(name, "$")
+		p.fields[strings.ToLower(name)] = policyCondition{match: match, name: name, value: value}
+	}
+
+	return p, nil
+}
+
+// rangeReader 在读取过程中校验内容长度位于 [min, max] 区间（max < 0 表示不限制）.
+type rangeReader struct {
+	r        io.Reader
+	n        int64
+	min, max int64
+	err      error
+}
+
+func (c *rangeReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	switch {
+	case c.max >= 0 && c.n > c.max:
+		c.err = fmt.Errorf("your proposed upload exceeds the maximum allowed size %d", c.max)
+		return n, c.err
+	case errors.Is(err, io.EOF) && c.n < c.min:
+		c.err = fmt.Errorf("your proposed upload is smaller than the minimum allowed size %d", c.min)
+		return n, c.err
+	}
+
+	return n, err
+}
+
+// escapeKey 逐段转义对象键，保留 "/".
+func escapeKey(objectKey string) string {
+	segments := strings.Split(objectKey, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// writeError 以 S3 兼容的 XML 格式输出错误.
+func writeError(w http.ResponseWriter, err error) {
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "" || resp.StatusCode == 0 {
+		resp = errorResponse(http.StatusInternalServerError, "InternalError", "", "", err.Error())
+	}
+
+	body, _ := xml.Marshal(resp)
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(append([]byte(xml.Header), body...))
+}
@@ -0,0 +1,535 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// MemoryStore 线程安全的内存对象存储，支持按 bucket 开启版本化（含删除标记），
+// 用于不依赖外部服务的测试. 预签名 URL 只用于展示，不能实际访问.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]*memoryBucket
+	seq     uint64 // 版本 ID 与修改时间的单调计数
+}
+
+var _ ObjectStore = (*MemoryStore)(nil)
+
+type memoryBucket struct {
+	versioned bool
+	objects   map[string][]*memoryVersion // 按写入顺序，最后一个为最新版本
+}
+
+type memoryVersion struct {
+	id           string
+	data         []byte
+	deleteMarker bool
+	info         minio.ObjectInfo
+}
+
+// NewMemoryStore 创建内存存储，versioned 为 true 时所有 bucket 启用版本化.
+func NewMemoryStore(versioned bool, buckets ...string) *MemoryStore {
+	m := &MemoryStore{buckets: make(map[string]*memoryBucket, len(buckets))}
+	for _, b := range buckets {
+		m.buckets[b] = &memoryBucket{versioned: versioned, objects: make(map[string][]*memoryVersion)}
+	}
+
+	return m
+}
+
+// PutObject 写入对象；版本化的 bucket 追加新版本，否则替换.
+func (m *MemoryStore) PutObject(ctx context.Context, bucket, objectKey string, reader io.Reader, size int64,
+	opts minio.PutObjectOptions) (minio.UploadInfo, error) {
+	if reader == nil { // 目录标记等空对象
+		reader = bytes.NewReader(nil)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return minio.UploadInfo{}, fmt.Errorf("read %s: %w", objectKey, err)
+	}
+
+	if size >= 0 && int64(len(data)) != size {
+		return minio.UploadInfo{}, errorResponse(http.StatusBadRequest, "IncompleteBody", bucket, objectKey,
+			fmt.Sprintf("expected %d bytes, got %d", size, len(data)))
+	}
+
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	v := m.addVersion(b, objectKey, data, contentType, opts.StorageClass, canonicalMetadata(opts.UserMetadata))
+
+	return minio.UploadInfo{
+		Bucket:       bucket,
+		Key:          objectKey,
+		ETag:         v.info.ETag,
+		Size:         v.info.Size,
+		LastModified: v.info.LastModified,
+		VersionID:    v.info.VersionID,
+	}, nil
+}
+
+// GetObject 读取对象（或指定版本），支持 Range 与 If-Match.
+func (m *MemoryStore) GetObject(ctx context.Context, bucket, objectKey string, opts minio.GetObjectOptions) (Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	v, err := m.find(bucket, objectKey, opts.VersionID)
+	if err != nil {
+		return nil, err
+	}
+
+	header := opts.Header()
+	if match := strings.Trim(header.Get("If-Match"), "\""); match != "" && match != v.info.ETag {
+		return nil, errorResponse(http.StatusPreconditionFailed, "PreconditionFailed", bucket, objectKey,
+			"At least one of the pre-conditions you specified did not hold")
+	}
+
+	start, length, err := parseRange(header.Get("Range"), v.info.Size)
+	if err != nil {
+		return nil, errorResponse(http.StatusRequestedRangeNotSatisfiable, "InvalidRange", bucket, objectKey, err.Error())
+	}
+
+	// 数据写入后不再修改，可以直接共享底层切片
+	return &memoryObject{SectionReader: io.NewSectionReader(bytes.NewReader(v.data), start, length), info: v.objectInfo()}, nil
+}
+
+// StatObject 获取对象（或指定版本）信息.
+func (m *MemoryStore) StatObject(ctx context.Context, bucket, objectKey string, opts minio.StatObjectOptions) (minio.ObjectInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	v, err := m.find(bucket, objectKey, opts.VersionID)
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+
+	return v.objectInfo(), nil
+}
+
+// ListObjects 按前缀列举对象；WithVersions 时按键升序、同一键内由新到旧返回全部版本与删除标记.
+func (m *MemoryStore) ListObjects(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo {
+	ch := make(chan minio.ObjectInfo, 1)
+
+	m.mu.RLock()
+	infos, err := m.list(bucket, opts)
+	m.mu.RUnlock()
+
+	if err != nil {
+		infos = []minio.ObjectInfo{{Err: err}}
+	}
+
+	go func() {
+		defer close(ch)
+
+		for _, info := range infos {
+			select {
+			case ch <- info:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// CopyObject 复制对象（或指定版本）；ReplaceMetadata 或指定了 UserMetadata 时使用目标元数据.
+func (m *MemoryStore) CopyObject(ctx context.Context, dst minio.CopyDestOptions, src minio.CopySrcOptions) (minio.UploadInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, err := m.find(src.Bucket, src.Object, src.VersionID)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	if src.MatchETag != "" && strings.Trim(src.MatchETag, "\"") != v.info.ETag {
+		return minio.UploadInfo{}, errorResponse(http.StatusPreconditionFailed, "PreconditionFailed", src.Bucket, src.Object,
+			"At least one of the pre-conditions you specified did not hold")
+	}
+
+	b, err := m.bucket(dst.Bucket)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+
+	// 与 S3 一致：复制时未指定存储类型则使用默认存储类型
+	var storageClass string
+
+	contentType, meta := v.info.ContentType, v.info.UserMetadata
+	if dst.ReplaceMetadata || len(dst.UserMetadata) > 0 {
+		storageClass, meta = splitStorageClass(dst.UserMetadata)
+		meta = canonicalMetadata(meta)
+
+		if dst.ContentType != "" {
+			contentType = dst.ContentType
+		}
+	}
+
+	nv := m.addVersion(b, dst.Object, v.data, contentType, storageClass, maps.Clone(meta))
+
+	return minio.UploadInfo{
+		Bucket:       dst.Bucket,
+		Key:          dst.Object,
+		ETag:         nv.info.ETag,
+		Size:         nv.info.Size,
+		LastModified: nv.info.LastModified,
+		VersionID:    nv.info.VersionID,
+	}, nil
+}
+
+// RemoveObject 删除对象：指定 VersionID 时永久删除该版本；版本化的 bucket 未指定时写入删除标记.
+func (m *MemoryStore) RemoveObject(ctx context.Context, bucket, objectKey string, opts minio.RemoveObjectOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return err
+	}
+
+	versions := b.objects[objectKey]
+
+	switch {
+	case opts.VersionID != "" && (b.versioned || opts.VersionID != "null"):
+		versions = slices.DeleteFunc(versions, func(v *memoryVersion) bool { return v.id == opts.VersionID })
+	case b.versioned:
+		m.seq++
+		id := m.versionID()
+		versions = append(versions, &memoryVersion{
+			id:           id,
+			deleteMarker: true,
+			info:         minio.ObjectInfo{Key: objectKey, VersionID: id, LastModified: m.modTime()},
+		})
+	default:
+		versions = nil
+	}
+
+	if len(versions) == 0 {
+		delete(b.objects, objectKey)
+	} else {
+		b.objects[objectKey] = versions
+	}
+
+	return nil
+}
+
+// PresignedGetObject 返回 memory:// 形式的占位 URL.
+func (m *MemoryStore) PresignedGetObject(ctx context.Context, bucket, objectKey string, expires time.Duration,
+	reqParams url.Values) (*url.URL, error) {
+	return m.presign(http.MethodGet, bucket, objectKey, expires, reqParams)
+}
+
+// PresignedPutObject 返回 memory:// 形式的占位 URL.
+func (m *MemoryStore) PresignedPutObject(ctx context.Context, bucket, objectKey string, expires time.Duration) (*url.URL, error) {
+	return m.presign(http.MethodPut, bucket, objectKey, expires, nil)
+}
+
+// PresignedPostPolicy 返回占位地址与策略中的表单字段.
+func (m *MemoryStore) PresignedPostPolicy(ctx context.Context, policy *minio.PostPolicy) (*url.URL, map[string]string, error) {
+	p, err := parsePostPolicy(policy.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bucket := p.value("bucket")
+
+	m.mu.RLock()
+	_, err = m.bucket(bucket)
+	m.mu.RUnlock()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, ok := p.fields["key"]; !ok {
+		return nil, nil, fmt.Errorf("object key must be specified")
+	}
+
+	formData := map[string]string{localPolicyField: policy.String()}
+	for field, cond := range p.fields {
+		if field != "bucket" {
+			formData[cond.name] = cond.value
+		}
+	}
+
+	return &url.URL{Scheme: "memory", Host: bucket, Path: "/"}, formData, nil
+}
+
+// ListIncompleteUploads 内存存储不支持分片上传，返回空结果.
+func (m *MemoryStore) ListIncompleteUploads(ctx context.Context, bucket, prefix string,
+	recursive bool) <-chan minio.ObjectMultipartInfo {
+	ch := make(chan minio.ObjectMultipartInfo, 1)
+
+	m.mu.RLock()
+	_, err := m.bucket(bucket)
+	m.mu.RUnlock()
+
+	if err != nil {
+		ch <- minio.ObjectMultipartInfo{Err: err}
+	}
+
+	close(ch)
+
+	return ch
+}
+
+// RemoveIncompleteUpload 内存存储不支持分片上传，总是成功.
+func (m *MemoryStore) RemoveIncompleteUpload(ctx context.Context, bucket, objectKey string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, err := m.bucket(bucket)
+
+	return err
+}
+
+// GetBucketVersioning 返回 bucket 的版本化状态.
+func (m *MemoryStore) GetBucketVersioning(ctx context.Context, bucket string) (minio.BucketVersioningConfiguration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return minio.BucketVersioningConfiguration{}, err
+	}
+
+	if b.versioned {
+		return minio.BucketVersioningConfiguration{Status: "Enabled"}, nil
+	}
+
+	return minio.BucketVersioningConfiguration{}, nil
+}
+
+// ObjectLockEnabled 内存存储不支持对象锁定.
+func (m *MemoryStore) ObjectLockEnabled(ctx context.Context, bucket string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, err := m.bucket(bucket); err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+// PutObjectLegalHold 内存存储不支持对象锁定.
+func (m *MemoryStore) PutObjectLegalHold(ctx context.Context, bucket, objectKey string, opts minio.PutObjectLegalHoldOptions) error {
+	return errorResponse(http.StatusNotImplemented, "NotImplemented", bucket, objectKey, "object lock is not supported by memory storage")
+}
+
+// PutObjectRetention 内存存储不支持对象锁定.
+func (m *MemoryStore) PutObjectRetention(ctx context.Context, bucket, objectKey string, opts minio.PutObjectRetentionOptions) error {
+	return errorResponse(http.StatusNotImplemented, "NotImplemented", bucket, objectKey, "object lock is not supported by memory storage")
+}
+
+// HealthCheck 内存存储始终可用.
+func (m *MemoryStore) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// Close 无需释放资源.
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// addVersion 写入新版本，调用方需持有写锁.
+func (m *MemoryStore) addVersion(b *memoryBucket, objectKey string, data []byte, contentType, storageClass string,
+	meta map[string]string) *memoryVersion {
+	m.seq++
+	sum := md5.Sum(data)
+
+	v := &memoryVersion{
+		id:   "null",
+		data: data,
+		info: minio.ObjectInfo{
+			Key:          objectKey,
+			Size:         int64(len(data)),
+			ETag:         hex.EncodeToString(sum[:]),
+			ContentType:  contentType,
+			LastModified: m.modTime(),
+			StorageClass: storageClass,
+			UserMetadata: meta,
+		},
+	}
+
+	if !b.versioned {
+		b.objects[objectKey] = []*memoryVersion{v}
+		return v
+	}
+
+	v.id = m.versionID()
+	v.info.VersionID = v.id
+	b.objects[objectKey] = append(b.objects[objectKey], v)
+
+	return v
+}
+
+// find 查找对象版本，versionID 为空时返回最新版本；调用方需持有锁.
+func (m *MemoryStore) find(bucket, objectKey, versionID string) (*memoryVersion, error) {
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := b.objects[objectKey]
+
+	if versionID == "" {
+		if len(versions) == 0 || versions[len(versions)-1].deleteMarker {
+			return nil, errorResponse(http.StatusNotFound, "NoSuchKey", bucket, objectKey, "The specified key does not exist.")
+		}
+
+		return versions[len(versions)-1], nil
+	}
+
+	for _, v := range versions {
+		if v.id != versionID {
+			continue
+		}
+
+		if v.deleteMarker {
+			return nil, errorResponse(http.StatusMethodNotAllowed, "MethodNotAllowed", bucket, objectKey,
+				"The specified method is not allowed against this resource.")
+		}
+
+		return v, nil
+	}
+
+	return nil, errorResponse(http.StatusNotFound, "NoSuchVersion", bucket, objectKey, "The specified version does not exist.")
+}
+
+// list 收集匹配前缀的对象，调用方需持有读锁.
+func (m *MemoryStore) list(bucket string, opts minio.ListObjectsOptions) ([]minio.ObjectInfo, error) {
+	b, err := m.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(b.objects))
+	for k := range b.objects {
+		if strings.HasPrefix(k, opts.Prefix) && (opts.StartAfter == "" || k > opts.StartAfter) {
+			keys = append(keys, k)
+		}
+	}
+
+	slices.Sort(keys)
+
+	var infos []minio.ObjectInfo
+
+	for _, k := range keys {
+		versions := b.objects[k]
+		latest := versions[len(versions)-1]
+
+		if !opts.WithVersions {
+			if !latest.deleteMarker {
+				infos = append(infos, latest.listInfo(opts.WithMetadata, true))
+			}
+
+			continue
+		}
+
+		for i := len(versions) - 1; i >= 0; i-- {
+			infos = append(infos, versions[i].listInfo(opts.WithMetadata, i == len(versions)-1))
+		}
+	}
+
+	if !opts.Recursive {
+		infos = collapsePrefixes(infos, opts.Prefix)
+	}
+
+	return infos, nil
+}
+
+func (m *MemoryStore) bucket(bucket string) (*memoryBucket, error) {
+	b, ok := m.buckets[bucket]
+	if !ok {
+		return nil, errorResponse(http.StatusNotFound, "NoSuchBucket", bucket, "", "The specified bucket does not exist")
+	}
+
+	return b, nil
+}
+
+func (m *MemoryStore) presign(method, bucket, objectKey string, expires time.Duration, reqParams url.Values) (*url.URL, error) {
+	m.mu.RLock()
+	_, err := m.bucket(bucket)
+	m.mu.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	for k, v := range reqParams {
+		q[k] = append([]string(nil), v...)
+	}
+
+	q.Set("X-Nv-Method", method)
+	q.Set(localExpiresParam, strconv.FormatInt(time.Now().Add(expires).Unix(), 10))
+
+	return &url.URL{Scheme: "memory", Host: bucket, Path: "/" + objectKey, RawQuery: q.Encode()}, nil
+}
+
+// versionID 以计数生成版本 ID（按字典序即写入顺序）.
+func (m *MemoryStore) versionID() string {
+	return fmt.Sprintf("%016x", m.seq)
+}
+
+// modTime 生成严格递增的修改时间，避免同一时刻的多次写入无法区分先后.
+func (m *MemoryStore) modTime() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond).Add(time.Duration(m.seq) * time.Microsecond)
+}
+
+func (v *memoryVersion) objectInfo() minio.ObjectInfo {
+	info := v.info
+	info.UserMetadata = maps.Clone(v.info.UserMetadata)
+	info.Metadata = http.Header{"Content-Type": []string{info.ContentType}}
+
+	return info
+}
+
+func (v *memoryVersion) listInfo(withMetadata, latest bool) minio.ObjectInfo {
+	info := v.objectInfo()
+	info.IsLatest = latest
+	info.IsDeleteMarker = v.deleteMarker
+
+	if !withMetadata {
+		info.UserMetadata = nil
+	}
+
+	return info
+}
+
+// memoryObject GetObject 返回的对象内容.
+type memoryObject struct {
+	*io.SectionReader
+	info minio.ObjectInfo
+}
+
+func (o *memoryObject) Stat() (minio.ObjectInfo, error) { return o.info, nil }
+
+func (o *memoryObject) Close() error { return nil }
@@ -0,0 +1,185 @@
+package s3_test
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	minio "github.com/minio/minio-go/v7"
+
+	"github.com/yeisme/notevault/pkg/internal/storage/s3"
+)
+
+func put(t *testing.T, st s3.ObjectStore, key, body string) minio.UploadInfo {
+	t.Helper()
+
+	info, err := st.PutObject(context.Background(), "b", key, strings.NewReader(body), int64(len(body)), minio.PutObjectOptions{})
+	if err != nil {
+		t.Fatalf("put %s: %v", key, err)
+	}
+
+	return info
+}
+
+func read(t *testing.T, st s3.ObjectStore, key string, opts minio.GetObjectOptions) (string, error) {
+	t.Helper()
+
+	obj, err := st.GetObject(context.Background(), "b", key, opts)
+	if err != nil {
+		return "", err
+	}
+	defer obj.Close()
+
+	b, err := io.ReadAll(obj)
+
+	return string(b), err
+}
+
+func keys(st s3.ObjectStore, opts minio.ListObjectsOptions) []string {
+	var out []string
+
+	for obj := range st.ListObjects(context.Background(), "b", opts) {
+		if obj.IsDeleteMarker {
+			out = append(out, obj.Key+"@marker")
+			continue
+		}
+
+		out = append(out, obj.Key)
+	}
+
+	return out
+}
+
+// TestMemoryStoreVersions 验证版本化 bucket 的版本读取、删除标记与按版本删除.
+func TestMemoryStoreVersions(t *testing.T) {
+	ctx := context.Background()
+	st := s3.NewMemoryStore(true, "b")
+
+	v1 := put(t, st, "u/a.txt", "one")
+	v2 := put(t, st, "u/a.txt", "two")
+
+	if v1.VersionID == "" || v1.VersionID == v2.VersionID {
+		t.Fatalf("version ids = %q, %q", v1.VersionID, v2.VersionID)
+	}
+
+	tests := []struct {
+		name      string
+		versionID string
+		want      string
+	}{
+		{name: "latest", want: "two"},
+		{name: "old version", versionID: v1.VersionID, want: "one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := read(t, st, "u/a.txt", minio.GetObjectOptions{VersionID: tt.versionID})
+			if err != nil || got != tt.want {
+				t.Fatalf("read = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if err := st.RemoveObject(ctx, "b", "u/a.txt", minio.RemoveObjectOptions{}); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if _, err := st.StatObject(ctx, "b", "u/a.txt", minio.StatObjectOptions{}); minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		t.Fatalf("stat after delete marker: %v", err)
+	}
+
+	if got := keys(st, minio.ListObjectsOptions{Recursive: true}); len(got) != 0 {
+		t.Fatalf("list without versions = %v", got)
+	}
+
+	want := []string{"u/a.txt@marker", "u/a.txt", "u/a.txt"}
+	if got := keys(st, minio.ListObjectsOptions{Recursive: true, WithVersions: true}); !slices.Equal(got, want) {
+		t.Fatalf("list versions = %v, want %v", got, want)
+	}
+
+	if err := st.RemoveObject(ctx, "b", "u/a.txt", minio.RemoveObjectOptions{VersionID: v1.VersionID}); err != nil {
+		t.Fatalf("remove version: %v", err)
+	}
+
+	if _, err := read(t, st, "u/a.txt", minio.GetObjectOptions{VersionID: v1.VersionID}); minio.ToErrorResponse(err).Code != "NoSuchVersion" {
+		t.Fatalf("read removed version: %v", err)
+	}
+}
+
+// TestMemoryStoreListAndCopy 验证前缀列举（公共前缀合并）、Range 读取与复制时的元数据处理.
+func TestMemoryStoreListAndCopy(t *testing.T) {
+	ctx := context.Background()
+	st := s3.NewMemoryStore(false, "b")
+
+	for _, k := range []string{"u/", "u/docs/", "u/docs/a.md", "u/docs/sub/b.md", "u/c.txt", "v/d.txt"} {
+		put(t, st, k, "hello "+k)
+	}
+
+	listTests := []struct {
+		name string
+		opts minio.ListObjectsOptions
+		want []string
+	}{
+		{name: "recursive", opts: minio.ListObjectsOptions{Prefix: "u/docs/", Recursive: true},
+			want: []string{"u/docs/", "u/docs/a.md", "u/docs/sub/b.md"}},
+		{name: "delimited", opts: minio.ListObjectsOptions{Prefix: "u/"},
+			want: []string{"u/", "u/c.txt", "u/docs/"}},
+		{name: "start after", opts: minio.ListObjectsOptions{Prefix: "u/", Recursive: true, StartAfter: "u/docs/a.md"},
+			want: []string{"u/docs/sub/b.md"}},
+	}
+
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keys(st, tt.opts); !slices.Equal(got, tt.want) {
+				t.Fatalf("keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	opts := minio.GetObjectOptions{}
+	_ = opts.SetRange(6, 7)
+
+	if got, err := read(t, st, "u/c.txt", opts); err != nil || got != "u/" {
+		t.Fatalf("range read = %q, %v", got, err)
+	}
+
+	_, err := st.PutObject(ctx, "b", "u/m.txt", strings.NewReader("m"), 1,
+		minio.PutObjectOptions{ContentType: "text/plain", UserMetadata: map[string]string{"x-amz-meta-tag": "a"}})
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	copyTests := []struct {
+		name string
+		dst  minio.CopyDestOptions
+		want map[string]string
+	}{
+		{name: "keep metadata", dst: minio.CopyDestOptions{Object: "u/m1.txt"}, want: map[string]string{"Tag": "a"}},
+		{name: "replace metadata", dst: minio.CopyDestOptions{Object: "u/m2.txt", UserMetadata: map[string]string{"tag": "b"}},
+			want: map[string]string{"Tag": "b"}},
+	}
+
+	for _, tt := range copyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dst.Bucket = "b"
+			if _, err := st.CopyObject(ctx, tt.dst, minio.CopySrcOptions{Bucket: "b", Object: "u/m.txt"}); err != nil {
+				t.Fatalf("copy: %v", err)
+			}
+
+			info, err := st.StatObject(ctx, "b", tt.dst.Object, minio.StatObjectOptions{})
+			if err != nil || info.ContentType != "text/plain" || info.UserMetadata["Tag"] != tt.want["Tag"] {
+				t.Fatalf("stat = %+v, %v", info, err)
+			}
+		})
+	}
+
+	if err := st.RemoveObject(ctx, "b", "u/c.txt", minio.RemoveObjectOptions{}); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if got := keys(st, minio.ListObjectsOptions{Prefix: "u/c", WithVersions: true}); len(got) != 0 {
+		t.Fatalf("unversioned delete left %v", got)
+	}
+}
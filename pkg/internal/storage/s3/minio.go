@@ -0,0 +1,103 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	minio "github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/yeisme/notevault/pkg/configs"
+	nlog "github.com/yeisme/notevault/pkg/log"
+)
+
+// MinioStore 基于 minio-go 的 S3 兼容存储实现.
+type MinioStore struct {
+	*minio.Client
+}
+
+var _ ObjectStore = (*MinioStore)(nil)
+
+// NewMinioStore 连接 S3 兼容存储，若 bucket 不存在则尝试创建.
+func NewMinioStore(ctx context.Context, cfg configs.S3Config) (*MinioStore, error) {
+	endpoint := cfg.Endpoint
+	// 允许用户传完整 schema endpoint（http:// 或 https://）
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		endpoint = u.Host
+		if u.Scheme == "https" {
+			cfg.UseSSL = true
+		}
+	}
+
+	cli, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create minio client: %w", err)
+	}
+
+	cli.SetAppInfo("notevault", configs.AppVersion)
+
+	// ensure all buckets
+	for i, bkt := range cfg.Buckets {
+		if bkt == "" {
+			continue
+		}
+
+		exists, err := cli.BucketExists(ctx, bkt)
+		if err != nil {
+			return nil, fmt.Errorf("check bucket %s: %w", bkt, err)
+		}
+
+		if !exists {
+			if err := cli.MakeBucket(ctx, bkt, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
+				return nil, fmt.Errorf("create bucket %s: %w", bkt, err)
+			}
+
+			nlog.Logger().Info().Str("bucket", bkt).Msgf("bucket %d created", i)
+		}
+	}
+
+	nlog.Logger().Info().Str("endpoint", cfg.Endpoint).Int("bucket_count", len(cfg.Buckets)).Msg("s3 connected")
+
+	return &MinioStore{Client: cli}, nil
+}
+
+// GetObject 读取对象.
+func (m *MinioStore) GetObject(ctx context.Context, bucket, objectKey string, opts minio.GetObjectOptions) (Object, error) {
+	obj, err := m.Client.GetObject(ctx, bucket, objectKey, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// ObjectLockEnabled 通过存储桶的对象锁定配置判断是否启用 WORM，未配置或服务端不支持时返回 false.
+func (m *MinioStore) ObjectLockEnabled(ctx context.Context, bucket string) (bool, error) {
+	status, _, _, _, err := m.GetObjectLockConfig(ctx, bucket)
+	if err != nil {
+		switch minio.ToErrorResponse(err).Code {
+		case "ObjectLockConfigurationNotFoundError", "NotImplemented":
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return status == "Enabled", nil
+}
+
+// HealthCheck 简单的健康检查，通过列出桶来验证连接.
+func (m *MinioStore) HealthCheck(ctx context.Context) error {
+	_, err := m.ListBuckets(ctx)
+	return err
+}
+
+// Close 关闭 S3 客户端连接（无实际操作，接口兼容）.
+func (m *MinioStore) Close() error {
+	return nil
+}
@@ -1,83 +1,79 @@
-// Package s3 处理S3存储操作.
+// Package s3 处理对象存储操作：ObjectStore 接口及 S3 兼容（MinIO）与本地磁盘两种实现.
 package s3
 
 import (
 	"context"
 	"fmt"
-	"net/url"
-
-	minio "github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
 
 	"github.com/yeisme/notevault/pkg/configs"
-	nlog "github.com/yeisme/notevault/pkg/log"
 )
 
-// Client 包装 MinIO 客户端.
+// Client 提供对 ObjectStore 的封装，简化使用.
 type Client struct {
-	*minio.Client
+	ObjectStore
 }
 
-// New 初始化 MinIO 客户端，若 bucket 不存在则尝试创建.
+// New 根据配置的驱动创建对象存储客户端.
 // 默认情况下，第一个 bucket 用于存储文件. 为了可以创建多个 bucket，配置中允许传入多个 bucket 名称.
 func New(ctx context.Context) (*Client, error) {
-	cfg := configs.GetConfig().S3
-	endpoint := cfg.Endpoint
-	// 允许用户传完整 schema endpoint（http:// 或 https://）
-	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
-		endpoint = u.Host
-		if u.Scheme == "https" {
-			cfg.UseSSL = true
-		}
-	}
+	return NewWithConfig(ctx, configs.GetConfig().S3)
+}
 
-	cli, err := minio.New(endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
-		Secure: cfg.UseSSL,
-		Region: cfg.Region,
-	})
-	cli.SetAppInfo("notevault", configs.AppVersion)
+// NewReplica 创建复制目标的对象存储客户端；未配置 buckets 时沿用主存储的 bucket 名称.
+// 副本 bucket 改名时加密规则仍按对应的主存储 bucket 匹配，按 bucket 配置的加密规则同样作用于副本.
+func NewReplica(ctx context.Context) (*Client, error) {
+	cfg := configs.GetConfig()
 
+	target := cfg.Replication.Target
+	target.Buckets = cfg.Replication.TargetBuckets(cfg.S3.Buckets)
+
+	c, err := NewWithConfig(ctx, target)
 	if err != nil {
-		return nil, fmt.Errorf("create minio client: %w", err)
+		return nil, err
 	}
 
-	// ensure all buckets
-	for i, bkt := range cfg.Buckets {
-		if bkt == "" {
-			continue
+	if es, ok := c.ObjectStore.(*EncryptedStore); ok {
+		ruleBuckets := make(map[string]string, len(cfg.S3.Buckets))
+		for _, b := range cfg.S3.Buckets {
+			ruleBuckets[cfg.Replication.TargetBucket(cfg.S3.Buckets, b)] = b
 		}
 
-		exists, err := cli.BucketExists(ctx, bkt)
-		if err != nil {
-			return nil, fmt.Errorf("check bucket %s: %w", bkt, err)
-		}
+		es.SetRuleBuckets(ruleBuckets)
+	}
 
-		if !exists {
-			if err := cli.MakeBucket(ctx, bkt, minio.MakeBucketOptions{Region: cfg.Region}); err != nil {
-				return nil, fmt.Errorf("create bucket %s: %w", bkt, err)
-			}
+	return c, nil
+}
 
-			nlog.Logger().Info().Str("bucket", bkt).Msgf("bucket %d created", i)
-		}
+// NewWithConfig 按给定的对象存储配置创建客户端，启用加密时同样套上加密层.
+func NewWithConfig(ctx context.Context, cfg configs.S3Config) (*Client, error) {
+	var (
+		store ObjectStore
+		err   error
+	)
+
+	switch cfg.Driver {
+	case configs.S3DriverLocal:
+		store, err = NewLocalStore(cfg)
+	case configs.S3DriverMinio, "":
+		store, err = NewMinioStore(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unsupported object store driver: %s", cfg.Driver)
 	}
 
-	nlog.Logger().Info().Str("endpoint", cfg.Endpoint).Int("bucket_count", len(cfg.Buckets)).Msg("s3 connected")
-
-	return &Client{Client: cli}, nil
-}
+	if err != nil {
+		return nil, err
+	}
 
-// HealthCheck 简单的健康检查，通过列出桶来验证连接.
-func (c *Client) HealthCheck(ctx context.Context) error {
-	_, err := c.ListBuckets(ctx)
-	return err
-}
+	if enc := configs.GetConfig().Encryption; enc.Enabled() {
+		if store, err = NewEncryptedStore(store, &enc); err != nil {
+			return nil, err
+		}
+	}
 
-// Close 关闭 S3 客户端连接（无实际操作，接口兼容）.
-func (c *Client) Close() error {
-	return nil
+	return &Client{ObjectStore: store}, nil
 }
 
+// GetConfig 返回对象存储配置.
 func (c *Client) GetConfig() configs.S3Config {
 	return configs.GetConfig().S3
 }
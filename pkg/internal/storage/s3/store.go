@@ -0,0 +1,65 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+)
+
+// StorageClassMetaKey 复制对象时在 CopyDestOptions.UserMetadata（需同时设置 ReplaceMetadata）中指定目标存储类型的键，
+// minio-go 将其作为 x-amz-storage-class 请求头发送，本地与内存驱动同样识别该键.
+const StorageClassMetaKey = "X-Amz-Storage-Class"
+
+// ObjectStore 对象存储接口，覆盖服务层用到的对象读写、列举、复制、删除、预签名与版本操作.
+// 参数与返回值沿用 minio-go 的选项与结果类型，错误使用 minio.ErrorResponse 表达（如 NoSuchKey），
+// 服务层可继续通过 minio.ToErrorResponse 判断错误码.
+type ObjectStore interface {
+	// PutObject 写入对象，size 为 -1 时读取到 EOF.
+	PutObject(ctx context.Context, bucket, objectKey string, reader io.Reader, size int64,
+		opts minio.PutObjectOptions) (minio.UploadInfo, error)
+	// GetObject 读取对象，支持 opts 中的 Range、If-Match 与 VersionID.
+	GetObject(ctx context.Context, bucket, objectKey string, opts minio.GetObjectOptions) (Object, error)
+	// StatObject 获取对象信息.
+	StatObject(ctx context.Context, bucket, objectKey string, opts minio.StatObjectOptions) (minio.ObjectInfo, error)
+	// ListObjects 按前缀列举对象，结果按键的字典序返回；出错时通过 ObjectInfo.Err 返回.
+	ListObjects(ctx context.Context, bucket string, opts minio.ListObjectsOptions) <-chan minio.ObjectInfo
+	// CopyObject 服务端复制对象.
+	CopyObject(ctx context.Context, dst minio.CopyDestOptions, src minio.CopySrcOptions) (minio.UploadInfo, error)
+	// RemoveObject 删除对象（或指定版本）.
+	RemoveObject(ctx context.Context, bucket, objectKey string, opts minio.RemoveObjectOptions) error
+	// PresignedGetObject 生成预签名下载 URL，reqParams 支持 response-content-* 覆盖响应头.
+	PresignedGetObject(ctx context.Context, bucket, objectKey string, expires time.Duration,
+		reqParams url.Values) (*url.URL, error)
+	// PresignedPutObject 生成预签名上传 URL.
+	PresignedPutObject(ctx context.Context, bucket, objectKey string, expires time.Duration) (*url.URL, error)
+	// PresignedPostPolicy 生成表单上传地址与表单字段.
+	PresignedPostPolicy(ctx context.Context, policy *minio.PostPolicy) (*url.URL, map[string]string, error)
+	// ListIncompleteUploads 列举前缀下未完成的分片上传.
+	ListIncompleteUploads(ctx context.Context, bucket, prefix string, recursive bool) <-chan minio.ObjectMultipartInfo
+	// RemoveIncompleteUpload 中止对象的全部未完成分片上传.
+	RemoveIncompleteUpload(ctx context.Context, bucket, objectKey string) error
+	// GetBucketVersioning 获取存储桶版本化配置.
+	GetBucketVersioning(ctx context.Context, bucket string) (minio.BucketVersioningConfiguration, error)
+	// ObjectLockEnabled 报告存储桶是否启用了 S3 对象锁定（WORM），不支持的驱动返回 false.
+	ObjectLockEnabled(ctx context.Context, bucket string) (bool, error)
+	// PutObjectLegalHold 设置对象（或指定版本）的法律保留状态.
+	PutObjectLegalHold(ctx context.Context, bucket, objectKey string, opts minio.PutObjectLegalHoldOptions) error
+	// PutObjectRetention 设置对象（或指定版本）的保留模式与保留截止时间.
+	PutObjectRetention(ctx context.Context, bucket, objectKey string, opts minio.PutObjectRetentionOptions) error
+	// HealthCheck 检查存储是否可用.
+	HealthCheck(ctx context.Context) error
+	// Close 释放资源.
+	Close() error
+}
+
+// Object GetObject 返回的对象内容，*minio.Object 满足该接口.
+type Object interface {
+	io.ReadCloser
+	io.Seeker
+	io.ReaderAt
+	// Stat 返回对象信息.
+	Stat() (minio.ObjectInfo, error)
+}
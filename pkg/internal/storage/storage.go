@@ -23,6 +23,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/yeisme/notevault/pkg/configs"
 	dbc "github.com/yeisme/notevault/pkg/internal/storage/db"
 	kvc "github.com/yeisme/notevault/pkg/internal/storage/kv"
 	mqc "github.com/yeisme/notevault/pkg/internal/storage/mq"
@@ -33,9 +34,11 @@ import (
 // Manager 聚合所有存储资源.
 type Manager struct {
 	s3 *s3c.Client
-	db *dbc.Client
-	mq *mqc.Client
-	kv *kvc.Client
+	// replica 复制目标的对象存储客户端，未启用复制时为 nil
+	replica *s3c.Client
+	db      *dbc.Client
+	mq      *mqc.Client
+	kv      *kvc.Client
 }
 
 // Init 初始化存储管理器，使用全局配置.每次调用都创建新的实例以支持配置热重载.
@@ -60,6 +63,15 @@ func Init(ctx context.Context) (*Manager, error) {
 		m.s3 = s3i
 	}
 
+	if configs.GetConfig().Replication.Enabled {
+		if replica, e := s3c.NewReplica(ctx); e != nil {
+			nlog.Logger().Error().Err(e).Msg("init replica s3 failed")
+			collectedErrs = append(collectedErrs, e)
+		} else {
+			m.replica = replica
+		}
+	}
+
 	// MQ（同样收集错误）
 	if mqMgr, e := mqc.New(ctx); e != nil {
 		nlog.Logger().Error().Err(e).Msg("init mq failed")
@@ -86,11 +98,27 @@ func Init(ctx context.Context) (*Manager, error) {
 	return m, err
 }
 
+// NewManager 使用已创建的存储客户端构造管理器（如测试中使用内存实现），为 nil 的客户端视为未初始化.
+func NewManager(s3 *s3c.Client, db *dbc.Client, mq *mqc.Client, kv *kvc.Client) *Manager {
+	return &Manager{s3: s3, db: db, mq: mq, kv: kv}
+}
+
 // GetS3Client 获取 S3 客户端.
 func (m *Manager) GetS3Client() *s3c.Client {
 	return m.s3
 }
 
+// WithReplica 设置复制目标的对象存储客户端（如测试中使用内存实现）.
+func (m *Manager) WithReplica(replica *s3c.Client) *Manager {
+	m.replica = replica
+	return m
+}
+
+// GetReplicaS3Client 获取复制目标的对象存储客户端，未启用复制时返回 nil.
+func (m *Manager) GetReplicaS3Client() *s3c.Client {
+	return m.replica
+}
+
 // GetDBClient 获取 DB 客户端.
 func (m *Manager) GetDBClient() *dbc.Client {
 	return m.db
@@ -117,6 +145,12 @@ func (m *Manager) Close() error {
 		}
 	}
 
+	if m.replica != nil {
+		if err := m.replica.Close(); err != nil {
+			collectedErrs = append(collectedErrs, err)
+		}
+	}
+
 	if m.db != nil {
 		if err := m.db.Close(); err != nil {
 			collectedErrs = append(collectedErrs, err)
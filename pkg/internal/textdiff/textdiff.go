@@ -0,0 +1,232 @@
+// Package textdiff 提供按行比较文本的 Myers 差异算法与 unified diff 分块.
+package textdiff
+
+import "slices"
+
+// DefaultMaxEdits 默认的最大编辑距离；超过后不再搜索最短编辑脚本，退化为整段删除 + 整段插入.
+// Myers 算法的回溯信息占用 O(D²) 内存，限制 D 以保证最坏情况下的内存可控.
+const DefaultMaxEdits = 1000
+
+// Kind 编辑类型.
+type Kind int
+
+const (
+	Equal  Kind = iota // 两侧相同的行
+	Delete             // 仅存在于旧文本的行
+	Insert             // 仅存在于新文本的行
+)
+
+// Edit 编辑脚本中的一步：A 为旧文本行下标，B 为新文本行下标（均从 0 开始）.
+// Insert 的 A 为插入位置（之前已处理的旧文本行数），Delete 的 B 同理.
+type Edit struct {
+	Kind Kind
+	A, B int
+}
+
+// Hunk 带上下文的一组连续修改，Start 为从 1 开始的行号（长度为 0 时为前一行的行号，与 unified diff 一致）.
+type Hunk struct {
+	AStart, ALen int
+	BStart, BLen int
+	Edits        []Edit
+}
+
+// Lines 计算 a 到 b 的编辑脚本；编辑距离超过 maxEdits（<=0 时使用 DefaultMaxEdits）时返回的脚本不保证最短.
+func Lines(a, b []string, maxEdits int) []Edit {
+	if maxEdits <= 0 {
+		maxEdits = DefaultMaxEdits
+	}
+
+	// 去掉公共前后缀，缩小搜索范围
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	edits := make([]Edit, 0, max(len(a), len(b)))
+	for i := range pre {
+		edits = append(edits, Edit{Kind: Equal, A: i, B: i})
+	}
+
+	midA, midB := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	mid, ok := myers(midA, midB, maxEdits)
+	if !ok {
+		mid = replaceAll(len(midA), len(midB))
+	}
+
+	for _, e := range mid {
+		edits = append(edits, Edit{Kind: e.Kind, A: e.A + pre, B: e.B + pre})
+	}
+
+	for i := range suf {
+		edits = append(edits, Edit{Kind: Equal, A: len(a) - suf + i, B: len(b) - suf + i})
+	}
+
+	return edits
+}
+
+// Hunks 将编辑脚本按 context 行上下文分组，间隔不超过 2*context 行的修改合并到同一块.
+func Hunks(edits []Edit, context int) []Hunk {
+	context = max(context, 0)
+
+	var (
+		hunks []Hunk
+		start = -1 // 当前块第一条编辑的下标
+		last  = -1 // 当前块最后一条修改的下标
+	)
+
+	flush := func() {
+		end := min(last+context+1, len(edits))
+		hunks = append(hunks, newHunk(edits[start:end]))
+	}
+
+	for i, e := range edits {
+		if e.Kind == Equal {
+			continue
+		}
+
+		if start >= 0 && i-last-1 > 2*context {
+			flush()
+
+			start = -1
+		}
+
+		if start < 0 {
+			start = max(i-context, 0)
+		}
+
+		last = i
+	}
+
+	if start >= 0 {
+		flush()
+	}
+
+	return hunks
+}
+
+func newHunk(edits []Edit) Hunk {
+	h := Hunk{Edits: edits}
+
+	for _, e := range edits {
+		if e.Kind != Insert {
+			h.ALen++
+		}
+
+		if e.Kind != Delete {
+			h.BLen++
+		}
+	}
+
+	h.AStart, h.BStart = edits[0].A, edits[0].B
+	if h.ALen > 0 {
+		h.AStart++
+	}
+
+	if h.BLen > 0 {
+		h.BStart++
+	}
+
+	return h
+}
+
+// myers 使用 Myers O(ND) 算法求最短编辑脚本；编辑距离超过 maxD 时返回 false.
+func myers(a, b []string, maxD int) ([]Edit, bool) {
+	n, m := len(a), len(b)
+	maxD = min(maxD, n+m)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+
+	// trace[d] 保存第 d 步开始前 k ∈ [-d, d] 的 V 值，用于回溯
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // 向下：插入 b 的一行
+			} else {
+				x = v[offset+k-1] + 1 // 向右：删除 a 的一行
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func backtrack(trace [][]int, n, m int) []Edit {
+	var edits []Edit
+
+	x, y := n, m
+
+	for d := len(trace) - 1; d > 0; d-- {
+		vd := trace[d]
+		at := func(k int) int { return vd[k+d] }
+		k := x - y
+
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Kind: Equal, A: x, B: y})
+		}
+
+		if x == prevX {
+			y--
+			edits = append(edits, Edit{Kind: Insert, A: x, B: y})
+		} else {
+			x--
+			edits = append(edits, Edit{Kind: Delete, A: x, B: y})
+		}
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, Edit{Kind: Equal, A: x, B: y})
+	}
+
+	slices.Reverse(edits)
+
+	return edits
+}
+
+// replaceAll 返回整段删除 + 整段插入的编辑脚本.
+func replaceAll(n, m int) []Edit {
+	edits := make([]Edit, 0, n+m)
+	for i := range n {
+		edits = append(edits, Edit{Kind: Delete, A: i, B: 0})
+	}
+
+	for j := range m {
+		edits = append(edits, Edit{Kind: Insert, A: n, B: j})
+	}
+
+	return edits
+}
@@ -0,0 +1,128 @@
+package textdiff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yeisme/notevault/pkg/internal/textdiff"
+)
+
+// apply 按编辑脚本从 a 还原出 b，用于验证脚本的正确性.
+func apply(t *testing.T, a, b []string, edits []textdiff.Edit) []string {
+	t.Helper()
+
+	var out []string
+
+	ai := 0
+
+	for _, e := range edits {
+		switch e.Kind {
+		case textdiff.Equal:
+			if a[e.A] != b[e.B] {
+				t.Fatalf("equal edit with different lines: %q vs %q", a[e.A], b[e.B])
+			}
+
+			out = append(out, a[e.A])
+			ai++
+		case textdiff.Delete:
+			if e.A != ai {
+				t.Fatalf("delete out of order: got %d want %d", e.A, ai)
+			}
+
+			ai++
+		case textdiff.Insert:
+			out = append(out, b[e.B])
+		}
+	}
+
+	if ai != len(a) {
+		t.Fatalf("consumed %d of %d old lines", ai, len(a))
+	}
+
+	return out
+}
+
+func countChanges(edits []textdiff.Edit) int {
+	n := 0
+
+	for _, e := range edits {
+		if e.Kind != textdiff.Equal {
+			n++
+		}
+	}
+
+	return n
+}
+
+// TestLines 验证编辑脚本可还原新文本且为最短脚本.
+func TestLines(t *testing.T) {
+	cases := []struct {
+		a, b    string
+		changes int
+	}{
+		{"", "", 0},
+		{"a b c", "a b c", 0},
+		{"", "a b", 2},
+		{"a b", "", 2},
+		{"a b c a b b a", "c b a b a c", 5},
+		{"# title intro old end", "# title intro new extra end", 3},
+	}
+
+	for _, tc := range cases {
+		a, b := strings.Fields(tc.a), strings.Fields(tc.b)
+
+		edits := textdiff.Lines(a, b, 0)
+		if got := apply(t, a, b, edits); strings.Join(got, " ") != strings.Join(b, " ") {
+			t.Fatalf("%q -> %q: applied %q", tc.a, tc.b, got)
+		}
+
+		if n := countChanges(edits); n != tc.changes {
+			t.Fatalf("%q -> %q: %d changes, want %d", tc.a, tc.b, n, tc.changes)
+		}
+	}
+}
+
+// TestLinesMaxEdits 验证超过编辑距离上限时退化为整段替换且结果仍然正确.
+func TestLinesMaxEdits(t *testing.T) {
+	a := strings.Fields("x a b c d y")
+	b := strings.Fields("x e f g h y")
+
+	edits := textdiff.Lines(a, b, 2)
+	if got := apply(t, a, b, edits); strings.Join(got, " ") != strings.Join(b, " ") {
+		t.Fatalf("applied %q", got)
+	}
+
+	if n := countChanges(edits); n != 8 {
+		t.Fatalf("%d changes, want 8", n)
+	}
+}
+
+// TestHunks 验证上下文截取、相近修改合并与 unified diff 行号.
+func TestHunks(t *testing.T) {
+	a := strings.Fields("1 2 3 4 5 6 7 8 9 10 11 12 13 14 15")
+	b := strings.Fields("1 2 X 4 5 6 7 8 9 10 11 12 13 15")
+
+	hunks := textdiff.Hunks(textdiff.Lines(a, b, 0), 2)
+	if len(hunks) != 2 {
+		t.Fatalf("got %d hunks, want 2", len(hunks))
+	}
+
+	if h := hunks[0]; h.AStart != 1 || h.ALen != 5 || h.BStart != 1 || h.BLen != 5 {
+		t.Fatalf("hunk 0: %+v", h)
+	}
+
+	if h := hunks[1]; h.AStart != 12 || h.ALen != 4 || h.BStart != 12 || h.BLen != 3 {
+		t.Fatalf("hunk 1: %+v", h)
+	}
+
+	// 间隔不超过 2*context 时合并为一块
+	if merged := textdiff.Hunks(textdiff.Lines(a, b, 0), 5); len(merged) != 1 {
+		t.Fatalf("got %d hunks, want 1", len(merged))
+	}
+
+	// 空文件插入：旧文件起始行号为 0
+	ins := textdiff.Hunks(textdiff.Lines(nil, []string{"a"}, 0), 3)
+	if len(ins) != 1 || ins[0].AStart != 0 || ins[0].ALen != 0 || ins[0].BStart != 1 || ins[0].BLen != 1 {
+		t.Fatalf("insert hunk: %+v", ins)
+	}
+}
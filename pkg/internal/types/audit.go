@@ -0,0 +1,95 @@
+package types
+
+import "time"
+
+// 审计动作.
+const (
+	AuditActionFileUpload       = "file.upload"       // 经服务端上传文件
+	AuditActionFileUploadURL    = "file.upload_url"   // 签发直传地址
+	AuditActionFileDownload     = "file.download"     // 下载文件或打包下载
+	AuditActionFileDownloadURL  = "file.download_url" // 签发下载直链
+	AuditActionFileDelete       = "file.delete"       // 永久删除文件
+	AuditActionFileTrash        = "file.trash"        // 移入回收站
+	AuditActionTrashDelete      = "trash.delete"      // 永久删除回收站条目
+	AuditActionTrashEmpty       = "trash.empty"       // 清空回收站
+	AuditActionFolderDelete     = "folder.delete"     // 删除文件夹
+	AuditActionVersionCreate    = "version.create"    // 创建新版本（可替换内容类型与元数据）
+	AuditActionVersionDelete    = "version.delete"    // 删除历史版本
+	AuditActionVersionRestore   = "version.restore"   // 将历史版本恢复为最新版本
+	AuditActionHoldSet          = "hold.set"          // 设置或解除法律保留、设置保留期
+	AuditActionShareCreate      = "share.create"      // 创建分享
+	AuditActionShareDelete      = "share.delete"      // 删除分享
+	AuditActionSharePassword    = "share.password"    // 修改分享密码
+	AuditActionSharePermissions = "share.permissions" // 修改分享权限（匿名访问、授权用户）
+	AuditActionShareDownload    = "share.download"    // 通过分享下载
+	AuditActionConfigReload     = "config.reload"     // 配置文件热重载
+	AuditActionAuditExport      = "audit.export"      // 管理员导出审计日志
+)
+
+// 没有用户身份时记录的操作者.
+const (
+	AuditActorSystem    = "system"    // 后台任务与配置重载
+	AuditActorAnonymous = "anonymous" // 未登录的访问者
+)
+
+// 审计结果.
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomePartial = "partial" // 批量操作部分失败
+	AuditOutcomeFailure = "failure"
+	AuditOutcomeDenied  = "denied" // 无权限或对象受保护
+)
+
+// AuditQuery 审计日志查询条件，查询与导出共用.
+type AuditQuery struct {
+	Actor   string `form:"actor"`
+	Action  string `form:"action"`
+	Outcome string `form:"outcome"  binding:"omitempty,oneof=success partial failure denied"`
+	// Target 目标对象键或分享 ID（精确匹配数组中的元素）
+	Target  string `form:"target"`
+	TraceID string `form:"trace_id"`
+	// Since/Until 时间范围（RFC3339）
+	Since    *time.Time `form:"since"`
+	Until    *time.Time `form:"until"`
+	Page     int        `form:"page"`
+	PageSize int        `form:"page_size"`
+}
+
+// AuditEventInfo 单条审计记录.
+type AuditEventInfo struct {
+	ID         uint      `json:"id"`
+	Actor      string    `json:"actor"`
+	Action     string    `json:"action"`
+	TargetKeys []string  `json:"target_keys"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	TraceID    string    `json:"trace_id,omitempty"`
+	PrevHash   string    `json:"prev_hash,omitempty"`
+	Hash       string    `json:"hash,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ListAuditEventsResponse 审计日志查询响应，按时间倒序.
+type ListAuditEventsResponse struct {
+	Total int              `json:"total"`
+	Page  int              `json:"page"`
+	Size  int              `json:"size"`
+	Items []AuditEventInfo `json:"items"`
+}
+
+// VerifyAuditResponse 哈希链校验结果.
+type VerifyAuditResponse struct {
+	Valid bool `json:"valid"`
+	// Checked 校验的记录数
+	Checked int `json:"checked"`
+	// Unchained 未启用哈希链时写入的记录数
+	Unchained int `json:"unchained"`
+	// BrokenAt 第一条校验失败的记录 ID
+	BrokenAt uint   `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// LastHash 链尾哈希，可保存到外部用于发现链尾记录被删除
+	LastHash string `json:"last_hash,omitempty"`
+}
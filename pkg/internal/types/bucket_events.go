@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+// 存储桶事件动作.
+const (
+	BucketEventCreated = "created"
+	BucketEventRemoved = "removed"
+)
+
+// BucketEvent 归一化后的存储桶事件（来自 S3/MinIO Event Notification 的单条 Record）.
+type BucketEvent struct {
+	Name      string    `json:"name"`   // 原始事件名，如 s3:ObjectCreated:Put
+	Action    string    `json:"action"` // created / removed
+	Bucket    string    `json:"bucket"`
+	ObjectKey string    `json:"object_key"` // 已 URL 解码
+	User      string    `json:"user"`       // 对象键的第一段
+	Size      int64     `json:"size,omitempty"`
+	ETag      string    `json:"etag,omitempty"`
+	VersionID string    `json:"version_id,omitempty"`
+	EventTime time.Time `json:"event_time"`
+}
+
+// BucketEventsResponse 事件处理结果统计.
+type BucketEventsResponse struct {
+	Received int `json:"received"` // 可处理的事件数量
+	Applied  int `json:"applied"`  // 已更新数据库的事件数量
+	Skipped  int `json:"skipped"`  // 无需处理的事件（重复、过期或不属于已配置存储桶）
+}
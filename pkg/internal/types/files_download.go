@@ -32,19 +32,66 @@ type PresignedDownloadItem struct {
 	ExpiresIn int    `json:"expires_in"`
 }
 
+// 打包下载格式.
+const (
+	ArchiveFormatZip    = "zip"
+	ArchiveFormatTar    = "tar"
+	ArchiveFormatTarGz  = "tar.gz"
+	ArchiveFormatTarZst = "tar.zst"
+)
+
 // DownloadFilesRequest 直传下载请求（支持单个/批量）。
-// 当 `archive=true` 且包含多个对象时，服务端将以 zip 流式返回.
+// 当 `archive=true`、包含多个对象或包含文件夹时，服务端将按 archive_format 流式打包返回.
 type DownloadFilesRequest struct {
-	Objects     []DownloadObjectItem `binding:"required"            json:"objects"`
-	Archive     bool                 `json:"archive,omitempty"`      // 可选：当为 true 且对象数量>1 时，打包为 zip 返回
-	ArchiveName string               `json:"archive_name,omitempty"` // 可选：打包文件名（仅当 Archive=true 或多文件时生效），例如 my-photos.zip
+	Objects []DownloadObjectItem `binding:"required,dive" json:"objects"`
+	// 可选：为 true 时即使只有一个对象也打包返回
+	Archive bool `json:"archive,omitempty"`
+	// 可选：打包文件名（仅当打包时生效），例如 my-photos.zip
+	ArchiveName string `json:"archive_name,omitempty"`
+	// 可选：打包格式 zip/tar/tar.gz/tar.zst，默认 zip
+	ArchiveFormat string `binding:"omitempty,oneof=zip tar tar.gz tar.zst" json:"archive_format,omitempty"`
+}
+
+// DownloadFileQuery GET 方式下载单个文件的查询参数.
+type DownloadFileQuery struct {
+	ObjectKey string `binding:"required" form:"object_key"`
+	FileName  string `form:"file_name"`
+	Inline    bool   `form:"inline"` // 为 true 时 Content-Disposition 使用 inline
 }
 
-// DownloadObjectItem 下载对象项.
+// DownloadObjectItem 下载对象项，object_key 与 folder_id 二选一.
 type DownloadObjectItem struct {
-	ObjectKey string `binding:"required" json:"object_key"`
-	// 可选：指定在 zip 中的文件名；未提供则使用对象键的最后一段
+	ObjectKey string `binding:"required_without=FolderID" json:"object_key,omitempty"`
+	// 可选：指定在压缩包中的文件名；未提供则使用对象键的最后一段
 	FileName string `json:"file_name,omitempty"`
+	// 可选：文件夹 ID，打包时展开为文件夹内的全部对象，条目路径为 <文件夹名>/<相对路径>
+	FolderID string `binding:"required_without=ObjectKey" json:"folder_id,omitempty"`
+}
+
+// 压缩包清单条目状态.
+const (
+	ArchiveEntryOK     = "ok"
+	ArchiveEntryFailed = "failed"
+)
+
+// ArchiveManifest 打包下载时写入压缩包根目录的 MANIFEST.json.
+type ArchiveManifest struct {
+	Format    string                 `json:"format"`
+	CreatedAt string                 `json:"created_at"` // RFC3339
+	Total     int                    `json:"total"`
+	Failed    int                    `json:"failed"`
+	Entries   []ArchiveManifestEntry `json:"entries"`
+}
+
+// ArchiveManifestEntry 清单中的单个请求对象（文件夹展开后逐个列出）.
+type ArchiveManifestEntry struct {
+	ObjectKey string `json:"object_key,omitempty"`
+	FolderID  string `json:"folder_id,omitempty"` // 来自文件夹展开时记录所属文件夹
+	Path      string `json:"path,omitempty"`      // 压缩包内路径
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
 }
 
 // ObjectInfo 对象信息（用于返回给客户端展示）。
@@ -58,6 +105,7 @@ type ObjectInfo struct {
 	StorageClass string            `json:"storage_class,omitempty"`
 	Bucket       string            `json:"bucket,omitempty"`
 	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+	Media        *MediaInfo        `json:"media,omitempty"` // 已提取的媒体元数据（仅搜索结果）
 }
 
 // DownloadFilesResponse 批量下载时可返回每个对象的 info.
@@ -0,0 +1,61 @@
+package types
+
+// 重复文件分组方式.
+const (
+	DuplicateModeExact   = "exact"   // 内容完全相同（ETag 与大小一致）
+	DuplicateModeSimilar = "similar" // 感知哈希相近的图片（缩放、重新编码后的同一张照片）
+	DuplicateModeAll     = "all"
+)
+
+// DuplicatesQuery 重复文件查询参数.
+type DuplicatesQuery struct {
+	// Mode exact / similar / all（默认）
+	Mode string `binding:"omitempty,oneof=exact similar all" form:"mode"`
+	// Distance 近似判定的最大汉明距离（pHash 与 dHash 均需满足），默认 6
+	Distance *int `binding:"omitempty,min=0,max=32" form:"distance"`
+	// Prefix 仅在该对象键前缀下查找
+	Prefix string `form:"prefix"`
+}
+
+// DuplicateFile 重复分组中的文件.
+type DuplicateFile struct {
+	ObjectKey    string `json:"object_key"`
+	FileName     string `json:"file_name"`
+	Size         int64  `json:"size"`
+	ETag         string `json:"etag,omitempty"`
+	ContentType  string `json:"content_type,omitempty"`
+	LastModified string `json:"last_modified,omitempty"` // RFC3339
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	// Distance 与分组中建议保留文件的汉明距离（仅 similar 分组）
+	Distance int `json:"distance,omitempty"`
+}
+
+// DuplicateGroup 一组重复文件，Files[0] 为建议保留的文件.
+type DuplicateGroup struct {
+	Mode string `json:"mode"`
+	// Hash exact 分组为 ETag，similar 分组为建议保留文件的 pHash（十六进制）
+	Hash          string          `json:"hash"`
+	SuggestedKeep string          `json:"suggested_keep"`
+	Files         []DuplicateFile `json:"files"`
+}
+
+// DuplicatesResponse 重复文件查询响应.
+type DuplicatesResponse struct {
+	Distance int              `json:"distance"`
+	Exact    []DuplicateGroup `json:"exact"`
+	Similar  []DuplicateGroup `json:"similar"`
+	// Truncated 参与近似比较的图片数超过上限时为 true
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ResolveDuplicatesRequest 批量处理重复文件：每组保留一个，其余移入回收站.
+type ResolveDuplicatesRequest struct {
+	Groups []DuplicateResolution `binding:"required,min=1,dive" json:"groups"`
+}
+
+// DuplicateResolution 单组处理方式.
+type DuplicateResolution struct {
+	Keep       string   `binding:"required"       json:"keep"`
+	ObjectKeys []string `binding:"required,min=2" json:"object_keys"` // 分组内全部对象键（包含 Keep）
+}
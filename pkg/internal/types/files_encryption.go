@@ -0,0 +1,25 @@
+package types
+
+// RotateKeysQuery 轮换主密钥参数.
+type RotateKeysQuery struct {
+	// DryRun 为 true 时只统计需要重新加密的对象，不修改对象
+	DryRun bool `form:"dry_run"`
+}
+
+// RotateKeyError 单个对象轮换失败的原因.
+type RotateKeyError struct {
+	Bucket    string `json:"bucket"`
+	ObjectKey string `json:"object_key"`
+	Error     string `json:"error"`
+}
+
+// RotateKeysResponse 轮换主密钥结果.
+type RotateKeysResponse struct {
+	DryRun bool `json:"dry_run"`
+	// 检查的对象数
+	Scanned int `json:"scanned"`
+	// 已（或在 dry_run 时将要）改用当前主密钥的对象数
+	Rotated int              `json:"rotated"`
+	Failed  int              `json:"failed"`
+	Errors  []RotateKeyError `json:"errors"`
+}
@@ -0,0 +1,81 @@
+package types
+
+import "time"
+
+// 保护审计记录的结果.
+const (
+	HoldOutcomeApplied = "applied" // 保护已设置或变更
+	HoldOutcomeDenied  = "denied"  // 操作因保护被拒绝
+)
+
+// SetObjectHoldRequest 设置文件的法律保留或保留期，未提供的字段保持不变.
+type SetObjectHoldRequest struct {
+	ObjectKey string `json:"object_key" binding:"required"`
+	// LegalHold 设置（true）或解除（false）法律保留
+	LegalHold *bool `json:"legal_hold,omitempty"`
+	// RetainUntil 保留截止时间（RFC3339），只能延长，不能缩短或取消
+	RetainUntil *time.Time `json:"retain_until,omitempty"`
+	// RetainDays 从现在起的保留天数，与 RetainUntil 二选一
+	RetainDays int    `json:"retain_days,omitempty" binding:"gte=0"`
+	Reason     string `json:"reason,omitempty"      binding:"max=512"`
+}
+
+// ObjectHoldInfo 文件的保护状态.
+type ObjectHoldInfo struct {
+	ObjectKey   string     `json:"object_key"`
+	Bucket      string     `json:"bucket"`
+	LegalHold   bool       `json:"legal_hold"`
+	RetainUntil *time.Time `json:"retain_until,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+	SetBy       string     `json:"set_by"`
+	// ObjectLock 是否已同步到 S3 对象锁定，false 时仅由 notevault 拦截
+	ObjectLock bool `json:"object_lock"`
+	// Active 当前是否生效（法律保留或保留期未到）
+	Active    bool      `json:"active"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListObjectHoldsRequest 保护列表参数.
+type ListObjectHoldsRequest struct {
+	// Prefix 对象键前缀
+	Prefix string `form:"prefix"`
+	// Active 为 true 时只返回生效中的保护
+	Active   bool `form:"active"`
+	Page     int  `form:"page"`
+	PageSize int  `form:"page_size"`
+}
+
+// ListObjectHoldsResponse 保护列表响应.
+type ListObjectHoldsResponse struct {
+	Total int              `json:"total"`
+	Page  int              `json:"page"`
+	Size  int              `json:"size"`
+	Items []ObjectHoldInfo `json:"items"`
+}
+
+// ListObjectHoldEventsRequest 保护审计记录查询参数.
+type ListObjectHoldEventsRequest struct {
+	ObjectKey string `form:"object_key"`
+	// Outcome applied / denied
+	Outcome  string `form:"outcome"   binding:"omitempty,oneof=applied denied"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+// ObjectHoldEventInfo 单条保护审计记录.
+type ObjectHoldEventInfo struct {
+	ObjectKey string    `json:"object_key"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Outcome   string    `json:"outcome"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListObjectHoldEventsResponse 保护审计记录响应.
+type ListObjectHoldEventsResponse struct {
+	Total int                   `json:"total"`
+	Page  int                   `json:"page"`
+	Size  int                   `json:"size"`
+	Items []ObjectHoldEventInfo `json:"items"`
+}
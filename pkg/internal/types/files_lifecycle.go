@@ -0,0 +1,37 @@
+package types
+
+// 生命周期动作.
+const (
+	LifecycleActionTransition  = "transition"   // 迁移到其他存储类型或 bucket
+	LifecycleActionExpire      = "expire"       // 过期后移入回收站
+	LifecycleActionAbortUpload = "abort_upload" // 中止未完成的分片上传
+)
+
+// LifecycleRunQuery 执行生命周期参数.
+type LifecycleRunQuery struct {
+	// DryRun 为 true 时只返回将执行的动作，不修改对象
+	DryRun bool `form:"dry_run"`
+}
+
+// LifecycleActionResult 单个生命周期动作.
+type LifecycleActionResult struct {
+	Action    string `json:"action"`
+	Rule      string `json:"rule,omitempty"`
+	ObjectKey string `json:"object_key"`
+	Bucket    string `json:"bucket"`
+	// 迁移目标（仅 transition）
+	TargetBucket string `json:"target_bucket,omitempty"`
+	StorageClass string `json:"storage_class,omitempty"`
+	Size         int64  `json:"size"`
+	Error        string `json:"error,omitempty"`
+}
+
+// LifecycleRunResponse 执行生命周期结果.
+type LifecycleRunResponse struct {
+	DryRun       bool                    `json:"dry_run"`
+	Actions      []LifecycleActionResult `json:"actions"`
+	Transitioned int                     `json:"transitioned"`
+	Expired      int                     `json:"expired"`
+	Aborted      int                     `json:"aborted"`
+	Failed       int                     `json:"failed"`
+}
@@ -0,0 +1,30 @@
+package types
+
+import "time"
+
+// TimeRange 时间范围过滤，From/To 为空表示该端不限制.
+type TimeRange struct {
+	From time.Time `json:"from,omitzero"`
+	To   time.Time `json:"to,omitzero"`
+}
+
+// MediaInfo 从文件内容中提取的媒体元数据，随搜索结果返回.
+type MediaInfo struct {
+	Kind        string     `json:"kind"` // image / pdf / audio
+	Width       int        `json:"width,omitempty"`
+	Height      int        `json:"height,omitempty"`
+	TakenAt     *time.Time `json:"taken_at,omitempty"`
+	CameraMake  string     `json:"camera_make,omitempty"`
+	CameraModel string     `json:"camera_model,omitempty"`
+	Latitude    *float64   `json:"latitude,omitempty"`
+	Longitude   *float64   `json:"longitude,omitempty"`
+	PageCount   int        `json:"page_count,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	Subject     string     `json:"subject,omitempty"`
+	Artist      string     `json:"artist,omitempty"`
+	Album       string     `json:"album,omitempty"`
+	Genre       string     `json:"genre,omitempty"`
+	Year        int        `json:"year,omitempty"`
+	Track       int        `json:"track,omitempty"`
+}
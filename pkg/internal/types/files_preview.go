@@ -0,0 +1,7 @@
+package types
+
+// PreviewQuery 获取缩略图的查询参数.
+type PreviewQuery struct {
+	Key  string `binding:"required" form:"key"`  // 原图对象键
+	Size int    `binding:"required" form:"size"` // 长边像素，必须为配置的尺寸之一
+}
@@ -0,0 +1,42 @@
+package types
+
+// 对账差异类型.
+const (
+	DriftMissingInDB   = "missing_in_db"  // 对象存在于对象存储，但数据库无记录
+	DriftMissingInS3   = "missing_in_s3"  // 数据库有记录，但对象已不存在
+	DriftStaleETag     = "stale_etag"     // ETag 不一致（对象内容已变化）
+	DriftStaleMetadata = "stale_metadata" // 大小/类型/存储类别等元数据不一致
+)
+
+// ReconcileRequest 对账参数.
+type ReconcileRequest struct {
+	// Prefix 对账范围（对象键前缀），为空表示该用户全部对象；必须位于用户目录下
+	Prefix string `form:"prefix" json:"prefix,omitempty"`
+	// Fix 是否修复差异：补录/刷新数据库记录，软删除对象已不存在的记录
+	Fix bool `form:"fix" json:"fix,omitempty"`
+	// Deep 是否对每个对象执行 Stat 以比对 ContentType（较慢）
+	Deep bool `form:"deep" json:"deep,omitempty"`
+}
+
+// ReconcileEntry 单个差异条目.
+type ReconcileEntry struct {
+	ObjectKey string `json:"object_key"`
+	Kind      string `json:"kind"`
+	Detail    string `json:"detail,omitempty"`
+	Fixed     bool   `json:"fixed"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReconcileResponse 对账报告.
+type ReconcileResponse struct {
+	User      string           `json:"user"`
+	Prefix    string           `json:"prefix"`
+	Fix       bool             `json:"fix"`
+	Scanned   int              `json:"scanned"`    // 扫描的对象数量
+	DBRecords int              `json:"db_records"` // 范围内的数据库记录数量
+	Counts    map[string]int   `json:"counts"`     // 各差异类型数量
+	Fixed     int              `json:"fixed"`
+	Entries   []ReconcileEntry `json:"entries"`
+	// Truncated 为 true 表示差异条目超过上限，Entries 仅包含前一部分（Counts 仍为完整统计）
+	Truncated bool `json:"truncated,omitempty"`
+}
@@ -0,0 +1,74 @@
+package types
+
+import "time"
+
+// 复制动作.
+const (
+	ReplicationOpPut    = "put"    // 将源对象内容复制到副本
+	ReplicationOpDelete = "delete" // 源对象已删除，删除副本
+)
+
+// 复制状态.
+const (
+	ReplicationStatusReplicated = "replicated"
+	ReplicationStatusFailed     = "failed"
+)
+
+// ListReplicationRequest 复制状态列表参数.
+type ListReplicationRequest struct {
+	// 状态过滤：replicated / failed
+	Status   string `form:"status"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+// ReplicationStatusInfo 单个对象的复制状态.
+type ReplicationStatusInfo struct {
+	Bucket        string     `json:"bucket"`
+	ObjectKey     string     `json:"object_key"`
+	Operation     string     `json:"operation"`
+	Status        string     `json:"status"`
+	ETag          string     `json:"etag,omitempty"`
+	Size          int64      `json:"size"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	ReplicatedAt  *time.Time `json:"replicated_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ListReplicationResponse 复制状态列表响应.
+type ListReplicationResponse struct {
+	Total int `json:"total"`
+	Page  int `json:"page"`
+	Size  int `json:"size"`
+	// Counts 当前用户各状态的对象数
+	Counts map[string]int          `json:"counts"`
+	Items  []ReplicationStatusInfo `json:"items"`
+}
+
+// ReplicationRunQuery 回填/重试参数.
+type ReplicationRunQuery struct {
+	// DryRun 为 true 时只统计需要复制的对象，不修改副本
+	DryRun bool `form:"dry_run"`
+}
+
+// ReplicationError 单个对象复制失败的原因.
+type ReplicationError struct {
+	Bucket    string `json:"bucket"`
+	ObjectKey string `json:"object_key"`
+	Error     string `json:"error"`
+}
+
+// ReplicationRunResponse 回填/重试结果.
+type ReplicationRunResponse struct {
+	DryRun bool `json:"dry_run"`
+	// 检查的对象数
+	Scanned int `json:"scanned"`
+	// 已（或在 dry_run 时将要）同步到副本的对象数
+	Replicated int `json:"replicated"`
+	// 副本已是最新而跳过的对象数
+	Skipped int                `json:"skipped"`
+	Failed  int                `json:"failed"`
+	Errors  []ReplicationError `json:"errors"`
+}
@@ -20,6 +20,21 @@ type SearchFilesRequest struct {
 	// 时间范围（对象 last_modified）
 	Start time.Time `json:"start_time,omitzero"`
 	End   time.Time `json:"end_time,omitzero"`
+	// 媒体元数据过滤（基于已提取的 file_media，未提取的文件不会命中）
+	// 拍摄时间范围（EXIF DateTimeOriginal）
+	TakenBetween *TimeRange `json:"taken_between,omitempty"`
+	// 图片最小宽/高（像素）
+	MinWidth  int `json:"min_width,omitempty"`
+	MinHeight int `json:"min_height,omitempty"`
+	// 是否包含 GPS 坐标
+	HasGPS *bool `json:"has_gps,omitempty"`
+	// 相机型号/厂商（LIKE 匹配）
+	Camera string `json:"camera,omitempty"`
+	// PDF 最少页数
+	MinPages int `json:"min_pages,omitempty"`
+	// 音频艺术家/专辑（LIKE 匹配）
+	Artist string `json:"artist,omitempty"`
+	Album  string `json:"album,omitempty"`
 	// 分页
 	Page     int `json:"page,omitempty"`
 	PageSize int `json:"page_size,omitempty"`
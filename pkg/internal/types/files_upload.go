@@ -56,7 +56,7 @@ type UploadFileMetadata struct {
 	Category     string            `form:"category"      json:"category,omitempty"`      // 可选：分类
 	Folder       string            `form:"folder"        json:"folder,omitempty"`        // 可选：文件夹
 	IsPublic     bool              `form:"is_public"     json:"is_public,omitempty"`     // 可选：是否公开
-	ExpiryDays   int               `form:"expiry_days"   json:"expiry_days,omitempty"`   // 可选：过期天数
+	ExpiryDays   int               `form:"expiry_days"   json:"expiry_days,omitempty"`   // 可选：过期天数，到期后由生命周期任务移入回收站
 	LastModified string            `form:"last_modified" json:"last_modified,omitempty"` // 可选：最后修改时间 (RFC3339格式)
 }
 
@@ -12,6 +12,14 @@ type FileVersionInfo struct {
 	StorageClass string            `json:"storage_class,omitempty"`
 	Bucket       string            `json:"bucket,omitempty"`
 	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+	// 以下字段来自版本元数据表，早于版本记录的历史版本为空
+	Action       string   `json:"action,omitempty"`  // 版本来源：upload / create / restore / bucket_event 等
+	Creator      string   `json:"creator,omitempty"` // 创建者
+	Message      string   `json:"message,omitempty"` // 版本说明
+	Labels       []string `json:"labels,omitempty"`  // 命名标签（如 final）
+	Checksum     string   `json:"checksum,omitempty"`
+	RestoredFrom string   `json:"restored_from,omitempty"` // 由哪个版本恢复而来
+	CreatedAt    string   `json:"created_at,omitempty"`    // RFC3339
 }
 
 // ListFileVersionsResponse 获取文件版本列表响应。
@@ -29,6 +37,8 @@ type CreateFileVersionRequest struct {
 	BaseVersion string            `json:"base_version,omitempty"`  // 可选：基于哪个版本创建
 	ContentType string            `json:"content_type,omitempty"`  // 可选：覆盖内容类型
 	UserMeta    map[string]string `json:"user_metadata,omitempty"` // 可选：覆盖用户元数据
+	Message     string            `json:"message,omitempty"`       // 可选：版本说明
+	Labels      []string          `json:"labels,omitempty"`        // 可选：命名标签，已被其他版本使用的标签会移到新版本
 }
 
 // CreateFileVersionResponse 创建版本响应。
@@ -55,3 +65,143 @@ type RestoreFileVersionResponse struct {
 	Success     bool   `json:"success"`
 	Error       string `json:"error,omitempty"`
 }
+
+// SetFileVersionLabelsRequest 设置版本标签与说明.
+type SetFileVersionLabelsRequest struct {
+	// Labels 版本的完整标签列表（替换原有标签）；同一对象内标签唯一，已被其他版本使用的标签会移到该版本
+	Labels []string `binding:"omitempty,dive,max=64" json:"labels"`
+	// Message 可选：更新版本说明，为 nil 时不修改
+	Message *string `json:"message,omitempty"`
+}
+
+// VersionRetentionRule 版本保留规则.
+type VersionRetentionRule struct {
+	// FolderID 规则作用的文件夹，为空表示用户级规则（作用于未被文件夹规则覆盖的全部文件）
+	FolderID string `json:"folder_id,omitempty"`
+	// Prefix 规则作用的对象键前缀
+	Prefix string `json:"prefix"`
+	// KeepLast 保留最近 N 个版本，0 表示不按数量清理
+	KeepLast int `json:"keep_last"`
+	// KeepDays 保留 D 天内创建的版本，0 表示不按时间清理
+	KeepDays  int    `json:"keep_days"`
+	UpdatedAt string `json:"updated_at,omitempty"` // RFC3339
+}
+
+// SetVersionRetentionRequest 设置版本保留规则；当前版本与带标签的版本总是保留.
+type SetVersionRetentionRequest struct {
+	FolderID string `json:"folder_id,omitempty"`
+	KeepLast int    `binding:"min=0" json:"keep_last"`
+	KeepDays int    `binding:"min=0" json:"keep_days"`
+}
+
+// ListVersionRetentionResponse 版本保留规则列表.
+type ListVersionRetentionResponse struct {
+	Rules []VersionRetentionRule `json:"rules"`
+	// Default 未设置用户级规则时使用的服务端默认规则
+	Default VersionRetentionRule `json:"default"`
+}
+
+// PruneVersionsQuery 清理旧版本参数.
+type PruneVersionsQuery struct {
+	// DryRun 为 true 时只返回将被删除的版本，不实际删除
+	DryRun bool `form:"dry_run"`
+}
+
+// PrunedVersion 被清理（或将被清理）的版本.
+type PrunedVersion struct {
+	ObjectKey string `json:"object_key"`
+	VersionID string `json:"version_id"`
+	Size      int64  `json:"size"`
+	CreatedAt string `json:"created_at"` // RFC3339
+	Error     string `json:"error,omitempty"`
+}
+
+// PruneVersionsResponse 清理旧版本结果.
+type PruneVersionsResponse struct {
+	DryRun bool `json:"dry_run"`
+	// Objects 检查的多版本对象数
+	Objects  int             `json:"objects"`
+	Versions []PrunedVersion `json:"versions"`
+	Pruned   int             `json:"pruned"`
+	Failed   int             `json:"failed"`
+	// ReclaimedBytes 释放（dry_run 时为可释放）的字节数
+	ReclaimedBytes int64 `json:"reclaimed_bytes"`
+}
+
+// 版本对比结果中的行操作.
+const (
+	DiffOpEqual  = "equal"
+	DiffOpDelete = "delete"
+	DiffOpInsert = "insert"
+)
+
+// 版本对比未比较文本内容的原因.
+const (
+	DiffReasonBinary   = "binary"    // 非文本内容类型或内容不是合法 UTF-8
+	DiffReasonTooLarge = "too_large" // 任一版本超过文本对比大小上限
+)
+
+// DiffFileVersionsQuery 版本对比参数.
+type DiffFileVersionsQuery struct {
+	// From 旧版本 ID（ListFileVersions 返回的 version_id）
+	From string `binding:"required" form:"from"`
+	// To 新版本 ID，为空表示当前版本
+	To string `form:"to"`
+	// Format 文本差异格式：lines(默认，结构化行差异) 或 unified(以 text/plain 流式返回 unified diff)
+	Format string `binding:"omitempty,oneof=lines unified" form:"format"`
+	// Context 每个差异块前后保留的上下文行数，默认 3
+	Context *int `binding:"omitempty,min=0,max=100" form:"context"`
+}
+
+// FileVersionSide 参与对比的版本信息.
+type FileVersionSide struct {
+	VersionID    string            `json:"version_id"`
+	Size         int64             `json:"size"`
+	ETag         string            `json:"etag,omitempty"`
+	Checksum     string            `json:"checksum,omitempty"`
+	ContentType  string            `json:"content_type,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"` // RFC3339
+	UserMetadata map[string]string `json:"user_metadata,omitempty"`
+}
+
+// MetadataChange 两个版本间发生变化的元数据字段；用户元数据字段名为 user_metadata.<key>.
+type MetadataChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// DiffLine 差异块中的一行；FromLine/ToLine 为从 1 开始的行号，该侧不存在时为 0.
+type DiffLine struct {
+	Op       string `json:"op"` // equal / delete / insert
+	FromLine int    `json:"from_line,omitempty"`
+	ToLine   int    `json:"to_line,omitempty"`
+	Text     string `json:"text"`
+}
+
+// DiffHunk 带上下文的差异块，起始行号与 unified diff 的 @@ 行一致.
+type DiffHunk struct {
+	FromStart int        `json:"from_start"`
+	FromCount int        `json:"from_count"`
+	ToStart   int        `json:"to_start"`
+	ToCount   int        `json:"to_count"`
+	Lines     []DiffLine `json:"lines"`
+}
+
+// FileVersionDiff 两个版本的对比结果.
+type FileVersionDiff struct {
+	ObjectKey string          `json:"object_key"`
+	From      FileVersionSide `json:"from"`
+	To        FileVersionSide `json:"to"`
+	// Text 是否进行了文本对比；为 false 时 Reason 说明原因，只比较大小、校验和与元数据
+	Text   bool   `json:"text"`
+	Reason string `json:"reason,omitempty"` // binary / too_large
+	// SameContent 两个版本内容是否相同（文本对比时按内容判断，否则按校验和判断）
+	SameContent     bool             `json:"same_content"`
+	SizeDelta       int64            `json:"size_delta"`
+	MetadataChanges []MetadataChange `json:"metadata_changes,omitempty"`
+	// Added/Removed 新增与删除的行数（仅文本对比）
+	Added   int        `json:"added"`
+	Removed int        `json:"removed"`
+	Hunks   []DiffHunk `json:"hunks,omitempty"`
+}
@@ -0,0 +1,66 @@
+package types
+
+import "time"
+
+// JobInfo 异步任务信息.
+type JobInfo struct {
+	JobID           string     `json:"job_id"`
+	Type            string     `json:"type"`
+	Status          string     `json:"status"`
+	Total           int        `json:"total"`
+	Processed       int        `json:"processed"`
+	Success         int        `json:"success"`
+	Failed          int        `json:"failed"`
+	Error           string     `json:"error,omitempty"`
+	CancelRequested bool       `json:"cancel_requested"`
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+}
+
+// SubmitJobResponse 提交异步任务的响应（async=true 时批量接口返回）.
+type SubmitJobResponse struct {
+	Job JobInfo `json:"job"`
+}
+
+// ListJobsRequest 任务列表查询参数.
+type ListJobsRequest struct {
+	Status   string `form:"status"`    // 可选：按状态过滤
+	Type     string `form:"type"`      // 可选：按任务类型过滤
+	Page     int    `form:"page"`      // 页码（从 1 开始）
+	PageSize int    `form:"page_size"` // 每页数量
+}
+
+// ListJobsResponse 任务列表响应.
+type ListJobsResponse struct {
+	Total int       `json:"total"`
+	Page  int       `json:"page"`
+	Size  int       `json:"size"`
+	Jobs  []JobInfo `json:"jobs"`
+}
+
+// JobItemResult 任务中单个条目的执行结果.
+type JobItemResult struct {
+	Seq     int    `json:"seq"`
+	Key     string `json:"key"`
+	Target  string `json:"target,omitempty"` // 复制/移动的目标对象键
+	Detail  string `json:"detail,omitempty"` // 附加说明（如对账差异类型与详情）
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ListJobResultsResponse 任务条目结果分页响应.
+type ListJobResultsResponse struct {
+	JobID string          `json:"job_id"`
+	Total int             `json:"total"`
+	Page  int             `json:"page"`
+	Size  int             `json:"size"`
+	Items []JobItemResult `json:"items"`
+}
+
+// SyncObjectsRequest 同步对象存储到数据库的参数（异步任务载荷）.
+type SyncObjectsRequest struct {
+	Year  int `json:"year,omitempty"`
+	Month int `json:"month,omitempty"`
+	Day   int `json:"day,omitempty"`
+}
@@ -2,16 +2,49 @@ package types
 
 import "time"
 
+// 分享类型.
+const (
+	// ShareTypeObjects 分享创建时指定的对象列表（快照）
+	ShareTypeObjects = "objects"
+	// ShareTypeFolder 分享一个文件夹，访问时实时列出其中的文件
+	ShareTypeFolder = "folder"
+	// ShareTypeUpload 上传型分享（文件收集）：访客只能向目标文件夹上传文件，不能查看或下载
+	ShareTypeUpload = "upload"
+)
+
+// 分享访问日志中的动作.
+const (
+	ShareActionView         = "view"          // 访问成功（签发令牌）
+	ShareActionList         = "list"          // 查看文件列表
+	ShareActionDownload     = "download"      // 整体下载（直链或打包）
+	ShareActionFileDownload = "file_download" // 下载单个文件
+	ShareActionUpload       = "upload"        // 上传型分享收到文件
+	ShareActionDenied       = "denied"        // 被拒绝的访问（密码错误、锁定、次数用尽等）
+)
+
 // CreateShareRequest 创建分享所需参数.
 type CreateShareRequest struct {
-	// ObjectKeys 需要分享的对象键（S3 Key）列表，按创建时快照保存
+	// ObjectKeys 需要分享的对象键（S3 Key）列表，按创建时快照保存；与 FolderID 二选一
 	ObjectKeys []string `form:"object_keys" json:"object_keys"`
+	// FolderID 需要分享的文件夹 ID；访问时解析文件夹内容，之后新增的文件同样可见。
+	// 上传型分享中为接收上传文件的目标文件夹
+	FolderID string `form:"folder_id" json:"folder_id"`
+	// Upload 不为空时创建上传型分享，需同时指定 FolderID
+	Upload *ShareUploadPolicy `json:"upload,omitempty"`
 	// Password 可选访问密码（服务端仅存储密码哈希）
 	Password string `form:"password" json:"password"`
 	// ExpireDays 分享有效天数；>0 则按天计算过期时间，为 0 表示不过期
 	ExpireDays int `form:"expire_days" json:"expire_days"`
 	// AllowDownload 是否允许生成下载直链
 	AllowDownload bool `form:"allow_download" json:"allow_download"`
+	// AllowAnonymous 是否允许未登录用户访问（设置了密码仍需校验），默认 true
+	AllowAnonymous *bool `form:"allow_anonymous" json:"allow_anonymous"`
+	// Users 允许访问的用户白名单（创建者总是允许）
+	Users []string `form:"users" json:"users"`
+	// MaxViews 最多可访问次数（owner 访问不计），0 表示不限
+	MaxViews int `binding:"omitempty,min=0" form:"max_views" json:"max_views"`
+	// MaxDownloads 最多可下载次数，0 表示不限
+	MaxDownloads int `binding:"omitempty,min=0" form:"max_downloads" json:"max_downloads"`
 }
 
 // ShareInfo 分享的公开信息。
@@ -20,7 +53,11 @@ type ShareInfo struct {
 	ShareID string `json:"share_id"`
 	// Owner 分享拥有者（用户名或租户标识）
 	Owner string `json:"owner"`
-	// ObjectKeys 分享包含的对象键列表
+	// Type 分享类型：objects / folder / upload
+	Type string `json:"type"`
+	// FolderID 文件夹分享的文件夹 ID
+	FolderID string `json:"folder_id,omitempty"`
+	// ObjectKeys 分享包含的对象键列表（文件夹分享为空，通过文件列表接口获取）
 	ObjectKeys []string `json:"object_keys"`
 	// CreatedAt 分享创建时间（UTC）
 	CreatedAt time.Time `json:"created_at"`
@@ -28,6 +65,20 @@ type ShareInfo struct {
 	ExpireAt *time.Time `json:"expire_at,omitempty"`
 	// AllowDownload 是否允许下载直链
 	AllowDownload bool `json:"allow_download"`
+	// MaxViews 最多可访问次数，0 表示不限
+	MaxViews int `json:"max_views,omitempty"`
+	// MaxDownloads 最多可下载次数，0 表示不限
+	MaxDownloads int `json:"max_downloads,omitempty"`
+	// Upload 上传型分享的上传限制
+	Upload *ShareUploadPolicy `json:"upload,omitempty"`
+}
+
+// ShareUploadPolicy 上传型分享的上传限制.
+type ShareUploadPolicy struct {
+	// MaxFileSize 单个文件的最大字节数，0 表示使用服务端上限（share.upload_max_file_size_mb）
+	MaxFileSize int64 `binding:"omitempty,min=0" json:"max_file_size"`
+	// AllowedContentTypes 允许的内容类型，支持 "image/*" 形式的通配，为空表示不限
+	AllowedContentTypes []string `json:"allowed_content_types,omitempty"`
 }
 
 // CreateShareResponse 创建分享的响应体。
@@ -48,6 +99,22 @@ type AccessShareRequest struct {
 	Password string `form:"password" json:"password"`
 }
 
+// AccessShareResponse 访问分享的响应体：分享信息与访问令牌.
+type AccessShareResponse struct {
+	ShareInfo
+
+	// AccessToken 分享访问令牌，下载与文件列表接口通过 X-Share-Token 头或 token 参数携带
+	AccessToken string `json:"access_token"`
+	// TokenExpireAt 令牌过期时间（UTC）
+	TokenExpireAt time.Time `json:"token_expire_at"`
+}
+
+// UpdateSharePasswordRequest 修改分享密码请求体.
+type UpdateSharePasswordRequest struct {
+	// Password 新密码，为空表示取消密码
+	Password string `json:"password"`
+}
+
 // SharePermissions 分享权限配置。
 type SharePermissions struct {
 	// AllowAnonymous 是否允许匿名访问（设了密码则仍需校验密码）
@@ -77,3 +144,123 @@ type AddShareUserRequest struct {
 	// UserID 被添加的目标用户 ID/标识
 	UserID string `form:"user_id" json:"user_id"`
 }
+
+// ShareFile 分享中的单个文件.
+type ShareFile struct {
+	// Index 文件在列表中的序号，用于单独下载；文件夹分享内容变化后序号可能变化
+	Index int `json:"index"`
+	// Path 文件相对路径（文件夹分享为 <文件夹名>/<相对路径>）
+	Path         string `json:"path"`
+	Size         int64  `json:"size"`
+	ContentType  string `json:"content_type,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// Available 对象是否仍存在（对象分享中的对象可能已被删除）
+	Available bool `json:"available"`
+}
+
+// ListShareFilesResponse 分享文件列表响应体.
+type ListShareFilesResponse struct {
+	ShareID string      `json:"share_id"`
+	Type    string      `json:"type"`
+	Files   []ShareFile `json:"files"`
+	Total   int         `json:"total"`
+}
+
+// ShareDownloadQuery 分享下载参数.
+type ShareDownloadQuery struct {
+	// Archive 为 true 时即使只有一个对象也打包返回；多个对象或文件夹分享总是打包返回
+	Archive bool `form:"archive"`
+	// ArchiveFormat 打包格式 zip/tar/tar.gz/tar.zst，默认 zip
+	ArchiveFormat string `binding:"omitempty,oneof=zip tar tar.gz tar.zst" form:"archive_format"`
+}
+
+// ShareFileDownloadQuery 分享中单个文件的下载参数.
+type ShareFileDownloadQuery struct {
+	// Inline 为 true 时 Content-Disposition 使用 inline
+	Inline bool `form:"inline"`
+}
+
+// ShareStatsQuery 分享统计查询参数.
+type ShareStatsQuery struct {
+	// Days 时间线覆盖的天数（按 UTC 日期），默认 30
+	Days int `binding:"omitempty,min=1,max=365" form:"days"`
+}
+
+// ShareTotals 分享访问汇总.
+type ShareTotals struct {
+	// ViewCount/DownloadCount 计入次数限制的访问与下载次数
+	ViewCount     int64 `json:"view_count"`
+	DownloadCount int64 `json:"download_count"`
+	MaxViews      int   `json:"max_views"`
+	MaxDownloads  int   `json:"max_downloads"`
+	// Accesses 访问日志总条数，ByAction 按动作统计
+	Accesses int64            `json:"accesses"`
+	ByAction map[string]int64 `json:"by_action"`
+	// UniqueIPs 不同来源 IP 数
+	UniqueIPs int64 `json:"unique_ips"`
+}
+
+// ShareTimelinePoint 时间线中的一天.
+type ShareTimelinePoint struct {
+	Date     string           `json:"date"` // YYYY-MM-DD（UTC）
+	ByAction map[string]int64 `json:"by_action"`
+}
+
+// ShareAccessEntry 单条访问记录.
+type ShareAccessEntry struct {
+	Action    string    `json:"action"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	User      string    `json:"user,omitempty"`
+	ObjectKey string    `json:"object_key,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShareStatsResponse 分享统计响应体.
+type ShareStatsResponse struct {
+	ShareID  string               `json:"share_id"`
+	Totals   ShareTotals          `json:"totals"`
+	Timeline []ShareTimelinePoint `json:"timeline"`
+	// Recent 最近的访问记录（最多 50 条）
+	Recent []ShareAccessEntry `json:"recent"`
+}
+
+// ShareUploadFile 访客声明的待上传文件.
+type ShareUploadFile struct {
+	FileName    string `binding:"required"       json:"file_name"`
+	ContentType string `json:"content_type,omitempty"`
+	// Size 文件大小（字节），用于提前拒绝超限文件；实际大小由上传策略强制限制
+	Size int64 `binding:"omitempty,min=0" json:"size,omitempty"`
+}
+
+// ShareUploadRequest 向上传型分享申请上传.
+type ShareUploadRequest struct {
+	Files []ShareUploadFile `binding:"required,min=1,dive" json:"files"`
+}
+
+// ShareUploadResponse 上传型分享的预签名 POST 表单.
+type ShareUploadResponse struct {
+	ShareID string                `json:"share_id"`
+	Results []PresignedUploadItem `json:"results"`
+}
+
+// ShareUploadCompleteRequest 上传完成后确认的对象键列表.
+type ShareUploadCompleteRequest struct {
+	ObjectKeys []string `binding:"required,min=1" json:"object_keys"`
+}
+
+// ShareUploadResult 单个上传确认结果.
+type ShareUploadResult struct {
+	ObjectKey string `json:"object_key"`
+	FileName  string `json:"file_name,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ShareUploadCompleteResponse 上传确认响应体.
+type ShareUploadCompleteResponse struct {
+	ShareID string              `json:"share_id"`
+	Results []ShareUploadResult `json:"results"`
+}
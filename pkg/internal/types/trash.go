@@ -0,0 +1,67 @@
+package types
+
+import "time"
+
+// 回收站条目来源.
+const (
+	TrashReasonUser      = "user"      // 用户手动移入
+	TrashReasonDuplicate = "duplicate" // 重复文件处理
+	TrashReasonExpired   = "expired"   // 生命周期任务按过期时间移入
+)
+
+// TrashFilesRequest 将文件移入回收站请求.
+type TrashFilesRequest struct {
+	ObjectKeys []string `binding:"required" json:"object_keys"`
+}
+
+// TrashItemInfo 回收站条目.
+type TrashItemInfo struct {
+	TrashID     string    `json:"trash_id"`
+	ObjectKey   string    `json:"object_key"` // 原对象键，恢复时移回该位置
+	FileName    string    `json:"file_name"`
+	Size        int64     `json:"size"`
+	ETag        string    `json:"etag,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	TrashedAt   time.Time `json:"trashed_at"`
+	ExpireAt    time.Time `json:"expire_at"`
+}
+
+// ListTrashRequest 回收站列表/搜索参数（GET 使用 query，POST /search 使用 JSON）.
+type ListTrashRequest struct {
+	// 关键字匹配文件名与原对象键
+	Keyword string `form:"keyword" json:"keyword,omitempty"`
+	// 来源过滤：user / duplicate / expired
+	Reason   string `form:"reason"    json:"reason,omitempty"`
+	Page     int    `form:"page"      json:"page,omitempty"`
+	PageSize int    `form:"page_size" json:"page_size,omitempty"`
+}
+
+// ListTrashResponse 回收站列表响应.
+type ListTrashResponse struct {
+	Total int             `json:"total"`
+	Page  int             `json:"page"`
+	Size  int             `json:"size"`
+	Items []TrashItemInfo `json:"items"`
+}
+
+// TrashBatchRequest 批量恢复/永久删除请求.
+type TrashBatchRequest struct {
+	TrashIDs []string `binding:"required" json:"trash_ids"`
+}
+
+// TrashOpResult 单个条目的回收站操作结果.
+type TrashOpResult struct {
+	TrashID   string `json:"trash_id,omitempty"`
+	ObjectKey string `json:"object_key,omitempty"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TrashOpResponse 回收站批量操作响应.
+type TrashOpResponse struct {
+	Results []TrashOpResult `json:"results"`
+	Total   int             `json:"total"`
+	Success int             `json:"success"`
+	Failed  int             `json:"failed"`
+}
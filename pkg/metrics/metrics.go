@@ -55,6 +55,41 @@ var (
 		},
 	)
 
+	// LifecycleActions 生命周期动作计数，action 为 transition/expire/abort_upload，result 为 ok/failed/dry_run.
+	LifecycleActions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "lifecycle_actions_total",
+			Help: "Total number of object lifecycle actions",
+		},
+		[]string{"action", "result"},
+	)
+
+	// LifecycleBytes 生命周期动作涉及的对象字节数（不含 dry_run）.
+	LifecycleBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "lifecycle_bytes_total",
+			Help: "Total bytes of objects handled by lifecycle actions",
+		},
+		[]string{"action"},
+	)
+
+	// ReplicationOps 副本复制计数，operation 为 put/delete，result 为 ok/failed.
+	ReplicationOps = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "replication_operations_total",
+			Help: "Total number of object replication operations",
+		},
+		[]string{"operation", "result"},
+	)
+
+	// ReplicationBytes 复制到副本的对象字节数.
+	ReplicationBytes = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "replication_bytes_total",
+			Help: "Total bytes copied to the replica object store",
+		},
+	)
+
 	// registry Prometheus注册表.
 	registry = prometheus.NewRegistry()
 )
@@ -74,7 +109,8 @@ func InitMetrics(config configs.MetricsConfig) error {
 	}
 
 	// 注册自定义指标
-	registry.MustRegister(RequestCounter, RequestDuration, ActiveConnections)
+	registry.MustRegister(RequestCounter, RequestDuration, ActiveConnections, LifecycleActions, LifecycleBytes,
+		ReplicationOps, ReplicationBytes)
 
 	// TODO 注册自定义指标
 	for _, metric := range config.CustomMetrics {
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yeisme/notevault/pkg/context"
+)
+
+// RequestInfoMiddleware 将请求来源（客户端 IP、User-Agent、声明的用户与请求 ID）写入请求上下文，供审计日志使用.
+func RequestInfoMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.GetHeader("X-User")
+		if user == "" {
+			user = c.Query("user")
+		}
+
+		ctx := context.WithRequestInfo(c.Request.Context(), context.RequestInfo{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			User:      strings.TrimSpace(user),
+			RequestID: c.GetHeader("X-Request-ID"),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
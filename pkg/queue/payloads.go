@@ -59,6 +59,21 @@ type ObjectDeletedPayload struct {
 	DeletedSince string    `json:"deleted_since,omitempty"` // 起始删除时间（RFC3339），可选
 }
 
+// ObjectLifecyclePayload 生命周期动作（迁移、过期、中止分片上传）.
+type ObjectLifecyclePayload struct {
+	Object ObjectRef `json:"object"`
+	// Action 动作：transition / expire / abort_upload
+	Action string `json:"action"`
+	// Rule 触发迁移的规则名称
+	Rule string `json:"rule,omitempty"`
+	// 迁移前后的 bucket 与存储类型（仅 transition）
+	FromBucket       string `json:"from_bucket,omitempty"`
+	FromStorageClass string `json:"from_storage_class,omitempty"`
+	ToStorageClass   string `json:"to_storage_class,omitempty"`
+	// UploadID 被中止的分片上传 ID（仅 abort_upload）
+	UploadID string `json:"upload_id,omitempty"`
+}
+
 // -------------------------- 向量解析领域 --------------------------
 
 // VectorParseRequestedPayload 请求解析并向量化.
@@ -148,3 +163,16 @@ type KGBuildFailedPayload struct {
 	Object ObjectRef `json:"object"`
 	Error  string    `json:"error"`
 }
+
+// -------------------------- 分享领域 --------------------------
+
+// ShareUploadReceivedPayload 上传型分享收到访客上传的文件，用于通知分享 owner.
+type ShareUploadReceivedPayload struct {
+	ShareID  string    `json:"share_id"`
+	Owner    string    `json:"owner"`
+	Object   ObjectRef `json:"object"`
+	FileName string    `json:"file_name,omitempty"`
+	// Uploader 上传者信息：登录用户或来源 IP
+	UploaderUser string `json:"uploader_user,omitempty"`
+	UploaderIP   string `json:"uploader_ip,omitempty"`
+}
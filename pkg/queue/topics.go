@@ -2,7 +2,7 @@
 package queue
 
 // 主题命名规范：nv.<域>.<动作>[.<状态>][.<子类型>]，尽量稳定且向后兼容.
-// 域：object(对象存储)、vector(向量解析)、meta(元数据)、kg(知识图谱)、process(数据处理)、audit(审核)等
+// 域：object(对象存储)、vector(向量解析)、meta(元数据)、kg(知识图谱)、process(数据处理)、audit(审核)、share(分享)等
 // 动作：存储相关(stored/updated/deleted)、处理相关(parse/build/sync/extract)
 // 状态：请求(requested)、进行中(ing)、完成(ed)、失败(failed)
 // 子类型：针对多模态细分场景(如text/image/audio)
@@ -18,6 +18,11 @@ const (
 	TopicObjectAccessed    = "nv.object.accessed"     // 对象被访问（用于热点数据统计）
 	TopicObjectStorageFull = "nv.object.storage.full" // 对象存储空间不足告警
 
+	// 对象生命周期.
+	TopicObjectTransitioned  = "nv.object.transitioned"   // 对象迁移到其他存储类型或 bucket
+	TopicObjectExpired       = "nv.object.expired"        // 对象到达过期时间，已移入回收站
+	TopicObjectUploadAborted = "nv.object.upload.aborted" // 长时间未完成的分片上传被中止
+
 	// 按数据类型细分的对象存储主题.
 	TopicObjectTextStored  = "nv.object.text.stored"  // 文本类型对象存储完成
 	TopicObjectImageStored = "nv.object.image.stored" // 图像类型对象存储完成
@@ -76,6 +81,9 @@ const (
 	TopicAuditPassed    = "nv.audit.passed"    // 内容审核通过
 	TopicAuditRejected  = "nv.audit.rejected"  // 内容审核拒绝
 	TopicAuditExpired   = "nv.audit.expired"   // 审核任务超时
+
+	// 分享领域.
+	TopicShareUploadReceived = "nv.share.upload.received" // 上传型分享收到访客上传的文件（通知 owner）
 )
 
 // 主题分组，用于批量操作或权限控制.
@@ -85,6 +93,7 @@ var (
 		TopicObjectStored, TopicObjectUpdated, TopicObjectDeleted,
 		TopicObjectVersioned, TopicObjectRestored, TopicObjectMoved,
 		TopicObjectAccessed, TopicObjectStorageFull,
+		TopicObjectTransitioned, TopicObjectExpired, TopicObjectUploadAborted,
 		TopicObjectTextStored, TopicObjectImageStored,
 		TopicObjectAudioStored, TopicObjectVideoStored,
 		TopicObjectMixedStored,
@@ -124,4 +133,9 @@ var (
 		TopicAuditRequested, TopicAuditing, TopicAuditPassed,
 		TopicAuditRejected, TopicAuditExpired,
 	}
+
+	// 分享相关主题集合.
+	ShareTopics = []string{
+		TopicShareUploadReceived,
+	}
 )